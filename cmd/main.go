@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/openshift/assisted-service/pkg/thread"
@@ -15,6 +18,7 @@ import (
 	"github.com/openshift/assisted-service/internal/imgexpirer"
 
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/go-openapi/strfmt"
@@ -24,17 +28,18 @@ import (
 	"github.com/openshift/assisted-service/internal/bminventory"
 	"github.com/openshift/assisted-service/internal/cluster"
 	"github.com/openshift/assisted-service/internal/cluster/validations"
-	"github.com/openshift/assisted-service/internal/common"
 	"github.com/openshift/assisted-service/internal/connectivity"
 	"github.com/openshift/assisted-service/internal/domains"
 	"github.com/openshift/assisted-service/internal/events"
 	"github.com/openshift/assisted-service/internal/hardware"
+	"github.com/openshift/assisted-service/internal/history"
 	"github.com/openshift/assisted-service/internal/host"
 	"github.com/openshift/assisted-service/internal/manifests"
 	"github.com/openshift/assisted-service/internal/metrics"
 	"github.com/openshift/assisted-service/internal/migrations"
+	"github.com/openshift/assisted-service/internal/refresh"
 	"github.com/openshift/assisted-service/internal/versions"
-	"github.com/openshift/assisted-service/models"
+	"github.com/openshift/assisted-service/internal/webhooks"
 	"github.com/openshift/assisted-service/pkg/app"
 	"github.com/openshift/assisted-service/pkg/auth"
 	paramctx "github.com/openshift/assisted-service/pkg/context"
@@ -47,14 +52,15 @@ import (
 	"github.com/openshift/assisted-service/pkg/ocm"
 	"github.com/openshift/assisted-service/pkg/requestid"
 	"github.com/openshift/assisted-service/pkg/s3wrapper"
+	"github.com/openshift/assisted-service/pkg/shard"
+	"github.com/openshift/assisted-service/pkg/tracing"
 	"github.com/openshift/assisted-service/restapi"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
-	"gorm.io/driver/sqlite"
+	"go.opentelemetry.io/otel"
 	"gorm.io/gorm"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
 func init() {
@@ -72,9 +78,9 @@ var Options struct {
 	HWValidatorConfig           hardware.ValidatorCfg
 	JobConfig                   job.Config
 	InstructionConfig           host.InstructionConfig
-	ClusterStateMonitorInterval time.Duration `envconfig:"CLUSTER_MONITOR_INTERVAL" default:"10s"`
+	ClusterStateMonitorInterval time.Duration `envconfig:"CLUSTER_MONITOR_INTERVAL" default:"1m"`
 	S3Config                    s3wrapper.Config
-	HostStateMonitorInterval    time.Duration `envconfig:"HOST_MONITOR_INTERVAL" default:"8s"`
+	HostStateMonitorInterval    time.Duration `envconfig:"HOST_MONITOR_INTERVAL" default:"1m"`
 	Versions                    versions.Versions
 	CreateS3Bucket              bool          `envconfig:"CREATE_S3_BUCKET" default:"false"`
 	ImageExpirationInterval     time.Duration `envconfig:"IMAGE_EXPIRATION_INTERVAL" default:"30m"`
@@ -84,9 +90,18 @@ var Options struct {
 	HostConfig                  host.Config
 	LogConfig                   logconfig.Config
 	LeaderConfig                leader.Config
+	ShardConfig                 shard.Config
+	RefreshConfig               refresh.Config
+	EventsConfig                events.Config
+	EventSinksConfig            events.SinksConfig
+	EventRetentionConfig        events.RetentionConfig
+	FleetMetricsConfig          metrics.FleetConfig
+	WebhooksConfig              webhooks.Config
 	DeletionWorkerInterval      time.Duration `envconfig:"DELETION_WORKER_INTERVAL" default:"1h"`
+	IdempotencyKeyGCInterval    time.Duration `envconfig:"IDEMPOTENCY_KEY_GC_INTERVAL" default:"1h"`
 	ValidationsConfig           validations.Config
 	AssistedServiceISOConfig    assistedserviceiso.Config
+	TracingConfig               tracing.Config
 }
 
 func InitLogs() *logrus.Entry {
@@ -122,17 +137,43 @@ func main() {
 	}
 
 	port := flag.String("port", "8090", "define port that the service will listen to")
+	kubeconfig := flag.String("kubeconfig", "", "Paths to a kubeconfig. Only required if out-of-cluster.")
 	flag.Parse()
 
+	if flag.Arg(0) == migrateCommand {
+		if err = runMigrateCommand(flag.Args()[1:], log); err != nil {
+			log.WithError(err).Fatal("Migrate command failed")
+		}
+		return
+	}
+
 	log.Println("Starting bm service")
 
-	// Connect to db
-	// Connect to db
-	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
-	if err != nil {
-		log.Fatal("Fail to connect to DB, ", err)
+	// main ends in log.Fatal without running its deferred calls, these run when the service is stopped
+	var closers []func()
+
+	if Options.TracingConfig.Endpoint != "" {
+		tracerProvider, tracingErr := tracing.NewProvider(Options.TracingConfig)
+		if tracingErr != nil {
+			log.WithError(tracingErr).Fatal("Failed to create the tracer provider")
+		}
+		tracingLog := log.WithField("pkg", "tracing")
+		otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+			tracingLog.WithError(err).Warn("Failed to export spans")
+		}))
+		otel.SetTracerProvider(tracerProvider)
+		closers = append(closers, func() {
+			ctx, cancel := context.WithTimeout(context.Background(), Options.TracingConfig.ExportTimeout)
+			defer cancel()
+			if err := tracerProvider.Shutdown(ctx); err != nil {
+				tracingLog.WithError(err).Warn("Failed to export the last spans")
+			}
+		})
 	}
 
+	// Connect to db
+	db := setupDB(log)
+
 	prometheusRegistry := prometheus.DefaultRegisterer
 	metricsManager := metrics.NewMetricsManager(prometheusRegistry)
 
@@ -152,10 +193,17 @@ func main() {
 	authzHandler := auth.NewAuthzHandler(Options.Auth, ocmClient, log.WithField("pkg", "authz"))
 	versionHandler := versions.NewHandler(Options.Versions)
 	domainHandler := domains.NewHandler(Options.BMConfig.BaseDNSDomains)
-	eventsHandler := events.New(db, log.WithField("pkg", "events"))
+	eventSinks, err := events.NewSinks(Options.EventSinksConfig, log.WithField("pkg", "events"))
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create event sinks")
+	}
+	eventsHandler := events.New(db, log.WithField("pkg", "events"), eventSinks...)
+	// the sinks are flushed before the last spans are exported
+	closeOnSignal(log, append([]func(){eventsHandler.Close}, closers...)...)
 	hwValidator := hardware.NewValidator(log.WithField("pkg", "validators"), Options.HWValidatorConfig)
 	connectivityValidator := connectivity.NewValidator(log.WithField("pkg", "validators"))
-	instructionApi := host.NewInstructionManager(log.WithField("pkg", "instructions"), db, hwValidator, Options.InstructionConfig, connectivityValidator)
+	instructionApi := host.NewInstructionManager(log.WithField("pkg", "instructions"), db, hwValidator, &Options.HWValidatorConfig,
+		Options.InstructionConfig, connectivityValidator)
 
 	pullSecretValidator, err := validations.NewPullSecretValidator(Options.ValidationsConfig, []string{
 		Options.JobConfig.ReleaseImage,
@@ -167,6 +215,7 @@ func main() {
 		Options.InstructionConfig.FreeAddressesImage,
 		Options.InstructionConfig.DhcpLeaseAllocatorImage,
 		Options.InstructionConfig.APIVIPConnectivityCheckImage,
+		Options.InstructionConfig.DiskSpeedCheckImage,
 	}...)
 
 	if err != nil {
@@ -200,8 +249,13 @@ func main() {
 			log.Fatal("failed to create S3 client, ", err)
 		}
 		createS3Bucket(objectHandler)
+		objectHandler = s3wrapper.WithTracing(objectHandler)
 
-		kclient, err = client.New(config.GetConfigOrDie(), client.Options{Scheme: scheme.Scheme})
+		kubeCfg, kerr := loadKubeConfig(*kubeconfig)
+		if kerr != nil {
+			log.WithError(kerr).Fatal("unable to get kubeconfig")
+		}
+		kclient, err = client.New(kubeCfg, client.Options{Scheme: scheme.Scheme})
 		if err != nil {
 			log.Fatal("failed to create client:", err)
 		}
@@ -213,33 +267,19 @@ func main() {
 		}
 		k8sClient = kubernetes.NewForConfigOrDie(cfg)
 
-		autoMigrationLeader = leader.NewElector(k8sClient, leader.Config{LeaseDuration: 5 * time.Second,
-			RetryInterval: 2 * time.Second, Namespace: Options.LeaderConfig.Namespace, RenewDeadline: 4 * time.Second},
-			"assisted-service-migration-helper",
-			log.WithField("pkg", "migrationLeader"))
-
-		lead = leader.NewElector(k8sClient, Options.LeaderConfig, "assisted-service-leader-election-helper",
-			log.WithField("pkg", "monitor-runner"))
-
-		err = lead.StartLeaderElection(context.Background())
-		if err != nil {
-			log.WithError(err).Fatalf("Failed to start leader")
-		}
-
 		ocpClient, err = k8sclient.NewK8SClient("", log)
 		if err != nil {
 			log.WithError(err).Fatalf("Failed to create client for OCP")
 		}
 
 	case deployment_type_onprem, deployment_type_ocp:
-		lead = &leader.DummyElector{}
-		autoMigrationLeader = lead
 		// in on-prem mode, setup file system s3 driver and use localjob implementation
 		objectHandler = s3wrapper.NewFSClient("/data", log)
 		if objectHandler == nil {
 			log.Fatal("failed to create S3 file system client, ", err)
 		}
 		createS3Bucket(objectHandler)
+		objectHandler = s3wrapper.WithTracing(objectHandler)
 		generator = job.NewLocalJob(log.WithField("pkg", "local-job-wrapper"), Options.JobConfig)
 		if Options.DeployTarget == deployment_type_ocp {
 			ocpClient, err = k8sclient.NewK8SClient("", log)
@@ -250,16 +290,63 @@ func main() {
 	default:
 		log.Fatalf("not supported deploy target %s", Options.DeployTarget)
 	}
+	generator = generatorWithTracing(generator)
+
+	autoMigrationLeader = newElector(k8sClient, db, leader.Config{LeaseDuration: 5 * time.Second,
+		RetryInterval: 2 * time.Second, Namespace: Options.LeaderConfig.Namespace, RenewDeadline: 4 * time.Second,
+		Elector: Options.LeaderConfig.Elector},
+		"assisted-service-migration-helper",
+		log.WithField("pkg", "migrationLeader"))
+
+	lead = newElector(k8sClient, db, Options.LeaderConfig, "assisted-service-leader-election-helper",
+		log.WithField("pkg", "monitor-runner"))
+	err = lead.StartLeaderElection(context.Background())
+	if err != nil {
+		log.WithError(err).Fatalf("Failed to start leader")
+	}
 
 	err = autoMigrationWithLeader(autoMigrationLeader, db, log)
 	if err != nil {
 		log.WithError(err).Fatal("Failed auto migration process")
 	}
 
+	// the monitors run on the leader, unless they are partitioned between all the replicas
+	var monitorLead leader.Leader = lead
+	if Options.ShardConfig.Enabled {
+		membership := shard.NewMembership(db, Options.ShardConfig, prometheusRegistry, log.WithField("pkg", "monitor-shard"))
+		if err = membership.Start(context.Background()); err != nil {
+			log.WithError(err).Fatalf("Failed to join monitoring shard members")
+		}
+		monitorLead = membership
+	}
+
+	eventRetention := events.NewRetention(Options.EventRetentionConfig, db, log.WithField("pkg", "event-retention"), lead)
+	eventRetentionTask := thread.New(
+		log.WithField("pkg", "event-retention"), "Event Retention", Options.EventRetentionConfig.Interval, eventRetention.RetentionTask)
+	eventRetentionTask.Start()
+	defer eventRetentionTask.Stop()
+
+	fleetReporter := metrics.NewFleetReporter(Options.FleetMetricsConfig, db, log.WithField("pkg", "fleet-metrics"), metricsManager, lead)
+	fleetMetricsTask := thread.New(
+		log.WithField("pkg", "fleet-metrics"), "Fleet Metrics", Options.FleetMetricsConfig.Interval, fleetReporter.ReportTask)
+	fleetMetricsTask.Start()
+	defer fleetMetricsTask.Stop()
+
+	// webhook deliveries are queued by the replica that changes the status and sent by the leader
+	webhooksManager := webhooks.NewManager(Options.WebhooksConfig, log.WithField("pkg", "webhooks"), db, lead)
+	webhookDelivery := thread.New(
+		log.WithField("pkg", "webhook-delivery"), "Webhook Delivery", Options.WebhooksConfig.DeliveryInterval, webhooksManager.DeliveryTask)
+	webhookDelivery.Start()
+	defer webhookDelivery.Stop()
+
 	hostApi := host.NewManager(log.WithField("pkg", "host-state"), db, eventsHandler, hwValidator,
-		instructionApi, &Options.HWValidatorConfig, metricsManager, &Options.HostConfig, lead)
+		instructionApi, &Options.HWValidatorConfig, metricsManager, &Options.HostConfig, monitorLead, webhooksManager)
 	clusterApi := cluster.NewManager(Options.ClusterConfig, log.WithField("pkg", "cluster-state"), db,
-		eventsHandler, hostApi, metricsManager, lead)
+		eventsHandler, hostApi, metricsManager, monitorLead, webhooksManager)
+
+	// changes reported by the API are refreshed right away, the monitors catch up with everything else
+	refreshBus := refresh.NewBus(Options.RefreshConfig, log.WithField("pkg", "refresh-bus"), db, hostApi, clusterApi)
+	refreshBus.Start(context.Background())
 
 	clusterStateMonitor := thread.New(
 		log.WithField("pkg", "cluster-monitor"), "Cluster State Monitor", Options.ClusterStateMonitorInterval, clusterApi.ClusterMonitoring)
@@ -278,7 +365,7 @@ func main() {
 	}
 
 	bm := bminventory.NewBareMetalInventory(db, log.WithField("pkg", "Inventory"), hostApi, clusterApi, Options.BMConfig,
-		generator, eventsHandler, objectHandler, metricsManager, *authHandler, ocpClient, lead, pullSecretValidator)
+		generator, eventsHandler, objectHandler, metricsManager, *authHandler, ocpClient, lead, pullSecretValidator, refreshBus)
 
 	deletionWorker := thread.New(
 		log.WithField("inventory", "Deletion Worker"),
@@ -288,9 +375,16 @@ func main() {
 	deletionWorker.Start()
 	defer deletionWorker.Stop()
 
-	events := events.NewApi(eventsHandler, logrus.WithField("pkg", "eventsApi"))
+	idempotencyKeyGC := thread.New(
+		log.WithField("pkg", "idempotency-key-gc"), "Idempotency Key GC", Options.IdempotencyKeyGCInterval, bm.DeleteExpiredIdempotencyKeys)
+	idempotencyKeyGC.Start()
+	defer idempotencyKeyGC.Stop()
+
+	events := events.NewApi(Options.EventsConfig, eventsHandler, logrus.WithField("pkg", "eventsApi"))
+	webhooksApi := webhooks.NewApi(Options.WebhooksConfig, db, log.WithField("pkg", "webhooksApi"))
+	historyApi := history.NewApi(db, log.WithField("pkg", "historyApi"))
 	manifests := manifests.NewManifestsAPI(db, log.WithField("pkg", "manifests"), objectHandler)
-	expirer := imgexpirer.NewManager(objectHandler, eventsHandler, Options.BMConfig.ImageExpirationTime, lead)
+	expirer := imgexpirer.NewManager(objectHandler, eventsHandler, db, Options.BMConfig.ImageExpirationTime, lead)
 	imageExpirationMonitor := thread.New(
 		log.WithField("pkg", "image-expiration-monitor"), "Image Expiration Monitor", Options.ImageExpirationInterval, expirer.ExpirationTask)
 	imageExpirationMonitor.Start()
@@ -301,6 +395,7 @@ func main() {
 	innerHandler := func() func(http.Handler) http.Handler {
 		return func(h http.Handler) http.Handler {
 			wrapped := metrics.WithMatchedRoute(log.WithField("pkg", "matched-h"), prometheusRegistry)(h)
+			wrapped = tracing.Middleware(wrapped)
 			wrapped = paramctx.ContextHandler()(wrapped)
 			return wrapped
 		}
@@ -314,6 +409,8 @@ func main() {
 		InstallerAPI:          bm,
 		AssistedServiceIsoAPI: assistedServiceISO,
 		EventsAPI:             events,
+		WebhooksAPI:           webhooksApi,
+		HistoryAPI:            historyApi,
 		Logger:                log.Printf,
 		VersionsAPI:           versionHandler,
 		ManagedDomainsAPI:     domainHandler,
@@ -340,8 +437,9 @@ func main() {
 	case deployment_type_k8s:
 		go func() {
 			defer apiEnabler.Enable()
-			baseISOUploadLeader := leader.NewElector(k8sClient, leader.Config{LeaseDuration: 5 * time.Second,
-				RetryInterval: 2 * time.Second, Namespace: Options.LeaderConfig.Namespace, RenewDeadline: 4 * time.Second},
+			baseISOUploadLeader := newElector(k8sClient, db, leader.Config{LeaseDuration: 5 * time.Second,
+				RetryInterval: 2 * time.Second, Namespace: Options.LeaderConfig.Namespace, RenewDeadline: 4 * time.Second,
+				Elector: Options.LeaderConfig.Elector},
 				"assisted-service-baseiso-helper",
 				log.WithField("pkg", "baseISOUploadLeader"))
 			err = uploadBaseISOWithLeader(baseISOUploadLeader, objectHandler, generator, log)
@@ -364,6 +462,53 @@ func main() {
 	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%s", swag.StringValue(port)), h))
 }
 
+// closeOnSignal runs the close functions and exits once the service receives SIGINT or SIGTERM
+func closeOnSignal(log logrus.FieldLogger, closers ...func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		log.Infof("Received %s, stopping", sig)
+		for _, c := range closers {
+			c()
+		}
+		os.Exit(0)
+	}()
+}
+
+// loadKubeConfig loads the config of the kubernetes API the way controller-runtime's config.GetConfig does, its
+// package can't be built with the logr version of the OpenTelemetry SDK. The config is loaded from the --kubeconfig
+// flag, the KUBECONFIG variable, the in-cluster config or $HOME/.kube/config, in this order.
+func loadKubeConfig(kubeconfig string) (*rest.Config, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		loadingRules = &clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig}
+	} else if os.Getenv(clientcmd.RecommendedConfigPathEnvVar) == "" {
+		if cfg, err := rest.InClusterConfig(); err == nil {
+			return withDefaultRateLimits(cfg), nil
+		}
+	}
+	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return withDefaultRateLimits(cfg), nil
+}
+
+// withDefaultRateLimits applies the rate limits of the kubernetes controller manager, as controller-runtime does
+func withDefaultRateLimits(cfg *rest.Config) *rest.Config {
+	if cfg.QPS == 0.0 {
+		cfg.QPS = 20.0
+		cfg.Burst = 30
+	}
+	return cfg
+}
+
+// generatorWithTracing wraps the generator where its package isn't shadowed by the variable of main
+func generatorWithTracing(isoGenerator generator.ISOInstallConfigGenerator) generator.ISOInstallConfigGenerator {
+	return generator.WithTracing(isoGenerator)
+}
+
 func createS3Bucket(objectHandler s3wrapper.API) {
 	if Options.CreateS3Bucket {
 		if err := objectHandler.CreateBucket(); err != nil {
@@ -401,10 +546,48 @@ func (a *ApiEnabler) Enable() {
 	a.log.Info("API is enabled")
 }
 
+func setupDB(log logrus.FieldLogger) *gorm.DB {
+	dbConnection, err := db.Open(Options.DBConfig)
+	if err != nil {
+		log.Fatal("Fail to connect to DB, ", err)
+	}
+	log.Infof("Connected to %s database", Options.DBConfig.Dialect)
+	return dbConnection
+}
+
+// newElector creates the leader elector selected by the configuration, the name identifies the work
+// that the leader runs
+func newElector(k8sClient *kubernetes.Clientset, db *gorm.DB, cfg leader.Config, name string, log logrus.FieldLogger) leader.ElectorInterface {
+	elector := cfg.Elector
+	if elector == "" {
+		elector = leader.ElectorNone
+		if Options.DeployTarget == deployment_type_k8s {
+			elector = leader.ElectorKubernetes
+		}
+	}
+	switch elector {
+	case leader.ElectorKubernetes:
+		if k8sClient == nil {
+			log.Fatalf("%s leader elector is supported only with %s deploy target", elector, deployment_type_k8s)
+		}
+		return leader.NewElector(k8sClient, cfg, name, log)
+	case leader.ElectorDatabase:
+		if err := migrations.AutoMigrateLeaderLeases(db); err != nil {
+			log.WithError(err).Fatal("Failed to migrate leader leases")
+		}
+		return leader.NewDBElector(db, cfg, name, log)
+	case leader.ElectorNone:
+		return &leader.DummyElector{}
+	default:
+		log.Fatalf("not supported leader elector %s", elector)
+		return nil
+	}
+}
+
 func autoMigrationWithLeader(migrationLeader leader.ElectorInterface, db *gorm.DB, log logrus.FieldLogger) error {
 	return migrationLeader.RunWithLeader(context.Background(), func() error {
 		log.Infof("Start automigration")
-		err := db.AutoMigrate(&models.Host{}, &common.Cluster{}, &events.Event{})
+		err := migrations.AutoMigrate(db)
 		if err != nil {
 			log.WithError(err).Fatal("Failed auto migration process")
 			return err
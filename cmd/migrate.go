@@ -0,0 +1,123 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/openshift/assisted-service/internal/migrations"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const migrateCommand = "migrate"
+
+const migrateUsage = `Usage: assisted-service migrate <command> [flags]
+
+Commands:
+  status      list all migrations and whether they were applied
+  pending     list the migrations that were not applied yet
+  up          apply the schema and all pending migrations
+  rollback    roll back the last applied migrations
+
+Flags:
+`
+
+// runMigrateCommand handles the migrate subcommand of the service binary. It works directly on the database
+// configured for the service and does not wait for leader election, so it should not run alongside a
+// replica that is applying migrations itself.
+func runMigrateCommand(args []string, log logrus.FieldLogger) error {
+	fs := flag.NewFlagSet(migrateCommand, flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "print the statements that would run, without changing the database")
+	steps := fs.Int("steps", 1, "number of migrations to roll back")
+	force := fs.Bool("force", false, "roll back migrations even if their rollback deletes data")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), migrateUsage)
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		return errors.New("missing migrate command")
+	}
+	command := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	db := setupDB(log)
+	out := os.Stdout
+
+	switch command {
+	case "status":
+		return printMigrationStatus(db, out)
+	case "pending":
+		pending, err := migrations.Pending(db)
+		if err != nil {
+			return err
+		}
+		for _, id := range pending {
+			fmt.Fprintln(out, id)
+		}
+		return nil
+	case "up":
+		return runMigration(db, out, *dryRun, func(tx *gorm.DB) error {
+			if err := migrations.AutoMigrate(tx); err != nil {
+				return errors.Wrap(err, "auto migration failed")
+			}
+			return migrations.Migrate(tx)
+		})
+	case "rollback":
+		warnings, err := migrations.RollbackWarnings(db, *steps)
+		if err != nil {
+			return err
+		}
+		for _, w := range warnings {
+			if *dryRun {
+				fmt.Fprintf(out, "-- DESTRUCTIVE: %s\n", w)
+			} else {
+				log.Warn(w)
+			}
+		}
+		if len(warnings) > 0 && !*dryRun && !*force {
+			return errors.New("refusing to run destructive rollbacks without --force")
+		}
+		return runMigration(db, out, *dryRun, func(tx *gorm.DB) error {
+			ids, err := migrations.Rollback(tx, *steps, *force || *dryRun)
+			for _, id := range ids {
+				log.Infof("Rolled back migration %s", id)
+			}
+			return err
+		})
+	default:
+		fs.Usage()
+		return errors.Errorf("unknown migrate command %s", command)
+	}
+}
+
+func printMigrationStatus(db *gorm.DB, out io.Writer) error {
+	statuses, err := migrations.Status(db)
+	if err != nil {
+		return err
+	}
+	for _, s := range statuses {
+		state := "pending"
+		if s.Applied {
+			state = "applied"
+		}
+		fmt.Fprintf(out, "%s\t%s\n", s.ID, state)
+	}
+	return nil
+}
+
+func runMigration(db *gorm.DB, out io.Writer, dryRun bool, run func(tx *gorm.DB) error) error {
+	if !dryRun {
+		return run(db)
+	}
+	statements, err := migrations.DryRun(db, run)
+	for _, s := range statements {
+		fmt.Fprintf(out, "%s;\n", s)
+	}
+	return err
+}
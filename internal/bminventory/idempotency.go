@@ -0,0 +1,188 @@
+package bminventory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/pkg/auth"
+	"github.com/openshift/assisted-service/pkg/leader"
+	logutil "github.com/openshift/assisted-service/pkg/log"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// idempotent runs the handler of a request that was sent with an Idempotency-Key header once per user,
+// operation and key. The response is kept for IdempotencyKeyTTL, retries of the request during that time
+// get the original response. Responses with a server error are not kept, so the request can be retried.
+// Retries of a request that is still running get a conflict for IdempotencyKeyLease, after that they run
+// the request again.
+func (b *bareMetalInventory) idempotent(ctx context.Context, operation string, key *string, request interface{},
+	handler func() middleware.Responder) middleware.Responder {
+	if key == nil || *key == "" {
+		return handler()
+	}
+	log := logutil.FromContext(ctx, b.log)
+
+	requestHash, err := hashRequest(request)
+	if err != nil {
+		return common.NewApiError(http.StatusInternalServerError, err)
+	}
+
+	now := time.Now()
+	leaseExpiresAt := now.Add(b.Config.IdempotencyKeyLease)
+	record := common.IdempotencyKey{
+		UserName:       auth.UserNameFromContext(ctx),
+		Operation:      operation,
+		Key:            *key,
+		RequestHash:    requestHash,
+		ExpiresAt:      now.Add(b.Config.IdempotencyKeyTTL),
+		LeaseExpiresAt: &leaseExpiresAt,
+	}
+	if err = b.db.Create(&record).Error; err != nil {
+		// the key was already used, unless creating the record failed for another reason
+		claimed, claimErr := b.claimIdempotencyKey(&record, now)
+		if claimErr != nil {
+			log.WithError(claimErr).Errorf("failed to claim idempotency key %s of %s", *key, operation)
+			return common.NewApiError(http.StatusInternalServerError, claimErr)
+		}
+		if !claimed {
+			var existing common.IdempotencyKey
+			if lookupErr := b.db.Where(idempotencyKeyQuery(&record)).Take(&existing).Error; lookupErr != nil {
+				log.WithError(err).Errorf("failed to store idempotency key %s of %s", *key, operation)
+				return common.NewApiError(http.StatusInternalServerError, err)
+			}
+			return replayResponse(&existing, requestHash)
+		}
+		log.Infof("Running the request of idempotency key %s of %s again", *key, operation)
+	}
+
+	release := func() {
+		if err := b.db.Where(idempotencyKeyQuery(&record)).Delete(&common.IdempotencyKey{}).Error; err != nil {
+			log.WithError(err).Errorf("failed to release idempotency key %s of %s", *key, operation)
+		}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			release()
+			panic(r)
+		}
+	}()
+
+	responder := handler()
+
+	recorder := httptest.NewRecorder()
+	responder.WriteResponse(recorder, runtime.JSONProducer())
+	if recorder.Code >= http.StatusInternalServerError {
+		release()
+		return responder
+	}
+	header, err := json.Marshal(recorder.Header())
+	if err != nil {
+		log.WithError(err).Errorf("failed to store the response for idempotency key %s of %s", *key, operation)
+		return responder
+	}
+	if err = b.db.Model(&common.IdempotencyKey{}).Where(idempotencyKeyQuery(&record)).Updates(map[string]interface{}{
+		"status_code": recorder.Code,
+		"header":      string(header),
+		"body":        recorder.Body.Bytes(),
+	}).Error; err != nil {
+		log.WithError(err).Errorf("failed to store the response for idempotency key %s of %s", *key, operation)
+	}
+	return responder
+}
+
+// claimIdempotencyKey takes over a key that is no longer kept, or whose request with the same parameters didn't
+// complete before its lease expired. The condition is checked by the update, so only one retry claims the key.
+func (b *bareMetalInventory) claimIdempotencyKey(record *common.IdempotencyKey, now time.Time) (bool, error) {
+	reply := b.db.Model(&common.IdempotencyKey{}).Where(idempotencyKeyQuery(record)).
+		Where("expires_at < ? or (status_code = 0 and request_hash = ? and (lease_expires_at is null or lease_expires_at < ?))",
+			now, record.RequestHash, now).
+		Updates(map[string]interface{}{
+			"request_hash":     record.RequestHash,
+			"status_code":      0,
+			"header":           "",
+			"body":             nil,
+			"expires_at":       record.ExpiresAt,
+			"lease_expires_at": record.LeaseExpiresAt,
+		})
+	return reply.RowsAffected == 1, reply.Error
+}
+
+// DeleteExpiredIdempotencyKeys deletes the idempotency keys whose responses are no longer kept, it runs on the leader
+func (b *bareMetalInventory) DeleteExpiredIdempotencyKeys() {
+	if !b.leaderElector.IsLeader() {
+		return
+	}
+	var deleted int64
+	err := leader.InTransaction(b.db, b.leaderElector, func(tx *gorm.DB) error {
+		reply := tx.Where("expires_at < ?", time.Now()).Delete(&common.IdempotencyKey{})
+		deleted = reply.RowsAffected
+		return reply.Error
+	})
+	if err != nil {
+		b.log.WithError(err).Error("failed to delete expired idempotency keys")
+		return
+	}
+	if deleted > 0 {
+		b.log.Infof("Deleted %d expired idempotency keys", deleted)
+	}
+}
+
+func idempotencyKeyQuery(record *common.IdempotencyKey) map[string]interface{} {
+	return map[string]interface{}{
+		"user_name": record.UserName,
+		"operation": record.Operation,
+		"key":       record.Key,
+	}
+}
+
+func hashRequest(request interface{}) (string, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to hash request")
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func replayResponse(record *common.IdempotencyKey, requestHash string) middleware.Responder {
+	if record.RequestHash != requestHash {
+		return common.NewApiError(http.StatusUnprocessableEntity,
+			errors.Errorf("Idempotency-Key %s was already used with a different request", record.Key))
+	}
+	if record.StatusCode == 0 {
+		return common.NewApiError(http.StatusConflict,
+			errors.Errorf("a request with Idempotency-Key %s is still in progress", record.Key))
+	}
+	response := &replayedResponse{statusCode: record.StatusCode, body: record.Body}
+	if err := json.Unmarshal([]byte(record.Header), &response.header); err != nil {
+		return common.NewApiError(http.StatusInternalServerError, errors.Wrap(err, "failed to replay response"))
+	}
+	return response
+}
+
+// replayedResponse writes a response that was recorded for an idempotency key
+type replayedResponse struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+func (r *replayedResponse) WriteResponse(rw http.ResponseWriter, _ runtime.Producer) {
+	for name, values := range r.header {
+		rw.Header()[name] = values
+	}
+	rw.Header().Set("Idempotent-Replayed", "true")
+	rw.WriteHeader(r.statusCode)
+	if _, err := rw.Write(r.body); err != nil {
+		panic(err) // let the recovery middleware deal with this
+	}
+}
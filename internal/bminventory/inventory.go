@@ -34,6 +34,7 @@ import (
 	"github.com/openshift/assisted-service/internal/cluster/validations"
 	"github.com/openshift/assisted-service/internal/common"
 	"github.com/openshift/assisted-service/internal/events"
+	"github.com/openshift/assisted-service/internal/hardware"
 	"github.com/openshift/assisted-service/internal/host"
 	"github.com/openshift/assisted-service/internal/hostutil"
 	"github.com/openshift/assisted-service/internal/identity"
@@ -42,14 +43,16 @@ import (
 	"github.com/openshift/assisted-service/internal/manifests"
 	"github.com/openshift/assisted-service/internal/metrics"
 	"github.com/openshift/assisted-service/internal/network"
+	"github.com/openshift/assisted-service/internal/refresh"
 	"github.com/openshift/assisted-service/models"
 	"github.com/openshift/assisted-service/pkg/auth"
+	dbPkg "github.com/openshift/assisted-service/pkg/db"
 	"github.com/openshift/assisted-service/pkg/filemiddleware"
 	"github.com/openshift/assisted-service/pkg/generator"
 	"github.com/openshift/assisted-service/pkg/k8sclient"
 	logutil "github.com/openshift/assisted-service/pkg/log"
-	"github.com/openshift/assisted-service/pkg/requestid"
 	"github.com/openshift/assisted-service/pkg/s3wrapper"
+	"github.com/openshift/assisted-service/pkg/tracing"
 	"github.com/openshift/assisted-service/pkg/transaction"
 	"github.com/openshift/assisted-service/restapi"
 	"github.com/openshift/assisted-service/restapi/operations/installer"
@@ -57,6 +60,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/thoas/go-funk"
 	"github.com/vincent-petithory/dataurl"
+	"go.opentelemetry.io/otel/attribute"
 	"gorm.io/gorm"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/tools/clientcmd"
@@ -93,6 +97,8 @@ type Config struct {
 	AgentTimeoutStart        time.Duration     `envconfig:"AGENT_TIMEOUT_START" default:"3m"`
 	ServiceIPs               string            `envconfig:"SERVICE_IPS" default:""`
 	DeletedUnregisteredAfter time.Duration     `envconfig:"DELETED_UNREGISTERED_AFTER" default:"168h"`
+	IdempotencyKeyTTL        time.Duration     `envconfig:"IDEMPOTENCY_KEY_TTL" default:"24h"`
+	IdempotencyKeyLease      time.Duration     `envconfig:"IDEMPOTENCY_KEY_LEASE" default:"5m"`
 }
 
 const agentMessageOfTheDay = `
@@ -225,6 +231,7 @@ type bareMetalInventory struct {
 	k8sClient       k8sclient.K8SClient
 	leaderElector   leader.Leader
 	secretValidator validations.PullSecretValidator
+	refresher       refresh.Notifier
 }
 
 var _ restapi.InstallerAPI = &bareMetalInventory{}
@@ -243,6 +250,7 @@ func NewBareMetalInventory(
 	k8sClient k8sclient.K8SClient,
 	leaderElector leader.Leader,
 	pullSecretValidator validations.PullSecretValidator,
+	refresher refresh.Notifier,
 ) *bareMetalInventory {
 	return &bareMetalInventory{
 		db:              db,
@@ -258,6 +266,7 @@ func NewBareMetalInventory(
 		k8sClient:       k8sClient,
 		leaderElector:   leaderElector,
 		secretValidator: pullSecretValidator,
+		refresher:       refresher,
 	}
 }
 
@@ -400,8 +409,16 @@ func (b *bareMetalInventory) UpdateDiscoveryIgnition(ctx context.Context, params
 		return installer.NewUpdateDiscoveryIgnitionBadRequest().WithPayload(common.GenerateError(http.StatusBadRequest, err))
 	}
 
-	err = b.db.Model(&common.Cluster{}).Where(identity.AddUserFilter(ctx, "id = ?"), params.ClusterID).Update("ignition_config_overrides", params.DiscoveryIgnitionParams.Config).Error
+	err = transaction.InClusterTransaction(ctx, b.db.WithContext(ctx), params.ClusterID, func(ctx context.Context, tx *gorm.DB) error {
+		if err = checkClusterResourceVersion(tx, params.ClusterID, params.IfMatch); err != nil {
+			return err
+		}
+		return tx.Model(&common.Cluster{}).Where(identity.AddUserFilter(ctx, "id = ?"), params.ClusterID).Update("ignition_config_overrides", params.DiscoveryIgnitionParams.Config).Error
+	})
 	if err != nil {
+		if common.IsKnownError(err) {
+			return common.GenerateErrorResponder(err)
+		}
 		return installer.NewUpdateDiscoveryIgnitionInternalServerError().WithPayload(common.GenerateError(http.StatusInternalServerError, err))
 	}
 
@@ -409,6 +426,12 @@ func (b *bareMetalInventory) UpdateDiscoveryIgnition(ctx context.Context, params
 }
 
 func (b *bareMetalInventory) RegisterCluster(ctx context.Context, params installer.RegisterClusterParams) middleware.Responder {
+	return b.idempotent(ctx, "RegisterCluster", params.IdempotencyKey, params.NewClusterParams, func() middleware.Responder {
+		return b.registerCluster(ctx, params)
+	})
+}
+
+func (b *bareMetalInventory) registerCluster(ctx context.Context, params installer.RegisterClusterParams) middleware.Responder {
 	log := logutil.FromContext(ctx, b.log)
 	id := strfmt.UUID(uuid.New().String())
 	url := installer.GetClusterURL{ClusterID: id}
@@ -419,7 +442,8 @@ func (b *bareMetalInventory) RegisterCluster(ctx context.Context, params install
 			msg := fmt.Sprintf("Successfully registered cluster %s with id %s",
 				swag.StringValue(params.NewClusterParams.Name), id)
 			log.Info(msg)
-			b.eventsHandler.AddEvent(ctx, id, nil, models.EventSeverityInfo, msg, time.Now())
+			b.eventsHandler.AddEvent(ctx, id, nil, models.EventSeverityInfo, msg, time.Now(), events.ClusterRegistered,
+				map[string]interface{}{"name": swag.StringValue(params.NewClusterParams.Name), "add_hosts": false})
 		} else {
 			log.Errorf("Failed to registered cluster %s with id %s",
 				swag.StringValue(params.NewClusterParams.Name), id)
@@ -469,6 +493,7 @@ func (b *bareMetalInventory) RegisterCluster(ctx context.Context, params install
 		HTTPSProxy:               swag.StringValue(params.NewClusterParams.HTTPSProxy),
 		NoProxy:                  swag.StringValue(params.NewClusterParams.NoProxy),
 		VipDhcpAllocation:        params.NewClusterParams.VipDhcpAllocation,
+		HardwareProfile:          hardware.DefaultProfile,
 	}}
 
 	if proxyHash, err := computeClusterProxyHash(params.NewClusterParams.HTTPProxy,
@@ -498,6 +523,19 @@ func (b *bareMetalInventory) RegisterCluster(ctx context.Context, params install
 		return common.NewApiError(http.StatusBadRequest, err)
 	}
 
+	if params.NewClusterParams.HardwareProfile != nil {
+		if err = b.validateHardwareProfile(*params.NewClusterParams.HardwareProfile); err != nil {
+			return common.GenerateErrorResponder(err)
+		}
+		cluster.HardwareProfile = *params.NewClusterParams.HardwareProfile
+	}
+
+	if params.NewClusterParams.DiskSelectionPolicy != nil {
+		if cluster.DiskSelectionPolicy, err = marshalDiskSelectionPolicy(params.NewClusterParams.DiskSelectionPolicy); err != nil {
+			return common.GenerateErrorResponder(err)
+		}
+	}
+
 	if sshPublicKey := swag.StringValue(&cluster.SSHPublicKey); sshPublicKey != "" {
 		sshPublicKey = strings.TrimSpace(cluster.SSHPublicKey)
 		if err = validations.ValidateSSHPublicKey(sshPublicKey); err != nil {
@@ -539,6 +577,7 @@ func (b *bareMetalInventory) RegisterAddHostsCluster(ctx context.Context, params
 		EmailDomain:      auth.EmailDomainFromContext(ctx),
 		UpdatedAt:        strfmt.DateTime{},
 		APIVipDNSName:    swag.String(apivipDnsname),
+		HardwareProfile:  hardware.DefaultProfile,
 	}}
 
 	err := validations.ValidateClusterNameFormat(clusterName)
@@ -598,22 +637,27 @@ func (b *bareMetalInventory) DeregisterCluster(ctx context.Context, params insta
 	var cluster common.Cluster
 	log.Infof("Deregister cluster id %s", params.ClusterID)
 
-	if err := b.db.First(&cluster, "id = ?", params.ClusterID).Error; err != nil {
-		return installer.NewDeregisterClusterNotFound().
-			WithPayload(common.GenerateError(http.StatusNotFound, err))
+	err := transaction.InClusterTransaction(ctx, b.db.WithContext(ctx), params.ClusterID, func(ctx context.Context, tx *gorm.DB) error {
+		if err := tx.First(&cluster, "id = ?", params.ClusterID).Error; err != nil {
+			return common.NewApiError(http.StatusNotFound, err)
+		}
+		if err := checkResourceVersion(params.IfMatch, cluster.ResourceVersion); err != nil {
+			return err
+		}
+		if err := b.clusterApi.DeregisterCluster(ctx, &cluster, tx); err != nil {
+			log.WithError(err).Errorf("failed to deregister cluster cluster %s", params.ClusterID)
+			return common.NewApiError(http.StatusNotFound, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return common.GenerateErrorResponder(err)
 	}
 
 	if err := b.deleteDNSRecordSets(ctx, cluster); err != nil {
 		log.Warnf("failed to delete DNS record sets for base domain: %s", cluster.BaseDNSDomain)
 	}
 
-	err := b.clusterApi.DeregisterCluster(ctx, &cluster)
-	if err != nil {
-		log.WithError(err).Errorf("failed to deregister cluster cluster %s", params.ClusterID)
-		return installer.NewDeregisterClusterNotFound().
-			WithPayload(common.GenerateError(http.StatusNotFound, err))
-	}
-
 	return installer.NewDeregisterClusterNoContent()
 }
 
@@ -621,7 +665,7 @@ func (b *bareMetalInventory) DownloadClusterISO(ctx context.Context, params inst
 	log := logutil.FromContext(ctx, b.log)
 	var cluster common.Cluster
 
-	if err := b.db.First(&cluster, "id = ?", params.ClusterID).Error; err != nil {
+	if err := b.db.WithContext(ctx).First(&cluster, "id = ?", params.ClusterID).Error; err != nil {
 		log.WithError(err).Errorf("failed to get cluster %s", params.ClusterID)
 		return common.NewApiError(http.StatusNotFound, err)
 	}
@@ -631,13 +675,14 @@ func (b *bareMetalInventory) DownloadClusterISO(ctx context.Context, params inst
 	if err != nil {
 		log.WithError(err).Errorf("Failed to get ISO for cluster %s", cluster.ID.String())
 		b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityError,
-			"Failed to download image: error fetching from storage backend", time.Now())
+			"Failed to download image: error fetching from storage backend", time.Now(), events.ClusterImageDownloadFailed, nil)
 		return installer.NewDownloadClusterISOInternalServerError().
 			WithPayload(common.GenerateError(http.StatusInternalServerError, err))
 	}
 	if !exists {
 		b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityError,
-			"Failed to download image: the image was not found (perhaps it expired) - please generate the image and try again", time.Now())
+			"Failed to download image: the image was not found (perhaps it expired) - please generate the image and try again", time.Now(),
+			events.ClusterImageDownloadFailed, nil)
 		return installer.NewDownloadClusterISONotFound().
 			WithPayload(common.GenerateError(http.StatusNotFound, errors.New("The image was not found "+
 				"(perhaps it expired) - please generate the image and try again")))
@@ -646,11 +691,12 @@ func (b *bareMetalInventory) DownloadClusterISO(ctx context.Context, params inst
 	if err != nil {
 		log.WithError(err).Errorf("Failed to get ISO for cluster %s", cluster.ID.String())
 		b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityError,
-			"Failed to download image: error fetching from storage backend", time.Now())
+			"Failed to download image: error fetching from storage backend", time.Now(), events.ClusterImageDownloadFailed, nil)
 		return installer.NewDownloadClusterISOInternalServerError().
 			WithPayload(common.GenerateError(http.StatusInternalServerError, err))
 	}
-	b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityInfo, "Started image download", time.Now())
+	b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityInfo, "Started image download", time.Now(),
+		events.ClusterImageDownloadStarted, nil)
 
 	return filemiddleware.NewResponder(installer.NewDownloadClusterISOOK().WithPayload(reader),
 		fmt.Sprintf("cluster-%s-discovery.iso", params.ClusterID.String()),
@@ -682,7 +728,7 @@ func (b *bareMetalInventory) updateImageInfoPostUpload(ctx context.Context, clus
 		cluster.ProxyHash = clusterProxyHash
 	}
 
-	dbReply := b.db.Model(&common.Cluster{}).Where("id = ?", cluster.ID.String()).Updates(updates)
+	dbReply := b.db.WithContext(ctx).Model(&common.Cluster{}).Where("id = ?", cluster.ID.String()).Updates(updates)
 	if dbReply.Error != nil {
 		return errors.New("Failed to generate image: error updating image record")
 	}
@@ -691,12 +737,19 @@ func (b *bareMetalInventory) updateImageInfoPostUpload(ctx context.Context, clus
 }
 
 func (b *bareMetalInventory) GenerateClusterISO(ctx context.Context, params installer.GenerateClusterISOParams) middleware.Responder {
+	return b.idempotent(ctx, "GenerateClusterISO", params.IdempotencyKey, []interface{}{params.ClusterID, params.ImageCreateParams}, func() middleware.Responder {
+		return b.generateClusterISO(ctx, params)
+	})
+}
+
+func (b *bareMetalInventory) generateClusterISO(ctx context.Context, params installer.GenerateClusterISOParams) middleware.Responder {
 	log := logutil.FromContext(ctx, b.log)
 	log.Infof("prepare image for cluster %s", params.ClusterID)
 	var cluster common.Cluster
 
 	txSuccess := false
-	tx := b.db.Begin()
+	ctx, tx, unlock, lockErr := transaction.BeginInCluster(ctx, b.db.WithContext(ctx), params.ClusterID)
+	defer unlock()
 	defer func() {
 		if !txSuccess {
 			log.Error("generate cluster ISO failed")
@@ -710,18 +763,27 @@ func (b *bareMetalInventory) GenerateClusterISO(ctx context.Context, params inst
 
 	if tx.Error != nil {
 		msg := "Failed to generate image: error starting DB transaction"
-		b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityError, msg, time.Now())
+		b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityError, msg, time.Now(), events.ClusterImageGenerationFailed, nil)
 		log.WithError(tx.Error).Errorf("failed to start db transaction")
 		return installer.NewInstallClusterInternalServerError().
 			WithPayload(common.GenerateError(http.StatusInternalServerError, errors.New("DB error, failed to start transaction")))
 	}
 
+	if lockErr != nil {
+		log.WithError(lockErr).Errorf("failed to lock cluster %s", params.ClusterID)
+		return common.NewApiError(http.StatusInternalServerError, lockErr)
+	}
+
 	if err := tx.First(&cluster, "id = ?", params.ClusterID).Error; err != nil {
 		log.WithError(err).Errorf("failed to get cluster: %s", params.ClusterID)
 		return installer.NewGenerateClusterISONotFound().
 			WithPayload(common.GenerateError(http.StatusNotFound, err))
 	}
 
+	if err := checkResourceVersion(params.IfMatch, cluster.ResourceVersion); err != nil {
+		return common.GenerateErrorResponder(err)
+	}
+
 	/* We need to ensure that the metadata in the DB matches the image that will be uploaded to S3,
 	so we check that at least 10 seconds have past since the previous request to reduce the chance
 	of a race between two consecutive requests.
@@ -731,7 +793,7 @@ func (b *bareMetalInventory) GenerateClusterISO(ctx context.Context, params inst
 	if previousCreatedAt.Add(10 * time.Second).After(now) {
 		log.Error("request came too soon after previous request")
 		msg := "Failed to generate image: another request to generate an image has been recently submitted - please wait a few seconds and try again"
-		b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityError, msg, time.Now())
+		b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityError, msg, time.Now(), events.ClusterImageGenerationFailed, nil)
 		return installer.NewGenerateClusterISOConflict().WithPayload(common.GenerateError(http.StatusConflict,
 			errors.New("Another request to generate an image has been recently submitted. Please wait a few seconds and try again.")))
 	}
@@ -762,7 +824,7 @@ func (b *bareMetalInventory) GenerateClusterISO(ctx context.Context, params inst
 		if err != nil {
 			log.WithError(err).Errorf("failed to contact storage backend")
 			b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityError,
-				"Failed to generate image: error contacting storage backend", time.Now())
+				"Failed to generate image: error contacting storage backend", time.Now(), events.ClusterImageGenerationFailed, nil)
 			return installer.NewInstallClusterInternalServerError().
 				WithPayload(common.GenerateError(http.StatusInternalServerError, errors.New("failed to contact storage backend")))
 		}
@@ -778,21 +840,21 @@ func (b *bareMetalInventory) GenerateClusterISO(ctx context.Context, params inst
 	if dbReply.Error != nil {
 		log.WithError(dbReply.Error).Errorf("failed to update cluster: %s", params.ClusterID)
 		msg := "Failed to generate image: error updating metadata"
-		b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityError, msg, time.Now())
+		b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityError, msg, time.Now(), events.ClusterImageGenerationFailed, nil)
 		return installer.NewGenerateClusterISOInternalServerError()
 	}
 
 	if err := tx.Commit().Error; err != nil {
 		log.Error(err)
 		msg := "Failed to generate image: error committing the transaction"
-		b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityError, msg, time.Now())
+		b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityError, msg, time.Now(), events.ClusterImageGenerationFailed, nil)
 		return installer.NewGenerateClusterISOInternalServerError()
 	}
 	txSuccess = true
-	if err := b.db.Preload("Hosts").First(&cluster, "id = ?", params.ClusterID).Error; err != nil {
+	if err := b.db.WithContext(ctx).Preload("Hosts").First(&cluster, "id = ?", params.ClusterID).Error; err != nil {
 		log.WithError(err).Errorf("failed to get cluster %s after update", params.ClusterID)
 		msg := "Failed to generate image: error fetching updated cluster metadata"
-		b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityError, msg, time.Now())
+		b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityError, msg, time.Now(), events.ClusterImageGenerationFailed, nil)
 		return installer.NewUpdateClusterInternalServerError().
 			WithPayload(common.GenerateError(http.StatusInternalServerError, err))
 	}
@@ -804,21 +866,23 @@ func (b *bareMetalInventory) GenerateClusterISO(ctx context.Context, params inst
 		}
 
 		log.Infof("Re-used existing cluster <%s> image", params.ClusterID)
-		b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityInfo, "Re-used existing image rather than generating a new one", time.Now())
+		b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityInfo, "Re-used existing image rather than generating a new one", time.Now(),
+			events.ClusterImageReused, nil)
 		return installer.NewGenerateClusterISOCreated().WithPayload(&cluster.Cluster)
 	}
 	ignitionConfig, formatErr := b.formatIgnitionFile(&cluster, params, log, false)
 	if formatErr != nil {
 		log.WithError(formatErr).Errorf("failed to format ignition config file for cluster %s", cluster.ID)
 		msg := "Failed to generate image: error formatting ignition file"
-		b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityError, msg, time.Now())
+		b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityError, msg, time.Now(), events.ClusterImageGenerationFailed, nil)
 		return installer.NewGenerateClusterISOInternalServerError().
 			WithPayload(common.GenerateError(http.StatusInternalServerError, formatErr))
 	}
 
 	if err := b.objectHandler.UploadISO(ctx, ignitionConfig, fmt.Sprintf("discovery-image-%s", cluster.ID.String())); err != nil {
 		log.WithError(err).Errorf("Upload ISO failed for cluster %s", cluster.ID)
-		b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityError, "Failed to upload image", time.Now())
+		b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityError, "Failed to upload image", time.Now(),
+			events.ClusterImageGenerationFailed, nil)
 		return installer.NewGenerateClusterISOInternalServerError().WithPayload(common.GenerateError(http.StatusInternalServerError, err))
 	}
 
@@ -847,7 +911,7 @@ func (b *bareMetalInventory) GenerateClusterISO(ctx context.Context, params inst
 
 	msg = fmt.Sprintf("%s (%s)", msg, strings.Join(msgExtras, ", "))
 
-	b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityInfo, msg, time.Now())
+	b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityInfo, msg, time.Now(), events.ClusterImageGenerated, nil)
 	return installer.NewGenerateClusterISOCreated().WithPayload(&cluster.Cluster)
 }
 
@@ -856,17 +920,16 @@ func getImageName(clusterID strfmt.UUID) string {
 }
 
 type clusterInstaller struct {
-	ctx    context.Context
 	b      *bareMetalInventory
 	log    logrus.FieldLogger
 	params installer.InstallClusterParams
 }
 
-func (c *clusterInstaller) installHosts(cluster *common.Cluster, tx *gorm.DB) error {
+func (c *clusterInstaller) installHosts(ctx context.Context, cluster *common.Cluster, tx *gorm.DB) error {
 	success := true
 	err := errors.Errorf("Failed to install cluster <%s>", cluster.ID.String())
 	for i := range cluster.Hosts {
-		if installErr := c.b.hostApi.Install(c.ctx, cluster.Hosts[i], tx); installErr != nil {
+		if installErr := c.b.hostApi.Install(ctx, cluster.Hosts[i], tx); installErr != nil {
 			success = false
 			// collect multiple errors
 			err = errors.Wrap(installErr, err.Error())
@@ -897,32 +960,29 @@ func (b *bareMetalInventory) refreshAllHosts(ctx context.Context, cluster *commo
 	return nil
 }
 
-func (c clusterInstaller) install(tx *gorm.DB) error {
+func (c clusterInstaller) install(ctx context.Context, tx *gorm.DB) error {
 	var cluster common.Cluster
 	var err error
 
-	// in case host monitor already updated the state we need to use FOR UPDATE option
-	tx = transaction.AddForUpdateQueryOption(tx)
-
 	if err = tx.Preload("Hosts").First(&cluster, "id = ?", c.params.ClusterID).Error; err != nil {
 		return errors.Wrapf(err, "failed to find cluster %s", c.params.ClusterID)
 	}
 
-	if err = c.b.createDNSRecordSets(c.ctx, cluster); err != nil {
+	if err = c.b.createDNSRecordSets(ctx, cluster); err != nil {
 		return errors.Wrapf(err, "failed to create DNS record sets for base domain: %s", cluster.BaseDNSDomain)
 	}
 
-	if err = c.b.clusterApi.Install(c.ctx, &cluster, tx); err != nil {
+	if err = c.b.clusterApi.Install(ctx, &cluster, tx); err != nil {
 		return errors.Wrapf(err, "failed to install cluster %s", cluster.ID.String())
 	}
 
 	// set one of the master nodes as bootstrap
-	if err = c.b.setBootstrapHost(c.ctx, cluster, tx); err != nil {
+	if err = c.b.setBootstrapHost(ctx, cluster, tx); err != nil {
 		return err
 	}
 
 	// move hosts states to installing
-	if err = c.installHosts(&cluster, tx); err != nil {
+	if err = c.installHosts(ctx, &cluster, tx); err != nil {
 		return err
 	}
 
@@ -948,7 +1008,7 @@ func (b *bareMetalInventory) storeOpenshiftClusterID(ctx context.Context, cluste
 	log.Debugf("Got OpenShift cluster ID of %s", openshiftClusterID)
 
 	log.Debugf("Storing Openshift cluster ID of cluster %s to DB", clusterID)
-	if err = b.db.Model(&common.Cluster{}).Where("id = ?", clusterID).Update(
+	if err = b.db.WithContext(ctx).Model(&common.Cluster{}).Where("id = ?", clusterID).Update(
 		"openshift_cluster_id", openshiftClusterID).Error; err != nil {
 		log.WithError(err).Errorf("Failed storing Openshift cluster ID of cluster %s to DB", clusterID)
 		return err
@@ -958,16 +1018,27 @@ func (b *bareMetalInventory) storeOpenshiftClusterID(ctx context.Context, cluste
 }
 
 func (b *bareMetalInventory) InstallCluster(ctx context.Context, params installer.InstallClusterParams) middleware.Responder {
+	return b.idempotent(ctx, "InstallCluster", params.IdempotencyKey, params.ClusterID, func() middleware.Responder {
+		return b.installCluster(ctx, params)
+	})
+}
+
+func (b *bareMetalInventory) installCluster(ctx context.Context, params installer.InstallClusterParams) middleware.Responder {
 	log := logutil.FromContext(ctx, b.log)
 	var cluster common.Cluster
 	var err error
+	// the statements run with the context of the request to be part of its trace
+	db := b.db.WithContext(ctx)
 
-	if err = b.db.Preload("Hosts", "status <> ?", models.HostStatusDisabled).
+	if err = db.Preload("Hosts", "status <> ?", models.HostStatusDisabled).
 		First(&cluster, "id = ?", params.ClusterID).Error; err != nil {
 		return common.NewApiError(http.StatusNotFound, err)
 	}
 	// auto select hosts roles if not selected yet.
-	err = b.db.Transaction(func(tx *gorm.DB) error {
+	err = transaction.InClusterTransaction(ctx, db, params.ClusterID, func(ctx context.Context, tx *gorm.DB) error {
+		if err = checkClusterResourceVersion(tx, params.ClusterID, params.IfMatch); err != nil {
+			return err
+		}
 		for i := range cluster.Hosts {
 			if err = b.hostApi.AutoAssignRole(ctx, cluster.Hosts[i], tx); err != nil {
 				return err
@@ -982,12 +1053,12 @@ func (b *bareMetalInventory) InstallCluster(ctx context.Context, params installe
 	if err = b.refreshAllHosts(ctx, &cluster); err != nil {
 		return common.GenerateErrorResponder(err)
 	}
-	if _, err = b.clusterApi.RefreshStatus(ctx, &cluster, b.db); err != nil {
+	if _, err = b.clusterApi.RefreshStatus(ctx, &cluster, db); err != nil {
 		return common.GenerateErrorResponder(err)
 	}
 
 	// Reload again after refresh
-	if err = b.db.Preload("Hosts", "status <> ?", models.HostStatusDisabled).First(&cluster, "id = ?", params.ClusterID).Error; err != nil {
+	if err = db.Preload("Hosts", "status <> ?", models.HostStatusDisabled).First(&cluster, "id = ?", params.ClusterID).Error; err != nil {
 		return common.NewApiError(http.StatusNotFound, err)
 	}
 	// Verify cluster is ready to install
@@ -997,10 +1068,8 @@ func (b *bareMetalInventory) InstallCluster(ctx context.Context, params installe
 	}
 
 	// prepare cluster and hosts for installation
-	err = b.db.Transaction(func(tx *gorm.DB) error {
-		// in case host monitor already updated the state we need to use FOR UPDATE option
-		tx = transaction.AddForUpdateQueryOption(tx)
-
+	// in case host monitor already updated the state we need to hold the cluster lock
+	err = transaction.InClusterTransaction(ctx, db, params.ClusterID, func(ctx context.Context, tx *gorm.DB) error {
 		if err = b.clusterApi.PrepareForInstallation(ctx, &cluster, tx); err != nil {
 			return err
 		}
@@ -1017,19 +1086,21 @@ func (b *bareMetalInventory) InstallCluster(ctx context.Context, params installe
 		return common.GenerateErrorResponder(err)
 	}
 
-	if err = b.db.Preload("Hosts").First(&cluster, "id = ?", params.ClusterID).Error; err != nil {
+	if err = db.Preload("Hosts").First(&cluster, "id = ?", params.ClusterID).Error; err != nil {
 		return common.GenerateErrorResponder(err)
 	}
 
 	go func() {
 		var err error
-		asyncCtx := requestid.ToContext(context.Background(), requestid.FromContext(ctx))
+		asyncCtx, span := tracing.Start(tracing.Detach(ctx), "InstallClusterAsync", attribute.String("cluster.id", params.ClusterID.String()))
 
 		defer func() {
 			if err != nil {
 				log.WithError(err).Warn("Cluster installation initialization failed")
 				b.clusterApi.HandlePreInstallError(asyncCtx, &cluster, err)
 			}
+			b.refresher.ClusterChanged(params.ClusterID)
+			tracing.End(asyncCtx, span, err)
 		}()
 
 		if err = b.generateClusterInstallConfig(asyncCtx, cluster); err != nil {
@@ -1043,12 +1114,12 @@ func (b *bareMetalInventory) InstallCluster(ctx context.Context, params installe
 		}
 
 		cInstaller := clusterInstaller{
-			ctx:    asyncCtx, // Need a new context for async part
 			b:      b,
 			log:    log,
 			params: params,
 		}
-		if err = b.db.Transaction(cInstaller.install); err != nil {
+		// in case host monitor already updated the state we need to hold the cluster lock
+		if err = transaction.InClusterTransaction(asyncCtx, b.db.WithContext(asyncCtx), params.ClusterID, cInstaller.install); err != nil {
 			return
 		}
 
@@ -1056,9 +1127,10 @@ func (b *bareMetalInventory) InstallCluster(ctx context.Context, params installe
 		b.metricApi.InstallationStarted(cluster.OpenshiftVersion, *cluster.ID, cluster.EmailDomain)
 		b.eventsHandler.AddEvent(
 			ctx, *cluster.ID, nil, models.EventSeverityInfo,
-			fmt.Sprintf("Updated status of cluster %s to installing", cluster.Name), time.Now())
+			fmt.Sprintf("Updated status of cluster %s to installing", cluster.Name), time.Now(), events.ClusterInstallStarted, nil)
 	}()
 
+	b.refresher.ClusterChanged(params.ClusterID)
 	log.Infof("Successfully prepared cluster <%s> for installation", params.ClusterID.String())
 	return installer.NewInstallClusterAccepted().WithPayload(&cluster.Cluster)
 }
@@ -1068,12 +1140,14 @@ func (b *bareMetalInventory) InstallHosts(ctx context.Context, params installer.
 	var cluster common.Cluster
 	var err error
 
-	if err = b.db.Preload("Hosts").First(&cluster, "id = ?", params.ClusterID).Error; err != nil {
+	if err = b.db.WithContext(ctx).Preload("Hosts").First(&cluster, "id = ?", params.ClusterID).Error; err != nil {
 		return common.GenerateErrorResponder(err)
 	}
-
 	// auto select hosts roles if not selected yet.
-	err = b.db.Transaction(func(tx *gorm.DB) error {
+	err = transaction.InClusterTransaction(ctx, b.db.WithContext(ctx), params.ClusterID, func(ctx context.Context, tx *gorm.DB) error {
+		if err = checkClusterResourceVersion(tx, params.ClusterID, params.IfMatch); err != nil {
+			return err
+		}
 		for i := range cluster.Hosts {
 			if swag.StringValue(cluster.Hosts[i].Status) != models.HostStatusKnown {
 				continue
@@ -1094,7 +1168,9 @@ func (b *bareMetalInventory) InstallHosts(ctx context.Context, params installer.
 	}
 
 	txSuccess := false
-	tx := b.db.Begin()
+	// in case host monitor already updated the state we need to hold the cluster lock
+	ctx, tx, unlock, lockErr := transaction.BeginInCluster(ctx, b.db.WithContext(ctx), params.ClusterID)
+	defer unlock()
 	defer func() {
 		if !txSuccess {
 			log.Error("InstallHosts failed")
@@ -1106,8 +1182,10 @@ func (b *bareMetalInventory) InstallHosts(ctx context.Context, params installer.
 		}
 	}()
 
-	// in case host monitor already updated the state we need to use FOR UPDATE option
-	tx = transaction.AddForUpdateQueryOption(tx)
+	if lockErr != nil {
+		log.WithError(lockErr).Errorf("failed to lock cluster %s", params.ClusterID)
+		return common.NewApiError(http.StatusInternalServerError, lockErr)
+	}
 
 	if err = tx.Preload("Hosts").First(&cluster, "id = ?", params.ClusterID).Error; err != nil {
 		return common.GenerateErrorResponder(err)
@@ -1131,6 +1209,7 @@ func (b *bareMetalInventory) InstallHosts(ctx context.Context, params installer.
 	}
 	txSuccess = true
 
+	b.refresher.ClusterChanged(params.ClusterID)
 	return installer.NewInstallHostsAccepted().WithPayload(&cluster.Cluster)
 }
 
@@ -1188,7 +1267,7 @@ func (b *bareMetalInventory) UpdateClusterInstallConfig(ctx context.Context, par
 	var cluster common.Cluster
 	query := "id = ?"
 
-	err := b.db.First(&cluster, query, params.ClusterID).Error
+	err := b.db.WithContext(ctx).First(&cluster, query, params.ClusterID).Error
 	if err != nil {
 		log.WithError(err).Errorf("failed to find cluster %s", params.ClusterID)
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -1202,11 +1281,20 @@ func (b *bareMetalInventory) UpdateClusterInstallConfig(ctx context.Context, par
 		return installer.NewUpdateClusterInstallConfigBadRequest().WithPayload(common.GenerateError(http.StatusBadRequest, err))
 	}
 
-	err = b.db.Model(&common.Cluster{}).Where(query, params.ClusterID).Update("install_config_overrides", params.InstallConfigParams).Error
+	err = transaction.InClusterTransaction(ctx, b.db.WithContext(ctx), params.ClusterID, func(ctx context.Context, tx *gorm.DB) error {
+		if err = checkClusterResourceVersion(tx, params.ClusterID, params.IfMatch); err != nil {
+			return err
+		}
+		return tx.Model(&common.Cluster{}).Where(query, params.ClusterID).Update("install_config_overrides", params.InstallConfigParams).Error
+	})
 	if err != nil {
+		if common.IsKnownError(err) {
+			return common.GenerateErrorResponder(err)
+		}
 		return installer.NewUpdateClusterInstallConfigInternalServerError().WithPayload(common.GenerateError(http.StatusInternalServerError, err))
 	}
 
+	b.refresher.ClusterChanged(params.ClusterID)
 	return installer.NewUpdateClusterInstallConfigCreated()
 }
 
@@ -1284,6 +1372,12 @@ func (b *bareMetalInventory) UpdateCluster(ctx context.Context, params installer
 		*params.ClusterUpdateParams.SSHPublicKey = sshPublicKey
 	}
 
+	if params.ClusterUpdateParams.HardwareProfile != nil {
+		if err = b.validateHardwareProfile(*params.ClusterUpdateParams.HardwareProfile); err != nil {
+			return common.GenerateErrorResponder(err)
+		}
+	}
+
 	if params.ClusterUpdateParams.HTTPProxy != nil &&
 		(params.ClusterUpdateParams.HTTPSProxy == nil || *params.ClusterUpdateParams.HTTPSProxy == "") {
 		params.ClusterUpdateParams.HTTPSProxy = params.ClusterUpdateParams.HTTPProxy
@@ -1296,7 +1390,9 @@ func (b *bareMetalInventory) UpdateCluster(ctx context.Context, params installer
 	}
 
 	txSuccess := false
-	tx := b.db.Begin()
+	// in case host monitor already updated the state we need to hold the cluster lock
+	ctx, tx, unlock, lockErr := transaction.BeginInCluster(ctx, b.db.WithContext(ctx), params.ClusterID)
+	defer unlock()
 	defer func() {
 		if !txSuccess {
 			log.Error("update cluster failed")
@@ -1313,15 +1409,20 @@ func (b *bareMetalInventory) UpdateCluster(ctx context.Context, params installer
 		return installer.NewUpdateClusterInternalServerError().
 			WithPayload(common.GenerateError(http.StatusInternalServerError, errors.New("DB error, failed to start transaction")))
 	}
-
-	// in case host monitor already updated the state we need to use FOR UPDATE option
-	tx = transaction.AddForUpdateQueryOption(tx)
+	if lockErr != nil {
+		log.WithError(lockErr).Errorf("failed to lock cluster %s", params.ClusterID)
+		return common.NewApiError(http.StatusInternalServerError, lockErr)
+	}
 
 	if err = tx.Preload("Hosts").First(&cluster, "id = ?", params.ClusterID).Error; err != nil {
 		log.WithError(err).Errorf("failed to get cluster: %s", params.ClusterID)
 		return installer.NewUpdateClusterNotFound().WithPayload(common.GenerateError(http.StatusNotFound, err))
 	}
 
+	if err = checkResourceVersion(params.IfMatch, cluster.ResourceVersion); err != nil {
+		return common.GenerateErrorResponder(err)
+	}
+
 	if err = b.clusterApi.VerifyClusterUpdatability(&cluster); err != nil {
 		log.WithError(err).Errorf("cluster %s can't be updated in current state", params.ClusterID)
 		return installer.NewUpdateClusterConflict().WithPayload(common.GenerateError(http.StatusConflict, err))
@@ -1352,12 +1453,15 @@ func (b *bareMetalInventory) UpdateCluster(ctx context.Context, params installer
 		return common.GenerateErrorResponder(errors.Errorf("DB error, failed to commit"))
 	}
 	txSuccess = true
+	// the hosts were refreshed before the cluster, refresh them again with the updated cluster
+	b.refresher.ClusterChanged(params.ClusterID)
 
 	if proxySettingsChanged(params.ClusterUpdateParams, &cluster) {
-		b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityInfo, "Proxy settings changed", time.Now())
+		b.eventsHandler.AddEvent(ctx, params.ClusterID, nil, models.EventSeverityInfo, "Proxy settings changed", time.Now(),
+			events.ClusterProxyChanged, nil)
 	}
 
-	if err := b.db.Preload("Hosts").First(&cluster, "id = ?", params.ClusterID).Error; err != nil {
+	if err := b.db.WithContext(ctx).Preload("Hosts").First(&cluster, "id = ?", params.ClusterID).Error; err != nil {
 		log.WithError(err).Errorf("failed to get cluster %s after update", params.ClusterID)
 		return common.GenerateErrorResponder(err)
 	}
@@ -1371,7 +1475,7 @@ func (b *bareMetalInventory) UpdateCluster(ctx context.Context, params installer
 		host.FreeAddresses = ""
 	}
 
-	return installer.NewUpdateClusterCreated().WithPayload(&cluster.Cluster)
+	return installer.NewUpdateClusterCreated().WithPayload(&cluster.Cluster).WithETag(resourceVersionETag(cluster.ResourceVersion))
 }
 
 func setMachineNetworkCIDRForUpdate(updates map[string]interface{}, machineNetworkCIDR string) {
@@ -1506,6 +1610,16 @@ func (b *bareMetalInventory) updateClusterData(ctx context.Context, cluster *com
 	if params.ClusterUpdateParams.SSHPublicKey != nil {
 		updates["ssh_public_key"] = *params.ClusterUpdateParams.SSHPublicKey
 	}
+	if params.ClusterUpdateParams.HardwareProfile != nil {
+		updates["hardware_profile"] = *params.ClusterUpdateParams.HardwareProfile
+	}
+	if params.ClusterUpdateParams.DiskSelectionPolicy != nil {
+		var policy string
+		if policy, err = marshalDiskSelectionPolicy(params.ClusterUpdateParams.DiskSelectionPolicy); err != nil {
+			return err
+		}
+		updates["disk_selection_policy"] = policy
+	}
 
 	if params.ClusterUpdateParams.PullSecret != nil {
 		cluster.PullSecret = *params.ClusterUpdateParams.PullSecret
@@ -1600,8 +1714,7 @@ func calculateHostNetworks(log logrus.FieldLogger, cluster *common.Cluster) []*m
 		if h.Inventory == "" {
 			continue
 		}
-		var inventory models.Inventory
-		err := json.Unmarshal([]byte(h.Inventory), &inventory)
+		inventory, err := hostutil.UnmarshalInventory(h)
 		if err != nil {
 			log.WithError(err).Warnf("Could not parse inventory of host %s", *h.ID)
 			continue
@@ -1630,7 +1743,7 @@ func calculateHostNetworks(log logrus.FieldLogger, cluster *common.Cluster) []*m
 
 func (b *bareMetalInventory) ListClusters(ctx context.Context, params installer.ListClustersParams) middleware.Responder {
 	log := logutil.FromContext(ctx, b.log)
-	db := b.db
+	db := b.db.WithContext(ctx)
 	if swag.BoolValue(params.GetUnregisteredClusters) {
 		if !identity.IsAdmin(ctx) {
 			return installer.NewListClustersForbidden().WithPayload(common.GenerateInfraError(
@@ -1664,13 +1777,13 @@ func (b *bareMetalInventory) GetCluster(ctx context.Context, params installer.Ge
 	log := logutil.FromContext(ctx, b.log)
 	var cluster common.Cluster
 
-	db := b.db
+	db := b.db.WithContext(ctx)
 	if swag.BoolValue(params.GetUnregisteredClusters) {
 		if !identity.IsAdmin(ctx) {
 			return installer.NewGetClusterForbidden().WithPayload(common.GenerateInfraError(
 				http.StatusForbidden, errors.New("only admin users are allowed to get unregistered clusters")))
 		}
-		db = b.db.Unscoped()
+		db = db.Unscoped()
 	}
 
 	if err := db.Preload(
@@ -1693,33 +1806,75 @@ func (b *bareMetalInventory) GetCluster(ctx context.Context, params installer.Ge
 		// Clear this field as it is not needed to be sent via API
 		host.FreeAddresses = ""
 	}
-	return installer.NewGetClusterOK().WithPayload(&cluster.Cluster)
+	return installer.NewGetClusterOK().WithPayload(&cluster.Cluster).WithETag(resourceVersionETag(cluster.ResourceVersion))
 }
 
 func (b *bareMetalInventory) GetHostRequirements(ctx context.Context, params installer.GetHostRequirementsParams) middleware.Responder {
-	masterReqs := b.hostApi.GetHostRequirements(models.HostRoleMaster)
-	workerReqs := b.hostApi.GetHostRequirements(models.HostRoleWorker)
+	if params.ClusterID != nil && params.HardwareProfile != nil {
+		return common.NewApiError(http.StatusBadRequest,
+			errors.New("Either the cluster or the hardware profile of the requirements can be set, not both"))
+	}
+	profile := swag.StringValue(params.HardwareProfile)
+	if params.ClusterID != nil {
+		cluster, err := b.getCluster(ctx, params.ClusterID.String())
+		if err != nil {
+			return common.GenerateErrorResponder(err)
+		}
+		profile = cluster.HardwareProfile
+	}
+	if profile == "" {
+		profile = hardware.DefaultProfile
+	}
+	masterReqs, err := b.hostApi.GetHostRequirements(models.HostRoleMaster, profile)
+	if err != nil {
+		return common.NewApiError(http.StatusBadRequest, err)
+	}
+	workerReqs, err := b.hostApi.GetHostRequirements(models.HostRoleWorker, profile)
+	if err != nil {
+		return common.NewApiError(http.StatusBadRequest, err)
+	}
 	return installer.NewGetHostRequirementsOK().WithPayload(
 		&models.HostRequirements{
-			Master: &masterReqs,
-			Worker: &workerReqs,
+			HardwareProfile: profile,
+			Master:          &masterReqs,
+			Worker:          &workerReqs,
 		})
 }
 
+// validateHardwareProfile returns a bad request error if the profile isn't defined
+func (b *bareMetalInventory) validateHardwareProfile(profile string) error {
+	if _, err := b.hostApi.GetHostRequirements(models.HostRoleMaster, profile); err != nil {
+		return common.NewApiError(http.StatusBadRequest, err)
+	}
+	return nil
+}
+
+// marshalDiskSelectionPolicy returns the policy as stored in the cluster, a bad request error if it isn't valid
+func marshalDiskSelectionPolicy(policy *models.DiskSelectionPolicy) (string, error) {
+	if err := hardware.ValidateDiskSelectionPolicy(policy); err != nil {
+		return "", common.NewApiError(http.StatusBadRequest, err)
+	}
+	b, err := json.Marshal(policy)
+	if err != nil {
+		return "", common.NewApiError(http.StatusInternalServerError, err)
+	}
+	return string(b), nil
+}
+
 func (b *bareMetalInventory) RegisterHost(ctx context.Context, params installer.RegisterHostParams) middleware.Responder {
 	log := logutil.FromContext(ctx, b.log)
 	var host models.Host
 	var cluster common.Cluster
 	log.Infof("Register host: %+v", params)
 
-	if err := b.db.First(&cluster, "id = ?", params.ClusterID.String()).Error; err != nil {
+	if err := b.db.WithContext(ctx).First(&cluster, "id = ?", params.ClusterID.String()).Error; err != nil {
 		log.WithError(err).Errorf("failed to get cluster: %s", params.ClusterID.String())
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return common.NewApiError(http.StatusNotFound, err)
 		}
 		return common.NewApiError(http.StatusInternalServerError, err)
 	}
-	err := b.db.First(&host, "id = ? and cluster_id = ?", *params.NewHostParams.HostID, params.ClusterID).Error
+	err := b.db.WithContext(ctx).First(&host, "id = ? and cluster_id = ?", *params.NewHostParams.HostID, params.ClusterID).Error
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		log.WithError(err).Errorf("failed to get host %s in cluster: %s",
 			*params.NewHostParams.HostID, params.ClusterID.String())
@@ -1733,7 +1888,7 @@ func (b *bareMetalInventory) RegisterHost(ctx context.Context, params installer.
 			log.WithError(err).Errorf("failed to register host <%s> to cluster %s due to: %s",
 				params.NewHostParams.HostID, params.ClusterID.String(), err.Error())
 			b.eventsHandler.AddEvent(ctx, params.ClusterID, params.NewHostParams.HostID, models.EventSeverityError,
-				"Failed to register host: cluster cannot accept new hosts in its current state", time.Now())
+				"Failed to register host: cluster cannot accept new hosts in its current state", time.Now(), events.HostRegistrationFailed, nil)
 			return common.NewApiError(http.StatusConflict, err)
 		}
 	}
@@ -1762,18 +1917,20 @@ func (b *bareMetalInventory) RegisterHost(ctx context.Context, params installer.
 			params.NewHostParams.HostID.String(), params.ClusterID.String())
 		uerr := errors.Wrap(err, "Failed to register host: error creating host metadata")
 		b.eventsHandler.AddEvent(ctx, params.ClusterID, params.NewHostParams.HostID, models.EventSeverityError,
-			uerr.Error(), time.Now())
+			uerr.Error(), time.Now(), events.HostRegistrationFailed, map[string]interface{}{"error": uerr.Error()})
 		return returnRegisterHostTransitionError(http.StatusBadRequest, err)
 	}
 
 	if err = b.customizeHost(&host); err != nil {
 		b.eventsHandler.AddEvent(ctx, params.ClusterID, params.NewHostParams.HostID, models.EventSeverityError,
-			"Failed to register host: error setting host properties", time.Now())
+			"Failed to register host: error setting host properties", time.Now(), events.HostRegistrationFailed, nil)
 		return common.GenerateErrorResponder(err)
 	}
 
 	b.eventsHandler.AddEvent(ctx, params.ClusterID, params.NewHostParams.HostID, models.EventSeverityInfo,
-		fmt.Sprintf("Host %s: registered to cluster", hostutil.GetHostnameForMsg(&host)), time.Now())
+		fmt.Sprintf("Host %s: registered to cluster", hostutil.GetHostnameForMsg(&host)), time.Now(),
+		events.HostRegistered, map[string]interface{}{"hostname": hostutil.GetHostnameForMsg(&host)})
+	b.refresher.HostChanged(params.ClusterID, *params.NewHostParams.HostID)
 
 	hostRegistration := models.HostRegistrationResponse{
 		Host:                  host,
@@ -1836,8 +1993,16 @@ func (b *bareMetalInventory) DeregisterHost(ctx context.Context, params installe
 	log := logutil.FromContext(ctx, b.log)
 	log.Infof("Deregister host: %s cluster %s", params.HostID, params.ClusterID)
 
-	if err := b.db.Where("id = ? and cluster_id = ?", params.HostID, params.ClusterID).
-		Delete(&models.Host{}).Error; err != nil {
+	err := transaction.InClusterTransaction(ctx, b.db.WithContext(ctx), params.ClusterID, func(ctx context.Context, tx *gorm.DB) error {
+		if err := checkHostResourceVersion(tx, params.ClusterID, params.HostID, params.IfMatch); err != nil {
+			return err
+		}
+		return tx.Where("id = ? and cluster_id = ?", params.HostID, params.ClusterID).Delete(&models.Host{}).Error
+	})
+	if err != nil {
+		if common.IsKnownError(err) {
+			return common.GenerateErrorResponder(err)
+		}
 		// TODO: check error type
 		return installer.NewDeregisterHostBadRequest().
 			WithPayload(common.GenerateError(http.StatusBadRequest, err))
@@ -1845,14 +2010,16 @@ func (b *bareMetalInventory) DeregisterHost(ctx context.Context, params installe
 
 	// TODO: need to check that host can be deleted from the cluster
 	b.eventsHandler.AddEvent(ctx, params.ClusterID, &params.HostID, models.EventSeverityInfo,
-		fmt.Sprintf("Host %s: deregistered from cluster", params.HostID.String()), time.Now())
+		fmt.Sprintf("Host %s: deregistered from cluster", params.HostID.String()), time.Now(), events.HostDeregistered, nil)
+	// the hosts of the cluster are validated against each other
+	b.refresher.ClusterChanged(params.ClusterID)
 	return installer.NewDeregisterHostNoContent()
 }
 
 func (b *bareMetalInventory) GetHost(ctx context.Context, params installer.GetHostParams) middleware.Responder {
 	var host models.Host
 	// TODO: validate what is the error
-	if err := b.db.Where("id = ? and cluster_id = ?", params.HostID, params.ClusterID).
+	if err := b.db.WithContext(ctx).Where("id = ? and cluster_id = ?", params.HostID, params.ClusterID).
 		First(&host).Error; err != nil {
 		return installer.NewGetHostNotFound().WithPayload(common.GenerateError(http.StatusNotFound, err))
 	}
@@ -1863,13 +2030,13 @@ func (b *bareMetalInventory) GetHost(ctx context.Context, params installer.GetHo
 
 	// Clear this field as it is not needed to be sent via API
 	host.FreeAddresses = ""
-	return installer.NewGetHostOK().WithPayload(&host)
+	return installer.NewGetHostOK().WithPayload(&host).WithETag(resourceVersionETag(host.ResourceVersion))
 }
 
 func (b *bareMetalInventory) ListHosts(ctx context.Context, params installer.ListHostsParams) middleware.Responder {
 	log := logutil.FromContext(ctx, b.log)
 	var hosts []*models.Host
-	if err := b.db.Find(&hosts, "cluster_id = ?", params.ClusterID).Error; err != nil {
+	if err := b.db.WithContext(ctx).Find(&hosts, "cluster_id = ?", params.ClusterID).Error; err != nil {
 		log.WithError(err).Errorf("failed to get list of hosts for cluster %s", params.ClusterID)
 		return installer.NewListHostsInternalServerError().
 			WithPayload(common.GenerateError(http.StatusInternalServerError, err))
@@ -1892,7 +2059,7 @@ func (b *bareMetalInventory) GetNextSteps(ctx context.Context, params installer.
 	var host models.Host
 
 	txSuccess := false
-	tx := b.db.Begin()
+	tx := b.db.WithContext(ctx).Begin()
 	defer func() {
 		if !txSuccess {
 			log.Error("get next steps failed")
@@ -1918,7 +2085,8 @@ func (b *bareMetalInventory) GetNextSteps(ctx context.Context, params installer.
 	}
 
 	host.CheckedInAt = strfmt.DateTime(time.Now())
-	if err := tx.Model(&host).Update("checked_in_at", host.CheckedInAt).Error; err != nil {
+	// agents check in every few seconds, it isn't a change of the host that users are editing
+	if err := dbPkg.SkipResourceVersion(tx).Model(&host).Update("checked_in_at", host.CheckedInAt).Error; err != nil {
 		log.WithError(err).Errorf("failed to update host: %s", params.ClusterID)
 		return installer.NewGetNextStepsInternalServerError()
 	}
@@ -1928,6 +2096,10 @@ func (b *bareMetalInventory) GetNextSteps(ctx context.Context, params installer.
 		return installer.NewGetNextStepsInternalServerError()
 	}
 	txSuccess = true
+	if swag.StringValue(host.Status) == models.HostStatusDisconnected {
+		// the host came back, don't wait for the monitor to notice
+		b.refresher.HostChanged(params.ClusterID, params.HostID)
+	}
 
 	var err error
 	steps, err = b.hostApi.GetNextSteps(ctx, &host)
@@ -1945,7 +2117,7 @@ func (b *bareMetalInventory) PostStepReply(ctx context.Context, params installer
 		params.HostID, params.Reply.ExitCode, params.Reply.Output, params.Reply.Error)
 
 	var host models.Host
-	if err = b.db.First(&host, "id = ? and cluster_id = ?", params.HostID, params.ClusterID).Error; err != nil {
+	if err = b.db.WithContext(ctx).First(&host, "id = ? and cluster_id = ?", params.HostID, params.ClusterID).Error; err != nil {
 		log.WithError(err).Errorf("Failed to find host <%s> cluster <%s> step <%s> exit code %d stdout <%s> stderr <%s>",
 			params.HostID, params.ClusterID, params.Reply.StepID, params.Reply.ExitCode, params.Reply.Output, params.Reply.Error)
 		return installer.NewPostStepReplyNotFound().
@@ -1960,6 +2132,7 @@ func (b *bareMetalInventory) PostStepReply(ctx context.Context, params installer
 		if handlingError != nil {
 			log.WithError(handlingError).Errorf("Failed handling reply error for host <%s> cluster <%s>", params.HostID, params.ClusterID)
 		}
+		b.refresher.HostChanged(params.ClusterID, params.HostID)
 		return installer.NewPostStepReplyNoContent()
 	}
 
@@ -1974,7 +2147,15 @@ func (b *bareMetalInventory) PostStepReply(ctx context.Context, params installer
 			WithPayload(common.GenerateError(http.StatusBadRequest, err))
 	}
 
-	err = handleReplyByType(params, b, ctx, host, stepReply)
+	// hold the cluster lock, so the reply won't race with concurrent updates of the cluster.
+	// The writes of the agents don't change the resource versions the users edit by.
+	err = transaction.InClusterTransaction(ctx, dbPkg.SkipResourceVersion(b.db.WithContext(ctx)), params.ClusterID, func(ctx context.Context, tx *gorm.DB) error {
+		// reload the host, it might have been changed while waiting for the lock
+		if err = tx.First(&host, "id = ? and cluster_id = ?", params.HostID, params.ClusterID).Error; err != nil {
+			return err
+		}
+		return handleReplyByType(params, b, ctx, host, stepReply, tx)
+	})
 	if err != nil {
 		log.WithError(err).Errorf("Failed to update step reply for host <%s> cluster <%s> step <%s>",
 			params.HostID, params.ClusterID, params.Reply.StepID)
@@ -1982,6 +2163,12 @@ func (b *bareMetalInventory) PostStepReply(ctx context.Context, params installer
 			WithPayload(common.GenerateError(http.StatusInternalServerError, err))
 	}
 
+	if params.Reply.StepType == models.StepTypeConnectivityCheck {
+		// the connectivity of a host affects the validations of the other hosts of the cluster
+		b.refresher.ClusterChanged(params.ClusterID)
+	} else {
+		b.refresher.HostChanged(params.ClusterID, params.HostID)
+	}
 	return installer.NewPostStepReplyNoContent()
 }
 
@@ -1999,7 +2186,7 @@ func (b *bareMetalInventory) handleReplyError(params installer.PostStepReplyPara
 	return nil
 }
 
-func (b *bareMetalInventory) updateFreeAddressesReport(ctx context.Context, host *models.Host, freeAddressesReport string) error {
+func (b *bareMetalInventory) updateFreeAddressesReport(ctx context.Context, host *models.Host, freeAddressesReport string, db *gorm.DB) error {
 	var (
 		err           error
 		freeAddresses models.FreeNetworksAddresses
@@ -2014,7 +2201,7 @@ func (b *bareMetalInventory) updateFreeAddressesReport(ctx context.Context, host
 		log.WithError(err).Warn("Update free addresses")
 		return err
 	}
-	if err = b.db.Model(&models.Host{}).Where("id = ? and cluster_id = ?", host.ID.String(),
+	if err = db.Model(&models.Host{}).Where("id = ? and cluster_id = ?", host.ID.String(),
 		host.ClusterID.String()).Updates(map[string]interface{}{"free_addresses": freeAddressesReport}).Error; err != nil {
 		log.WithError(err).Warnf("Update free addresses of host %s", host.ID.String())
 		return err
@@ -2024,14 +2211,14 @@ func (b *bareMetalInventory) updateFreeAddressesReport(ctx context.Context, host
 	return nil
 }
 
-func (b *bareMetalInventory) processDhcpAllocationResponse(ctx context.Context, host *models.Host, dhcpAllocationResponseStr string) error {
+func (b *bareMetalInventory) processDhcpAllocationResponse(ctx context.Context, host *models.Host, dhcpAllocationResponseStr string, db *gorm.DB) error {
 	var (
 		err                   error
 		dhcpAllocationReponse models.DhcpAllocationResponse
 		cluster               common.Cluster
 	)
 	log := logutil.FromContext(ctx, b.log)
-	if err = b.db.Take(&cluster, "id = ?", host.ClusterID.String()).Error; err != nil {
+	if err = db.Take(&cluster, "id = ?", host.ClusterID.String()).Error; err != nil {
 		log.WithError(err).Warnf("Get cluster %s", host.ClusterID.String())
 		return err
 	}
@@ -2074,22 +2261,24 @@ func (b *bareMetalInventory) processDhcpAllocationResponse(ctx context.Context,
 		log.WithError(err).Warnf("Ingress Vip not validated")
 		return err
 	}
-	return b.clusterApi.SetVipsData(ctx, &cluster, apiVip, ingressVip, dhcpAllocationReponse.APIVipLease, dhcpAllocationReponse.IngressVipLease, b.db)
+	return b.clusterApi.SetVipsData(ctx, &cluster, apiVip, ingressVip, dhcpAllocationReponse.APIVipLease, dhcpAllocationReponse.IngressVipLease, db)
 }
 
-func handleReplyByType(params installer.PostStepReplyParams, b *bareMetalInventory, ctx context.Context, host models.Host, stepReply string) error {
+func handleReplyByType(params installer.PostStepReplyParams, b *bareMetalInventory, ctx context.Context, host models.Host, stepReply string, db *gorm.DB) error {
 	var err error
 	switch params.Reply.StepType {
 	case models.StepTypeInventory:
-		err = b.hostApi.UpdateInventory(ctx, &host, stepReply)
+		err = b.hostApi.UpdateInventory(ctx, &host, stepReply, db)
 	case models.StepTypeConnectivityCheck:
-		err = b.hostApi.UpdateConnectivityReport(ctx, &host, stepReply)
+		err = b.hostApi.UpdateConnectivityReport(ctx, &host, stepReply, db)
 	case models.StepTypeAPIVipConnectivityCheck:
-		err = b.hostApi.UpdateApiVipConnectivityReport(ctx, &host, stepReply)
+		err = b.hostApi.UpdateApiVipConnectivityReport(ctx, &host, stepReply, db)
+	case models.StepTypeDiskSpeedCheck:
+		err = b.hostApi.UpdateDiskSpeedReport(ctx, &host, stepReply, db)
 	case models.StepTypeFreeNetworkAddresses:
-		err = b.updateFreeAddressesReport(ctx, &host, stepReply)
+		err = b.updateFreeAddressesReport(ctx, &host, stepReply, db)
 	case models.StepTypeDhcpLeaseAllocate:
-		err = b.processDhcpAllocationResponse(ctx, &host, stepReply)
+		err = b.processDhcpAllocationResponse(ctx, &host, stepReply, db)
 	}
 	return err
 }
@@ -2106,6 +2295,8 @@ func filterReplyByType(params installer.PostStepReplyParams) (string, error) {
 		stepReply, err = filterReply(&models.ConnectivityReport{}, params.Reply.Output)
 	case models.StepTypeAPIVipConnectivityCheck:
 		stepReply, err = filterReply(&models.APIVipConnectivityResponse{}, params.Reply.Output)
+	case models.StepTypeDiskSpeedCheck:
+		stepReply, err = filterReply(&models.DiskSpeedCheckResponse{}, params.Reply.Output)
 	case models.StepTypeFreeNetworkAddresses:
 		stepReply, err = filterReply(&models.FreeNetworksAddresses{}, params.Reply.Output)
 	case models.StepTypeDhcpLeaseAllocate:
@@ -2134,9 +2325,8 @@ func (b *bareMetalInventory) DisableHost(ctx context.Context, params installer.D
 	log.Info("disabling host: ", params.HostID)
 
 	txSuccess := false
-	tx := b.db.Begin()
-	tx = transaction.AddForUpdateQueryOption(tx)
-
+	ctx, tx, unlock, lockErr := transaction.BeginInCluster(ctx, b.db.WithContext(ctx), params.ClusterID)
+	defer unlock()
 	defer func() {
 		if !txSuccess {
 			log.Error("update cluster failed")
@@ -2148,6 +2338,11 @@ func (b *bareMetalInventory) DisableHost(ctx context.Context, params installer.D
 		}
 	}()
 
+	if lockErr != nil {
+		log.WithError(lockErr).Errorf("failed to lock cluster %s", params.ClusterID)
+		return common.NewApiError(http.StatusInternalServerError, lockErr)
+	}
+
 	if err := tx.First(&host, "id = ? and cluster_id = ?", params.HostID, params.ClusterID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			log.WithError(err).Errorf("host %s not found", params.HostID)
@@ -2155,14 +2350,18 @@ func (b *bareMetalInventory) DisableHost(ctx context.Context, params installer.D
 		}
 		log.WithError(err).Errorf("failed to get host %s", params.HostID)
 		msg := "Failed to disable host: error fetching host from DB"
-		b.eventsHandler.AddEvent(ctx, params.ClusterID, &params.HostID, models.EventSeverityError, msg, time.Now())
+		b.eventsHandler.AddEvent(ctx, params.ClusterID, &params.HostID, models.EventSeverityError, msg, time.Now(), events.HostDisableFailed, nil)
 		return common.NewApiError(http.StatusInternalServerError, err)
 	}
 
+	if err := checkResourceVersion(params.IfMatch, host.ResourceVersion); err != nil {
+		return common.GenerateErrorResponder(err)
+	}
+
 	if err := b.hostApi.DisableHost(ctx, &host, tx); err != nil {
 		log.WithError(err).Errorf("failed to disable host <%s> from cluster <%s>", params.HostID, params.ClusterID)
 		msg := "Failed to disable host: error disabling host in current status"
-		b.eventsHandler.AddEvent(ctx, params.ClusterID, &params.HostID, models.EventSeverityError, msg, time.Now())
+		b.eventsHandler.AddEvent(ctx, params.ClusterID, &params.HostID, models.EventSeverityError, msg, time.Now(), events.HostDisableFailed, nil)
 		return common.GenerateErrorResponderWithDefault(err, http.StatusConflict)
 	}
 
@@ -2179,7 +2378,8 @@ func (b *bareMetalInventory) DisableHost(ctx context.Context, params installer.D
 	txSuccess = true
 
 	msg := "Host disabled by user"
-	b.eventsHandler.AddEvent(ctx, params.ClusterID, &params.HostID, models.EventSeverityInfo, msg, time.Now())
+	b.eventsHandler.AddEvent(ctx, params.ClusterID, &params.HostID, models.EventSeverityInfo, msg, time.Now(), events.HostDisabled, nil)
+	b.refresher.ClusterChanged(params.ClusterID)
 	return installer.NewDisableHostOK().WithPayload(&c.Cluster)
 }
 
@@ -2190,9 +2390,8 @@ func (b *bareMetalInventory) EnableHost(ctx context.Context, params installer.En
 	log.Info("enable host: ", params.HostID)
 
 	txSuccess := false
-	tx := b.db.Begin()
-	tx = transaction.AddForUpdateQueryOption(tx)
-
+	ctx, tx, unlock, lockErr := transaction.BeginInCluster(ctx, b.db.WithContext(ctx), params.ClusterID)
+	defer unlock()
 	defer func() {
 		if !txSuccess {
 			log.Error("update cluster failed")
@@ -2204,6 +2403,11 @@ func (b *bareMetalInventory) EnableHost(ctx context.Context, params installer.En
 		}
 	}()
 
+	if lockErr != nil {
+		log.WithError(lockErr).Errorf("failed to lock cluster %s", params.ClusterID)
+		return common.NewApiError(http.StatusInternalServerError, lockErr)
+	}
+
 	if err := tx.First(&host, "id = ? and cluster_id = ?", params.HostID, params.ClusterID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			log.WithError(err).Errorf("host %s not found", params.HostID)
@@ -2211,14 +2415,18 @@ func (b *bareMetalInventory) EnableHost(ctx context.Context, params installer.En
 		}
 		log.WithError(err).Errorf("failed to get host %s", params.HostID)
 		msg := "Failed to enable host: error fetching host from DB"
-		b.eventsHandler.AddEvent(ctx, params.ClusterID, &params.HostID, models.EventSeverityError, msg, time.Now())
+		b.eventsHandler.AddEvent(ctx, params.ClusterID, &params.HostID, models.EventSeverityError, msg, time.Now(), events.HostEnableFailed, nil)
 		return common.NewApiError(http.StatusInternalServerError, err)
 	}
 
+	if err := checkResourceVersion(params.IfMatch, host.ResourceVersion); err != nil {
+		return common.GenerateErrorResponder(err)
+	}
+
 	if err := b.hostApi.EnableHost(ctx, &host, tx); err != nil {
 		log.WithError(err).Errorf("failed to enable host <%s> from cluster <%s>", params.HostID, params.ClusterID)
 		msg := "Failed to enable host: error disabling host in current status"
-		b.eventsHandler.AddEvent(ctx, params.ClusterID, &params.HostID, models.EventSeverityError, msg, time.Now())
+		b.eventsHandler.AddEvent(ctx, params.ClusterID, &params.HostID, models.EventSeverityError, msg, time.Now(), events.HostEnableFailed, nil)
 		return common.GenerateErrorResponderWithDefault(err, http.StatusConflict)
 	}
 
@@ -2234,7 +2442,8 @@ func (b *bareMetalInventory) EnableHost(ctx context.Context, params installer.En
 	txSuccess = true
 
 	msg := "Host enabled by user"
-	b.eventsHandler.AddEvent(ctx, params.ClusterID, &params.HostID, models.EventSeverityInfo, msg, time.Now())
+	b.eventsHandler.AddEvent(ctx, params.ClusterID, &params.HostID, models.EventSeverityInfo, msg, time.Now(), events.HostEnabled, nil)
+	b.refresher.ClusterChanged(params.ClusterID)
 	return installer.NewEnableHostOK().WithPayload(&c.Cluster)
 }
 
@@ -2261,7 +2470,9 @@ func (b *bareMetalInventory) refreshHostAndClusterStatuses(
 				hostID,
 				models.EventSeverityError,
 				err.Error(),
-				time.Now())
+				time.Now(),
+				events.HostStatusRefreshFailed,
+				map[string]interface{}{"operation": eventName, "error": err.Error()})
 		}
 		err = common.NewApiError(http.StatusInternalServerError, err)
 	}()
@@ -2444,7 +2655,7 @@ func (b *bareMetalInventory) checkFileForDownload(ctx context.Context, clusterID
 		return common.NewApiError(http.StatusBadRequest, err)
 	}
 
-	if err := b.db.First(&c, "id = ?", clusterID).Error; err != nil {
+	if err := b.db.WithContext(ctx).First(&c, "id = ?", clusterID).Error; err != nil {
 		log.WithError(err).Errorf("failed to find cluster %s", clusterID)
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return common.NewApiError(http.StatusNotFound, err)
@@ -2487,14 +2698,57 @@ func (b *bareMetalInventory) UpdateHostIgnition(ctx context.Context, params inst
 		return installer.NewUpdateHostIgnitionBadRequest().WithPayload(common.GenerateError(http.StatusBadRequest, err))
 	}
 
-	err = b.db.Model(&models.Host{}).Where(identity.AddUserFilter(ctx, "id = ? and cluster_id = ?"), params.HostID, params.ClusterID).Update("ignition_config_overrides", params.HostIgnitionParams.Config).Error
+	err = transaction.InClusterTransaction(ctx, b.db.WithContext(ctx), params.ClusterID, func(ctx context.Context, tx *gorm.DB) error {
+		if err = checkHostResourceVersion(tx, params.ClusterID, params.HostID, params.IfMatch); err != nil {
+			return err
+		}
+		return tx.Model(&models.Host{}).Where(identity.AddUserFilter(ctx, "id = ? and cluster_id = ?"), params.HostID, params.ClusterID).Update("ignition_config_overrides", params.HostIgnitionParams.Config).Error
+	})
 	if err != nil {
+		if common.IsKnownError(err) {
+			return common.GenerateErrorResponder(err)
+		}
 		return installer.NewUpdateHostIgnitionInternalServerError().WithPayload(common.GenerateError(http.StatusInternalServerError, err))
 	}
 
 	return installer.NewUpdateHostIgnitionCreated()
 }
 
+func (b *bareMetalInventory) UpdateHostInstallationDisk(ctx context.Context, params installer.UpdateHostInstallationDiskParams) middleware.Responder {
+	log := logutil.FromContext(ctx, b.log)
+
+	err := transaction.InClusterTransaction(ctx, b.db.WithContext(ctx), params.ClusterID, func(ctx context.Context, tx *gorm.DB) error {
+		var host models.Host
+		if err := tx.Take(&host, "id = ? and cluster_id = ?", params.HostID, params.ClusterID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return common.NewApiError(http.StatusNotFound, errors.Errorf("Host %s not found", params.HostID))
+			}
+			return common.NewApiError(http.StatusInternalServerError, err)
+		}
+		if err := checkResourceVersion(params.IfMatch, host.ResourceVersion); err != nil {
+			return err
+		}
+		if err := b.hostApi.UpdateInstallationDisk(ctx, &host, params.HostInstallationDiskParams.DiskID, tx); err != nil {
+			log.WithError(err).Errorf("failed to set the installation disk of host %s to %s", params.HostID,
+				params.HostInstallationDiskParams.DiskID)
+			return err
+		}
+		_, err := b.refreshHostAndClusterStatuses(ctx, "update installation disk", &params.HostID, &params.ClusterID, tx)
+		return err
+	})
+	if err != nil {
+		return common.GenerateErrorResponderWithDefault(err, http.StatusInternalServerError)
+	}
+
+	b.refresher.HostChanged(params.ClusterID, params.HostID)
+
+	host, err := b.getHost(ctx, params.ClusterID.String(), params.HostID.String())
+	if err != nil {
+		return common.GenerateErrorResponder(err)
+	}
+	return installer.NewUpdateHostInstallationDiskCreated().WithPayload(host)
+}
+
 func (b *bareMetalInventory) GetHostIgnition(ctx context.Context, params installer.GetHostIgnitionParams) middleware.Responder {
 	log := logutil.FromContext(ctx, b.log)
 
@@ -2563,7 +2817,7 @@ func (b *bareMetalInventory) GetCredentials(ctx context.Context, params installe
 	log := logutil.FromContext(ctx, b.log)
 	var cluster common.Cluster
 
-	if err := b.db.First(&cluster, "id = ?", params.ClusterID).Error; err != nil {
+	if err := b.db.WithContext(ctx).First(&cluster, "id = ?", params.ClusterID).Error; err != nil {
 		log.WithError(err).Errorf("failed to find cluster %s", params.ClusterID)
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return common.NewApiError(http.StatusNotFound, err)
@@ -2598,7 +2852,7 @@ func (b *bareMetalInventory) GetCredentials(ctx context.Context, params installe
 func (b *bareMetalInventory) UpdateHostInstallProgress(ctx context.Context, params installer.UpdateHostInstallProgressParams) middleware.Responder {
 	log := logutil.FromContext(ctx, b.log)
 	var host models.Host
-	if err := b.db.First(&host, "id = ? and cluster_id = ?", params.HostID, params.ClusterID).Error; err != nil {
+	if err := b.db.WithContext(ctx).First(&host, "id = ? and cluster_id = ?", params.HostID, params.ClusterID).Error; err != nil {
 		log.WithError(err).Errorf("failed to find host %s", params.HostID)
 		return installer.NewUpdateHostInstallProgressNotFound().
 			WithPayload(common.GenerateError(http.StatusNotFound, err))
@@ -2618,7 +2872,9 @@ func (b *bareMetalInventory) UpdateHostInstallProgress(ctx context.Context, para
 	log.Info(fmt.Sprintf("Host %s in cluster %s: %s", host.ID, host.ClusterID, event))
 	msg := fmt.Sprintf("Host %s: %s", hostutil.GetHostnameForMsg(&host), event)
 
-	b.eventsHandler.AddEvent(ctx, host.ClusterID, host.ID, models.EventSeverityInfo, msg, time.Now())
+	b.eventsHandler.AddEvent(ctx, host.ClusterID, host.ID, models.EventSeverityInfo, msg, time.Now(), events.HostInstallProgressUpdated,
+		map[string]interface{}{"stage": params.HostProgress.CurrentStage, "stage_info": params.HostProgress.ProgressInfo})
+	b.refresher.HostChanged(params.ClusterID, params.HostID)
 	return installer.NewUpdateHostInstallProgressOK()
 }
 
@@ -2627,7 +2883,7 @@ func (b *bareMetalInventory) UploadClusterIngressCert(ctx context.Context, param
 	log.Infof("UploadClusterIngressCert for cluster %s with params %s", params.ClusterID, params.IngressCertParams)
 	var cluster common.Cluster
 
-	if err := b.db.First(&cluster, "id = ?", params.ClusterID).Error; err != nil {
+	if err := b.db.WithContext(ctx).First(&cluster, "id = ?", params.ClusterID).Error; err != nil {
 		log.WithError(err).Errorf("failed to find cluster %s", params.ClusterID)
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return installer.NewUploadClusterIngressCertNotFound().WithPayload(common.GenerateError(http.StatusNotFound, err))
@@ -2743,8 +2999,8 @@ func (b *bareMetalInventory) CancelInstallation(ctx context.Context, params inst
 	var c common.Cluster
 
 	txSuccess := false
-	tx := b.db.Begin()
-	tx = transaction.AddForUpdateQueryOption(tx)
+	ctx, tx, unlock, lockErr := transaction.BeginInCluster(ctx, b.db.WithContext(ctx), params.ClusterID)
+	defer unlock()
 	defer func() {
 		if !txSuccess {
 			log.Error("cancel installation failed")
@@ -2759,11 +3015,16 @@ func (b *bareMetalInventory) CancelInstallation(ctx context.Context, params inst
 	if tx.Error != nil {
 		msg := "Failed to cancel installation: error starting DB transaction"
 		log.WithError(tx.Error).Errorf(msg)
-		b.eventsHandler.AddEvent(ctx, *c.ID, nil, models.EventSeverityError, msg, time.Now())
+		b.eventsHandler.AddEvent(ctx, *c.ID, nil, models.EventSeverityError, msg, time.Now(), events.ClusterInstallCancelFailed, nil)
 		return installer.NewCancelInstallationInternalServerError().WithPayload(
 			common.GenerateError(http.StatusInternalServerError, errors.New(msg)))
 	}
 
+	if lockErr != nil {
+		log.WithError(lockErr).Errorf("failed to lock cluster %s", params.ClusterID)
+		return common.NewApiError(http.StatusInternalServerError, lockErr)
+	}
+
 	if err := tx.Preload("Hosts").First(&c, "id = ?", params.ClusterID).Error; err != nil {
 		log.WithError(err).Errorf("Failed to cancel installation: could not find cluster %s", params.ClusterID)
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -2772,6 +3033,10 @@ func (b *bareMetalInventory) CancelInstallation(ctx context.Context, params inst
 		return installer.NewCancelInstallationInternalServerError().WithPayload(common.GenerateError(http.StatusInternalServerError, err))
 	}
 
+	if err := checkResourceVersion(params.IfMatch, c.ResourceVersion); err != nil {
+		return common.GenerateErrorResponder(err)
+	}
+
 	// cancellation is made by setting the cluster and and hosts states to error.
 	if err := b.clusterApi.CancelInstallation(ctx, &c, "Installation was canceled by user", tx); err != nil {
 		return common.GenerateErrorResponder(err)
@@ -2788,12 +3053,13 @@ func (b *bareMetalInventory) CancelInstallation(ctx context.Context, params inst
 	if err := tx.Commit().Error; err != nil {
 		log.Errorf("Failed to cancel installation: error committing DB transaction (%s)", err)
 		msg := "Failed to cancel installation: error committing DB transaction"
-		b.eventsHandler.AddEvent(ctx, *c.ID, nil, models.EventSeverityError, msg, time.Now())
+		b.eventsHandler.AddEvent(ctx, *c.ID, nil, models.EventSeverityError, msg, time.Now(), events.ClusterInstallCancelFailed, nil)
 		return installer.NewCancelInstallationInternalServerError().WithPayload(
 			common.GenerateError(http.StatusInternalServerError, errors.New("DB error, failed to commit transaction")))
 	}
 	txSuccess = true
 
+	b.refresher.ClusterChanged(params.ClusterID)
 	return installer.NewCancelInstallationAccepted().WithPayload(&c.Cluster)
 }
 
@@ -2804,8 +3070,8 @@ func (b *bareMetalInventory) ResetCluster(ctx context.Context, params installer.
 	var c common.Cluster
 
 	txSuccess := false
-	tx := b.db.Begin()
-	tx = transaction.AddForUpdateQueryOption(tx)
+	ctx, tx, unlock, lockErr := transaction.BeginInCluster(ctx, b.db.WithContext(ctx), params.ClusterID)
+	defer unlock()
 	defer func() {
 		if !txSuccess {
 			log.Error("reset cluster failed")
@@ -2823,6 +3089,11 @@ func (b *bareMetalInventory) ResetCluster(ctx context.Context, params installer.
 			common.GenerateError(http.StatusInternalServerError, errors.New("DB error, failed to start transaction")))
 	}
 
+	if lockErr != nil {
+		log.WithError(lockErr).Errorf("failed to lock cluster %s", params.ClusterID)
+		return common.NewApiError(http.StatusInternalServerError, lockErr)
+	}
+
 	if err := tx.Preload("Hosts").First(&c, "id = ?", params.ClusterID).Error; err != nil {
 		log.WithError(err).Errorf("failed to find cluster %s", params.ClusterID)
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -2831,6 +3102,10 @@ func (b *bareMetalInventory) ResetCluster(ctx context.Context, params installer.
 		return installer.NewResetClusterInternalServerError().WithPayload(common.GenerateError(http.StatusInternalServerError, err))
 	}
 
+	if err := checkResourceVersion(params.IfMatch, c.ResourceVersion); err != nil {
+		return common.GenerateErrorResponder(err)
+	}
+
 	if err := b.clusterApi.ResetCluster(ctx, &c, "cluster was reset by user", tx); err != nil {
 		return common.GenerateErrorResponder(err)
 	}
@@ -2863,6 +3138,7 @@ func (b *bareMetalInventory) ResetCluster(ctx context.Context, params installer.
 	}
 	txSuccess = true
 
+	b.refresher.ClusterChanged(params.ClusterID)
 	return installer.NewResetClusterAccepted().WithPayload(&c.Cluster)
 }
 
@@ -2872,7 +3148,7 @@ func (b *bareMetalInventory) CompleteInstallation(ctx context.Context, params in
 	log.Infof("complete cluster %s installation", params.ClusterID)
 
 	var c common.Cluster
-	if err := b.db.Preload("Hosts").First(&c, "id = ?", params.ClusterID).Error; err != nil {
+	if err := b.db.WithContext(ctx).Preload("Hosts").First(&c, "id = ?", params.ClusterID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return common.NewApiError(http.StatusNotFound, err)
 		}
@@ -2884,6 +3160,7 @@ func (b *bareMetalInventory) CompleteInstallation(ctx context.Context, params in
 		return common.GenerateErrorResponder(err)
 	}
 
+	b.refresher.ClusterChanged(params.ClusterID)
 	return installer.NewCompleteInstallationAccepted().WithPayload(&c.Cluster)
 }
 
@@ -3044,7 +3321,7 @@ func applyLimit(ret models.FreeAddressesList, limitParam *int64) models.FreeAddr
 
 func (b *bareMetalInventory) getFreeAddresses(ctx context.Context, params installer.GetFreeAddressesParams, log logrus.FieldLogger) (models.FreeAddressesList, error) {
 	var hosts []*models.Host
-	err := b.db.Select("free_addresses").Find(&hosts, "cluster_id = ? and status in (?)", params.ClusterID.String(), []string{models.HostStatusInsufficient, models.HostStatusKnown}).Error
+	err := b.db.WithContext(ctx).Select("free_addresses").Find(&hosts, "cluster_id = ? and status in (?)", params.ClusterID.String(), []string{models.HostStatusInsufficient, models.HostStatusKnown}).Error
 	if err != nil {
 		return nil, common.NewApiError(http.StatusInternalServerError, errors.Wrapf(err, "Error retreiving hosts for cluster %s", params.ClusterID.String()))
 	}
@@ -3223,13 +3500,65 @@ func (b *bareMetalInventory) getHost(ctx context.Context, clusterId string, host
 	log := logutil.FromContext(ctx, b.log)
 	var host models.Host
 
-	if err := b.db.First(&host, "id = ? and cluster_id = ?", hostId, clusterId).Error; err != nil {
+	if err := b.db.WithContext(ctx).First(&host, "id = ? and cluster_id = ?", hostId, clusterId).Error; err != nil {
 		log.WithError(err).Errorf("failed to find host: %s", hostId)
 		return nil, common.NewApiError(http.StatusNotFound, errors.Errorf("Host %s not found", hostId))
 	}
 	return &host, nil
 }
 
+// resourceVersionETag formats the resource version of a cluster or a host as a strong entity tag
+func resourceVersionETag(resourceVersion int64) string {
+	return strconv.Quote(strconv.FormatInt(resourceVersion, 10))
+}
+
+// checkResourceVersion fails with 412 unless the If-Match precondition, if given, matches the resource version
+func checkResourceVersion(ifMatch *string, resourceVersion int64) error {
+	if ifMatch == nil {
+		return nil
+	}
+	etag := resourceVersionETag(resourceVersion)
+	for _, tag := range strings.Split(*ifMatch, ",") {
+		if tag = strings.TrimSpace(tag); tag == "*" || tag == etag {
+			return nil
+		}
+	}
+	return common.NewApiError(http.StatusPreconditionFailed,
+		errors.Errorf("If-Match %s doesn't match the current resource version %s", *ifMatch, etag))
+}
+
+// checkClusterResourceVersion is checkResourceVersion for callers that haven't loaded the cluster,
+// it should run in a transaction that holds the cluster lock.
+func checkClusterResourceVersion(tx *gorm.DB, clusterID strfmt.UUID, ifMatch *string) error {
+	if ifMatch == nil {
+		return nil
+	}
+	var cluster common.Cluster
+	if err := tx.Select("resource_version").Take(&cluster, "id = ?", clusterID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return common.NewApiError(http.StatusNotFound, err)
+		}
+		return common.NewApiError(http.StatusInternalServerError, err)
+	}
+	return checkResourceVersion(ifMatch, cluster.ResourceVersion)
+}
+
+// checkHostResourceVersion is checkResourceVersion for callers that haven't loaded the host,
+// it should run in a transaction that holds the cluster lock.
+func checkHostResourceVersion(tx *gorm.DB, clusterID, hostID strfmt.UUID, ifMatch *string) error {
+	if ifMatch == nil {
+		return nil
+	}
+	var host models.Host
+	if err := tx.Select("resource_version").Take(&host, "id = ? and cluster_id = ?", hostID, clusterID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return common.NewApiError(http.StatusNotFound, err)
+		}
+		return common.NewApiError(http.StatusInternalServerError, err)
+	}
+	return checkResourceVersion(ifMatch, host.ResourceVersion)
+}
+
 type returnHosts bool
 type includeDeleted bool
 
@@ -3238,9 +3567,9 @@ func (b *bareMetalInventory) getCluster(ctx context.Context, clusterID string, f
 	var cluster common.Cluster
 
 	isUnscoped := funk.Contains(flags, includeDeleted(true))
-	db := b.db
+	db := b.db.WithContext(ctx)
 	if isUnscoped {
-		db = b.db.Unscoped()
+		db = db.Unscoped()
 	}
 	if funk.Contains(flags, returnHosts(true)) {
 		db = db.Preload("Hosts", func(db *gorm.DB) *gorm.DB {
@@ -3417,7 +3746,7 @@ func (b *bareMetalInventory) RegisterOCPCluster(ctx context.Context) error {
 	}
 
 	txSuccess := false
-	tx := b.db.Begin()
+	tx := b.db.WithContext(ctx).Begin()
 	defer func() {
 		if !txSuccess {
 			log.Error("update cluster failed")
@@ -3481,7 +3810,7 @@ func (b bareMetalInventory) PermanentlyDeleteUnregisteredClustersAndHosts() {
 	b.log.Debugf(
 		"Permanently deleting all clusters that were de-registered before %s",
 		olderThen)
-	if err := b.clusterApi.PermanentClustersDeletion(context.Background(), olderThen, b.objectHandler); err != nil {
+	if err := b.clusterApi.PermanentClustersDeletion(context.Background(), olderThen, b.objectHandler, b.leaderElector); err != nil {
 		b.log.WithError(err).Errorf("Failed deleting de-registered clusters")
 		return
 	}
@@ -3489,7 +3818,7 @@ func (b bareMetalInventory) PermanentlyDeleteUnregisteredClustersAndHosts() {
 	b.log.Debugf(
 		"Permanently deleting all hosts that were soft-deleted before %s",
 		olderThen)
-	if err := b.hostApi.PermanentHostsDeletion(olderThen); err != nil {
+	if err := b.hostApi.PermanentHostsDeletion(olderThen, b.leaderElector); err != nil {
 		b.log.WithError(err).Errorf("Failed deleting soft-deleted hosts")
 		return
 	}
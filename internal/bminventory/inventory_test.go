@@ -9,6 +9,7 @@ import (
 	"io/ioutil"
 	"mime/multipart"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
 	"sort"
@@ -21,6 +22,7 @@ import (
 
 	ign_3_1 "github.com/coreos/ignition/v2/config/v3_1"
 	ign_3_1_types "github.com/coreos/ignition/v2/config/v3_1/types"
+	"github.com/go-openapi/runtime"
 	"github.com/go-openapi/runtime/middleware"
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
@@ -34,14 +36,18 @@ import (
 	"github.com/openshift/assisted-service/internal/cluster/validations"
 	"github.com/openshift/assisted-service/internal/common"
 	"github.com/openshift/assisted-service/internal/events"
+	"github.com/openshift/assisted-service/internal/hardware"
 	"github.com/openshift/assisted-service/internal/host"
 	"github.com/openshift/assisted-service/internal/installcfg"
 	"github.com/openshift/assisted-service/internal/metrics"
+	"github.com/openshift/assisted-service/internal/refresh"
+	"github.com/openshift/assisted-service/internal/webhooks"
 	"github.com/openshift/assisted-service/models"
 	"github.com/openshift/assisted-service/pkg/auth"
 	"github.com/openshift/assisted-service/pkg/filemiddleware"
 	"github.com/openshift/assisted-service/pkg/generator"
 	"github.com/openshift/assisted-service/pkg/k8sclient"
+	"github.com/openshift/assisted-service/pkg/leader"
 	"github.com/openshift/assisted-service/pkg/s3wrapper"
 	"github.com/openshift/assisted-service/restapi/operations/installer"
 	"github.com/pkg/errors"
@@ -154,7 +160,7 @@ var _ = Describe("GenerateClusterISO", func() {
 			mockS3Client.EXPECT().IsAwsS3().Return(false)
 			mockS3Client.EXPECT().GetObjectSizeBytes(gomock.Any(), gomock.Any()).Return(int64(100), nil).Times(1)
 			mockS3Client.EXPECT().UploadISO(gomock.Any(), gomock.Any(), fmt.Sprintf("discovery-image-%s", clusterId.String()))
-			mockEvents.EXPECT().AddEvent(gomock.Any(), *clusterId, nil, models.EventSeverityInfo, "Generated image (SSH public key is not set)", gomock.Any())
+			mockEvents.EXPECT().AddEvent(gomock.Any(), *clusterId, nil, models.EventSeverityInfo, "Generated image (SSH public key is not set)", gomock.Any(), gomock.Any(), gomock.Any())
 			generateReply := bm.GenerateClusterISO(ctx, installer.GenerateClusterISOParams{
 				ClusterID:         *clusterId,
 				ImageCreateParams: &models.ImageCreateParams{},
@@ -170,7 +176,7 @@ var _ = Describe("GenerateClusterISO", func() {
 			mockS3Client.EXPECT().GetObjectSizeBytes(gomock.Any(), gomock.Any()).Return(int64(100), nil).Times(1)
 			mockS3Client.EXPECT().UploadISO(gomock.Any(), gomock.Any(), gomock.Any())
 			mockEvents.EXPECT().AddEvent(gomock.Any(), *clusterId, nil, models.EventSeverityInfo, "Generated image (proxy URL is \"http://1.1.1.1:1234\", SSH public key "+
-				"is not set)", gomock.Any())
+				"is not set)", gomock.Any(), gomock.Any(), gomock.Any())
 			generateReply := bm.GenerateClusterISO(ctx, installer.GenerateClusterISOParams{
 				ClusterID:         *clusterId,
 				ImageCreateParams: &models.ImageCreateParams{},
@@ -193,7 +199,7 @@ var _ = Describe("GenerateClusterISO", func() {
 			mockS3Client.EXPECT().UpdateObjectTimestamp(gomock.Any(), gomock.Any()).Return(true, nil).Times(1)
 			mockS3Client.EXPECT().GetObjectSizeBytes(gomock.Any(), gomock.Any()).Return(int64(100), nil).Times(1)
 			mockS3Client.EXPECT().GeneratePresignedDownloadURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("", nil).Times(1)
-			mockEvents.EXPECT().AddEvent(gomock.Any(), clusterId, nil, models.EventSeverityInfo, "Re-used existing image rather than generating a new one", gomock.Any())
+			mockEvents.EXPECT().AddEvent(gomock.Any(), clusterId, nil, models.EventSeverityInfo, "Re-used existing image rather than generating a new one", gomock.Any(), gomock.Any(), gomock.Any())
 			generateReply := bm.GenerateClusterISO(ctx, installer.GenerateClusterISOParams{
 				ClusterID:         clusterId,
 				ImageCreateParams: &models.ImageCreateParams{},
@@ -218,7 +224,7 @@ var _ = Describe("GenerateClusterISO", func() {
 			mockS3Client.EXPECT().UpdateObjectTimestamp(gomock.Any(), gomock.Any()).Return(false, nil).Times(1)
 			mockS3Client.EXPECT().GetObjectSizeBytes(gomock.Any(), gomock.Any()).Return(int64(100), nil).Times(1)
 			mockS3Client.EXPECT().GeneratePresignedDownloadURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("", nil).Times(1)
-			mockEvents.EXPECT().AddEvent(gomock.Any(), clusterId, nil, models.EventSeverityInfo, "Generated image (SSH public key is not set)", gomock.Any())
+			mockEvents.EXPECT().AddEvent(gomock.Any(), clusterId, nil, models.EventSeverityInfo, "Generated image (SSH public key is not set)", gomock.Any(), gomock.Any(), gomock.Any())
 			generateReply := bm.GenerateClusterISO(ctx, installer.GenerateClusterISOParams{
 				ClusterID:         clusterId,
 				ImageCreateParams: &models.ImageCreateParams{},
@@ -234,7 +240,7 @@ var _ = Describe("GenerateClusterISO", func() {
 			mockS3Client.EXPECT().UploadISO(gomock.Any(), gomock.Any(), gomock.Any())
 			mockS3Client.EXPECT().GetObjectSizeBytes(gomock.Any(), gomock.Any()).Return(int64(100), nil).Times(1)
 			mockS3Client.EXPECT().GeneratePresignedDownloadURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("", nil).Times(1)
-			mockEvents.EXPECT().AddEvent(gomock.Any(), *clusterId, nil, models.EventSeverityInfo, "Generated image (SSH public key is not set)", gomock.Any())
+			mockEvents.EXPECT().AddEvent(gomock.Any(), *clusterId, nil, models.EventSeverityInfo, "Generated image (SSH public key is not set)", gomock.Any(), gomock.Any(), gomock.Any())
 			generateReply := bm.GenerateClusterISO(ctx, installer.GenerateClusterISOParams{
 				ClusterID:         *clusterId,
 				ImageCreateParams: &models.ImageCreateParams{},
@@ -255,7 +261,7 @@ var _ = Describe("GenerateClusterISO", func() {
 		It("failed_to_upload_iso", func() {
 			clusterId := registerCluster(true).ID
 			mockS3Client.EXPECT().UploadISO(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("failed"))
-			mockEvents.EXPECT().AddEvent(gomock.Any(), *clusterId, nil, models.EventSeverityError, gomock.Any(), gomock.Any())
+			mockEvents.EXPECT().AddEvent(gomock.Any(), *clusterId, nil, models.EventSeverityError, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 			generateReply := bm.GenerateClusterISO(ctx, installer.GenerateClusterISOParams{
 				ClusterID:         *clusterId,
 				ImageCreateParams: &models.ImageCreateParams{},
@@ -277,7 +283,7 @@ var _ = Describe("GenerateClusterISO", func() {
 			cluster.PullSecret = "{\"auths\":{\"another.cloud.com\":{\"auth\":\"dG9rZW46dGVzdAo=\",\"email\":\"coyote@acme.com\"}}}"
 			clusterId := cluster.ID
 			mockS3Client.EXPECT().UploadISO(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("failed"))
-			mockEvents.EXPECT().AddEvent(gomock.Any(), *clusterId, nil, models.EventSeverityError, gomock.Any(), gomock.Any())
+			mockEvents.EXPECT().AddEvent(gomock.Any(), *clusterId, nil, models.EventSeverityError, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 			generateReply := bm.GenerateClusterISO(ctx, installer.GenerateClusterISOParams{
 				ClusterID:         *clusterId,
 				ImageCreateParams: &models.ImageCreateParams{},
@@ -289,7 +295,7 @@ var _ = Describe("GenerateClusterISO", func() {
 	Context("when kube job is used as generator", func() {
 		BeforeEach(func() {
 			mockGenerator := generator.NewMockISOInstallConfigGenerator(ctrl)
-			bm = NewBareMetalInventory(db, getTestLog(), nil, nil, cfg, mockGenerator, mockEvents, mockS3Client, nil, getTestAuthHandler(), nil, nil, mockSecretValidator)
+			bm = NewBareMetalInventory(db, getTestLog(), nil, nil, cfg, mockGenerator, mockEvents, mockS3Client, nil, getTestAuthHandler(), nil, nil, mockSecretValidator, &refresh.DummyNotifier{})
 		})
 		RunGenerateClusterISOTests()
 	})
@@ -498,7 +504,7 @@ var _ = Describe("RegisterHost", func() {
 		hostID = strfmt.UUID(uuid.New().String())
 		db = common.PrepareTestDB(dbName)
 		bm = NewBareMetalInventory(db, getTestLog(), mockHostAPI, mockClusterAPI, cfg, nil, mockEventsHandler,
-			nil, nil, getTestAuthHandler(), nil, nil, mockSecretValidator)
+			nil, nil, getTestAuthHandler(), nil, nil, mockSecretValidator, &refresh.DummyNotifier{})
 	})
 
 	AfterEach(func() {
@@ -533,7 +539,7 @@ var _ = Describe("RegisterHost", func() {
 		mockClusterAPI.EXPECT().AcceptRegistration(gomock.Any()).Return(err).Times(1)
 
 		mockEventsHandler.EXPECT().
-			AddEvent(gomock.Any(), *cluster.ID, &hostID, models.EventSeverityError, gomock.Any(), gomock.Any()).
+			AddEvent(gomock.Any(), *cluster.ID, &hostID, models.EventSeverityError, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Times(1)
 
 		By("trying to register an host while installation takes place")
@@ -564,7 +570,7 @@ var _ = Describe("RegisterHost", func() {
 			}).Times(1)
 		mockHostAPI.EXPECT().GetStagesByRole(gomock.Any(), gomock.Any()).Return(nil).Times(1)
 		mockEventsHandler.EXPECT().
-			AddEvent(gomock.Any(), *cluster.ID, &hostID, models.EventSeverityInfo, gomock.Any(), gomock.Any()).
+			AddEvent(gomock.Any(), *cluster.ID, &hostID, models.EventSeverityInfo, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Times(1)
 
 		bm.ServiceBaseURL = uuid.New().String()
@@ -598,7 +604,7 @@ var _ = Describe("RegisterHost", func() {
 		mockClusterAPI.EXPECT().AcceptRegistration(gomock.Any()).Return(nil).Times(1)
 		mockHostAPI.EXPECT().RegisterHost(gomock.Any(), gomock.Any()).Return(errors.New(expectedErrMsg)).Times(1)
 		mockEventsHandler.EXPECT().
-			AddEvent(gomock.Any(), *cluster.ID, &hostID, models.EventSeverityError, gomock.Any(), gomock.Any()).
+			AddEvent(gomock.Any(), *cluster.ID, &hostID, models.EventSeverityError, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Times(1)
 		reply := bm.RegisterHost(ctx, installer.RegisterHostParams{
 			ClusterID: *cluster.ID,
@@ -636,7 +642,7 @@ var _ = Describe("GetNextSteps", func() {
 		mockHostApi = host.NewMockAPI(ctrl)
 		mockEvents = events.NewMockHandler(ctrl)
 		mockSecretValidator = validations.NewMockPullSecretValidator(ctrl)
-		bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, nil, cfg, nil, mockEvents, nil, nil, getTestAuthHandler(), nil, nil, mockSecretValidator)
+		bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, nil, cfg, nil, mockEvents, nil, nil, getTestAuthHandler(), nil, nil, mockSecretValidator, &refresh.DummyNotifier{})
 	})
 
 	AfterEach(func() {
@@ -723,7 +729,7 @@ var _ = Describe("PostStepReply", func() {
 		mockEvents = events.NewMockHandler(ctrl)
 		mockClusterApi = cluster.NewMockAPI(ctrl)
 		mockSecretValidator = validations.NewMockPullSecretValidator(ctrl)
-		bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, mockClusterApi, cfg, nil, mockEvents, nil, nil, getTestAuthHandler(), nil, nil, mockSecretValidator)
+		bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, mockClusterApi, cfg, nil, mockEvents, nil, nil, getTestAuthHandler(), nil, nil, mockSecretValidator, &refresh.DummyNotifier{})
 	})
 
 	AfterEach(func() {
@@ -783,6 +789,84 @@ var _ = Describe("PostStepReply", func() {
 		})
 	})
 
+	It("disk speed check", func() {
+		clusterId := strToUUID(uuid.New().String())
+		hostId := strToUUID(uuid.New().String())
+		host := models.Host{
+			ID:        hostId,
+			ClusterID: *clusterId,
+			Status:    swag.String(models.HostStatusInsufficient),
+		}
+		Expect(db.Create(&host).Error).ShouldNot(HaveOccurred())
+		mockHostApi.EXPECT().UpdateDiskSpeedReport(gomock.Any(), gomock.Any(),
+			`{"fsync_latency_p50_ms":1.5,"fsync_latency_p99_ms":7.25,"path":"/dev/sda"}`, gomock.Any()).Return(nil).Times(1)
+		reply := bm.PostStepReply(ctx, installer.PostStepReplyParams{
+			ClusterID: *clusterId,
+			HostID:    *hostId,
+			Reply: &models.StepReply{
+				Output:   `{"path":"/dev/sda","fsync_latency_p50_ms":1.5,"fsync_latency_p99_ms":7.25,"fio_version":"3.19"}`,
+				StepType: models.StepTypeDiskSpeedCheck,
+			},
+		})
+		Expect(reply).Should(BeAssignableToTypeOf(installer.NewPostStepReplyNoContent()))
+	})
+
+	Context("Refresh notifications", func() {
+		var (
+			mockRefresher     *refresh.MockNotifier
+			clusterId, hostId *strfmt.UUID
+		)
+
+		BeforeEach(func() {
+			mockRefresher = refresh.NewMockNotifier(ctrl)
+			bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, mockClusterApi, cfg, nil, mockEvents, nil, nil, getTestAuthHandler(), nil, nil, mockSecretValidator, mockRefresher)
+			clusterId = strToUUID(uuid.New().String())
+			hostId = strToUUID(uuid.New().String())
+			host := models.Host{
+				ID:        hostId,
+				ClusterID: *clusterId,
+				Status:    swag.String("discovering"),
+			}
+			Expect(db.Create(&host).Error).ShouldNot(HaveOccurred())
+		})
+
+		It("refreshes the host after a reply", func() {
+			b, err := json.Marshal(makeFreeNetworksAddresses(makeFreeAddresses("10.0.0.0/24", "10.0.0.1")))
+			Expect(err).ToNot(HaveOccurred())
+			mockRefresher.EXPECT().HostChanged(*clusterId, *hostId).Times(1)
+			reply := bm.PostStepReply(ctx, installer.PostStepReplyParams{
+				ClusterID: *clusterId,
+				HostID:    *hostId,
+				Reply:     &models.StepReply{Output: string(b), StepType: models.StepTypeFreeNetworkAddresses},
+			})
+			Expect(reply).Should(BeAssignableToTypeOf(installer.NewPostStepReplyNoContent()))
+		})
+
+		It("refreshes the whole cluster after a connectivity reply", func() {
+			b, err := json.Marshal(&models.ConnectivityReport{})
+			Expect(err).ToNot(HaveOccurred())
+			mockHostApi.EXPECT().UpdateConnectivityReport(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			mockRefresher.EXPECT().ClusterChanged(*clusterId).Times(1)
+			reply := bm.PostStepReply(ctx, installer.PostStepReplyParams{
+				ClusterID: *clusterId,
+				HostID:    *hostId,
+				Reply:     &models.StepReply{Output: string(b), StepType: models.StepTypeConnectivityCheck},
+			})
+			Expect(reply).Should(BeAssignableToTypeOf(installer.NewPostStepReplyNoContent()))
+		})
+
+		It("doesn't refresh after a reply that failed to update", func() {
+			b, err := json.Marshal(makeFreeNetworksAddresses())
+			Expect(err).ToNot(HaveOccurred())
+			reply := bm.PostStepReply(ctx, installer.PostStepReplyParams{
+				ClusterID: *clusterId,
+				HostID:    *hostId,
+				Reply:     &models.StepReply{Output: string(b), StepType: models.StepTypeFreeNetworkAddresses},
+			})
+			Expect(reply).Should(BeAssignableToTypeOf(installer.NewPostStepReplyInternalServerError()))
+		})
+	})
+
 	Context("Dhcp allocation", func() {
 		var (
 			clusterId, hostId *strfmt.UUID
@@ -958,7 +1042,7 @@ var _ = Describe("GetFreeAddresses", func() {
 		mockHostApi = host.NewMockAPI(ctrl)
 		mockEvents = events.NewMockHandler(ctrl)
 		mockSecretValidator = validations.NewMockPullSecretValidator(ctrl)
-		bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, nil, cfg, nil, mockEvents, nil, nil, getTestAuthHandler(), nil, nil, mockSecretValidator)
+		bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, nil, cfg, nil, mockEvents, nil, nil, getTestAuthHandler(), nil, nil, mockSecretValidator, &refresh.DummyNotifier{})
 	})
 
 	AfterEach(func() {
@@ -1107,7 +1191,7 @@ var _ = Describe("UpdateHostInstallProgress", func() {
 		mockHostApi = host.NewMockAPI(ctrl)
 		mockEvents = events.NewMockHandler(ctrl)
 		mockSecretValidator = validations.NewMockPullSecretValidator(ctrl)
-		bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, nil, cfg, nil, mockEvents, nil, nil, getTestAuthHandler(), nil, nil, mockSecretValidator)
+		bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, nil, cfg, nil, mockEvents, nil, nil, getTestAuthHandler(), nil, nil, mockSecretValidator, &refresh.DummyNotifier{})
 		defaultProgressStage = "some progress"
 	})
 
@@ -1139,7 +1223,7 @@ var _ = Describe("UpdateHostInstallProgress", func() {
 		})
 
 		It("success", func() {
-			mockEvents.EXPECT().AddEvent(gomock.Any(), clusterID, &hostID, models.EventSeverityInfo, gomock.Any(), gomock.Any())
+			mockEvents.EXPECT().AddEvent(gomock.Any(), clusterID, &hostID, models.EventSeverityInfo, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 			mockHostApi.EXPECT().UpdateInstallProgress(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 			reply := bm.UpdateHostInstallProgress(ctx, installer.UpdateHostInstallProgressParams{
 				ClusterID:    clusterID,
@@ -1149,6 +1233,20 @@ var _ = Describe("UpdateHostInstallProgress", func() {
 			Expect(reply).Should(BeAssignableToTypeOf(installer.NewUpdateHostInstallProgressOK()))
 		})
 
+		It("refreshes the host", func() {
+			mockRefresher := refresh.NewMockNotifier(ctrl)
+			bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, nil, cfg, nil, mockEvents, nil, nil, getTestAuthHandler(), nil, nil, mockSecretValidator, mockRefresher)
+			mockEvents.EXPECT().AddEvent(gomock.Any(), clusterID, &hostID, models.EventSeverityInfo, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			mockHostApi.EXPECT().UpdateInstallProgress(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			mockRefresher.EXPECT().HostChanged(clusterID, hostID).Times(1)
+			reply := bm.UpdateHostInstallProgress(ctx, installer.UpdateHostInstallProgressParams{
+				ClusterID:    clusterID,
+				HostProgress: progressParams,
+				HostID:       hostID,
+			})
+			Expect(reply).Should(BeAssignableToTypeOf(installer.NewUpdateHostInstallProgressOK()))
+		})
+
 		It("update_failed", func() {
 			mockHostApi.EXPECT().UpdateInstallProgress(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.Errorf("some error"))
 			reply := bm.UpdateHostInstallProgress(ctx, installer.UpdateHostInstallProgressParams{
@@ -1559,7 +1657,7 @@ var _ = Describe("cluster", func() {
 				})
 
 				It("set a valid proxy", func() {
-					mockEvents.EXPECT().AddEvent(gomock.Any(), clusterID, nil, models.EventSeverityInfo, "Proxy settings changed", gomock.Any())
+					mockEvents.EXPECT().AddEvent(gomock.Any(), clusterID, nil, models.EventSeverityInfo, "Proxy settings changed", gomock.Any(), gomock.Any(), gomock.Any())
 					cluster := updateCluster("http://proxy.proxy", "", "proxy.proxy")
 
 					// ProxyHash shouldn't be changed when proxy is updated, only when generating new ISO
@@ -2180,7 +2278,7 @@ var _ = Describe("cluster", func() {
 				setIsReadyForInstallationTrue(mockClusterApi)
 				mockSetConnectivityMajorityGroupsForCluster(mockClusterApi)
 				mockEvents.EXPECT().
-					AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 					MinTimes(0)
 
 				reply := bm.InstallCluster(ctx, installer.InstallClusterParams{
@@ -2519,7 +2617,7 @@ var _ = Describe("cluster", func() {
 					"systemd":{}
 			}`))
 			mockS3Client.EXPECT().Download(gomock.Any(), gomock.Any()).Return(ignitionReader, int64(0), nil).MinTimes(0)
-			bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, mockClusterApi, cfg, mockGenerator, mockEvents, mockS3Client, mockMetric, getTestAuthHandler(), nil, nil, mockSecretValidator)
+			bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, mockClusterApi, cfg, mockGenerator, mockEvents, mockS3Client, mockMetric, getTestAuthHandler(), nil, nil, mockSecretValidator, &refresh.DummyNotifier{})
 		})
 		RunClusterTests()
 	})
@@ -2549,9 +2647,9 @@ var _ = Describe("KubeConfig download", func() {
 		mockS3Client = s3wrapper.NewMockAPI(ctrl)
 		mockSecretValidator = validations.NewMockPullSecretValidator(ctrl)
 		clusterApi = cluster.NewManager(cluster.Config{}, getTestLog().WithField("pkg", "cluster-monitor"),
-			db, nil, nil, nil, nil)
+			db, nil, nil, nil, nil, &webhooks.DummyNotifier{})
 
-		bm = NewBareMetalInventory(db, getTestLog(), nil, clusterApi, cfg, nil, nil, mockS3Client, nil, getTestAuthHandler(), nil, nil, mockSecretValidator)
+		bm = NewBareMetalInventory(db, getTestLog(), nil, clusterApi, cfg, nil, nil, mockS3Client, nil, getTestAuthHandler(), nil, nil, mockSecretValidator, &refresh.DummyNotifier{})
 		c = common.Cluster{Cluster: models.Cluster{
 			ID:     &clusterID,
 			APIVip: "10.11.12.13",
@@ -2677,8 +2775,8 @@ var _ = Describe("UploadClusterIngressCert test", func() {
 		clusterID = strfmt.UUID(uuid.New().String())
 		mockS3Client = s3wrapper.NewMockAPI(ctrl)
 		clusterApi = cluster.NewManager(cluster.Config{}, getTestLog().WithField("pkg", "cluster-monitor"),
-			db, nil, nil, nil, nil)
-		bm = NewBareMetalInventory(db, getTestLog(), nil, clusterApi, cfg, nil, nil, mockS3Client, nil, getTestAuthHandler(), nil, nil, mockSecretValidator)
+			db, nil, nil, nil, nil, &webhooks.DummyNotifier{})
+		bm = NewBareMetalInventory(db, getTestLog(), nil, clusterApi, cfg, nil, nil, mockS3Client, nil, getTestAuthHandler(), nil, nil, mockSecretValidator, &refresh.DummyNotifier{})
 		c = common.Cluster{Cluster: models.Cluster{
 			ID:     &clusterID,
 			APIVip: "10.11.12.13",
@@ -2852,7 +2950,7 @@ var _ = Describe("List unregistered clusters", func() {
 		mockHostApi = host.NewMockAPI(ctrl)
 		mockS3Client = s3wrapper.NewMockAPI(ctrl)
 		mockSecretValidator = validations.NewMockPullSecretValidator(ctrl)
-		bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, mockClusterAPI, cfg, nil, nil, mockS3Client, nil, getTestAuthHandler(), nil, nil, mockSecretValidator)
+		bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, mockClusterAPI, cfg, nil, nil, mockS3Client, nil, getTestAuthHandler(), nil, nil, mockSecretValidator, &refresh.DummyNotifier{})
 		c = common.Cluster{Cluster: models.Cluster{
 			ID:     &clusterID,
 			Name:   "mycluster",
@@ -2929,7 +3027,7 @@ var _ = Describe("Get unregistered clusters", func() {
 		mockHostApi = host.NewMockAPI(ctrl)
 		mockS3Client = s3wrapper.NewMockAPI(ctrl)
 		mockSecretValidator = validations.NewMockPullSecretValidator(ctrl)
-		bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, mockClusterAPI, cfg, nil, nil, mockS3Client, nil, getTestAuthHandler(), nil, nil, mockSecretValidator)
+		bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, mockClusterAPI, cfg, nil, nil, mockS3Client, nil, getTestAuthHandler(), nil, nil, mockSecretValidator, &refresh.DummyNotifier{})
 		c = common.Cluster{Cluster: models.Cluster{
 			ID:     &clusterID,
 			Name:   "mycluster",
@@ -3008,7 +3106,7 @@ var _ = Describe("Upload and Download logs test", func() {
 		mockS3Client = s3wrapper.NewMockAPI(ctrl)
 		mockSecretValidator = validations.NewMockPullSecretValidator(ctrl)
 
-		bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, mockClusterAPI, cfg, nil, nil, mockS3Client, nil, getTestAuthHandler(), nil, nil, mockSecretValidator)
+		bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, mockClusterAPI, cfg, nil, nil, mockS3Client, nil, getTestAuthHandler(), nil, nil, mockSecretValidator, &refresh.DummyNotifier{})
 		c = common.Cluster{Cluster: models.Cluster{
 			ID:     &clusterID,
 			Name:   "mycluster",
@@ -3364,7 +3462,7 @@ var _ = Describe("GetClusterInstallConfig", func() {
 		db = common.PrepareTestDB(dbName)
 		clusterID = strfmt.UUID(uuid.New().String())
 		mockSecretValidator = validations.NewMockPullSecretValidator(ctrl)
-		bm = NewBareMetalInventory(db, getTestLog(), nil, nil, cfg, nil, nil, nil, nil, getTestAuthHandler(), nil, nil, mockSecretValidator)
+		bm = NewBareMetalInventory(db, getTestLog(), nil, nil, cfg, nil, nil, nil, nil, getTestAuthHandler(), nil, nil, mockSecretValidator, &refresh.DummyNotifier{})
 		c = common.Cluster{Cluster: models.Cluster{
 			ID:                     &clusterID,
 			BaseDNSDomain:          "example.com",
@@ -3419,7 +3517,7 @@ var _ = Describe("UpdateClusterInstallConfig", func() {
 		db = common.PrepareTestDB(dbName)
 		clusterID = strfmt.UUID(uuid.New().String())
 		mockSecretValidator = validations.NewMockPullSecretValidator(ctrl)
-		bm = NewBareMetalInventory(db, getTestLog(), nil, nil, cfg, nil, nil, nil, nil, getTestAuthHandler(), nil, nil, mockSecretValidator)
+		bm = NewBareMetalInventory(db, getTestLog(), nil, nil, cfg, nil, nil, nil, nil, getTestAuthHandler(), nil, nil, mockSecretValidator, &refresh.DummyNotifier{})
 		c = common.Cluster{Cluster: models.Cluster{ID: &clusterID}}
 		err := db.Create(&c).Error
 		Expect(err).ShouldNot(HaveOccurred())
@@ -3494,7 +3592,7 @@ var _ = Describe("GetDiscoveryIgnition", func() {
 		db = common.PrepareTestDB(dbName)
 		clusterID = strfmt.UUID(uuid.New().String())
 		mockSecretValidator = validations.NewMockPullSecretValidator(ctrl)
-		bm = NewBareMetalInventory(db, getTestLog(), nil, nil, cfg, nil, nil, nil, nil, getTestAuthHandler(), nil, nil, mockSecretValidator)
+		bm = NewBareMetalInventory(db, getTestLog(), nil, nil, cfg, nil, nil, nil, nil, getTestAuthHandler(), nil, nil, mockSecretValidator, &refresh.DummyNotifier{})
 		c = common.Cluster{Cluster: models.Cluster{
 			ID:            &clusterID,
 			PullSecretSet: true,
@@ -3571,7 +3669,7 @@ var _ = Describe("UpdateDiscoveryIgnition", func() {
 		db = common.PrepareTestDB(dbName)
 		clusterID = strfmt.UUID(uuid.New().String())
 		mockSecretValidator = validations.NewMockPullSecretValidator(ctrl)
-		bm = NewBareMetalInventory(db, getTestLog(), nil, nil, cfg, nil, nil, nil, nil, getTestAuthHandler(), nil, nil, mockSecretValidator)
+		bm = NewBareMetalInventory(db, getTestLog(), nil, nil, cfg, nil, nil, nil, nil, getTestAuthHandler(), nil, nil, mockSecretValidator, &refresh.DummyNotifier{})
 		c = common.Cluster{Cluster: models.Cluster{ID: &clusterID}}
 		err := db.Create(&c).Error
 		Expect(err).ShouldNot(HaveOccurred())
@@ -3597,6 +3695,40 @@ var _ = Describe("UpdateDiscoveryIgnition", func() {
 		Expect(updated.IgnitionConfigOverrides).To(Equal(override))
 	})
 
+	It("applies the override when If-Match matches the resource version", func() {
+		override := `{"ignition": {"version": "3.1.0"}, "storage": {"files": [{"path": "/tmp/example", "contents": {"source": "data:text/plain;base64,aGVscGltdHJhcHBlZGluYXN3YWdnZXJzcGVj"}}]}}`
+		getReply := bm.GetCluster(ctx, installer.GetClusterParams{ClusterID: clusterID}).(*installer.GetClusterOK)
+		params := installer.UpdateDiscoveryIgnitionParams{
+			ClusterID:               clusterID,
+			DiscoveryIgnitionParams: &models.DiscoveryIgnitionParams{Config: override},
+			IfMatch:                 swag.String(getReply.ETag),
+		}
+		response := bm.UpdateDiscoveryIgnition(ctx, params)
+		Expect(response).To(BeAssignableToTypeOf(&installer.UpdateDiscoveryIgnitionCreated{}))
+
+		var updated common.Cluster
+		Expect(db.First(&updated, "id = ?", clusterID).Error).ShouldNot(HaveOccurred())
+		Expect(updated.ResourceVersion).To(Equal(int64(1)))
+		getReply = bm.GetCluster(ctx, installer.GetClusterParams{ClusterID: clusterID}).(*installer.GetClusterOK)
+		Expect(getReply.ETag).To(Equal(`"1"`))
+	})
+
+	It("returns precondition failed when If-Match doesn't match the resource version", func() {
+		override := `{"ignition": {"version": "3.1.0"}, "storage": {"files": [{"path": "/tmp/example", "contents": {"source": "data:text/plain;base64,aGVscGltdHJhcHBlZGluYXN3YWdnZXJzcGVj"}}]}}`
+		Expect(db.Model(&common.Cluster{}).Where("id = ?", clusterID).Update("name", "other").Error).ShouldNot(HaveOccurred())
+		params := installer.UpdateDiscoveryIgnitionParams{
+			ClusterID:               clusterID,
+			DiscoveryIgnitionParams: &models.DiscoveryIgnitionParams{Config: override},
+			IfMatch:                 swag.String(`"0"`),
+		}
+		response := bm.UpdateDiscoveryIgnition(ctx, params)
+		verifyApiError(response, http.StatusPreconditionFailed)
+
+		var updated common.Cluster
+		Expect(db.First(&updated, "id = ?", clusterID).Error).ShouldNot(HaveOccurred())
+		Expect(updated.IgnitionConfigOverrides).To(BeEmpty())
+	})
+
 	It("returns not found with a non-existant cluster", func() {
 		override := `{"ignition": {"version": "3.1.0"}, "storage": {"files": [{"path": "/tmp/example", "contents": {"source": "data:text/plain;base64,aGVscGltdHJhcHBlZGluYXN3YWdnZXJzcGVj"}}]}}`
 		params := installer.UpdateDiscoveryIgnitionParams{
@@ -3740,7 +3872,7 @@ var _ = Describe("Register OCPCluster test", func() {
 		mockK8sClient = k8sclient.NewMockK8SClient(ctrl)
 		mockSecretValidator = validations.NewMockPullSecretValidator(ctrl)
 		mockMetric = metrics.NewMockAPI(ctrl)
-		bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, mockClusterAPI, cfg, nil, nil, mockS3Client, mockMetric, getTestAuthHandler(), mockK8sClient, nil, mockSecretValidator)
+		bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, mockClusterAPI, cfg, nil, nil, mockS3Client, mockMetric, getTestAuthHandler(), mockK8sClient, nil, mockSecretValidator, &refresh.DummyNotifier{})
 		configMap.Data = make(map[string]string)
 		configMap.Data["install-config"] = "platform:\n  baremetal:\n    apiVIP: 192.168.126.141\n    bootstrapProvisioningIP: 172.22.0.2"
 		pullSecret.Data = make(map[string][]byte)
@@ -3888,7 +4020,7 @@ var _ = Describe("Register AddHostsCluster test", func() {
 		mockS3Client = s3wrapper.NewMockAPI(ctrl)
 		mockMetric = metrics.NewMockAPI(ctrl)
 		mockSecretValidator = validations.NewMockPullSecretValidator(ctrl)
-		bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, mockClusterAPI, cfg, nil, nil, mockS3Client, mockMetric, getTestAuthHandler(), nil, nil, mockSecretValidator)
+		bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, mockClusterAPI, cfg, nil, nil, mockS3Client, mockMetric, getTestAuthHandler(), nil, nil, mockSecretValidator, &refresh.DummyNotifier{})
 		body := &bytes.Buffer{}
 		request, _ = http.NewRequest("POST", "test", body)
 	})
@@ -3966,7 +4098,7 @@ var _ = Describe("Install Hosts test", func() {
 		mockHostApi = host.NewMockAPI(ctrl)
 		mockS3Client = s3wrapper.NewMockAPI(ctrl)
 		mockSecretValidator = validations.NewMockPullSecretValidator(ctrl)
-		bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, mockClusterApi, cfg, nil, nil, mockS3Client, nil, getTestAuthHandler(), nil, nil, mockSecretValidator)
+		bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, mockClusterApi, cfg, nil, nil, mockS3Client, nil, getTestAuthHandler(), nil, nil, mockSecretValidator, &refresh.DummyNotifier{})
 		body := &bytes.Buffer{}
 		request, _ = http.NewRequest("POST", "test", body)
 		mockSetConnectivityMajorityGroupsForCluster(mockClusterApi)
@@ -4048,7 +4180,7 @@ var _ = Describe("TestRegisterCluster", func() {
 		mockMetric = metrics.NewMockAPI(ctrl)
 		mockSecretValidator = validations.NewMockPullSecretValidator(ctrl)
 		bm = NewBareMetalInventory(db, getTestLog(), nil, mockClusterApi, cfg, nil, mockEvents,
-			nil, mockMetric, getTestAuthHandler(), nil, nil, mockSecretValidator)
+			nil, mockMetric, getTestAuthHandler(), nil, nil, mockSecretValidator, &refresh.DummyNotifier{})
 	})
 
 	AfterEach(func() {
@@ -4059,7 +4191,7 @@ var _ = Describe("TestRegisterCluster", func() {
 	It("success", func() {
 		mockClusterApi.EXPECT().RegisterCluster(ctx, gomock.Any()).Return(nil).Times(1)
 		mockEvents.EXPECT().
-			AddEvent(gomock.Any(), gomock.Any(), nil, models.EventSeverityInfo, gomock.Any(), gomock.Any()).
+			AddEvent(gomock.Any(), gomock.Any(), nil, models.EventSeverityInfo, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Times(1)
 		mockMetric.EXPECT().ClusterRegistered(gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
 		mockSecretValidator.EXPECT().ValidatePullSecret(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
@@ -4101,6 +4233,128 @@ var _ = Describe("TestRegisterCluster", func() {
 		})
 		Expect(reflect.TypeOf(reply)).Should(Equal(reflect.TypeOf(installer.NewRegisterClusterBadRequest())))
 	})
+
+	Context("with an idempotency key", func() {
+		var key string
+
+		BeforeEach(func() {
+			key = uuid.New().String()
+		})
+
+		// every retry is parsed again from the request, the handler may modify the parameters
+		newParams := func(name string) installer.RegisterClusterParams {
+			return installer.RegisterClusterParams{
+				IdempotencyKey: &key,
+				NewClusterParams: &models.ClusterCreateParams{
+					Name:             swag.String(name),
+					OpenshiftVersion: swag.String("4.6"),
+					PullSecret:       swag.String(`{\"auths\":{\"cloud.openshift.com\":{\"auth\":\"dG9rZW46dGVzdAo=\",\"email\":\"coyote@acme.com\"}}}"`),
+				},
+			}
+		}
+
+		writeResponse := func(reply middleware.Responder) *httptest.ResponseRecorder {
+			recorder := httptest.NewRecorder()
+			reply.WriteResponse(recorder, runtime.JSONProducer())
+			return recorder
+		}
+
+		It("replays the response to a retry", func() {
+			mockClusterApi.EXPECT().RegisterCluster(ctx, gomock.Any()).Return(nil).Times(1)
+			mockEvents.EXPECT().
+				AddEvent(gomock.Any(), gomock.Any(), nil, models.EventSeverityInfo, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Times(1)
+			mockMetric.EXPECT().ClusterRegistered(gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
+			mockSecretValidator.EXPECT().ValidatePullSecret(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+			reply := bm.RegisterCluster(ctx, newParams("some-cluster-name"))
+			Expect(reflect.TypeOf(reply)).Should(Equal(reflect.TypeOf(installer.NewRegisterClusterCreated())))
+			original := writeResponse(reply)
+
+			replayed := writeResponse(bm.RegisterCluster(ctx, newParams("some-cluster-name")))
+			Expect(replayed.Code).To(Equal(http.StatusCreated))
+			Expect(replayed.Body.String()).To(Equal(original.Body.String()))
+			Expect(replayed.Header().Get("Idempotent-Replayed")).To(Equal("true"))
+		})
+
+		It("rejects a retry with a different request", func() {
+			mockClusterApi.EXPECT().RegisterCluster(ctx, gomock.Any()).Return(nil).Times(1)
+			mockEvents.EXPECT().
+				AddEvent(gomock.Any(), gomock.Any(), nil, models.EventSeverityInfo, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Times(1)
+			mockMetric.EXPECT().ClusterRegistered(gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
+			mockSecretValidator.EXPECT().ValidatePullSecret(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+			reply := bm.RegisterCluster(ctx, newParams("some-cluster-name"))
+			Expect(reflect.TypeOf(reply)).Should(Equal(reflect.TypeOf(installer.NewRegisterClusterCreated())))
+
+			reply = bm.RegisterCluster(ctx, newParams("other-cluster-name"))
+			verifyApiError(reply, http.StatusUnprocessableEntity)
+		})
+
+		It("runs a retry of a request that failed with a server error", func() {
+			mockClusterApi.EXPECT().RegisterCluster(ctx, gomock.Any()).Return(errors.Errorf("error")).Times(2)
+			mockSecretValidator.EXPECT().ValidatePullSecret(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+			reply := bm.RegisterCluster(ctx, newParams("some-cluster-name"))
+			Expect(reflect.TypeOf(reply)).Should(Equal(reflect.TypeOf(installer.NewRegisterClusterInternalServerError())))
+			reply = bm.RegisterCluster(ctx, newParams("some-cluster-name"))
+			Expect(reflect.TypeOf(reply)).Should(Equal(reflect.TypeOf(installer.NewRegisterClusterInternalServerError())))
+		})
+
+		// the key of a request that is still running, or whose replica crashed while running it
+		storeRunningKey := func(leaseExpiresAt time.Time) {
+			requestHash, err := hashRequest(newParams("some-cluster-name").NewClusterParams)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(db.Create(&common.IdempotencyKey{
+				UserName:       auth.UserNameFromContext(ctx),
+				Operation:      "RegisterCluster",
+				Key:            key,
+				RequestHash:    requestHash,
+				ExpiresAt:      time.Now().Add(time.Hour),
+				LeaseExpiresAt: &leaseExpiresAt,
+			}).Error).ShouldNot(HaveOccurred())
+		}
+
+		It("rejects a retry of a request that is still running", func() {
+			storeRunningKey(time.Now().Add(time.Minute))
+
+			reply := bm.RegisterCluster(ctx, newParams("some-cluster-name"))
+			verifyApiError(reply, http.StatusConflict)
+		})
+
+		It("runs a retry of a request whose lease expired", func() {
+			storeRunningKey(time.Now().Add(-time.Minute))
+			mockClusterApi.EXPECT().RegisterCluster(ctx, gomock.Any()).Return(nil).Times(1)
+			mockEvents.EXPECT().
+				AddEvent(gomock.Any(), gomock.Any(), nil, models.EventSeverityInfo, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Times(1)
+			mockMetric.EXPECT().ClusterRegistered(gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
+			mockSecretValidator.EXPECT().ValidatePullSecret(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+			reply := bm.RegisterCluster(ctx, newParams("some-cluster-name"))
+			Expect(reflect.TypeOf(reply)).Should(Equal(reflect.TypeOf(installer.NewRegisterClusterCreated())))
+
+			replayed := writeResponse(bm.RegisterCluster(ctx, newParams("some-cluster-name")))
+			Expect(replayed.Code).To(Equal(http.StatusCreated))
+			Expect(replayed.Header().Get("Idempotent-Replayed")).To(Equal("true"))
+		})
+
+		It("deletes the expired keys on the leader", func() {
+			bm.leaderElector = &leader.DummyElector{}
+			expired := common.IdempotencyKey{Operation: "RegisterCluster", Key: key, ExpiresAt: time.Now().Add(-time.Minute)}
+			kept := common.IdempotencyKey{Operation: "RegisterCluster", Key: uuid.New().String(), ExpiresAt: time.Now().Add(time.Hour)}
+			Expect(db.Create(&expired).Error).ShouldNot(HaveOccurred())
+			Expect(db.Create(&kept).Error).ShouldNot(HaveOccurred())
+
+			bm.DeleteExpiredIdempotencyKeys()
+
+			var keys []string
+			Expect(db.Model(&common.IdempotencyKey{}).Where("key in (?)", []string{expired.Key, kept.Key}).
+				Pluck("key", &keys).Error).ShouldNot(HaveOccurred())
+			Expect(keys).To(ConsistOf(kept.Key))
+		})
+	})
 })
 
 var _ = Describe("extract image version", func() {
@@ -4155,7 +4409,7 @@ var _ = Describe("GetHostIgnition and DownloadHostIgnition", func() {
 		ctrl = gomock.NewController(GinkgoT())
 		db = common.PrepareTestDB(dbName)
 		mockS3Client = s3wrapper.NewMockAPI(ctrl)
-		bm = NewBareMetalInventory(db, getTestLog(), nil, nil, cfg, nil, nil, mockS3Client, nil, getTestAuthHandler(), nil, nil, validations.NewMockPullSecretValidator(ctrl))
+		bm = NewBareMetalInventory(db, getTestLog(), nil, nil, cfg, nil, nil, mockS3Client, nil, getTestAuthHandler(), nil, nil, validations.NewMockPullSecretValidator(ctrl), &refresh.DummyNotifier{})
 
 		// create a cluster
 		clusterID = strfmt.UUID(uuid.New().String())
@@ -4294,7 +4548,7 @@ var _ = Describe("UpdateHostIgnition", func() {
 		ctrl = gomock.NewController(GinkgoT())
 		db = common.PrepareTestDB(dbName)
 		clusterID = strfmt.UUID(uuid.New().String())
-		bm = NewBareMetalInventory(db, getTestLog(), nil, nil, cfg, nil, nil, nil, nil, getTestAuthHandler(), nil, nil, validations.NewMockPullSecretValidator(ctrl))
+		bm = NewBareMetalInventory(db, getTestLog(), nil, nil, cfg, nil, nil, nil, nil, getTestAuthHandler(), nil, nil, validations.NewMockPullSecretValidator(ctrl), &refresh.DummyNotifier{})
 		err := db.Create(&common.Cluster{Cluster: models.Cluster{ID: &clusterID}}).Error
 		Expect(err).ShouldNot(HaveOccurred())
 
@@ -4323,6 +4577,24 @@ var _ = Describe("UpdateHostIgnition", func() {
 		Expect(updated.IgnitionConfigOverrides).To(Equal(override))
 	})
 
+	It("returns precondition failed when If-Match doesn't match the resource version", func() {
+		override := `{"ignition": {"version": "3.1.0"}, "storage": {"files": [{"path": "/tmp/example", "contents": {"source": "data:text/plain;base64,aGVscGltdHJhcHBlZGluYXN3YWdnZXJzcGVj"}}]}}`
+		Expect(db.Model(&models.Host{}).Where("id = ?", hostID).Update("requested_hostname", "other").Error).ShouldNot(HaveOccurred())
+
+		params := installer.UpdateHostIgnitionParams{
+			ClusterID:          clusterID,
+			HostID:             hostID,
+			HostIgnitionParams: &models.HostIgnitionParams{Config: override},
+			IfMatch:            swag.String(`"0"`),
+		}
+		response := bm.UpdateHostIgnition(ctx, params)
+		verifyApiError(response, http.StatusPreconditionFailed)
+
+		params.IfMatch = swag.String(`"1"`)
+		response = bm.UpdateHostIgnition(ctx, params)
+		Expect(response).To(BeAssignableToTypeOf(&installer.UpdateHostIgnitionCreated{}))
+	})
+
 	It("returns not found with a non-existant cluster", func() {
 		override := `{"ignition": {"version": "3.1.0"}, "storage": {"files": [{"path": "/tmp/example", "contents": {"source": "data:text/plain;base64,aGVscGltdHJhcHBlZGluYXN3YWdnZXJzcGVj"}}]}}`
 		params := installer.UpdateHostIgnitionParams{
@@ -4398,3 +4670,377 @@ func prepareK8NodeList(names, ips, roles, archituctures []string, readyStatuses
 	}
 	return nodeList
 }
+
+var _ = Describe("Hardware profiles", func() {
+	var (
+		bm                  *bareMetalInventory
+		cfg                 Config
+		db                  *gorm.DB
+		ctx                 = context.Background()
+		ctrl                *gomock.Controller
+		mockHostApi         *host.MockAPI
+		mockClusterApi      *cluster.MockAPI
+		mockEvents          *events.MockHandler
+		mockMetric          *metrics.MockAPI
+		mockSecretValidator *validations.MockPullSecretValidator
+		clusterID           strfmt.UUID
+		dbName              = "hardware_profiles"
+		masterReqs          = models.HostRequirementsRole{CPUCores: 4, RAMGib: 12, DiskSizeGb: 60}
+		workerReqs          = models.HostRequirementsRole{CPUCores: 2, RAMGib: 8, DiskSizeGb: 60}
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		db = common.PrepareTestDB(dbName)
+		mockHostApi = host.NewMockAPI(ctrl)
+		mockClusterApi = cluster.NewMockAPI(ctrl)
+		mockEvents = events.NewMockHandler(ctrl)
+		mockMetric = metrics.NewMockAPI(ctrl)
+		mockSecretValidator = validations.NewMockPullSecretValidator(ctrl)
+		bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, mockClusterApi, cfg, nil, mockEvents, nil, mockMetric,
+			getTestAuthHandler(), nil, nil, mockSecretValidator, &refresh.DummyNotifier{})
+		clusterID = strfmt.UUID(uuid.New().String())
+		Expect(db.Create(&common.Cluster{Cluster: models.Cluster{ID: &clusterID, HardwareProfile: "edge"}}).Error).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+		common.DeleteTestDB(db, dbName)
+	})
+
+	mockRequirements := func(profile string) {
+		mockHostApi.EXPECT().GetHostRequirements(models.HostRoleMaster, profile).Return(masterReqs, nil).Times(1)
+		mockHostApi.EXPECT().GetHostRequirements(models.HostRoleWorker, profile).Return(workerReqs, nil).Times(1)
+	}
+
+	Context("GetHostRequirements", func() {
+		It("of the default profile", func() {
+			mockRequirements(hardware.DefaultProfile)
+			reply := bm.GetHostRequirements(ctx, installer.GetHostRequirementsParams{})
+			Expect(reply).Should(BeAssignableToTypeOf(installer.NewGetHostRequirementsOK()))
+			payload := reply.(*installer.GetHostRequirementsOK).Payload
+			Expect(payload.HardwareProfile).To(Equal(hardware.DefaultProfile))
+			Expect(*payload.Master).To(Equal(masterReqs))
+			Expect(*payload.Worker).To(Equal(workerReqs))
+		})
+
+		It("of a profile", func() {
+			mockRequirements("compute")
+			reply := bm.GetHostRequirements(ctx, installer.GetHostRequirementsParams{HardwareProfile: swag.String("compute")})
+			Expect(reply).Should(BeAssignableToTypeOf(installer.NewGetHostRequirementsOK()))
+			Expect(reply.(*installer.GetHostRequirementsOK).Payload.HardwareProfile).To(Equal("compute"))
+		})
+
+		It("of the profile of a cluster", func() {
+			mockRequirements("edge")
+			reply := bm.GetHostRequirements(ctx, installer.GetHostRequirementsParams{ClusterID: &clusterID})
+			Expect(reply).Should(BeAssignableToTypeOf(installer.NewGetHostRequirementsOK()))
+			Expect(reply.(*installer.GetHostRequirementsOK).Payload.HardwareProfile).To(Equal("edge"))
+		})
+
+		It("of a missing cluster", func() {
+			missingID := strfmt.UUID(uuid.New().String())
+			reply := bm.GetHostRequirements(ctx, installer.GetHostRequirementsParams{ClusterID: &missingID})
+			verifyApiError(reply, http.StatusNotFound)
+		})
+
+		It("of both a cluster and a profile", func() {
+			reply := bm.GetHostRequirements(ctx, installer.GetHostRequirementsParams{ClusterID: &clusterID,
+				HardwareProfile: swag.String("compute")})
+			verifyApiError(reply, http.StatusBadRequest)
+		})
+
+		It("of an unknown profile", func() {
+			mockHostApi.EXPECT().GetHostRequirements(models.HostRoleMaster, "gpu").
+				Return(models.HostRequirementsRole{}, errors.New("unknown hardware profile gpu")).Times(1)
+			reply := bm.GetHostRequirements(ctx, installer.GetHostRequirementsParams{HardwareProfile: swag.String("gpu")})
+			verifyApiError(reply, http.StatusBadRequest)
+		})
+	})
+
+	Context("RegisterCluster", func() {
+		register := func(profile *string) middleware.Responder {
+			return bm.RegisterCluster(ctx, installer.RegisterClusterParams{
+				NewClusterParams: &models.ClusterCreateParams{
+					Name:             swag.String("some-cluster-name"),
+					OpenshiftVersion: swag.String("4.6"),
+					PullSecret:       swag.String(`{\"auths\":{\"cloud.openshift.com\":{\"auth\":\"dG9rZW46dGVzdAo=\",\"email\":\"coyote@acme.com\"}}}`),
+					HardwareProfile:  profile,
+				},
+			})
+		}
+
+		BeforeEach(func() {
+			mockSecretValidator.EXPECT().ValidatePullSecret(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		})
+
+		expectRegistered := func(profile string) {
+			mockClusterApi.EXPECT().RegisterCluster(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, c *common.Cluster) error {
+				Expect(c.HardwareProfile).To(Equal(profile))
+				return nil
+			}).Times(1)
+			mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), nil, models.EventSeverityInfo, gomock.Any(), gomock.Any(),
+				gomock.Any(), gomock.Any()).Times(1)
+			mockMetric.EXPECT().ClusterRegistered(gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
+		}
+
+		It("with the default profile", func() {
+			expectRegistered(hardware.DefaultProfile)
+			Expect(register(nil)).Should(BeAssignableToTypeOf(installer.NewRegisterClusterCreated()))
+		})
+
+		It("with a profile", func() {
+			mockHostApi.EXPECT().GetHostRequirements(models.HostRoleMaster, "edge").Return(masterReqs, nil).Times(1)
+			expectRegistered("edge")
+			Expect(register(swag.String("edge"))).Should(BeAssignableToTypeOf(installer.NewRegisterClusterCreated()))
+		})
+
+		It("with an unknown profile", func() {
+			mockHostApi.EXPECT().GetHostRequirements(models.HostRoleMaster, "gpu").
+				Return(models.HostRequirementsRole{}, errors.New("unknown hardware profile gpu")).Times(1)
+			verifyApiError(register(swag.String("gpu")), http.StatusBadRequest)
+		})
+	})
+
+	It("UpdateCluster with an unknown profile", func() {
+		mockHostApi.EXPECT().GetHostRequirements(models.HostRoleMaster, "gpu").
+			Return(models.HostRequirementsRole{}, errors.New("unknown hardware profile gpu")).Times(1)
+		reply := bm.UpdateCluster(ctx, installer.UpdateClusterParams{
+			ClusterID:           clusterID,
+			ClusterUpdateParams: &models.ClusterUpdateParams{HardwareProfile: swag.String("gpu")},
+		})
+		verifyApiError(reply, http.StatusBadRequest)
+	})
+})
+
+var _ = Describe("UpdateHostInstallationDisk", func() {
+	var (
+		bm             *bareMetalInventory
+		cfg            Config
+		db             *gorm.DB
+		ctx            = context.Background()
+		ctrl           *gomock.Controller
+		mockHostApi    *host.MockAPI
+		mockClusterApi *cluster.MockAPI
+		clusterID      strfmt.UUID
+		hostID         strfmt.UUID
+		dbName         = "update_host_installation_disk"
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		db = common.PrepareTestDB(dbName)
+		mockHostApi = host.NewMockAPI(ctrl)
+		mockClusterApi = cluster.NewMockAPI(ctrl)
+		bm = NewBareMetalInventory(db, getTestLog(), mockHostApi, mockClusterApi, cfg, nil, nil, nil, nil,
+			getTestAuthHandler(), nil, nil, validations.NewMockPullSecretValidator(ctrl), &refresh.DummyNotifier{})
+		clusterID = strfmt.UUID(uuid.New().String())
+		Expect(db.Create(&common.Cluster{Cluster: models.Cluster{ID: &clusterID}}).Error).ShouldNot(HaveOccurred())
+		hostID = strfmt.UUID(uuid.New().String())
+		addHost(hostID, models.HostRoleMaster, models.HostStatusKnown, models.HostKindHost, clusterID, "{}", db)
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+		common.DeleteTestDB(db, dbName)
+	})
+
+	params := func(diskID string) installer.UpdateHostInstallationDiskParams {
+		return installer.UpdateHostInstallationDiskParams{
+			ClusterID:                  clusterID,
+			HostID:                     hostID,
+			HostInstallationDiskParams: &models.HostInstallationDiskParams{DiskID: diskID},
+		}
+	}
+
+	It("selects the disk and refreshes the host", func() {
+		mockHostApi.EXPECT().UpdateInstallationDisk(gomock.Any(), gomock.Any(), "/dev/sdb", gomock.Any()).
+			DoAndReturn(func(ctx context.Context, h *models.Host, diskID string, db *gorm.DB) error {
+				return db.Model(h).Update("installation_disk_id", diskID).Error
+			}).Times(1)
+		mockSetConnectivityMajorityGroupsForCluster(mockClusterApi)
+		mockHostApi.EXPECT().RefreshStatus(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		mockClusterApi.EXPECT().RefreshStatus(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+
+		response := bm.UpdateHostInstallationDisk(ctx, params("/dev/sdb"))
+		Expect(response).To(BeAssignableToTypeOf(&installer.UpdateHostInstallationDiskCreated{}))
+		Expect(response.(*installer.UpdateHostInstallationDiskCreated).Payload.InstallationDiskID).To(Equal("/dev/sdb"))
+	})
+
+	It("returns conflict when the host can't be updated", func() {
+		mockHostApi.EXPECT().UpdateInstallationDisk(gomock.Any(), gomock.Any(), "/dev/sdb", gomock.Any()).
+			Return(common.NewApiError(http.StatusConflict, errors.New("host is installing"))).Times(1)
+
+		response := bm.UpdateHostInstallationDisk(ctx, params("/dev/sdb"))
+		verifyApiError(response, http.StatusConflict)
+	})
+
+	It("returns precondition failed when If-Match doesn't match the resource version", func() {
+		p := params("/dev/sdb")
+		p.IfMatch = swag.String(`"7"`)
+		response := bm.UpdateHostInstallationDisk(ctx, p)
+		verifyApiError(response, http.StatusPreconditionFailed)
+	})
+
+	It("returns not found with a non-existent host", func() {
+		p := params("/dev/sdb")
+		p.HostID = strfmt.UUID(uuid.New().String())
+		response := bm.UpdateHostInstallationDisk(ctx, p)
+		verifyApiError(response, http.StatusNotFound)
+	})
+})
+
+var _ = Describe("DeregisterCluster", func() {
+	var (
+		bm             *bareMetalInventory
+		cfg            Config
+		db             *gorm.DB
+		ctx            = context.Background()
+		ctrl           *gomock.Controller
+		mockClusterApi *cluster.MockAPI
+		clusterID      strfmt.UUID
+		dbName         = "deregister_cluster"
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		db = common.PrepareTestDB(dbName)
+		mockClusterApi = cluster.NewMockAPI(ctrl)
+		bm = NewBareMetalInventory(db, getTestLog(), nil, mockClusterApi, cfg, nil, nil, nil, nil,
+			getTestAuthHandler(), nil, nil, validations.NewMockPullSecretValidator(ctrl), &refresh.DummyNotifier{})
+		clusterID = strfmt.UUID(uuid.New().String())
+		Expect(db.Create(&common.Cluster{Cluster: models.Cluster{ID: &clusterID}}).Error).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+		common.DeleteTestDB(db, dbName)
+	})
+
+	It("deregisters the cluster in the transaction that checked its resource version", func() {
+		mockClusterApi.EXPECT().DeregisterCluster(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, c *common.Cluster, db *gorm.DB) error {
+				return db.Delete(c).Error
+			}).Times(1)
+
+		response := bm.DeregisterCluster(ctx, installer.DeregisterClusterParams{ClusterID: clusterID, IfMatch: swag.String(`"0"`)})
+		Expect(response).To(BeAssignableToTypeOf(&installer.DeregisterClusterNoContent{}))
+		Expect(db.First(&common.Cluster{}, "id = ?", clusterID).Error).Should(Equal(gorm.ErrRecordNotFound))
+	})
+
+	It("returns precondition failed when If-Match doesn't match the resource version", func() {
+		response := bm.DeregisterCluster(ctx, installer.DeregisterClusterParams{ClusterID: clusterID, IfMatch: swag.String(`"7"`)})
+		verifyApiError(response, http.StatusPreconditionFailed)
+		Expect(db.First(&common.Cluster{}, "id = ?", clusterID).Error).ShouldNot(HaveOccurred())
+	})
+
+	It("returns not found with a non-existent cluster", func() {
+		response := bm.DeregisterCluster(ctx, installer.DeregisterClusterParams{ClusterID: strfmt.UUID(uuid.New().String())})
+		verifyApiError(response, http.StatusNotFound)
+	})
+})
+
+var _ = Describe("Disk selection policy", func() {
+	var (
+		bm                  *bareMetalInventory
+		cfg                 Config
+		db                  *gorm.DB
+		ctx                 = context.Background()
+		ctrl                *gomock.Controller
+		mockClusterApi      *cluster.MockAPI
+		mockEvents          *events.MockHandler
+		mockMetric          *metrics.MockAPI
+		mockSecretValidator *validations.MockPullSecretValidator
+		clusterID           strfmt.UUID
+		dbName              = "disk_selection_policy"
+		policy              = &models.DiskSelectionPolicy{
+			DriveTypes:       []string{hardware.DriveTypeNVMe, hardware.DriveTypeSSD},
+			ExcludeRemovable: true,
+			ByPathPatterns:   []string{"pci-0000:3b:*"},
+			MinSizeGib:       swag.Int64(200),
+			SizePreference:   hardware.SizePreferenceSmallest,
+		}
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		db = common.PrepareTestDB(dbName)
+		mockClusterApi = cluster.NewMockAPI(ctrl)
+		mockEvents = events.NewMockHandler(ctrl)
+		mockMetric = metrics.NewMockAPI(ctrl)
+		mockSecretValidator = validations.NewMockPullSecretValidator(ctrl)
+		bm = NewBareMetalInventory(db, getTestLog(), nil, mockClusterApi, cfg, nil, mockEvents, nil, mockMetric,
+			getTestAuthHandler(), nil, nil, mockSecretValidator, &refresh.DummyNotifier{})
+		clusterID = strfmt.UUID(uuid.New().String())
+		Expect(db.Create(&common.Cluster{Cluster: models.Cluster{ID: &clusterID}}).Error).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+		common.DeleteTestDB(db, dbName)
+	})
+
+	storedPolicy := func(c *common.Cluster) *models.DiskSelectionPolicy {
+		p, err := hardware.ParseDiskSelectionPolicy(c.DiskSelectionPolicy)
+		Expect(err).ToNot(HaveOccurred())
+		return p
+	}
+
+	Context("RegisterCluster", func() {
+		register := func(policy *models.DiskSelectionPolicy) middleware.Responder {
+			return bm.RegisterCluster(ctx, installer.RegisterClusterParams{
+				NewClusterParams: &models.ClusterCreateParams{
+					Name:                swag.String("some-cluster-name"),
+					OpenshiftVersion:    swag.String("4.6"),
+					PullSecret:          swag.String(`{\"auths\":{\"cloud.openshift.com\":{\"auth\":\"dG9rZW46dGVzdAo=\",\"email\":\"coyote@acme.com\"}}}`),
+					DiskSelectionPolicy: policy,
+				},
+			})
+		}
+
+		BeforeEach(func() {
+			mockSecretValidator.EXPECT().ValidatePullSecret(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		})
+
+		It("with a policy", func() {
+			mockClusterApi.EXPECT().RegisterCluster(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, c *common.Cluster) error {
+				Expect(storedPolicy(c)).To(Equal(policy))
+				return nil
+			}).Times(1)
+			mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), nil, models.EventSeverityInfo, gomock.Any(), gomock.Any(),
+				gomock.Any(), gomock.Any()).Times(1)
+			mockMetric.EXPECT().ClusterRegistered(gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
+			Expect(register(policy)).Should(BeAssignableToTypeOf(installer.NewRegisterClusterCreated()))
+		})
+
+		It("with an invalid by-path pattern", func() {
+			verifyApiError(register(&models.DiskSelectionPolicy{ByPathPatterns: []string{"pci-[0000"}}), http.StatusBadRequest)
+		})
+	})
+
+	Context("UpdateCluster", func() {
+		update := func(policy *models.DiskSelectionPolicy) middleware.Responder {
+			return bm.UpdateCluster(ctx, installer.UpdateClusterParams{
+				ClusterID:           clusterID,
+				ClusterUpdateParams: &models.ClusterUpdateParams{DiskSelectionPolicy: policy},
+			})
+		}
+
+		BeforeEach(func() {
+			mockClusterApi.EXPECT().VerifyClusterUpdatability(gomock.Any()).Return(nil).Times(1)
+		})
+
+		It("with a policy", func() {
+			mockSetConnectivityMajorityGroupsForCluster(mockClusterApi)
+			mockClusterApi.EXPECT().RefreshStatus(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+			Expect(update(policy)).To(BeAssignableToTypeOf(installer.NewUpdateClusterCreated()))
+			var c common.Cluster
+			Expect(db.First(&c, "id = ?", clusterID).Error).ShouldNot(HaveOccurred())
+			Expect(storedPolicy(&c)).To(Equal(policy))
+		})
+
+		It("with an invalid by-path pattern", func() {
+			verifyApiError(update(&models.DiskSelectionPolicy{ByPathPatterns: []string{"pci-[0000"}}), http.StatusBadRequest)
+		})
+	})
+})
@@ -19,16 +19,21 @@ import (
 
 	"github.com/openshift/assisted-service/pkg/s3wrapper"
 
-	"github.com/filanov/stateswitch"
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
 	"github.com/openshift/assisted-service/internal/common"
 	"github.com/openshift/assisted-service/internal/events"
+	"github.com/openshift/assisted-service/internal/history"
 	"github.com/openshift/assisted-service/internal/host"
 	"github.com/openshift/assisted-service/internal/metrics"
+	"github.com/openshift/assisted-service/internal/webhooks"
 	"github.com/openshift/assisted-service/models"
+	dbPkg "github.com/openshift/assisted-service/pkg/db"
 	logutil "github.com/openshift/assisted-service/pkg/log"
 	"github.com/openshift/assisted-service/pkg/requestid"
+	"github.com/openshift/assisted-service/pkg/shard"
+	"github.com/openshift/assisted-service/pkg/transaction"
+	"github.com/openshift/assisted-service/pkg/workerpool"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/thoas/go-funk"
@@ -37,6 +42,8 @@ import (
 
 const DhcpLeaseTimeoutMinutes = 2
 
+const defaultMonitorWorkers = 8
+
 var S3FileNames = []string{
 	"kubeconfig",
 	"bootstrap.ign",
@@ -58,7 +65,7 @@ type RegistrationAPI interface {
 	// Register a new add-host-ocp cluster
 	RegisterAddHostsOCPCluster(c *common.Cluster, db *gorm.DB) error
 	//deregister cluster
-	DeregisterCluster(ctx context.Context, c *common.Cluster) error
+	DeregisterCluster(ctx context.Context, c *common.Cluster, db *gorm.DB) error
 }
 
 type InstallationAPI interface {
@@ -90,7 +97,8 @@ type API interface {
 	SetConnectivityMajorityGroupsForCluster(clusterID strfmt.UUID, db *gorm.DB) error
 	DeleteClusterLogs(ctx context.Context, c *common.Cluster, objectHandler s3wrapper.API) error
 	DeleteClusterFiles(ctx context.Context, c *common.Cluster, objectHandler s3wrapper.API) error
-	PermanentClustersDeletion(ctx context.Context, olderThen strfmt.DateTime, objectHandler s3wrapper.API) error
+	// Delete the clusters that were deleted before olderThen with their files, only while leaderElector is still the leader
+	PermanentClustersDeletion(ctx context.Context, olderThen strfmt.DateTime, objectHandler s3wrapper.API, leaderElector leader.Leader) error
 }
 
 type PrepareConfig struct {
@@ -100,6 +108,8 @@ type PrepareConfig struct {
 type Config struct {
 	PrepareConfig    PrepareConfig
 	MonitorBatchSize int `envconfig:"CLUSTER_MONITOR_BATCH_SIZE" default:"100"`
+	// Number of clusters that the monitor refreshes concurrently, 0 selects 8, or 1 on sqlite
+	MonitorWorkers int `envconfig:"CLUSTER_MONITOR_WORKERS" default:"0"`
 }
 
 type Manager struct {
@@ -109,7 +119,7 @@ type Manager struct {
 	registrationAPI      RegistrationAPI
 	installationAPI      InstallationAPI
 	eventsHandler        events.Handler
-	sm                   stateswitch.StateMachine
+	sm                   *stateMachine
 	metricAPI            metrics.API
 	hostAPI              host.API
 	rp                   *refreshPreprocessor
@@ -118,18 +128,19 @@ type Manager struct {
 }
 
 func NewManager(cfg Config, log logrus.FieldLogger, db *gorm.DB, eventsHandler events.Handler, hostAPI host.API, metricApi metrics.API,
-	leaderElector leader.Leader) *Manager {
+	leaderElector leader.Leader, webhooksNotifier webhooks.Notifier) *Manager {
 	th := &transitionHandler{
 		log:           log,
 		db:            db,
 		prepareConfig: cfg.PrepareConfig,
+		webhooks:      webhooksNotifier,
 	}
 	return &Manager{
 		Config:               cfg,
 		log:                  log,
 		db:                   db,
 		registrationAPI:      NewRegistrar(log, db),
-		installationAPI:      NewInstaller(log, db),
+		installationAPI:      NewInstaller(log, db, webhooksNotifier),
 		eventsHandler:        eventsHandler,
 		sm:                   NewClusterStateMachine(th),
 		metricAPI:            metricApi,
@@ -144,10 +155,12 @@ func (m *Manager) RegisterCluster(ctx context.Context, c *common.Cluster) error
 	err := m.registrationAPI.RegisterCluster(ctx, c)
 	if err != nil {
 		m.eventsHandler.AddEvent(ctx, *c.ID, nil, models.EventSeverityError,
-			fmt.Sprintf("Failed to register cluster with name \"%s\". Error: %s", c.Name, err.Error()), time.Now())
+			fmt.Sprintf("Failed to register cluster with name \"%s\". Error: %s", c.Name, err.Error()), time.Now(),
+			events.ClusterRegistrationFailed, map[string]interface{}{"name": c.Name, "add_hosts": false, "error": err.Error()})
 	} else {
 		m.eventsHandler.AddEvent(ctx, *c.ID, nil, models.EventSeverityInfo,
-			fmt.Sprintf("Registered cluster \"%s\"", c.Name), time.Now())
+			fmt.Sprintf("Registered cluster \"%s\"", c.Name), time.Now(),
+			events.ClusterRegistered, map[string]interface{}{"name": c.Name, "add_hosts": false})
 	}
 	return err
 }
@@ -156,10 +169,12 @@ func (m *Manager) RegisterAddHostsCluster(ctx context.Context, c *common.Cluster
 	err := m.registrationAPI.RegisterAddHostsCluster(ctx, c)
 	if err != nil {
 		m.eventsHandler.AddEvent(ctx, *c.ID, nil, models.EventSeverityError,
-			fmt.Sprintf("Failed to register add-hosts cluster with name \"%s\". Error: %s", c.Name, err.Error()), time.Now())
+			fmt.Sprintf("Failed to register add-hosts cluster with name \"%s\". Error: %s", c.Name, err.Error()), time.Now(),
+			events.ClusterRegistrationFailed, map[string]interface{}{"name": c.Name, "add_hosts": true, "error": err.Error()})
 	} else {
 		m.eventsHandler.AddEvent(ctx, *c.ID, nil, models.EventSeverityInfo,
-			fmt.Sprintf("Registered add-hosts cluster \"%s\"", c.Name), time.Now())
+			fmt.Sprintf("Registered add-hosts cluster \"%s\"", c.Name), time.Now(),
+			events.ClusterRegistered, map[string]interface{}{"name": c.Name, "add_hosts": true})
 	}
 	return err
 }
@@ -168,14 +183,15 @@ func (m *Manager) RegisterAddHostsOCPCluster(c *common.Cluster, db *gorm.DB) err
 	return m.registrationAPI.RegisterAddHostsOCPCluster(c, db)
 }
 
-func (m *Manager) DeregisterCluster(ctx context.Context, c *common.Cluster) error {
-	err := m.registrationAPI.DeregisterCluster(ctx, c)
+func (m *Manager) DeregisterCluster(ctx context.Context, c *common.Cluster, db *gorm.DB) error {
+	err := m.registrationAPI.DeregisterCluster(ctx, c, db)
 	if err != nil {
 		m.eventsHandler.AddEvent(ctx, *c.ID, nil, models.EventSeverityError,
-			fmt.Sprintf("Failed to deregister cluster. Error: %s", err.Error()), time.Now())
+			fmt.Sprintf("Failed to deregister cluster. Error: %s", err.Error()), time.Now(),
+			events.ClusterDeregistrationFailed, map[string]interface{}{"error": err.Error()})
 	} else {
 		m.eventsHandler.AddEvent(ctx, *c.ID, nil, models.EventSeverityInfo,
-			fmt.Sprintf("Deregistered cluster: %s", c.ID.String()), time.Now())
+			fmt.Sprintf("Deregistered cluster: %s", c.ID.String()), time.Now(), events.ClusterDeregistered, nil)
 	}
 	return err
 }
@@ -184,7 +200,7 @@ func (m *Manager) RefreshStatus(ctx context.Context, c *common.Cluster, db *gorm
 
 	//new transition code
 	if db == nil {
-		db = m.db
+		db = m.db.WithContext(ctx)
 	}
 	vc, err := newClusterValidationContext(*c.ID, db)
 	if err != nil {
@@ -194,7 +210,7 @@ func (m *Manager) RefreshStatus(ctx context.Context, c *common.Cluster, db *gorm
 	if err != nil {
 		return c, err
 	}
-	err = m.sm.Run(TransitionTypeRefreshStatus, newStateCluster(vc.cluster), &TransitionArgsRefreshCluster{
+	err = m.sm.Run(ctx, TransitionTypeRefreshStatus, newStateCluster(vc.cluster), &TransitionArgsRefreshCluster{
 		ctx:               ctx,
 		db:                db,
 		eventHandler:      m.eventsHandler,
@@ -248,7 +264,7 @@ func (m *Manager) tryAssignMachineCidr(cluster *common.Cluster) error {
 	return nil
 }
 
-func (m *Manager) autoAssignMachineNetworkCidrs() error {
+func (m *Manager) autoAssignMachineNetworkCidrs(monitorShard shard.Shard) error {
 	var clusters []*common.Cluster
 	/*
 	 * The aim is to get from DB only clusters that are candidates for machine network CIDR auto assign
@@ -263,6 +279,9 @@ func (m *Manager) autoAssignMachineNetworkCidrs() error {
 		return err
 	}
 	for _, cluster := range clusters {
+		if !monitorShard.Owns(*cluster.ID) {
+			continue
+		}
 		err = m.tryAssignMachineCidr(cluster)
 		if err != nil {
 			m.log.WithError(err).Warnf("Set machine cidr for cluster %s", cluster.ID.String())
@@ -279,31 +298,35 @@ func (m *Manager) shouldTriggerLeaseTimeoutEvent(c *common.Cluster, curMonitorIn
 }
 
 func (m *Manager) triggerLeaseTimeoutEvent(ctx context.Context, c *common.Cluster) {
-	m.eventsHandler.AddEvent(ctx, *c.ID, nil, models.EventSeverityWarning, "API and Ingress VIPs lease allocation has been timed out", time.Now())
+	m.eventsHandler.AddEvent(ctx, *c.ID, nil, models.EventSeverityWarning, "API and Ingress VIPs lease allocation has been timed out", time.Now(),
+		events.ClusterVipLeaseTimedOut, nil)
 }
 
 func (m *Manager) ClusterMonitoring() {
-	if !m.leaderElector.IsLeader() {
+	monitorShard := shard.Of(m.leaderElector)
+	if !monitorShard.Active() {
 		m.log.Debugf("Not a leader, exiting ClusterMonitoring")
 		return
 	}
 	m.log.Debugf("Running ClusterMonitoring")
 	var (
-		offset              int
-		limit               = m.MonitorBatchSize
-		clusters            []*common.Cluster
-		clusterAfterRefresh *common.Cluster
-		requestID           = requestid.NewID()
-		ctx                 = requestid.ToContext(context.Background(), requestID)
-		log                 = requestid.RequestIDLogger(m.log, requestID)
-		err                 error
+		offset    int
+		monitored int
+		limit     = m.MonitorBatchSize
+		clusters  []*common.Cluster
+		requestID = requestid.NewID()
+		ctx       = requestid.ToContext(context.Background(), requestID)
+		log       = requestid.RequestIDLogger(m.log, requestID)
+		err       error
 	)
 
-	_ = m.autoAssignMachineNetworkCidrs()
+	_ = m.autoAssignMachineNetworkCidrs(monitorShard)
 	curMonitorInvokedAt := time.Now()
 	defer func() {
 		m.prevMonitorInvokedAt = curMonitorInvokedAt
 	}()
+	pool := workerpool.New(dbPkg.Workers(m.db, m.MonitorWorkers, defaultMonitorWorkers))
+	defer pool.Wait()
 
 	//no need to refresh cluster status if the cluster is in the following statuses
 	noNeedToMonitorInStates := []string{
@@ -322,29 +345,56 @@ func (m *Manager) ClusterMonitoring() {
 			break
 		}
 		for _, cluster := range clusters {
-			if !m.leaderElector.IsLeader() {
+			if !monitorShard.Active() {
 				m.log.Debugf("Not a leader, exiting ClusterMonitoring")
 				return
 			}
-			if err = m.SetConnectivityMajorityGroupsForCluster(*cluster.ID, m.db); err != nil {
-				log.WithError(err).Errorf("failed to set majority group for cluster %s", cluster.ID.String())
-			}
-			if clusterAfterRefresh, err = m.RefreshStatus(ctx, cluster, m.db); err != nil {
-				log.WithError(err).Errorf("failed to refresh cluster %s state", cluster.ID)
+			if !monitorShard.Owns(*cluster.ID) {
 				continue
 			}
-
-			if swag.StringValue(clusterAfterRefresh.Status) != swag.StringValue(cluster.Status) {
-				log.Infof("cluster %s updated status from %s to %s via monitor", cluster.ID,
-					swag.StringValue(cluster.Status), swag.StringValue(clusterAfterRefresh.Status))
-			}
-
-			if m.shouldTriggerLeaseTimeoutEvent(cluster, curMonitorInvokedAt) {
-				m.triggerLeaseTimeoutEvent(ctx, cluster)
-			}
+			monitored++
+			cluster := cluster
+			pool.Submit(cluster.ID.String(), func() {
+				m.monitorCluster(ctx, log, cluster, curMonitorInvokedAt)
+			})
 		}
 		offset += limit
 	}
+	pool.Wait()
+	m.metricAPI.MonitoringCycle(shard.MonitorClusters, time.Since(curMonitorInvokedAt))
+	monitorShard.PassCompleted(shard.MonitorClusters, curMonitorInvokedAt, monitored)
+}
+
+func (m *Manager) monitorCluster(ctx context.Context, log logrus.FieldLogger, cluster *common.Cluster, curMonitorInvokedAt time.Time) {
+	var clusterAfterRefresh *common.Cluster
+	// the writes of the monitor don't change the resource versions the users edit by
+	db := dbPkg.SkipResourceVersion(m.db)
+	if err := m.SetConnectivityMajorityGroupsForCluster(*cluster.ID, db); err != nil {
+		log.WithError(err).Errorf("failed to set majority group for cluster %s", cluster.ID.String())
+	}
+	// hold the cluster lock, so the refresh won't race with concurrent updates of the cluster
+	err := transaction.InClusterTransaction(ctx, db, *cluster.ID, func(ctx context.Context, tx *gorm.DB) error {
+		// reload the cluster, it might have been changed while waiting for the lock
+		if err := tx.Take(cluster, "id = ?", cluster.ID.String()).Error; err != nil {
+			return err
+		}
+		var err error
+		clusterAfterRefresh, err = m.RefreshStatus(ctx, cluster, tx)
+		return err
+	})
+	if err != nil {
+		log.WithError(err).Errorf("failed to refresh cluster %s state", cluster.ID)
+		return
+	}
+
+	if swag.StringValue(clusterAfterRefresh.Status) != swag.StringValue(cluster.Status) {
+		log.Infof("cluster %s updated status from %s to %s via monitor", cluster.ID,
+			swag.StringValue(cluster.Status), swag.StringValue(clusterAfterRefresh.Status))
+	}
+
+	if m.shouldTriggerLeaseTimeoutEvent(cluster, curMonitorInvokedAt) {
+		m.triggerLeaseTimeoutEvent(ctx, cluster)
+	}
 }
 
 func CanDownloadFiles(c *common.Cluster) (err error) {
@@ -414,11 +464,13 @@ func (m *Manager) CancelInstallation(ctx context.Context, c *common.Cluster, rea
 
 	eventSeverity := models.EventSeverityInfo
 	eventInfo := "Canceled cluster installation"
+	eventCode := events.ClusterInstallCancelled
+	var eventProps map[string]interface{}
 	defer func() {
-		m.eventsHandler.AddEvent(ctx, *c.ID, nil, eventSeverity, eventInfo, time.Now())
+		m.eventsHandler.AddEvent(ctx, *c.ID, nil, eventSeverity, eventInfo, time.Now(), eventCode, eventProps)
 	}()
 
-	err := m.sm.Run(TransitionTypeCancelInstallation, newStateCluster(c), &TransitionArgsCancelInstallation{
+	err := m.sm.Run(ctx, TransitionTypeCancelInstallation, newStateCluster(c), &TransitionArgsCancelInstallation{
 		ctx:    ctx,
 		reason: reason,
 		db:     db,
@@ -426,6 +478,8 @@ func (m *Manager) CancelInstallation(ctx context.Context, c *common.Cluster, rea
 	if err != nil {
 		eventSeverity = models.EventSeverityError
 		eventInfo = fmt.Sprintf("Failed to cancel installation: %s", err.Error())
+		eventCode = events.ClusterInstallCancelFailed
+		eventProps = map[string]interface{}{"error": err.Error()}
 		return common.NewApiError(http.StatusConflict, err)
 	}
 	//report installation finished metric
@@ -436,11 +490,13 @@ func (m *Manager) CancelInstallation(ctx context.Context, c *common.Cluster, rea
 func (m *Manager) ResetCluster(ctx context.Context, c *common.Cluster, reason string, db *gorm.DB) *common.ApiErrorResponse {
 	eventSeverity := models.EventSeverityInfo
 	eventInfo := "Reset cluster installation"
+	eventCode := events.ClusterInstallReset
+	var eventProps map[string]interface{}
 	defer func() {
-		m.eventsHandler.AddEvent(ctx, *c.ID, nil, eventSeverity, eventInfo, time.Now())
+		m.eventsHandler.AddEvent(ctx, *c.ID, nil, eventSeverity, eventInfo, time.Now(), eventCode, eventProps)
 	}()
 
-	err := m.sm.Run(TransitionTypeResetCluster, newStateCluster(c), &TransitionArgsResetCluster{
+	err := m.sm.Run(ctx, TransitionTypeResetCluster, newStateCluster(c), &TransitionArgsResetCluster{
 		ctx:    ctx,
 		reason: reason,
 		db:     db,
@@ -448,6 +504,8 @@ func (m *Manager) ResetCluster(ctx context.Context, c *common.Cluster, reason st
 	if err != nil {
 		eventSeverity = models.EventSeverityError
 		eventInfo = fmt.Sprintf("Failed to reset installation. Error: %s", err.Error())
+		eventCode = events.ClusterInstallResetFailed
+		eventProps = map[string]interface{}{"error": err.Error()}
 		return common.NewApiError(http.StatusConflict, err)
 	}
 	return nil
@@ -456,7 +514,7 @@ func (m *Manager) ResetCluster(ctx context.Context, c *common.Cluster, reason st
 func (m *Manager) CompleteInstallation(ctx context.Context, c *common.Cluster, successfullyFinished bool, reason string) *common.ApiErrorResponse {
 	log := logutil.FromContext(ctx, m.log)
 
-	err := m.sm.Run(TransitionTypeCompleteInstallation, newStateCluster(c), &TransitionArgsCompleteInstallation{
+	err := m.sm.Run(ctx, TransitionTypeCompleteInstallation, newStateCluster(c), &TransitionArgsCompleteInstallation{
 		ctx:       ctx,
 		isSuccess: successfullyFinished,
 		reason:    reason,
@@ -467,18 +525,22 @@ func (m *Manager) CompleteInstallation(ctx context.Context, c *common.Cluster, s
 	result := models.ClusterStatusInstalled
 	severity := models.EventSeverityInfo
 	eventMsg := fmt.Sprintf("Successfully finished installing cluster %s", c.Name)
+	eventCode := events.ClusterInstallCompleted
+	var eventProps map[string]interface{}
 	if !successfullyFinished {
 		result = models.ClusterStatusError
 		severity = models.EventSeverityCritical
 		eventMsg = fmt.Sprintf("Failed installing cluster %s. Reason: %s", c.Name, reason)
+		eventCode = events.ClusterInstallFailed
+		eventProps = map[string]interface{}{"reason": reason}
 	}
 	m.metricAPI.ClusterInstallationFinished(log, result, c.OpenshiftVersion, *c.ID, c.EmailDomain, c.InstallStartedAt)
-	m.eventsHandler.AddEvent(ctx, *c.ID, nil, severity, eventMsg, time.Now())
+	m.eventsHandler.AddEvent(ctx, *c.ID, nil, severity, eventMsg, time.Now(), eventCode, eventProps)
 	return nil
 }
 
 func (m *Manager) PrepareForInstallation(ctx context.Context, c *common.Cluster, db *gorm.DB) error {
-	err := m.sm.Run(TransitionTypePrepareForInstallation, newStateCluster(c),
+	err := m.sm.Run(ctx, TransitionTypePrepareForInstallation, newStateCluster(c),
 		&TransitionArgsPrepareForInstallation{
 			ctx: ctx,
 			db:  db,
@@ -489,7 +551,7 @@ func (m *Manager) PrepareForInstallation(ctx context.Context, c *common.Cluster,
 
 func (m *Manager) HandlePreInstallError(ctx context.Context, c *common.Cluster, installErr error) {
 	log := logutil.FromContext(ctx, m.log)
-	err := m.sm.Run(TransitionTypeHandlePreInstallationError, newStateCluster(c), &TransitionArgsHandlePreInstallationError{
+	err := m.sm.Run(ctx, TransitionTypeHandlePreInstallationError, newStateCluster(c), &TransitionArgsHandlePreInstallationError{
 		ctx:        ctx,
 		installErr: installErr,
 	})
@@ -508,7 +570,7 @@ func vipMismatchError(apiVip, ingressVip string, cluster *common.Cluster) error
 func (m *Manager) SetVipsData(ctx context.Context, c *common.Cluster, apiVip, ingressVip, apiVipLease, ingressVipLease string, db *gorm.DB) error {
 	var err error
 	if db == nil {
-		db = m.db
+		db = m.db.WithContext(ctx)
 	}
 	log := logutil.FromContext(ctx, m.log)
 	switch swag.StringValue(c.Status) {
@@ -528,7 +590,8 @@ func (m *Manager) SetVipsData(ctx context.Context, c *common.Cluster, apiVip, in
 				log.WithError(vipMismatchError(apiVip, ingressVip, c)).Warn("VIPs changed")
 			}
 			m.eventsHandler.AddEvent(ctx, *c.ID, nil, models.EventSeverityInfo,
-				fmt.Sprintf("Cluster %s was updated with api-vip %s, ingress-vip %s", c.ID.String(), apiVip, ingressVip), time.Now())
+				fmt.Sprintf("Cluster %s was updated with api-vip %s, ingress-vip %s", c.ID.String(), apiVip, ingressVip), time.Now(),
+				events.ClusterVipsUpdated, map[string]interface{}{"api_vip": apiVip, "ingress_vip": ingressVip})
 		}
 
 	case models.ClusterStatusInstalling, models.ClusterStatusPreparingForInstallation, models.ClusterStatusFinalizing:
@@ -690,13 +753,15 @@ func (m *Manager) DeleteClusterFiles(ctx context.Context, c *common.Cluster, obj
 	return nil
 }
 
-func (m Manager) PermanentClustersDeletion(ctx context.Context, olderThen strfmt.DateTime, objectHandler s3wrapper.API) error {
+func (m Manager) PermanentClustersDeletion(ctx context.Context, olderThen strfmt.DateTime, objectHandler s3wrapper.API, leaderElector leader.Leader) error {
 	var clusters []*common.Cluster
-	db := m.db.Unscoped()
-	if reply := db.Where("deleted_at < ?", olderThen).Find(&clusters); reply.Error != nil {
+	if reply := m.db.Unscoped().Where("deleted_at < ?", olderThen).Find(&clusters); reply.Error != nil {
 		return reply.Error
 	}
 	for _, c := range clusters {
+		if err := leader.Verify(m.db, leaderElector); err != nil {
+			return err
+		}
 		m.log.Debugf("Deleting all S3 files for cluster: %s", c.ID.String())
 
 		deleteFromDB := true
@@ -713,12 +778,20 @@ func (m Manager) PermanentClustersDeletion(ctx context.Context, olderThen strfmt
 			continue
 		}
 
-		if reply := db.Delete(&c); reply.Error != nil {
-			m.log.WithError(reply.Error).Warnf("Failed deleting cluster from db %s", c.ID.String())
-		} else if reply.RowsAffected > 0 {
-			m.log.Debugf("Deleted %s cluster from db", reply.RowsAffected)
+		err := leader.InTransaction(m.db, leaderElector, func(tx *gorm.DB) error {
+			reply := tx.Unscoped().Delete(&c)
+			if reply.Error != nil {
+				return reply.Error
+			}
+			if reply.RowsAffected > 0 {
+				m.log.Debugf("Deleted %d cluster from db", reply.RowsAffected)
+			}
+			return history.DeleteClusterTransitions(tx, *c.ID)
+		})
+		if err != nil {
+			m.log.WithError(err).Warnf("Failed deleting cluster from db %s", c.ID.String())
+			continue
 		}
-
 		m.eventsHandler.DeleteClusterEvents(*c.ID)
 	}
 	return nil
@@ -20,6 +20,7 @@ import (
 	"github.com/openshift/assisted-service/internal/events"
 	"github.com/openshift/assisted-service/internal/host"
 	"github.com/openshift/assisted-service/internal/metrics"
+	"github.com/openshift/assisted-service/internal/webhooks"
 	"github.com/openshift/assisted-service/models"
 	"github.com/openshift/assisted-service/pkg/leader"
 	"github.com/openshift/assisted-service/pkg/s3wrapper"
@@ -49,7 +50,7 @@ var _ = Describe("stateMachine", func() {
 	BeforeEach(func() {
 		db = common.PrepareTestDB(dbName, &events.Event{})
 		dummy := &leader.DummyElector{}
-		state = NewManager(getDefaultConfig(), getTestLog(), db, nil, nil, nil, dummy)
+		state = NewManager(getDefaultConfig(), getTestLog(), db, nil, nil, nil, dummy, &webhooks.DummyNotifier{})
 		id := strfmt.UUID(uuid.New().String())
 		cluster = &common.Cluster{Cluster: models.Cluster{
 			ID:     &id,
@@ -108,10 +109,12 @@ var _ = Describe("TestClusterMonitoring", func() {
 		ctrl = gomock.NewController(GinkgoT())
 		mockHostAPI = host.NewMockAPI(ctrl)
 		mockMetric = metrics.NewMockAPI(ctrl)
+		mockMetric.EXPECT().ClusterValidationFailed(gomock.Any()).AnyTimes()
+		mockMetric.EXPECT().MonitoringCycle(gomock.Any(), gomock.Any()).AnyTimes()
 		mockEvents = events.NewMockHandler(ctrl)
 		dummy := &leader.DummyElector{}
 		clusterApi = NewManager(getDefaultConfig(), getTestLog().WithField("pkg", "cluster-monitor"), db,
-			mockEvents, mockHostAPI, mockMetric, dummy)
+			mockEvents, mockHostAPI, mockMetric, dummy, &webhooks.DummyNotifier{})
 		expectedState = ""
 		shouldHaveUpdated = false
 	})
@@ -438,7 +441,7 @@ var _ = Describe("TestClusterMonitoring", func() {
 			c = geCluster(id, db)
 			saveUpdatedTime := c.StatusUpdatedAt
 			saveStatusInfo := c.StatusInfo
-			mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 			mockHostAPIIsRequireUserActionResetFalse()
 			clusterApi.ClusterMonitoring()
 			after := time.Now().Truncate(10 * time.Millisecond)
@@ -465,7 +468,7 @@ var _ = Describe("TestClusterMonitoring", func() {
 
 		monitorKnownToInsufficient := func(nClusters int) {
 			mockEvents.EXPECT().
-				AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+				AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 			mockHostAPI.EXPECT().IsRequireUserActionReset(gomock.Any()).Return(false).AnyTimes()
 			mockHostAPI.EXPECT().IsValidMasterCandidate(gomock.Any(), gomock.Any(), gomock.Any()).
 				Return(true, nil).AnyTimes()
@@ -534,10 +537,12 @@ var _ = Describe("lease timeout event", func() {
 		ctrl = gomock.NewController(GinkgoT())
 		mockHostAPI = host.NewMockAPI(ctrl)
 		mockMetric = metrics.NewMockAPI(ctrl)
+		mockMetric.EXPECT().ClusterValidationFailed(gomock.Any()).AnyTimes()
+		mockMetric.EXPECT().MonitoringCycle(gomock.Any(), gomock.Any()).AnyTimes()
 		mockEvents = events.NewMockHandler(ctrl)
 		dummy := &leader.DummyElector{}
 		clusterApi = NewManager(getDefaultConfig(), getTestLog().WithField("pkg", "cluster-monitor"), db,
-			mockEvents, mockHostAPI, mockMetric, dummy)
+			mockEvents, mockHostAPI, mockMetric, dummy, &webhooks.DummyNotifier{})
 	})
 	tests := []struct {
 		name                string
@@ -606,7 +611,7 @@ var _ = Describe("lease timeout event", func() {
 			}
 			Expect(db.Create(&c).Error).ShouldNot(HaveOccurred())
 			if t.eventCalllsExpected > 0 {
-				mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(t.eventCalllsExpected)
+				mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(t.eventCalllsExpected)
 			}
 			clusterApi.ClusterMonitoring()
 			ctrl.Finish()
@@ -629,14 +634,20 @@ var _ = Describe("Auto assign machine CIDR", func() {
 	)
 	BeforeEach(func() {
 		db = common.PrepareTestDB(dbName, &events.Event{})
+		// The monitor refreshes every cluster in the database, so clusters left behind by other specs in the shared
+		// in-memory database would make calls that are not expected here
+		Expect(db.Exec("DELETE FROM clusters").Error).ShouldNot(HaveOccurred())
+		Expect(db.Exec("DELETE FROM hosts").Error).ShouldNot(HaveOccurred())
 		id = strfmt.UUID(uuid.New().String())
 		ctrl = gomock.NewController(GinkgoT())
 		mockHostAPI = host.NewMockAPI(ctrl)
 		mockMetric = metrics.NewMockAPI(ctrl)
+		mockMetric.EXPECT().ClusterValidationFailed(gomock.Any()).AnyTimes()
+		mockMetric.EXPECT().MonitoringCycle(gomock.Any(), gomock.Any()).AnyTimes()
 		mockEvents = events.NewMockHandler(ctrl)
 		dummy := &leader.DummyElector{}
 		clusterApi = NewManager(getDefaultConfig(), getTestLog().WithField("pkg", "cluster-monitor"), db,
-			mockEvents, mockHostAPI, mockMetric, dummy)
+			mockEvents, mockHostAPI, mockMetric, dummy, &webhooks.DummyNotifier{})
 	})
 	tests := []struct {
 		name                    string
@@ -775,7 +786,7 @@ var _ = Describe("Auto assign machine CIDR", func() {
 				Expect(db.Create(h).Error).ShouldNot(HaveOccurred())
 			}
 			if t.eventCallExpected {
-				mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+				mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 			}
 			if len(t.hosts) > 0 {
 				mockHostAPI.EXPECT().IsValidMasterCandidate(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
@@ -807,7 +818,7 @@ var _ = Describe("VerifyRegisterHost", func() {
 		id = strfmt.UUID(uuid.New().String())
 		dummy := &leader.DummyElector{}
 		clusterApi = NewManager(getDefaultConfig(), getTestLog().WithField("pkg", "cluster-monitor"), db,
-			nil, nil, nil, dummy)
+			nil, nil, nil, dummy, &webhooks.DummyNotifier{})
 	})
 
 	checkVerifyRegisterHost := func(clusterStatus string, expectErr bool) {
@@ -857,7 +868,7 @@ var _ = Describe("VerifyClusterUpdatability", func() {
 		id = strfmt.UUID(uuid.New().String())
 		dummy := &leader.DummyElector{}
 		clusterApi = NewManager(getDefaultConfig(), getTestLog().WithField("pkg", "cluster-monitor"), db,
-			nil, nil, nil, dummy)
+			nil, nil, nil, dummy, &webhooks.DummyNotifier{})
 	})
 
 	checkVerifyClusterUpdatability := func(clusterStatus string, expectErr bool) {
@@ -906,8 +917,9 @@ var _ = Describe("CancelInstallation", func() {
 		eventsHandler = events.New(db, logrus.New())
 		ctrl = gomock.NewController(GinkgoT())
 		mockMetric = metrics.NewMockAPI(ctrl)
+		mockMetric.EXPECT().ClusterValidationFailed(gomock.Any()).AnyTimes()
 		dummy := &leader.DummyElector{}
-		state = NewManager(getDefaultConfig(), getTestLog(), db, eventsHandler, nil, mockMetric, dummy)
+		state = NewManager(getDefaultConfig(), getTestLog(), db, eventsHandler, nil, mockMetric, dummy, &webhooks.DummyNotifier{})
 		id := strfmt.UUID(uuid.New().String())
 		c = common.Cluster{Cluster: models.Cluster{
 			ID:         &id,
@@ -976,7 +988,7 @@ var _ = Describe("ResetCluster", func() {
 		db = common.PrepareTestDB(dbName, &events.Event{})
 		eventsHandler = events.New(db, logrus.New())
 		dummy := &leader.DummyElector{}
-		state = NewManager(getDefaultConfig(), getTestLog(), db, eventsHandler, nil, nil, dummy)
+		state = NewManager(getDefaultConfig(), getTestLog(), db, eventsHandler, nil, nil, dummy, &webhooks.DummyNotifier{})
 	})
 
 	It("reset_cluster", func() {
@@ -1157,6 +1169,25 @@ func defaultInventoryWithTimestamp(timestamp int64) string {
 	return string(b)
 }
 
+func inventoryWithMtu(hostname string, mtu int64) string {
+	inventory := models.Inventory{
+		Hostname: hostname,
+		Interfaces: []*models.Interface{
+			{
+				Name: "eth0",
+				IPV4Addresses: []string{
+					"1.2.3.4/24",
+				},
+				Mtu: mtu,
+			},
+		},
+		Timestamp: 1601909239,
+	}
+	b, err := json.Marshal(&inventory)
+	Expect(err).To(Not(HaveOccurred()))
+	return string(b)
+}
+
 func twoNetworksInventory() string {
 	inventory := models.Inventory{
 		Interfaces: []*models.Interface{
@@ -1202,7 +1233,7 @@ var _ = Describe("PrepareForInstallation", func() {
 	BeforeEach(func() {
 		db = common.PrepareTestDB(dbName, &events.Event{})
 		dummy := &leader.DummyElector{}
-		capi = NewManager(getDefaultConfig(), getTestLog(), db, nil, nil, nil, dummy)
+		capi = NewManager(getDefaultConfig(), getTestLog(), db, nil, nil, nil, dummy, &webhooks.DummyNotifier{})
 		clusterId = strfmt.UUID(uuid.New().String())
 	})
 
@@ -1282,7 +1313,7 @@ var _ = Describe("HandlePreInstallationError", func() {
 	BeforeEach(func() {
 		db = common.PrepareTestDB(dbName, &events.Event{})
 		dummy := &leader.DummyElector{}
-		capi = NewManager(getDefaultConfig(), getTestLog(), db, nil, nil, nil, dummy)
+		capi = NewManager(getDefaultConfig(), getTestLog(), db, nil, nil, nil, dummy, &webhooks.DummyNotifier{})
 		clusterId = strfmt.UUID(uuid.New().String())
 	})
 
@@ -1390,7 +1421,7 @@ var _ = Describe("SetVipsData", func() {
 		ctrl = gomock.NewController(GinkgoT())
 		mockEvents = events.NewMockHandler(ctrl)
 		dummy := &leader.DummyElector{}
-		capi = NewManager(getDefaultConfig(), getTestLog(), db, mockEvents, nil, nil, dummy)
+		capi = NewManager(getDefaultConfig(), getTestLog(), db, mockEvents, nil, nil, dummy, &webhooks.DummyNotifier{})
 		clusterId = strfmt.UUID(uuid.New().String())
 	})
 	AfterEach(func() {
@@ -1524,7 +1555,7 @@ var _ = Describe("SetVipsData", func() {
 			cluster.IngressVip = t.clusterIngressVip
 			Expect(db.Create(&cluster).Error).ShouldNot(HaveOccurred())
 			if t.eventExpected {
-				mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), nil, models.EventSeverityInfo, gomock.Any(), gomock.Any()).Times(1)
+				mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), nil, models.EventSeverityInfo, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
 			}
 			err := capi.SetVipsData(ctx, &cluster, t.apiVip, t.ingressVip, t.clusterApiLease, t.clusterIngressLease, db)
 			Expect(err != nil).To(Equal(t.errorExpected))
@@ -1554,12 +1585,19 @@ var _ = Describe("Majority groups", func() {
 
 	BeforeEach(func() {
 		db = common.PrepareTestDB(dbName, &events.Event{})
+		// The monitor refreshes every cluster in the database, so clusters left behind by other specs in the shared
+		// in-memory database would make calls that are not expected here
+		Expect(db.Exec("DELETE FROM clusters").Error).ShouldNot(HaveOccurred())
+		Expect(db.Exec("DELETE FROM hosts").Error).ShouldNot(HaveOccurred())
 		dbIndex++
 		ctrl = gomock.NewController(GinkgoT())
 		mockEvents = events.NewMockHandler(ctrl)
+		mockMetric := metrics.NewMockAPI(ctrl)
+		mockMetric.EXPECT().MonitoringCycle(gomock.Any(), gomock.Any()).AnyTimes()
+		mockMetric.EXPECT().ClusterValidationFailed(gomock.Any()).AnyTimes()
 		dummy := &leader.DummyElector{}
 		clusterApi = NewManager(getDefaultConfig(), getTestLog().WithField("pkg", "cluster-monitor"), db,
-			mockEvents, nil, nil, dummy)
+			mockEvents, nil, mockMetric, dummy, &webhooks.DummyNotifier{})
 
 		id = strfmt.UUID(uuid.New().String())
 		cluster = common.Cluster{Cluster: models.Cluster{
@@ -1652,9 +1690,12 @@ var _ = Describe("ready_state", func() {
 		db = common.PrepareTestDB(dbName, &events.Event{})
 		ctrl = gomock.NewController(GinkgoT())
 		mockEvents = events.NewMockHandler(ctrl)
+		mockMetric := metrics.NewMockAPI(ctrl)
+		mockMetric.EXPECT().MonitoringCycle(gomock.Any(), gomock.Any()).AnyTimes()
+		mockMetric.EXPECT().ClusterValidationFailed(gomock.Any()).AnyTimes()
 		dummy := &leader.DummyElector{}
 		clusterApi = NewManager(getDefaultConfig(), getTestLog().WithField("pkg", "cluster-monitor"), db,
-			mockEvents, nil, nil, dummy)
+			mockEvents, nil, mockMetric, dummy, &webhooks.DummyNotifier{})
 
 		id = strfmt.UUID(uuid.New().String())
 		cluster = common.Cluster{Cluster: models.Cluster{
@@ -1673,7 +1714,7 @@ var _ = Describe("ready_state", func() {
 		cluster = geCluster(*cluster.ID, db)
 		Expect(swag.StringValue(cluster.Status)).Should(Equal(models.ClusterStatusReady))
 		Expect(len(cluster.Hosts)).Should(Equal(3))
-		mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+		mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 	})
 
 	Context("refresh_state", func() {
@@ -1717,7 +1758,7 @@ var _ = Describe("insufficient_state", func() {
 		db = common.PrepareTestDB(dbName, &events.Event{})
 		dummy := &leader.DummyElector{}
 		clusterApi = NewManager(getDefaultConfig(), getTestLog().WithField("pkg", "cluster-monitor"), db,
-			mockEvents, mockHostAPI, nil, dummy)
+			mockEvents, mockHostAPI, nil, dummy, &webhooks.DummyNotifier{})
 
 		id = strfmt.UUID(uuid.New().String())
 		cluster = common.Cluster{Cluster: models.Cluster{
@@ -1730,7 +1771,7 @@ var _ = Describe("insufficient_state", func() {
 			PullSecretSet:      true,
 		}}
 
-		mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+		mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 		replyErr := clusterApi.RegisterCluster(ctx, &cluster)
 		Expect(replyErr).Should(BeNil())
 		Expect(swag.StringValue(cluster.Status)).Should(Equal(models.ClusterStatusInsufficient))
@@ -1758,7 +1799,9 @@ var _ = Describe("prepare-for-installation refresh status", func() {
 		mockHostAPI = host.NewMockAPI(ctrl)
 		mockEvents := events.NewMockHandler(ctrl)
 		dummy := &leader.DummyElector{}
-		capi = NewManager(cfg, getTestLog(), db, mockEvents, mockHostAPI, nil, dummy)
+		mockMetric := metrics.NewMockAPI(ctrl)
+		mockMetric.EXPECT().ClusterValidationFailed(gomock.Any()).AnyTimes()
+		capi = NewManager(cfg, getTestLog(), db, mockEvents, mockHostAPI, mockMetric, dummy, &webhooks.DummyNotifier{})
 		clusterId = strfmt.UUID(uuid.New().String())
 		cl = common.Cluster{
 			Cluster: models.Cluster{
@@ -1768,7 +1811,7 @@ var _ = Describe("prepare-for-installation refresh status", func() {
 			},
 		}
 		Expect(db.Create(&cl).Error).NotTo(HaveOccurred())
-		mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+		mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 	})
 
 	It("no change", func() {
@@ -1820,7 +1863,7 @@ var _ = Describe("Cluster tarred files", func() {
 		mockHostAPI = host.NewMockAPI(ctrl)
 		mockEvents := events.NewMockHandler(ctrl)
 		dummy := &leader.DummyElector{}
-		capi = NewManager(cfg, getTestLog(), db, mockEvents, mockHostAPI, nil, dummy)
+		capi = NewManager(cfg, getTestLog(), db, mockEvents, mockHostAPI, nil, dummy, &webhooks.DummyNotifier{})
 		clusterId = strfmt.UUID(uuid.New().String())
 		cl = common.Cluster{
 			Cluster: models.Cluster{
@@ -1832,7 +1875,7 @@ var _ = Describe("Cluster tarred files", func() {
 		tarFile = fmt.Sprintf("%s/logs/cluster_logs.tar", clusterId)
 		Expect(db.Create(&cl).Error).NotTo(HaveOccurred())
 		prefix = fmt.Sprintf("%s/logs/", cl.ID)
-		mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+		mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 	})
 
 	AfterEach(func() {
@@ -1891,10 +1934,11 @@ var _ = Describe("CompleteInstallation", func() {
 	BeforeEach(func() {
 		ctrl = gomock.NewController(GinkgoT())
 		mockMetric = metrics.NewMockAPI(ctrl)
+		mockMetric.EXPECT().ClusterValidationFailed(gomock.Any()).AnyTimes()
 		db = common.PrepareTestDB(dbName, &events.Event{})
 		eventsHandler = events.New(db, logrus.New())
 		dummy := &leader.DummyElector{}
-		state = NewManager(getDefaultConfig(), getTestLog(), db, eventsHandler, nil, mockMetric, dummy)
+		state = NewManager(getDefaultConfig(), getTestLog(), db, eventsHandler, nil, mockMetric, dummy, &webhooks.DummyNotifier{})
 		id := strfmt.UUID(uuid.New().String())
 		c = common.Cluster{Cluster: models.Cluster{
 			ID:     &id,
@@ -1958,7 +2002,7 @@ var _ = Describe("Permanently delete clusters", func() {
 
 	registerCluster := func() common.Cluster {
 		id := strfmt.UUID(uuid.New().String())
-		eventsHandler.AddEvent(ctx, id, &id, "", "", time.Now())
+		eventsHandler.AddEvent(ctx, id, &id, "", "", time.Now(), "test.event", nil)
 		c := common.Cluster{Cluster: models.Cluster{
 			ID: &id,
 		}}
@@ -1969,11 +2013,12 @@ var _ = Describe("Permanently delete clusters", func() {
 	BeforeEach(func() {
 		ctrl = gomock.NewController(GinkgoT())
 		mockMetric = metrics.NewMockAPI(ctrl)
+		mockMetric.EXPECT().ClusterValidationFailed(gomock.Any()).AnyTimes()
 		mockS3Api = s3wrapper.NewMockAPI(ctrl)
 		db = common.PrepareTestDB(dbName, &events.Event{})
 		eventsHandler = events.New(db, logrus.New())
 		dummy := &leader.DummyElector{}
-		state = NewManager(getDefaultConfig(), getTestLog(), db, eventsHandler, nil, mockMetric, dummy)
+		state = NewManager(getDefaultConfig(), getTestLog(), db, eventsHandler, nil, mockMetric, dummy, &webhooks.DummyNotifier{})
 		c1 = registerCluster()
 		c2 = registerCluster()
 		c3 = registerCluster()
@@ -1991,7 +2036,7 @@ var _ = Describe("Permanently delete clusters", func() {
 		mockS3Api.EXPECT().DeleteObject(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 		mockS3Api.EXPECT().ListObjectsByPrefix(gomock.Any(), gomock.Any()).Return([]string{}, nil).AnyTimes()
 
-		Expect(state.PermanentClustersDeletion(ctx, strfmt.DateTime(time.Now()), mockS3Api)).ShouldNot(HaveOccurred())
+		Expect(state.PermanentClustersDeletion(ctx, strfmt.DateTime(time.Now()), mockS3Api, &leader.DummyElector{})).ShouldNot(HaveOccurred())
 
 		Expect(db.Unscoped().Where("id = ?", c1.ID).Find(&common.Cluster{}).RowsAffected).Should(Equal(int64(0)))
 		Expect(db.Unscoped().Where("id = ?", c2.ID).Find(&common.Cluster{}).RowsAffected).Should(Equal(int64(0)))
@@ -2010,7 +2055,7 @@ var _ = Describe("Permanently delete clusters", func() {
 
 	It("permanently delete clusters - nothing to delete", func() {
 		deletedAt := strfmt.DateTime(time.Now().Add(-time.Hour))
-		Expect(state.PermanentClustersDeletion(ctx, deletedAt, mockS3Api)).ShouldNot(HaveOccurred())
+		Expect(state.PermanentClustersDeletion(ctx, deletedAt, mockS3Api, &leader.DummyElector{})).ShouldNot(HaveOccurred())
 
 		Expect(db.Where("id = ?", c1.ID).Find(&common.Cluster{}).RowsAffected).Should(Equal(int64(1)))
 		Expect(db.Where("id = ?", c2.ID).Find(&common.Cluster{}).RowsAffected).Should(Equal(int64(1)))
@@ -9,6 +9,8 @@ import (
 
 	"github.com/go-openapi/strfmt"
 	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/internal/history"
+	"github.com/openshift/assisted-service/internal/webhooks"
 
 	"github.com/pkg/errors"
 
@@ -18,16 +20,22 @@ import (
 	"gorm.io/gorm"
 )
 
-func NewInstaller(log logrus.FieldLogger, db *gorm.DB) *installer {
+// The start of the installation doesn't run the state machine, it is recorded in the history of the cluster as
+// this kind of transition
+const transitionTypeInstall = "Install"
+
+func NewInstaller(log logrus.FieldLogger, db *gorm.DB, webhooksNotifier webhooks.Notifier) *installer {
 	return &installer{
-		log: log,
-		db:  db,
+		log:      log,
+		db:       db,
+		webhooks: webhooksNotifier,
 	}
 }
 
 type installer struct {
-	log logrus.FieldLogger
-	db  *gorm.DB
+	log      logrus.FieldLogger
+	db       *gorm.DB
+	webhooks webhooks.Notifier
 }
 
 func (i *installer) Install(ctx context.Context, c *common.Cluster, db *gorm.DB) error {
@@ -58,12 +66,20 @@ func (i *installer) Install(ctx context.Context, c *common.Cluster, db *gorm.DB)
 		return errors.Errorf("cluster %s state is unclear - cluster state: %s", c.ID, swag.StringValue(c.Status))
 	}
 
-	if _, err := updateClusterStatus(i.log, db, *c.ID, swag.StringValue(c.Status),
-		models.ClusterStatusInstalling, statusInfoInstalling); err != nil {
+	updatedCluster, err := updateClusterStatus(i.log, db, *c.ID, swag.StringValue(c.Status),
+		models.ClusterStatusInstalling, statusInfoInstalling)
+	if err != nil {
+		return err
+	}
+	if err = i.webhooks.ClusterStatusChanged(ctx, db, updatedCluster, swag.StringValue(c.Status)); err != nil {
 		return err
 	}
 
-	return nil
+	return history.RecordClusterTransition(ctx, db, updatedCluster, &history.Transition{
+		Type:           transitionTypeInstall,
+		FromStatus:     swag.StringValue(c.Status),
+		FromStatusInfo: swag.StringValue(c.StatusInfo),
+	})
 }
 
 func (i *installer) GetMasterNodesIds(ctx context.Context, cluster *common.Cluster, db *gorm.DB) ([]*strfmt.UUID, error) {
@@ -5,10 +5,12 @@ import (
 
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
+	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/internal/webhooks"
 	"github.com/openshift/assisted-service/models"
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
@@ -18,6 +20,8 @@ var _ = Describe("installer", func() {
 	var (
 		ctx              = context.Background()
 		installerManager InstallationAPI
+		ctrl             *gomock.Controller
+		mockNotifier     *webhooks.MockNotifier
 		db               *gorm.DB
 		id               strfmt.UUID
 		cluster          common.Cluster
@@ -27,7 +31,9 @@ var _ = Describe("installer", func() {
 
 	BeforeEach(func() {
 		db = common.PrepareTestDB(dbName)
-		installerManager = NewInstaller(getTestLog(), db)
+		ctrl = gomock.NewController(GinkgoT())
+		mockNotifier = webhooks.NewMockNotifier(ctrl)
+		installerManager = NewInstaller(getTestLog(), db, mockNotifier)
 
 		id = strfmt.UUID(uuid.New().String())
 		cluster = common.Cluster{Cluster: models.Cluster{
@@ -38,6 +44,10 @@ var _ = Describe("installer", func() {
 		Expect(db.Create(&cluster).Error).ShouldNot(HaveOccurred())
 	})
 
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
 	Context("install cluster", func() {
 		It("cluster is insufficient", func() {
 			cluster = updateClusterState(cluster, models.ClusterStatusInsufficient, db)
@@ -75,8 +85,14 @@ var _ = Describe("installer", func() {
 		})
 		It("cluster is ready", func() {
 			cluster = updateClusterState(cluster, models.ClusterStatusPreparingForInstallation, db)
-			err := installerManager.Install(ctx, &cluster, db)
+			tx := db.Begin()
+			mockNotifier.EXPECT().ClusterStatusChanged(ctx, tx, gomock.Any(), models.ClusterStatusPreparingForInstallation).
+				Do(func(_ context.Context, _ *gorm.DB, c *common.Cluster, _ string) {
+					Expect(swag.StringValue(c.Status)).Should(Equal(models.ClusterStatusInstalling))
+				}).Return(nil)
+			err := installerManager.Install(ctx, &cluster, tx)
 			Expect(err).Should(BeNil())
+			Expect(tx.Commit().Error).ShouldNot(HaveOccurred())
 
 			Expect(db.Preload("Hosts").First(&cluster, "id = ?", cluster.ID).Error).ShouldNot(HaveOccurred())
 
@@ -134,6 +134,11 @@ func newValidations(log logrus.FieldLogger, api host.API) []validation {
 			condition: v.isNtpServerConfigured,
 			formatter: v.printNtpServerConfigured,
 		},
+		{
+			id:        IsMachineNetworkMtuConsistent,
+			condition: v.isMachineNetworkMtuConsistent,
+			formatter: v.printMachineNetworkMtuConsistent,
+		},
 	}
 	return ret
 }
@@ -75,34 +75,19 @@ func (r *registrar) RegisterAddHostsOCPCluster(c *common.Cluster, db *gorm.DB) e
 	return nil
 }
 
-func (r *registrar) DeregisterCluster(ctx context.Context, cluster *common.Cluster) error {
-	var txErr error
-	tx := r.db.Begin()
-
-	defer func() {
-		if txErr != nil {
-			tx.Rollback()
-		}
-	}()
-
+func (r *registrar) DeregisterCluster(ctx context.Context, cluster *common.Cluster, db *gorm.DB) error {
 	if swag.StringValue(cluster.Status) == models.ClusterStatusInstalling {
-		tx.Rollback()
 		return errors.Errorf("cluster %s can not be removed while being installed", cluster.ID)
 	}
 
-	if txErr = tx.Where("cluster_id = ?", cluster.ID).Delete(&models.Host{}).Error; txErr != nil {
-		tx.Rollback()
-		return errors.Errorf("failed to deregister host while unregistering cluster %s", cluster.ID)
-	}
-
-	if txErr = tx.Delete(cluster).Error; txErr != nil {
-		tx.Rollback()
-		return errors.Errorf("failed to delete cluster %s", cluster.ID)
-	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("cluster_id = ?", cluster.ID).Delete(&models.Host{}).Error; err != nil {
+			return errors.Errorf("failed to deregister host while unregistering cluster %s", cluster.ID)
+		}
 
-	if tx.Commit().Error != nil {
-		tx.Rollback()
-		return errors.Errorf("failed to delete cluster %s, commit tx", cluster.ID)
-	}
-	return nil
+		if err := tx.Delete(cluster).Error; err != nil {
+			return errors.Errorf("failed to delete cluster %s", cluster.ID)
+		}
+		return nil
+	})
 }
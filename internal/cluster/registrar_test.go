@@ -55,7 +55,7 @@ var _ = Describe("registrar", func() {
 
 	Context("deregister", func() {
 		It("unregister a registered cluster", func() {
-			updateErr = registerManager.DeregisterCluster(ctx, &cluster)
+			updateErr = registerManager.DeregisterCluster(ctx, &cluster, db)
 			Expect(updateErr).Should(BeNil())
 
 			Expect(db.Preload("Hosts").First(&cluster, "id = ?", cluster.ID).Error).Should(HaveOccurred())
@@ -69,7 +69,7 @@ var _ = Describe("registrar", func() {
 			cluster.Status = swag.String("installing")
 			Expect(db.Model(cluster).Update("Status", "installing").Error).NotTo(HaveOccurred())
 
-			updateErr = registerManager.DeregisterCluster(ctx, &cluster)
+			updateErr = registerManager.DeregisterCluster(ctx, &cluster, db)
 			Expect(updateErr).Should(HaveOccurred())
 
 			db.First(&cluster, "id = ?", cluster.ID)
@@ -1,20 +1,37 @@
 package cluster
 
 import (
+	"context"
+
 	"github.com/filanov/stateswitch"
 	"github.com/go-openapi/swag"
 	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/internal/history"
+	"github.com/openshift/assisted-service/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type stateCluster struct {
-	srcState string
-	cluster  *common.Cluster
+	srcState       string
+	srcStatusInfo  string
+	transitionType stateswitch.TransitionType
+	cluster        *common.Cluster
 }
 
 func newStateCluster(c *common.Cluster) *stateCluster {
 	return &stateCluster{
-		srcState: swag.StringValue(c.Status),
-		cluster:  c,
+		srcState:      swag.StringValue(c.Status),
+		srcStatusInfo: swag.StringValue(c.StatusInfo),
+		cluster:       c,
+	}
+}
+
+// transition returns the transition that is running, for the history of the cluster
+func (sh *stateCluster) transition() *history.Transition {
+	return &history.Transition{
+		Type:           string(sh.transitionType),
+		FromStatus:     sh.srcState,
+		FromStatusInfo: sh.srcStatusInfo,
 	}
 }
 
@@ -26,3 +43,28 @@ func (sh *stateCluster) SetState(state stateswitch.State) error {
 	sh.cluster.Status = swag.String(string(state))
 	return nil
 }
+
+// stateMachine keeps the type of the running transition in the state of the cluster, and records a span of the
+// transition when it's part of a traced operation
+type stateMachine struct {
+	stateswitch.StateMachine
+}
+
+func (sm *stateMachine) Run(ctx context.Context, transitionType stateswitch.TransitionType, sw stateswitch.StateSwitch,
+	args stateswitch.TransitionArgs) (err error) {
+	sCluster, ok := sw.(*stateCluster)
+	var attrs []attribute.KeyValue
+	if ok {
+		sCluster.transitionType = transitionType
+		attrs = []attribute.KeyValue{attribute.String("cluster.id", sCluster.cluster.ID.String()),
+			attribute.String("cluster.from_status", sCluster.srcState)}
+	}
+	ctx, span := tracing.StartInTrace(ctx, "cluster."+string(transitionType), attrs...)
+	defer func() {
+		if ok {
+			span.SetAttributes(attribute.String("cluster.to_status", swag.StringValue(sCluster.cluster.Status)))
+		}
+		tracing.End(ctx, span, err)
+	}()
+	return sm.StateMachine.Run(transitionType, sw, args)
+}
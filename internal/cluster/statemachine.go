@@ -14,7 +14,7 @@ const (
 	TransitionTypeRefreshStatus              = "RefreshStatus"
 )
 
-func NewClusterStateMachine(th *transitionHandler) stateswitch.StateMachine {
+func NewClusterStateMachine(th *transitionHandler) *stateMachine {
 	sm := stateswitch.NewStateMachine()
 
 	sm.AddTransition(stateswitch.TransitionRule{
@@ -91,7 +91,8 @@ func NewClusterStateMachine(th *transitionHandler) stateswitch.StateMachine {
 	var pendingConditions = stateswitch.And(If(IsMachineCidrDefined), If(isClusterCidrDefined), If(isServiceCidrDefined), If(IsDNSDomainDefined), If(IsPullSecretSet))
 	var vipsDefinedConditions = stateswitch.And(If(isApiVipDefined), If(isIngressVipDefined))
 	var requiredForInstall = stateswitch.And(If(isMachineCidrEqualsToCalculatedCidr), If(isApiVipValid), If(isIngressVipValid), If(AllHostsAreReadyToInstall),
-		If(SufficientMastersCount), If(networkPrefixValid), If(noCidrOverlapping), If(IsNtpServerConfigured))
+		If(SufficientMastersCount), If(networkPrefixValid), If(noCidrOverlapping), If(IsNtpServerConfigured),
+		If(IsMachineNetworkMtuConsistent))
 
 	// Refresh cluster status conditions - Non DHCP
 	var requiredInputFieldsExistNonDhcp = stateswitch.And(vipsDefinedConditions, pendingConditions)
@@ -284,5 +285,5 @@ func NewClusterStateMachine(th *transitionHandler) stateswitch.StateMachine {
 		})
 	}
 
-	return sm
+	return &stateMachine{StateMachine: sm}
 }
@@ -11,8 +11,10 @@ import (
 	"github.com/go-openapi/swag"
 	"github.com/openshift/assisted-service/internal/common"
 	"github.com/openshift/assisted-service/internal/events"
+	"github.com/openshift/assisted-service/internal/history"
 	"github.com/openshift/assisted-service/internal/host"
 	"github.com/openshift/assisted-service/internal/metrics"
+	"github.com/openshift/assisted-service/internal/webhooks"
 	"github.com/openshift/assisted-service/models"
 	logutil "github.com/openshift/assisted-service/pkg/log"
 	"github.com/pkg/errors"
@@ -25,6 +27,7 @@ type transitionHandler struct {
 	log           logrus.FieldLogger
 	db            *gorm.DB
 	prepareConfig PrepareConfig
+	webhooks      webhooks.Notifier
 }
 
 ////////////////////////////////////////////////////////////////////////////
@@ -47,7 +50,7 @@ func (th *transitionHandler) PostCancelInstallation(sw stateswitch.StateSwitch,
 		return errors.New("PostCancelInstallation invalid argument")
 	}
 
-	return th.updateTransitionCluster(logutil.FromContext(params.ctx, th.log), params.db, sCluster,
+	return th.updateTransitionCluster(params.ctx, logutil.FromContext(params.ctx, th.log), params.db, sCluster,
 		params.reason)
 }
 
@@ -71,7 +74,7 @@ func (th *transitionHandler) PostResetCluster(sw stateswitch.StateSwitch, args s
 		return errors.New("PostResetCluster invalid argument")
 	}
 
-	return th.updateTransitionCluster(logutil.FromContext(params.ctx, th.log), params.db, sCluster, params.reason,
+	return th.updateTransitionCluster(params.ctx, logutil.FromContext(params.ctx, th.log), params.db, sCluster, params.reason,
 		"ControllerLogsCollectedAt", strfmt.DateTime(time.Time{}),
 		"OpenshiftClusterID", "")
 }
@@ -95,7 +98,7 @@ func (th *transitionHandler) PostPrepareForInstallation(sw stateswitch.StateSwit
 		return errors.New("PostResetCluster invalid argument")
 	}
 
-	return th.updateTransitionCluster(logutil.FromContext(params.ctx, th.log), th.db, sCluster,
+	return th.updateTransitionCluster(params.ctx, logutil.FromContext(params.ctx, th.log), th.db, sCluster,
 		statusInfoPreparingForInstallation, "install_started_at", strfmt.DateTime(time.Now()))
 }
 
@@ -119,7 +122,7 @@ func (th *transitionHandler) PostCompleteInstallation(sw stateswitch.StateSwitch
 		return errors.New("PostCompleteInstallation invalid argument")
 	}
 
-	return th.updateTransitionCluster(logutil.FromContext(params.ctx, th.log), th.db, sCluster, params.reason)
+	return th.updateTransitionCluster(params.ctx, logutil.FromContext(params.ctx, th.log), th.db, sCluster, params.reason)
 }
 
 func (th *transitionHandler) isSuccess(stateSwitch stateswitch.StateSwitch, args stateswitch.TransitionArgs) (b bool, err error) {
@@ -144,11 +147,11 @@ type TransitionArgsHandlePreInstallationError struct {
 func (th *transitionHandler) PostHandlePreInstallationError(sw stateswitch.StateSwitch, args stateswitch.TransitionArgs) error {
 	sCluster, _ := sw.(*stateCluster)
 	params, _ := args.(*TransitionArgsHandlePreInstallationError)
-	return th.updateTransitionCluster(logutil.FromContext(params.ctx, th.log), th.db, sCluster,
+	return th.updateTransitionCluster(params.ctx, logutil.FromContext(params.ctx, th.log), th.db, sCluster,
 		params.installErr.Error())
 }
 
-func (th *transitionHandler) updateTransitionCluster(log logrus.FieldLogger, db *gorm.DB, state *stateCluster,
+func (th *transitionHandler) updateTransitionCluster(ctx context.Context, log logrus.FieldLogger, db *gorm.DB, state *stateCluster,
 	statusInfo string, extra ...interface{}) error {
 
 	if cluster, err := updateClusterStatus(log, db, *state.cluster.ID, state.srcState,
@@ -156,8 +159,19 @@ func (th *transitionHandler) updateTransitionCluster(log logrus.FieldLogger, db
 		return err
 	} else {
 		state.cluster = cluster
+		if err = th.notifyStatusChange(ctx, db, cluster, state.srcState); err != nil {
+			return err
+		}
+		return history.RecordClusterTransition(ctx, db, cluster, state.transition())
+	}
+}
+
+// notifyStatusChange queues the webhook deliveries of the status change of the cluster, if its status changed
+func (th *transitionHandler) notifyStatusChange(ctx context.Context, db *gorm.DB, cluster *common.Cluster, srcStatus string) error {
+	if swag.StringValue(cluster.Status) == srcStatus {
 		return nil
 	}
+	return th.webhooks.ClusterStatusChanged(ctx, db, cluster, srcStatus)
 }
 
 ////////////////////////////////////////////////////////////////////////////
@@ -293,11 +307,25 @@ func (th *transitionHandler) PostRefreshCluster(reason string) stateswitch.PostT
 		if err != nil {
 			return err
 		}
+		for _, id := range metrics.NewValidationFailures(sCluster.cluster.ValidationsInfo, string(b)) {
+			params.metricApi.ClusterValidationFailed(id)
+		}
 
-		//if status was changed - we need to send event and metrics
+		if updatedCluster != nil {
+			if err = history.RecordClusterTransition(params.ctx, params.db, updatedCluster, sCluster.transition()); err != nil {
+				return err
+			}
+		}
+
+		//if status was changed - we need to send event, webhooks and metrics
 		if updatedCluster != nil && sCluster.srcState != swag.StringValue(updatedCluster.Status) {
+			if err = th.notifyStatusChange(params.ctx, params.db, updatedCluster, sCluster.srcState); err != nil {
+				return err
+			}
 			msg := fmt.Sprintf("Updated status of cluster %s to %s", updatedCluster.Name, *updatedCluster.Status)
-			params.eventHandler.AddEvent(params.ctx, *updatedCluster.ID, nil, models.EventSeverityInfo, msg, time.Now())
+			params.eventHandler.AddEvent(params.ctx, *updatedCluster.ID, nil, models.EventSeverityInfo, msg, time.Now(),
+				events.ClusterStatusChanged, map[string]interface{}{"from": sCluster.srcState, "to": *updatedCluster.Status,
+					"status_info": swag.StringValue(updatedCluster.StatusInfo)})
 			//report installation finished metric if needed
 			reportInstallationCompleteStatuses := []string{models.ClusterStatusInstalled, models.ClusterStatusError}
 			if sCluster.srcState == models.ClusterStatusInstalling &&
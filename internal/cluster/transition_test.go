@@ -21,6 +21,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/openshift/assisted-service/internal/common"
 	"github.com/openshift/assisted-service/internal/events"
+	"github.com/openshift/assisted-service/internal/webhooks"
 	"github.com/openshift/assisted-service/models"
 	"gorm.io/gorm"
 )
@@ -42,7 +43,7 @@ var _ = Describe("Transition tests", func() {
 		eventsHandler = events.New(db, logrus.New())
 		ctrl = gomock.NewController(GinkgoT())
 		mockMetric = metrics.NewMockAPI(ctrl)
-		capi = NewManager(getDefaultConfig(), getTestLog(), db, eventsHandler, nil, mockMetric, nil)
+		capi = NewManager(getDefaultConfig(), getTestLog(), db, eventsHandler, nil, mockMetric, nil, &webhooks.DummyNotifier{})
 		clusterId = strfmt.UUID(uuid.New().String())
 	})
 
@@ -156,6 +157,7 @@ var _ = Describe("Cancel cluster installation", func() {
 		ctrl              *gomock.Controller
 		mockEventsHandler *events.MockHandler
 		mockMetric        *metrics.MockAPI
+		mockWebhooks      *webhooks.MockNotifier
 	)
 
 	BeforeEach(func() {
@@ -163,11 +165,12 @@ var _ = Describe("Cancel cluster installation", func() {
 		ctrl = gomock.NewController(GinkgoT())
 		mockEventsHandler = events.NewMockHandler(ctrl)
 		mockMetric = metrics.NewMockAPI(ctrl)
-		capi = NewManager(getDefaultConfig(), getTestLog(), db, mockEventsHandler, nil, mockMetric, nil)
+		mockWebhooks = webhooks.NewMockNotifier(ctrl)
+		capi = NewManager(getDefaultConfig(), getTestLog(), db, mockEventsHandler, nil, mockMetric, nil, mockWebhooks)
 	})
 
 	acceptNewEvents := func(times int) {
-		mockEventsHandler.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(times)
+		mockEventsHandler.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(times)
 	}
 
 	acceptClusterInstallationFinished := func(times int) {
@@ -200,12 +203,20 @@ var _ = Describe("Cancel cluster installation", func() {
 			if t.success {
 				eventsNum++
 				acceptClusterInstallationFinished(1)
+				mockWebhooks.EXPECT().ClusterStatusChanged(gomock.Any(), gomock.Any(), gomock.Any(), t.state).Return(nil).Times(1)
 			}
 			acceptNewEvents(eventsNum)
 			err := capi.CancelInstallation(ctx, &cluster, "reason", db)
+			var transitions []*models.StateTransition
+			Expect(db.Find(&transitions, "cluster_id = ?", clusterId.String()).Error).ShouldNot(HaveOccurred())
 			if t.success {
 				Expect(err).ShouldNot(HaveOccurred())
+				Expect(transitions).To(HaveLen(1))
+				Expect(transitions[0].TransitionType).Should(Equal(TransitionTypeCancelInstallation))
+				Expect(transitions[0].FromStatus).Should(Equal(t.state))
+				Expect(*transitions[0].ToStatus).Should(Equal(models.ClusterStatusError))
 			} else {
+				Expect(transitions).To(BeEmpty())
 				Expect(err).Should(HaveOccurred())
 				Expect(err.StatusCode()).Should(Equal(t.statusCode))
 			}
@@ -232,11 +243,11 @@ var _ = Describe("Reset cluster", func() {
 		db = common.PrepareTestDB(dbName, &events.Event{})
 		ctrl = gomock.NewController(GinkgoT())
 		mockEventsHandler = events.NewMockHandler(ctrl)
-		capi = NewManager(getDefaultConfig(), getTestLog(), db, mockEventsHandler, nil, nil, nil)
+		capi = NewManager(getDefaultConfig(), getTestLog(), db, mockEventsHandler, nil, nil, nil, &webhooks.DummyNotifier{})
 	})
 
 	acceptNewEvents := func(times int) {
-		mockEventsHandler.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(times)
+		mockEventsHandler.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(times)
 	}
 
 	tests := []struct {
@@ -368,8 +379,9 @@ var _ = Describe("Refresh Cluster - No DHCP", func() {
 		mockEvents = events.NewMockHandler(ctrl)
 		mockHostAPI = host.NewMockAPI(ctrl)
 		mockMetric = metrics.NewMockAPI(ctrl)
+		mockMetric.EXPECT().ClusterValidationFailed(gomock.Any()).AnyTimes()
 		clusterApi = NewManager(getDefaultConfig(), getTestLog().WithField("pkg", "cluster-monitor"), db,
-			mockEvents, mockHostAPI, mockMetric, nil)
+			mockEvents, mockHostAPI, mockMetric, nil, &webhooks.DummyNotifier{})
 
 		hid1 = strfmt.UUID(uuid.New().String())
 		hid2 = strfmt.UUID(uuid.New().String())
@@ -923,7 +935,7 @@ var _ = Describe("Refresh Cluster - No DHCP", func() {
 				cluster = getCluster(clusterId, db)
 				if srcState != t.dstState {
 					mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(),
-						gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+						gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 				}
 				if t.dstState == models.ClusterStatusInsufficient {
 					mockHostAPIIsRequireUserActionResetFalse()
@@ -974,8 +986,9 @@ var _ = Describe("Refresh Cluster - Advanced networking validations", func() {
 		mockEvents = events.NewMockHandler(ctrl)
 		mockHostAPI = host.NewMockAPI(ctrl)
 		mockMetric = metrics.NewMockAPI(ctrl)
+		mockMetric.EXPECT().ClusterValidationFailed(gomock.Any()).AnyTimes()
 		clusterApi = NewManager(getDefaultConfig(), getTestLog().WithField("pkg", "cluster-monitor"), db,
-			mockEvents, mockHostAPI, mockMetric, nil)
+			mockEvents, mockHostAPI, mockMetric, nil, &webhooks.DummyNotifier{})
 
 		hid1 = strfmt.UUID(uuid.New().String())
 		hid2 = strfmt.UUID(uuid.New().String())
@@ -1296,7 +1309,7 @@ var _ = Describe("Refresh Cluster - Advanced networking validations", func() {
 				cluster = getCluster(clusterId, db)
 				if srcState != t.dstState {
 					mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(),
-						gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+						gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 				}
 				if t.dstState == models.ClusterStatusInsufficient {
 					mockHostAPIIsRequireUserActionResetFalse()
@@ -1344,8 +1357,9 @@ var _ = Describe("Refresh Cluster - With DHCP", func() {
 		mockEvents = events.NewMockHandler(ctrl)
 		mockHostAPI = host.NewMockAPI(ctrl)
 		mockMetric = metrics.NewMockAPI(ctrl)
+		mockMetric.EXPECT().ClusterValidationFailed(gomock.Any()).AnyTimes()
 		clusterApi = NewManager(getDefaultConfig(), getTestLog().WithField("pkg", "cluster-monitor"), db,
-			mockEvents, mockHostAPI, mockMetric, nil)
+			mockEvents, mockHostAPI, mockMetric, nil, &webhooks.DummyNotifier{})
 
 		hid1 = strfmt.UUID(uuid.New().String())
 		hid2 = strfmt.UUID(uuid.New().String())
@@ -1747,7 +1761,7 @@ var _ = Describe("Refresh Cluster - With DHCP", func() {
 				cluster = getCluster(clusterId, db)
 				if srcState != t.dstState {
 					mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(),
-						gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+						gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 				}
 				if t.dstState == models.ClusterStatusInsufficient {
 					mockHostAPIIsRequireUserActionResetFalse()
@@ -1799,8 +1813,9 @@ var _ = Describe("Refresh Cluster - Installing Cases", func() {
 		mockEvents = events.NewMockHandler(ctrl)
 		mockHostAPI = host.NewMockAPI(ctrl)
 		mockMetric = metrics.NewMockAPI(ctrl)
+		mockMetric.EXPECT().ClusterValidationFailed(gomock.Any()).AnyTimes()
 		clusterApi = NewManager(getDefaultConfig(), getTestLog().WithField("pkg", "cluster-monitor"), db,
-			mockEvents, mockHostAPI, mockMetric, nil)
+			mockEvents, mockHostAPI, mockMetric, nil, &webhooks.DummyNotifier{})
 
 		hid1 = strfmt.UUID(uuid.New().String())
 		hid2 = strfmt.UUID(uuid.New().String())
@@ -2024,7 +2039,7 @@ var _ = Describe("Refresh Cluster - Installing Cases", func() {
 				cluster = getCluster(clusterId, db)
 				if srcState != t.dstState {
 					mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(),
-						gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+						gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 				}
 				reportInstallationCompleteStatuses := []string{models.ClusterStatusInstalled, models.ClusterStatusError}
 				if funk.Contains(reportInstallationCompleteStatuses, t.dstState) {
@@ -2068,8 +2083,9 @@ var _ = Describe("NTP refresh cluster", func() {
 		mockEvents = events.NewMockHandler(ctrl)
 		mockHostAPI = host.NewMockAPI(ctrl)
 		mockMetric = metrics.NewMockAPI(ctrl)
+		mockMetric.EXPECT().ClusterValidationFailed(gomock.Any()).AnyTimes()
 		clusterApi = NewManager(getDefaultConfig(), getTestLog().WithField("pkg", "cluster-monitor"), db,
-			mockEvents, mockHostAPI, mockMetric, nil)
+			mockEvents, mockHostAPI, mockMetric, nil, &webhooks.DummyNotifier{})
 		hid1 = strfmt.UUID(uuid.New().String())
 		hid2 = strfmt.UUID(uuid.New().String())
 		hid3 = strfmt.UUID(uuid.New().String())
@@ -2152,6 +2168,29 @@ var _ = Describe("NTP refresh cluster", func() {
 					IsPullSecretSet:                     {status: ValidationSuccess, messagePattern: "The pull secret is set"},
 					SufficientMastersCount:              {status: ValidationSuccess, messagePattern: "The cluster has a sufficient number of master candidates"},
 					IsNtpServerConfigured:               {status: ValidationSuccess, messagePattern: "No ntp problems found"},
+					IsMachineNetworkMtuConsistent:       {status: ValidationSuccess, messagePattern: "The machine network interfaces of the hosts have the same MTU"},
+				}),
+				errorExpected: false,
+			},
+			{
+				name:               "pending-for-input to insufficient - mtu mismatch",
+				srcState:           models.ClusterStatusPendingForInput,
+				dstState:           models.ClusterStatusInsufficient,
+				machineNetworkCidr: "1.2.3.0/24",
+				apiVip:             "1.2.3.5",
+				ingressVip:         "1.2.3.6",
+				dnsDomain:          "test.com",
+				pullSecretSet:      true,
+				hosts: []models.Host{
+					{ID: &hid1, Status: swag.String(models.HostStatusKnown), Inventory: inventoryWithMtu("master-1", 9000), Role: models.HostRoleMaster},
+					{ID: &hid2, Status: swag.String(models.HostStatusKnown), Inventory: inventoryWithMtu("master-2", 1500), Role: models.HostRoleMaster},
+					{ID: &hid3, Status: swag.String(models.HostStatusKnown), Inventory: inventoryWithMtu("master-3", 9000), Role: models.HostRoleMaster},
+				},
+				statusInfoChecker: makeValueChecker(statusInfoInsufficient),
+				validationsChecker: makeJsonChecker(map[validationID]validationCheckResult{
+					AllHostsAreReadyToInstall:     {status: ValidationSuccess, messagePattern: "All hosts in the cluster are ready to install"},
+					IsNtpServerConfigured:         {status: ValidationSuccess, messagePattern: "No ntp problems found"},
+					IsMachineNetworkMtuConsistent: {status: ValidationFailure, messagePattern: "The machine network interfaces of the hosts have different MTUs: 1500 \\(master-2\\), 9000 \\(master-1, master-3\\)"},
 				}),
 				errorExpected: false,
 			},
@@ -2372,7 +2411,7 @@ var _ = Describe("NTP refresh cluster", func() {
 				cluster = getCluster(clusterId, db)
 				if srcState != t.dstState {
 					mockEvents.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(),
-						gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+						gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 				}
 				mockHostAPIIsRequireUserActionResetFalse()
 				if t.dstState == models.ClusterStatusInsufficient {
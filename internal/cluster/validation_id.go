@@ -27,12 +27,14 @@ const (
 	IsDNSDomainDefined                  = validationID(models.ClusterValidationIDDNSDomainDefined)
 	IsPullSecretSet                     = validationID(models.ClusterValidationIDPullSecretSet)
 	IsNtpServerConfigured               = validationID(models.ClusterValidationIDNtpServerConfigured)
+	IsMachineNetworkMtuConsistent       = validationID(models.ClusterValidationIDMachineNetworkMtuConsistent)
 )
 
 func (v validationID) category() (string, error) {
 	switch v {
 	case IsMachineCidrDefined, isMachineCidrEqualsToCalculatedCidr, isApiVipDefined, isApiVipValid, isIngressVipDefined, isIngressVipValid,
-		isClusterCidrDefined, isServiceCidrDefined, noCidrOverlapping, networkPrefixValid, IsDNSDomainDefined, IsNtpServerConfigured:
+		isClusterCidrDefined, isServiceCidrDefined, noCidrOverlapping, networkPrefixValid, IsDNSDomainDefined, IsNtpServerConfigured,
+		IsMachineNetworkMtuConsistent:
 		return "network", nil
 	case AllHostsAreReadyToInstall, SufficientMastersCount:
 		return "hosts-data", nil
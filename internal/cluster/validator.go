@@ -1,7 +1,6 @@
 package cluster
 
 import (
-	"encoding/json"
 	"fmt"
 	"time"
 
@@ -9,6 +8,7 @@ import (
 	"github.com/go-openapi/swag"
 	"github.com/openshift/assisted-service/internal/common"
 	"github.com/openshift/assisted-service/internal/host"
+	"github.com/openshift/assisted-service/internal/hostutil"
 	"github.com/openshift/assisted-service/internal/network"
 	"github.com/openshift/assisted-service/models"
 	"github.com/sirupsen/logrus"
@@ -431,8 +431,8 @@ func (v *clusterValidator) isNtpServerConfigured(c *clusterPreprocessContext) va
 	var min int64
 	var max int64
 	for _, h := range c.cluster.Hosts {
-		var inventory models.Inventory
-		if err := json.Unmarshal([]byte(h.Inventory), &inventory); err != nil {
+		inventory, err := hostutil.UnmarshalInventory(h)
+		if err != nil {
 			v.log.WithError(err).Warnf("Illegal inventory for host %s", h.ID.String())
 			continue
 		}
@@ -465,3 +465,24 @@ func (v *clusterValidator) printNtpServerConfigured(c *clusterPreprocessContext,
 		return fmt.Sprintf("Unexpected status %s", status)
 	}
 }
+
+func (v *clusterValidator) isMachineNetworkMtuConsistent(c *clusterPreprocessContext) validationStatus {
+	if c.cluster.MachineNetworkCidr == "" {
+		return ValidationPending
+	}
+	return boolValue(len(network.GetMachineNetworkMtus(v.log, c.cluster)) <= 1)
+}
+
+func (v *clusterValidator) printMachineNetworkMtuConsistent(c *clusterPreprocessContext, status validationStatus) string {
+	switch status {
+	case ValidationSuccess:
+		return "The machine network interfaces of the hosts have the same MTU."
+	case ValidationFailure:
+		return fmt.Sprintf("The machine network interfaces of the hosts have different MTUs: %s.",
+			network.FormatMtus(network.GetMachineNetworkMtus(v.log, c.cluster)))
+	case ValidationPending:
+		return "The Machine Network CIDR is undefined."
+	default:
+		return fmt.Sprintf("Unexpected status %s.", status)
+	}
+}
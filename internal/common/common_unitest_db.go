@@ -6,14 +6,17 @@ import (
 
 	. "github.com/onsi/gomega"
 	"github.com/openshift/assisted-service/models"
+	dbPkg "github.com/openshift/assisted-service/pkg/db"
 )
 
 func PrepareTestDB(dbName string, extrasSchemas ...interface{}) *gorm.DB {
 
 	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
 	Expect(err).ShouldNot(HaveOccurred())
+	Expect(dbPkg.RegisterResourceVersion(db)).ShouldNot(HaveOccurred())
 	//db = db.Debug()
-	err = db.AutoMigrate(&models.Host{}, &Cluster{})
+	err = db.AutoMigrate(&models.Host{}, &Cluster{}, &IdempotencyKey{}, &HostDisk{}, &HostInterface{}, &HostAddress{},
+		&models.StateTransition{})
 	Expect(err).ShouldNot(HaveOccurred())
 
 	if len(extrasSchemas) > 0 {
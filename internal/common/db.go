@@ -3,6 +3,7 @@ package common
 import (
 	"time"
 
+	"github.com/go-openapi/strfmt"
 	"github.com/openshift/assisted-service/models"
 )
 
@@ -23,4 +24,75 @@ type Cluster struct {
 
 	// The lease acquired for API vip
 	IngressVipLease string `gorm:"type:text"`
+
+	// Incremented on every change of the cluster, returned as the ETag of the cluster
+	ResourceVersion int64 `json:"resource_version" gorm:"not null;default:0"`
+}
+
+// IdempotencyKey keeps the response of a request that was sent with an Idempotency-Key header,
+// so retries of the request get the same response instead of repeating its side effects
+type IdempotencyKey struct {
+	UserName  string `gorm:"primaryKey"`
+	Operation string `gorm:"primaryKey"`
+	Key       string `gorm:"primaryKey"`
+
+	// Hash of the request parameters, a retry with the same key must send the same request
+	RequestHash string
+
+	// Zero until the first request completes
+	StatusCode int
+	Header     string `gorm:"type:text"`
+	Body       []byte
+
+	CreatedAt time.Time
+	ExpiresAt time.Time `gorm:"index"`
+	// Until the request completes, retries get a conflict until the lease expires and then take over the key,
+	// the replica that ran the request may have crashed. Keys stored before leases were added have none.
+	LeaseExpiresAt *time.Time
+}
+
+// HostDisk is a disk of the last inventory the host reported, the inventory is kept in tables as well
+// so hosts can be queried by their hardware
+type HostDisk struct {
+	ID        uint        `gorm:"primaryKey"`
+	ClusterID strfmt.UUID `gorm:"index:idx_host_disks_host"`
+	HostID    strfmt.UUID `gorm:"index:idx_host_disks_host"`
+	Name      string
+	Path      string
+	ByPath    string
+	DriveType string `gorm:"index"`
+	SizeBytes int64
+	Vendor    string
+	Model     string
+	Serial    string
+	Wwn       string
+	Hctl      string
+	Bootable  bool
+	Removable bool
+}
+
+// HostInterface is a network interface of the last inventory the host reported
+type HostInterface struct {
+	ID          uint        `gorm:"primaryKey"`
+	ClusterID   strfmt.UUID `gorm:"index:idx_host_interfaces_host"`
+	HostID      strfmt.UUID `gorm:"index:idx_host_interfaces_host"`
+	Name        string
+	MacAddress  string `gorm:"index"`
+	Mtu         int64
+	SpeedMbps   int64
+	HasCarrier  bool
+	Vendor      string
+	Product     string
+	Biosdevname string
+}
+
+// HostAddress is an IP address of a network interface of the last inventory the host reported
+type HostAddress struct {
+	ID            uint        `gorm:"primaryKey"`
+	ClusterID     strfmt.UUID `gorm:"index:idx_host_addresses_host"`
+	HostID        strfmt.UUID `gorm:"index:idx_host_addresses_host"`
+	InterfaceName string
+	// Address with prefix length, like 192.168.126.10/24
+	Address string
+	IPV6    bool
 }
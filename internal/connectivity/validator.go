@@ -1,8 +1,7 @@
 package connectivity
 
 import (
-	"encoding/json"
-
+	"github.com/openshift/assisted-service/internal/hostutil"
 	"github.com/openshift/assisted-service/models"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -24,8 +23,8 @@ type validator struct {
 }
 
 func (v *validator) GetHostValidInterfaces(host *models.Host) ([]*models.Interface, error) {
-	var inventory models.Inventory
-	if err := json.Unmarshal([]byte(host.Inventory), &inventory); err != nil {
+	inventory, err := hostutil.UnmarshalInventory(host)
+	if err != nil {
 		return nil, err
 	}
 	if len(inventory.Interfaces) == 0 {
@@ -0,0 +1,70 @@
+package events
+
+// Event codes identify what happened for clients that react to events, so they must not change once released.
+// Each code is prefixed by the kind of entity the event relates to, the comments list the props of the event.
+const (
+	// name, add_hosts, error on failure
+	ClusterRegistered         = "cluster.registered"
+	ClusterRegistrationFailed = "cluster.registration_failed"
+
+	// error on failure
+	ClusterDeregistered         = "cluster.deregistered"
+	ClusterDeregistrationFailed = "cluster.deregistration_failed"
+
+	// from, to, status_info
+	ClusterStatusChanged = "cluster.status_changed"
+
+	ClusterProxyChanged = "cluster.proxy_changed"
+
+	// api_vip, ingress_vip
+	ClusterVipsUpdated = "cluster.vips_updated"
+
+	ClusterVipLeaseTimedOut = "cluster.vip_lease_timed_out"
+
+	ClusterImageGenerated        = "cluster.image_generated"
+	ClusterImageReused           = "cluster.image_reused"
+	ClusterImageGenerationFailed = "cluster.image_generation_failed"
+	ClusterImageDownloadStarted  = "cluster.image_download_started"
+	ClusterImageDownloadFailed   = "cluster.image_download_failed"
+	ClusterImageExpired          = "cluster.image_expired"
+
+	ClusterInstallStarted   = "cluster.install_started"
+	ClusterInstallCompleted = "cluster.install_completed"
+	// reason
+	ClusterInstallFailed = "cluster.install_failed"
+
+	// error on failure
+	ClusterInstallCancelled    = "cluster.install_cancelled"
+	ClusterInstallCancelFailed = "cluster.install_cancel_failed"
+	ClusterInstallReset        = "cluster.install_reset"
+	ClusterInstallResetFailed  = "cluster.install_reset_failed"
+
+	// hostname on success, error on failure
+	HostRegistered         = "host.registered"
+	HostRegistrationFailed = "host.registration_failed"
+
+	HostDeregistered = "host.deregistered"
+
+	// from, to, status_info
+	HostStatusChanged = "host.status_changed"
+
+	// operation, error
+	HostStatusRefreshFailed = "host.status_refresh_failed"
+
+	HostBootstrapSet  = "host.bootstrap_set"
+	HostDisabled      = "host.disabled"
+	HostDisableFailed = "host.disable_failed"
+	HostEnabled       = "host.enabled"
+	HostEnableFailed  = "host.enable_failed"
+
+	// stage, stage_info
+	HostInstallProgressUpdated = "host.install_progress_updated"
+
+	// error on failure
+	HostInstallCancelled             = "host.install_cancelled"
+	HostInstallCancelFailed          = "host.install_cancel_failed"
+	HostInstallReset                 = "host.install_reset"
+	HostInstallResetFailed           = "host.install_reset_failed"
+	HostResetPendingUserAction       = "host.reset_pending_user_action"
+	HostResetPendingUserActionFailed = "host.reset_pending_user_action_failed"
+)
@@ -2,12 +2,17 @@ package events
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
 	"time"
 
+	dbPkg "github.com/openshift/assisted-service/pkg/db"
 	logutil "github.com/openshift/assisted-service/pkg/log"
 	"github.com/openshift/assisted-service/pkg/requestid"
+	"github.com/openshift/assisted-service/pkg/transaction"
 
 	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
 	"github.com/openshift/assisted-service/models"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
@@ -21,9 +26,21 @@ type Handler interface {
 	//     host added to cluster, we have the host-id as the main entityID and
 	//     the cluster-id as another ID that this event should be related to
 	// otherEntities arguments provides for specifying mor IDs that are relevant for this event
-	AddEvent(ctx context.Context, clusterID strfmt.UUID, hostID *strfmt.UUID, severity string, msg string, eventTime time.Time)
+	// code is one of the event codes of this package and props are the parameters of the event, for clients
+	// that react to events without parsing the message
+	AddEvent(ctx context.Context, clusterID strfmt.UUID, hostID *strfmt.UUID, severity string, msg string, eventTime time.Time,
+		code string, props map[string]interface{})
 	GetEvents(clusterID strfmt.UUID, hostID *strfmt.UUID) ([]*Event, error)
+	// QueryEvents returns the events of the cluster that match the query
+	QueryEvents(clusterID strfmt.UUID, query *Query) ([]*Event, error)
 	DeleteClusterEvents(clusterID strfmt.UUID)
+	// GetEventsAfter returns up to limit events with an ID greater than afterID ordered by ID, of the
+	// cluster and host when they are given or of all the clusters otherwise
+	GetEventsAfter(clusterID *strfmt.UUID, hostID *strfmt.UUID, afterID uint, limit int) ([]*Event, error)
+	// LastEventID returns the ID of the last event that was written, 0 when there are none
+	LastEventID() (uint, error)
+	// Written returns a channel that is closed when this service writes the next event
+	Written() <-chan struct{}
 }
 
 var _ Handler = &Events{}
@@ -34,77 +51,251 @@ type Event struct {
 }
 
 type Events struct {
-	db  *gorm.DB
-	log logrus.FieldLogger
+	db      *gorm.DB
+	log     logrus.FieldLogger
+	written *broadcast
+	sinks   []Sink
 }
 
-func New(db *gorm.DB, log logrus.FieldLogger) *Events {
+// New returns an events handler that also sends the events it writes to the sinks, which must not block like
+// the ones of NewSinks
+func New(db *gorm.DB, log logrus.FieldLogger, sinks ...Sink) *Events {
 	return &Events{
-		db:  db,
-		log: log,
+		db:      db,
+		log:     log,
+		written: newBroadcast(),
+		sinks:   sinks,
 	}
 }
 
-func addEventToDB(log logrus.FieldLogger, db *gorm.DB, clusterID strfmt.UUID, hostID *strfmt.UUID, severity string, message string, t time.Time, requestID string) error {
-	tt := strfmt.DateTime(t)
+func addEventToDB(log logrus.FieldLogger, db *gorm.DB, clusterID strfmt.UUID, hostID *strfmt.UUID, severity string, message string, t time.Time, requestID string,
+	code string, props map[string]interface{}) (*Event, error) {
+	tt := utc(strfmt.DateTime(t))
 	uid := clusterID
 	rid := strfmt.UUID(requestID)
 
 	e := Event{
 		Event: models.Event{
-			EventTime: &tt,
-			ClusterID: &uid,
-			Severity:  &severity,
-			Message:   &message,
-			RequestID: rid,
+			EventTime:   &tt,
+			ClusterID:   &uid,
+			Severity:    &severity,
+			Message:     &message,
+			RequestID:   rid,
+			Code:        code,
+			EntityKind:  models.EventEntityKindCluster,
+			Occurrences: 1,
+			LastSeenAt:  tt,
 		},
 	}
 	if hostID != nil {
 		e.HostID = *hostID
+		e.EntityKind = models.EventEntityKindHost
+	}
+	if len(props) > 0 {
+		b, err := json.Marshal(props)
+		if err != nil {
+			log.WithError(err).Errorf("Failed to marshal props of event %s", code)
+		} else {
+			e.Props = string(b)
+		}
 	}
 
-	if err := db.Create(&e).Error; err != nil {
+	if err := lockEntityEvents(db, clusterID, hostID); err != nil {
+		log.WithError(err).Error("Error locking the events")
+		return nil, err
+	}
+	last, err := lastEntityEvent(db, clusterID, hostID)
+	if err != nil {
+		log.WithError(err).Error("Error getting the last event")
+		return nil, err
+	}
+	if last != nil && swag.StringValue(last.Severity) == severity && swag.StringValue(last.Message) == message && last.Code == code {
+		// collapses the identical consecutive events of the entity, like the repeats of a host that keeps
+		// disconnecting. Only strictly consecutive events are collapsed, a host that alternates between two
+		// statuses gets an event for each change. The event is written again with a new ID, so the streams
+		// that follow the IDs get the update of its occurrences.
+		if err = db.Unscoped().Delete(&Event{}, last.ID).Error; err != nil {
+			log.WithError(err).Error("Error updating event")
+			return nil, err
+		}
+		last.Model = gorm.Model{}
+		last.Occurrences++
+		if time.Time(tt).After(time.Time(last.LastSeenAt)) {
+			last.LastSeenAt = tt
+		}
+		if err = db.Create(last).Error; err != nil {
+			log.WithError(err).Error("Error updating event")
+			return nil, err
+		}
+		return last, nil
+	}
+
+	if err = db.Create(&e).Error; err != nil {
 		log.WithError(err).Error("Error adding event")
+		return nil, err
+	}
+	return &e, nil
+}
+
+// lockEntityEvents makes the writers of the events of the host, or of the cluster itself, wait for each other until
+// the end of their transaction, so they don't collapse an event into the same last one. On postgres it takes an
+// advisory lock rather than the lock of the cluster, which the event writers may be holding already. SQLite has a
+// single writer, a transaction that read the last event fails to write once another one committed.
+func lockEntityEvents(tx *gorm.DB, clusterID strfmt.UUID, hostID *strfmt.UUID) error {
+	if tx.Dialector.Name() != dbPkg.DialectPostgres {
+		return nil
 	}
-	return nil
+	key := "events/" + clusterID.String()
+	if hostID != nil {
+		key += "/" + hostID.String()
+	}
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", key).Error
 }
 
-func (e *Events) AddEvent(ctx context.Context, clusterID strfmt.UUID, hostID *strfmt.UUID, severity string, msg string, eventTime time.Time) {
+// lastEntityEvent returns the last event of the host when it is given or of the cluster itself otherwise, nil
+// when there are none
+func lastEntityEvent(db *gorm.DB, clusterID strfmt.UUID, hostID *strfmt.UUID) (*Event, error) {
+	host := ""
+	if hostID != nil {
+		host = hostID.String()
+	}
+	var evs []*Event
+	if err := db.Where("cluster_id = ? and host_id = ?", clusterID.String(), host).Order("id desc").Limit(1).Find(&evs).Error; err != nil {
+		return nil, err
+	}
+	if len(evs) == 0 {
+		return nil, nil
+	}
+	return evs[0], nil
+}
+
+func (e *Events) AddEvent(ctx context.Context, clusterID strfmt.UUID, hostID *strfmt.UUID, severity string, msg string, eventTime time.Time,
+	code string, props map[string]interface{}) {
+	// the events are written through their own connection, so the ones added while holding a cluster
+	// transaction are written once it ended
+	write := func() { e.addEvent(ctx, clusterID, hostID, severity, msg, eventTime, code, props) }
+	if !transaction.AfterClusterTransaction(ctx, write) {
+		write()
+	}
+}
+
+func (e *Events) addEvent(ctx context.Context, clusterID strfmt.UUID, hostID *strfmt.UUID, severity string, msg string, eventTime time.Time,
+	code string, props map[string]interface{}) {
 	log := logutil.FromContext(ctx, e.log)
 	var isSuccess bool = false
+	var ev *Event
 	tx := e.db.Begin()
 	defer func() {
 		if !isSuccess {
 			log.Warn("Rolling back transaction")
 			tx.Rollback()
-		} else {
-			tx.Commit()
+		} else if tx.Commit().Error == nil {
+			// the repeats of an event reach the streams and sinks with their occurrences counted
+			e.written.notify()
+			e.send(log, ev)
 		}
 	}()
 
 	requestID := requestid.FromContext(ctx)
-	err := addEventToDB(log, tx, clusterID, hostID, severity, msg, eventTime, requestID)
+	ev, err := addEventToDB(log, tx, clusterID, hostID, severity, msg, eventTime, requestID, code, props)
 	if err != nil {
 		return
 	}
 	isSuccess = true
 }
 
+func (e *Events) send(log logrus.FieldLogger, ev *Event) {
+	for _, s := range e.sinks {
+		if err := s.Send(ev); err != nil {
+			log.WithError(err).Warnf("Failed to send event %d to the %s sink", ev.ID, s.Name())
+		}
+	}
+}
+
+// Close flushes and closes the sinks
+func (e *Events) Close() {
+	for _, s := range e.sinks {
+		if err := s.Close(); err != nil {
+			e.log.WithError(err).Warnf("Failed to close the %s sink", s.Name())
+		}
+	}
+}
+
 func (e Events) GetEvents(clusterID strfmt.UUID, hostID *strfmt.UUID) ([]*Event, error) {
-	var evs []*Event
-	var err error
-	if hostID == nil {
-		err = e.db.Order("event_time").Find(&evs, "cluster_id = ?", clusterID.String()).Error
-	} else {
-		err = e.db.Order("event_time").Find(&evs, "cluster_id = ? AND host_id = ?", clusterID.String(), (*hostID).String()).Error
+	return e.QueryEvents(clusterID, &Query{HostID: hostID})
+}
+
+func (e Events) QueryEvents(clusterID strfmt.UUID, query *Query) ([]*Event, error) {
+	db := e.db.Where("cluster_id = ?", clusterID.String())
+	if query.HostID != nil {
+		db = db.Where("host_id = ?", query.HostID.String())
 	}
-	if err != nil {
+	if len(query.Severities) > 0 {
+		db = db.Where("severity in (?)", query.Severities)
+	}
+	if query.Since != nil {
+		db = db.Where("event_time >= ?", utc(*query.Since))
+	}
+	if query.Until != nil {
+		db = db.Where("event_time < ?", utc(*query.Until))
+	}
+	if query.Message != "" {
+		db = db.Where("lower(message) like ? escape '\\'", "%"+likeEscaper.Replace(strings.ToLower(query.Message))+"%")
+	}
+	if query.RequestID != nil {
+		db = db.Where("request_id = ?", query.RequestID.String())
+	}
+
+	order := "event_time, id"
+	if query.Descending {
+		order = "event_time desc, id desc"
+	}
+	if query.After != nil {
+		op := ">"
+		if query.Descending {
+			op = "<"
+		}
+		t := utc(query.After.EventTime)
+		db = db.Where("event_time "+op+" ? or (event_time = ? and id "+op+" ?)", t, t, query.After.ID)
+	}
+	if query.Limit > 0 {
+		db = db.Limit(query.Limit)
+	}
+
+	var evs []*Event
+	if err := db.Order(order).Find(&evs).Error; err != nil {
 		return nil, err
 	}
+	return evs, nil
+}
 
+func (e Events) GetEventsAfter(clusterID *strfmt.UUID, hostID *strfmt.UUID, afterID uint, limit int) ([]*Event, error) {
+	db := e.db.Where("id > ?", afterID)
+	if clusterID != nil {
+		db = db.Where("cluster_id = ?", clusterID.String())
+	}
+	if hostID != nil {
+		db = db.Where("host_id = ?", hostID.String())
+	}
+	var evs []*Event
+	if err := db.Order("id").Limit(limit).Find(&evs).Error; err != nil {
+		return nil, err
+	}
 	return evs, nil
 }
 
+func (e Events) LastEventID() (uint, error) {
+	var id uint
+	if err := e.db.Model(&Event{}).Select("coalesce(max(id), 0)").Row().Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (e Events) Written() <-chan struct{} {
+	return e.written.wait()
+}
+
 func (e Events) DeleteClusterEvents(clusterID strfmt.UUID) {
 	e.db.Where("cluster_id = ?", clusterID.String()).Delete(models.Event{})
 }
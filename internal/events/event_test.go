@@ -60,7 +60,7 @@ var _ = Describe("Events library", func() {
 
 	Context("With events", func() {
 		It("Adding a cluster event", func() {
-			theEvents.AddEvent(context.TODO(), cluster1, nil, models.EventSeverityInfo, "the event1", time.Now())
+			theEvents.AddEvent(context.TODO(), cluster1, nil, models.EventSeverityInfo, "the event1", time.Now(), "test.event", nil)
 			Expect(numOfEvents(cluster1, nil)).Should(Equal(1))
 			Expect(numOfEvents(cluster2, nil)).Should(Equal(0))
 
@@ -69,24 +69,24 @@ var _ = Describe("Events library", func() {
 			Expect(evs[0]).Should(WithMessage(swag.String("the event1")))
 			Expect(evs[0]).Should(WithSeverity(swag.String(models.EventSeverityInfo)))
 
-			theEvents.AddEvent(context.TODO(), cluster2, nil, models.EventSeverityInfo, "event2", time.Now())
+			theEvents.AddEvent(context.TODO(), cluster2, nil, models.EventSeverityInfo, "event2", time.Now(), "test.event", nil)
 			Expect(numOfEvents(cluster1, nil)).Should(Equal(1))
 			Expect(numOfEvents(cluster2, nil)).Should(Equal(1))
 		})
 
 		It("Adding a host event ", func() {
-			theEvents.AddEvent(context.TODO(), cluster1, nil, models.EventSeverityInfo, "event1", time.Now())
+			theEvents.AddEvent(context.TODO(), cluster1, nil, models.EventSeverityInfo, "event1", time.Now(), "test.event", nil)
 			Expect(numOfEvents(cluster1, nil)).Should(Equal(1))
 			Expect(numOfEvents(cluster1, &host)).Should(Equal(0))
 
-			theEvents.AddEvent(context.TODO(), cluster1, &host, models.EventSeverityInfo, "event2", time.Now())
+			theEvents.AddEvent(context.TODO(), cluster1, &host, models.EventSeverityInfo, "event2", time.Now(), "test.event", nil)
 			Expect(numOfEvents(cluster1, nil)).Should(Equal(2))
 			Expect(numOfEvents(cluster1, &host)).Should(Equal(1))
 		})
 
 		It("Adding same event multiple times", func() {
 			t1 := time.Now().Add(-1 * time.Second) // 1 second ago
-			theEvents.AddEvent(context.TODO(), cluster1, nil, models.EventSeverityInfo, "event1", t1)
+			theEvents.AddEvent(context.TODO(), cluster1, nil, models.EventSeverityInfo, "event1", t1, "test.event", nil)
 			Expect(numOfEvents(cluster1, nil)).Should(Equal(1))
 			evs, err := theEvents.GetEvents(cluster1, nil)
 			Expect(err).Should(BeNil())
@@ -95,17 +95,28 @@ var _ = Describe("Events library", func() {
 			Expect(evs[0]).Should(WithSeverity(swag.String(models.EventSeverityInfo)))
 
 			t2 := time.Now()
-			theEvents.AddEvent(context.TODO(), cluster1, nil, models.EventSeverityInfo, "event1", t2)
-			Expect(numOfEvents(cluster1, nil)).Should(Equal(2))
+			theEvents.AddEvent(context.TODO(), cluster1, nil, models.EventSeverityInfo, "event1", t2, "test.event", nil)
+			Expect(numOfEvents(cluster1, nil)).Should(Equal(1))
 
 			evs, err = theEvents.GetEvents(cluster1, nil)
 			Expect(err).Should(BeNil())
 			Expect(evs[0]).Should(WithMessage(swag.String("event1")))
 			Expect(evs[0]).Should(WithTime(t1))
 			Expect(evs[0]).Should(WithSeverity(swag.String(models.EventSeverityInfo)))
+			Expect(evs[0].Occurrences).Should(Equal(int64(2)))
+			Expect(time.Time(evs[0].LastSeenAt)).Should(BeTemporally("~", t2, time.Millisecond*100))
 
 			Expect(numOfEvents(cluster2, nil)).Should(Equal(0))
 		})
+
+		It("Adding the same event after another one", func() {
+			for _, msg := range []string{"known", "disconnected", "known"} {
+				theEvents.AddEvent(context.TODO(), cluster1, &host, models.EventSeverityInfo, msg, time.Now(), "test.event", nil)
+			}
+			theEvents.AddEvent(context.TODO(), cluster1, nil, models.EventSeverityInfo, "known", time.Now(), "test.event", nil)
+			Expect(numOfEvents(cluster1, &host)).Should(Equal(3))
+			Expect(numOfEvents(cluster1, nil)).Should(Equal(4))
+		})
 	})
 
 	Context("events with request ID", func() {
@@ -113,7 +124,7 @@ var _ = Describe("Events library", func() {
 			ctx := context.Background()
 			rid1 := uuid.NewRandom().String()
 			ctx = requestid.ToContext(ctx, rid1)
-			theEvents.AddEvent(ctx, cluster1, &host, models.EventSeverityInfo, "event1", time.Now())
+			theEvents.AddEvent(ctx, cluster1, &host, models.EventSeverityInfo, "event1", time.Now(), "test.event", nil)
 			Expect(numOfEvents(cluster1, &host)).Should(Equal(1))
 
 			evs, err := theEvents.GetEvents(cluster1, nil)
@@ -130,6 +141,106 @@ var _ = Describe("Events library", func() {
 		})
 	})
 
+	Context("structured events", func() {
+		It("saves the code, entity kind and props", func() {
+			theEvents.AddEvent(context.TODO(), cluster1, &host, models.EventSeverityInfo, "status changed", time.Now(),
+				events.HostStatusChanged, map[string]interface{}{"from": "discovering", "to": "known"})
+			theEvents.AddEvent(context.TODO(), cluster1, nil, models.EventSeverityInfo, "installing", time.Now().Add(time.Second),
+				events.ClusterInstallStarted, nil)
+
+			evs, err := theEvents.GetEvents(cluster1, nil)
+			Expect(err).Should(BeNil())
+			Expect(evs).To(HaveLen(2))
+			Expect(evs[0].Code).To(Equal(events.HostStatusChanged))
+			Expect(evs[0].EntityKind).To(Equal(models.EventEntityKindHost))
+			Expect(evs[0].Props).To(MatchJSON(`{"from": "discovering", "to": "known"}`))
+			Expect(evs[1].Code).To(Equal(events.ClusterInstallStarted))
+			Expect(evs[1].EntityKind).To(Equal(models.EventEntityKindCluster))
+			Expect(evs[1].Props).To(BeEmpty())
+		})
+	})
+
+	Context("query events", func() {
+		var (
+			start = time.Now().Add(-time.Hour)
+			rid   = uuid.NewRandom().String()
+		)
+
+		messages := func(evs []*events.Event) []string {
+			ret := make([]string, len(evs))
+			for i, ev := range evs {
+				ret[i] = *ev.Message
+			}
+			return ret
+		}
+
+		BeforeEach(func() {
+			ctx := requestid.ToContext(context.Background(), rid)
+			theEvents.AddEvent(context.Background(), cluster1, nil, models.EventSeverityInfo, "cluster registered", start, "test.event", nil)
+			theEvents.AddEvent(ctx, cluster1, &host, models.EventSeverityWarning, "Host disk is 50% full", start.Add(time.Minute), "test.event", nil)
+			theEvents.AddEvent(ctx, cluster1, &host, models.EventSeverityError, "host failed", start.Add(2*time.Minute), "test.event", nil)
+			theEvents.AddEvent(context.Background(), cluster1, nil, models.EventSeverityInfo, "installation started", start.Add(2*time.Minute), "test.event", nil)
+			theEvents.AddEvent(context.Background(), cluster2, nil, models.EventSeverityError, "host failed", start, "test.event", nil)
+		})
+
+		It("filters by severity", func() {
+			evs, err := theEvents.QueryEvents(cluster1, &events.Query{
+				Severities: []string{models.EventSeverityWarning, models.EventSeverityError}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(messages(evs)).To(Equal([]string{"Host disk is 50% full", "host failed"}))
+		})
+
+		It("filters by time range", func() {
+			since := strfmt.DateTime(start.Add(time.Minute))
+			until := strfmt.DateTime(start.Add(2 * time.Minute))
+			evs, err := theEvents.QueryEvents(cluster1, &events.Query{Since: &since, Until: &until})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(messages(evs)).To(Equal([]string{"Host disk is 50% full"}))
+		})
+
+		It("searches the message ignoring case", func() {
+			evs, err := theEvents.QueryEvents(cluster1, &events.Query{Message: "HOST"})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(messages(evs)).To(Equal([]string{"Host disk is 50% full", "host failed"}))
+
+			evs, err = theEvents.QueryEvents(cluster1, &events.Query{Message: "50%"})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(messages(evs)).To(Equal([]string{"Host disk is 50% full"}))
+
+			evs, err = theEvents.QueryEvents(cluster1, &events.Query{Message: "_"})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(evs).To(BeEmpty())
+		})
+
+		It("filters by request ID", func() {
+			requestID := strfmt.UUID(rid)
+			evs, err := theEvents.QueryEvents(cluster1, &events.Query{RequestID: &requestID})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(messages(evs)).To(Equal([]string{"Host disk is 50% full", "host failed"}))
+		})
+
+		It("pages through the events in descending order", func() {
+			var all []string
+			query := &events.Query{Descending: true, Limit: 3}
+			for {
+				evs, err := theEvents.QueryEvents(cluster1, query)
+				Expect(err).ShouldNot(HaveOccurred())
+				all = append(all, messages(evs)...)
+				if len(evs) < query.Limit {
+					break
+				}
+				query.After, err = events.ParseCursor(events.NewCursor(evs[len(evs)-1]).String())
+				Expect(err).ShouldNot(HaveOccurred())
+			}
+			Expect(all).To(Equal([]string{"installation started", "host failed", "Host disk is 50% full", "cluster registered"}))
+		})
+
+		It("rejects an invalid cursor", func() {
+			_, err := events.ParseCursor("not a cursor")
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
 	AfterEach(func() {
 		common.DeleteTestDB(db, dbName)
 	})
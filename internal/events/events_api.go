@@ -7,6 +7,7 @@ import (
 	"github.com/openshift/assisted-service/models"
 
 	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/swag"
 	"github.com/openshift/assisted-service/internal/common"
 	logutil "github.com/openshift/assisted-service/pkg/log"
 	"github.com/openshift/assisted-service/restapi"
@@ -17,12 +18,14 @@ import (
 var _ restapi.EventsAPI = &Api{}
 
 type Api struct {
+	cfg     Config
 	handler Handler
 	log     logrus.FieldLogger
 }
 
-func NewApi(handler Handler, log logrus.FieldLogger) *Api {
+func NewApi(cfg Config, handler Handler, log logrus.FieldLogger) *Api {
 	return &Api{
+		cfg:     cfg,
 		handler: handler,
 		log:     log,
 	}
@@ -31,7 +34,28 @@ func NewApi(handler Handler, log logrus.FieldLogger) *Api {
 func (a *Api) ListEvents(ctx context.Context, params events.ListEventsParams) middleware.Responder {
 	log := logutil.FromContext(ctx, a.log)
 
-	evs, err := a.handler.GetEvents(params.ClusterID, params.HostID)
+	query := &Query{
+		HostID:     params.HostID,
+		Severities: params.Severities,
+		Since:      params.Since,
+		Until:      params.Until,
+		Message:    swag.StringValue(params.Message),
+		RequestID:  params.RequestID,
+		Descending: swag.StringValue(params.Order) == "descending",
+	}
+	if params.Cursor != nil {
+		cursor, err := ParseCursor(*params.Cursor)
+		if err != nil {
+			return common.NewApiError(http.StatusBadRequest, err)
+		}
+		query.After = cursor
+	}
+	if params.Limit != nil {
+		// one more event tells whether there is a next page
+		query.Limit = int(*params.Limit) + 1
+	}
+
+	evs, err := a.handler.QueryEvents(params.ClusterID, query)
 	if err != nil {
 		if params.HostID != nil {
 			log.Errorf("failed to get events for cluster %s host %s", params.ClusterID.String(), params.HostID.String())
@@ -40,16 +64,54 @@ func (a *Api) ListEvents(ctx context.Context, params events.ListEventsParams) mi
 		}
 		return common.NewApiError(http.StatusInternalServerError, err)
 	}
+	reply := events.NewListEventsOK()
+	if params.Limit != nil && len(evs) > int(*params.Limit) {
+		evs = evs[:*params.Limit]
+		reply.SetXNextCursor(NewCursor(evs[len(evs)-1]).String())
+	}
 	ret := make(models.EventList, len(evs))
 	for i, ev := range evs {
-		ret[i] = &models.Event{
-			ClusterID: ev.ClusterID,
-			HostID:    ev.HostID,
-			Severity:  ev.Severity,
-			EventTime: ev.EventTime,
-			Message:   ev.Message,
+		ret[i] = toAPIEvent(ev)
+	}
+	return reply.WithPayload(ret)
+}
+
+func (a *Api) StreamClusterEvents(ctx context.Context, params events.StreamClusterEventsParams) middleware.Responder {
+	lastEventID, err := parseLastEventID(params.LastEventID)
+	if err != nil {
+		return streamError(http.StatusBadRequest, err)
+	}
+	return a.stream(ctx, &params.ClusterID, params.HostID, lastEventID)
+}
+
+func (a *Api) StreamEvents(ctx context.Context, params events.StreamEventsParams) middleware.Responder {
+	log := logutil.FromContext(ctx, a.log)
+	lastEventID, err := parseLastEventID(params.LastEventID)
+	if err != nil {
+		return streamError(http.StatusBadRequest, err)
+	}
+	if params.LastEventID == nil {
+		// the firehose starts from the events written after the request
+		if lastEventID, err = a.handler.LastEventID(); err != nil {
+			log.WithError(err).Error("failed to get the last event")
+			return streamError(http.StatusInternalServerError, err)
 		}
 	}
-	return events.NewListEventsOK().WithPayload(ret)
+	return a.stream(ctx, nil, nil, lastEventID)
+}
 
+func toAPIEvent(ev *Event) *models.Event {
+	return &models.Event{
+		ClusterID:   ev.ClusterID,
+		HostID:      ev.HostID,
+		Severity:    ev.Severity,
+		EventTime:   ev.EventTime,
+		Message:     ev.Message,
+		RequestID:   ev.RequestID,
+		Code:        ev.Code,
+		EntityKind:  ev.EntityKind,
+		Props:       ev.Props,
+		Occurrences: ev.Occurrences,
+		LastSeenAt:  ev.LastSeenAt,
+	}
 }
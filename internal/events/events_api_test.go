@@ -0,0 +1,209 @@
+package events_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/runtime/middleware"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/internal/events"
+	"github.com/openshift/assisted-service/models"
+	operations "github.com/openshift/assisted-service/restapi/operations/events"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("ListEvents", func() {
+	var (
+		db        *gorm.DB
+		api       *events.Api
+		dbName    = "events_api_test"
+		clusterID = strfmt.UUID("46a8d745-dfce-4fd8-9df0-549ee8eabb3d")
+	)
+
+	BeforeEach(func() {
+		db = common.PrepareTestDB(dbName, &events.Event{})
+		handler := events.New(db, logrus.WithField("pkg", "events"))
+		api = events.NewApi(events.Config{}, handler, logrus.WithField("pkg", "events"))
+		start := time.Now().Add(-time.Hour)
+		for i, msg := range []string{"first", "second", "third"} {
+			handler.AddEvent(context.Background(), clusterID, nil, models.EventSeverityInfo, msg, start.Add(time.Duration(i)*time.Minute), "test.event", nil)
+		}
+	})
+
+	AfterEach(func() {
+		common.DeleteTestDB(db, dbName)
+	})
+
+	list := func(params operations.ListEventsParams) *operations.ListEventsOK {
+		params.ClusterID = clusterID
+		reply := api.ListEvents(context.Background(), params)
+		Expect(reply).To(BeAssignableToTypeOf(&operations.ListEventsOK{}))
+		return reply.(*operations.ListEventsOK)
+	}
+
+	It("returns the next page cursor while there are more events", func() {
+		reply := list(operations.ListEventsParams{Limit: swag.Int64(2)})
+		Expect(reply.Payload).To(HaveLen(2))
+		Expect(*reply.Payload[1].Message).To(Equal("second"))
+		Expect(reply.XNextCursor).NotTo(BeEmpty())
+
+		reply = list(operations.ListEventsParams{Limit: swag.Int64(2), Cursor: swag.String(reply.XNextCursor)})
+		Expect(reply.Payload).To(HaveLen(1))
+		Expect(*reply.Payload[0].Message).To(Equal("third"))
+		Expect(reply.XNextCursor).To(BeEmpty())
+	})
+
+	It("returns all the events without a limit", func() {
+		reply := list(operations.ListEventsParams{Order: swag.String("descending")})
+		Expect(reply.Payload).To(HaveLen(3))
+		Expect(*reply.Payload[0].Message).To(Equal("third"))
+		Expect(reply.Payload[0].Code).To(Equal("test.event"))
+		Expect(reply.Payload[0].EntityKind).To(Equal(models.EventEntityKindCluster))
+		Expect(reply.XNextCursor).To(BeEmpty())
+	})
+
+	It("fails on an invalid cursor", func() {
+		reply := api.ListEvents(context.Background(), operations.ListEventsParams{ClusterID: clusterID, Cursor: swag.String("invalid")})
+		Expect(reply).To(BeAssignableToTypeOf(&common.ApiErrorResponse{}))
+		Expect(reply.(*common.ApiErrorResponse).StatusCode()).To(Equal(int32(http.StatusBadRequest)))
+	})
+})
+
+var _ = Describe("StreamEvents", func() {
+	var (
+		db        *gorm.DB
+		handler   *events.Events
+		api       *events.Api
+		server    *httptest.Server
+		dbName    = "events_stream_test"
+		clusterID = strfmt.UUID("8e1d9a2b-2b0c-4a8e-9b0e-5d1e0c4f7a21")
+		hostID    = strfmt.UUID("0b2f4c1e-6a1d-4f3e-8c5b-7e9d2a1c3b4f")
+	)
+
+	BeforeEach(func() {
+		db = common.PrepareTestDB(dbName, &events.Event{})
+		handler = events.New(db, logrus.WithField("pkg", "events"))
+		cfg := events.Config{
+			StreamPollInterval:      time.Second,
+			StreamKeepAliveInterval: time.Minute,
+		}
+		api = events.NewApi(cfg, handler, logrus.WithField("pkg", "events"))
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var reply middleware.Responder
+			lastEventID := swag.String(r.Header.Get("Last-Event-ID"))
+			if *lastEventID == "" {
+				lastEventID = nil
+			}
+			if r.URL.Path == "/cluster" {
+				reply = api.StreamClusterEvents(r.Context(), operations.StreamClusterEventsParams{ClusterID: clusterID, LastEventID: lastEventID})
+			} else {
+				reply = api.StreamEvents(r.Context(), operations.StreamEventsParams{LastEventID: lastEventID})
+			}
+			reply.WriteResponse(w, runtime.JSONProducer())
+		}))
+		handler.AddEvent(context.Background(), clusterID, nil, models.EventSeverityInfo, "registered", time.Now(), events.ClusterRegistered, nil)
+	})
+
+	AfterEach(func() {
+		server.Close()
+		common.DeleteTestDB(db, dbName)
+	})
+
+	type sse struct {
+		id, event string
+		data      models.Event
+	}
+
+	open := func(path, lastEventID string) (*http.Response, chan sse) {
+		req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ShouldNot(HaveOccurred())
+		received := make(chan sse, 10)
+		go func() {
+			defer GinkgoRecover()
+			var ev sse
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				line := scanner.Text()
+				switch {
+				case strings.HasPrefix(line, "id: "):
+					ev.id = strings.TrimPrefix(line, "id: ")
+				case strings.HasPrefix(line, "event: "):
+					ev.event = strings.TrimPrefix(line, "event: ")
+				case strings.HasPrefix(line, "data: "):
+					Expect(json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev.data)).ShouldNot(HaveOccurred())
+				case line == "":
+					received <- ev
+					ev = sse{}
+				}
+			}
+		}()
+		return resp, received
+	}
+
+	It("streams the history of the cluster and then the new events", func() {
+		resp, received := open("/cluster", "")
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+
+		var ev sse
+		Eventually(received).Should(Receive(&ev))
+		Expect(ev.event).To(Equal(events.ClusterRegistered))
+		Expect(*ev.data.Message).To(Equal("registered"))
+
+		handler.AddEvent(context.Background(), clusterID, &hostID, models.EventSeverityInfo, "host registered", time.Now(), events.HostRegistered, nil)
+		Eventually(received).Should(Receive(&ev))
+		Expect(ev.event).To(Equal(events.HostRegistered))
+		Expect(ev.data.HostID).To(Equal(hostID))
+	})
+
+	It("resumes after the last event ID", func() {
+		resp, received := open("/cluster", "")
+		var first sse
+		Eventually(received).Should(Receive(&first))
+		resp.Body.Close()
+
+		handler.AddEvent(context.Background(), clusterID, nil, models.EventSeverityInfo, "installing", time.Now(), events.ClusterInstallStarted, nil)
+		resp, received = open("/cluster", first.id)
+		defer resp.Body.Close()
+		var ev sse
+		Eventually(received).Should(Receive(&ev))
+		Expect(ev.event).To(Equal(events.ClusterInstallStarted))
+		Consistently(received, 100*time.Millisecond).ShouldNot(Receive())
+	})
+
+	It("streams only the events written after the request to the firehose", func() {
+		resp, received := open("/", "")
+		defer resp.Body.Close()
+		Consistently(received, 100*time.Millisecond).ShouldNot(Receive())
+
+		otherCluster := strfmt.UUID("f3a1c2d4-5e6f-4a7b-8c9d-0e1f2a3b4c5d")
+		handler.AddEvent(context.Background(), otherCluster, nil, models.EventSeverityInfo, "registered", time.Now(), events.ClusterRegistered, nil)
+		var ev sse
+		Eventually(received).Should(Receive(&ev))
+		Expect(*ev.data.ClusterID).To(Equal(otherCluster))
+	})
+
+	It("fails on an invalid last event ID", func() {
+		resp, _ := open("/cluster", "invalid")
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+})
@@ -0,0 +1,75 @@
+package events
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/pkg/errors"
+)
+
+// Query selects the events of a cluster, the zero value selects all of them ordered by their time
+type Query struct {
+	HostID     *strfmt.UUID
+	Severities []string
+	// Time range of the events, Until is exclusive
+	Since *strfmt.DateTime
+	Until *strfmt.DateTime
+	// Case insensitive text the message contains
+	Message    string
+	RequestID  *strfmt.UUID
+	Descending bool
+	// Return only the events that follow this one in the query order
+	After *Cursor
+	// Maximum number of events to return, 0 for all of them
+	Limit int
+}
+
+// Cursor is the position of an event in the order of a query, events with the same time are ordered by ID
+type Cursor struct {
+	EventTime strfmt.DateTime
+	ID        uint
+}
+
+func NewCursor(ev *Event) *Cursor {
+	c := &Cursor{ID: ev.ID}
+	if ev.EventTime != nil {
+		c.EventTime = *ev.EventTime
+	}
+	return c
+}
+
+// String encodes the cursor to be passed back by the client
+func (c *Cursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(utc(c.EventTime).String() + "," + strconv.FormatUint(uint64(c.ID), 10)))
+}
+
+func ParseCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.Errorf("invalid cursor %q", s)
+	}
+	parts := strings.Split(string(b), ",")
+	if len(parts) != 2 {
+		return nil, errors.Errorf("invalid cursor %q", s)
+	}
+	t, err := strfmt.ParseDateTime(parts[0])
+	if err != nil {
+		return nil, errors.Errorf("invalid cursor %q", s)
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return nil, errors.Errorf("invalid cursor %q", s)
+	}
+	return &Cursor{EventTime: t, ID: uint(id)}, nil
+}
+
+// Event times are stored in UTC, as text in databases without a time type, so the times they are compared
+// to must be in UTC as well
+func utc(t strfmt.DateTime) strfmt.DateTime {
+	return strfmt.DateTime(time.Time(t).UTC())
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
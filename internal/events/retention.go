@@ -0,0 +1,114 @@
+package events
+
+import (
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/openshift/assisted-service/pkg/leader"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type RetentionConfig struct {
+	Interval time.Duration `envconfig:"EVENT_RETENTION_INTERVAL" default:"1h"`
+	// Age of the events of each severity that they are deleted at, as severity:duration pairs like
+	// info:720h,warning:2160h, the events of the severities that are not listed are kept
+	MaxAge map[string]time.Duration `envconfig:"EVENT_RETENTION_MAX_AGE" default:""`
+	// Number of the events of each severity that are kept for each cluster, as severity:count pairs like
+	// info:1000, the newest events are kept
+	MaxCount map[string]int `envconfig:"EVENT_RETENTION_MAX_COUNT" default:""`
+}
+
+// The time an event was last seen at, since the collapsed events are as old as their last occurrence. The events
+// that were written before the occurrences were counted have no last_seen_at.
+const lastSeen = "coalesce(last_seen_at, event_time)"
+
+// Retention deletes the events that the retention policy doesn't keep
+type Retention struct {
+	cfg           RetentionConfig
+	db            *gorm.DB
+	log           logrus.FieldLogger
+	leaderElector leader.Leader
+}
+
+func NewRetention(cfg RetentionConfig, db *gorm.DB, log logrus.FieldLogger, leaderElector leader.Leader) *Retention {
+	return &Retention{
+		cfg:           cfg,
+		db:            db,
+		log:           log,
+		leaderElector: leaderElector,
+	}
+}
+
+func (r *Retention) RetentionTask() {
+	if !r.leaderElector.IsLeader() {
+		return
+	}
+	for severity, age := range r.cfg.MaxAge {
+		if err := r.deleteOlderThan(severity, age); err != nil {
+			r.log.WithError(err).Errorf("failed to delete the %s events older than %s", severity, age)
+		}
+	}
+	for severity, count := range r.cfg.MaxCount {
+		if err := r.deleteExceeding(severity, count); err != nil {
+			r.log.WithError(err).Errorf("failed to delete the %s events exceeding %d per cluster", severity, count)
+		}
+	}
+}
+
+func (r *Retention) deleteOlderThan(severity string, age time.Duration) error {
+	if age <= 0 {
+		return nil
+	}
+	before := utc(strfmt.DateTime(time.Now().Add(-age)))
+	var deleted int64
+	err := leader.InTransaction(r.db, r.leaderElector, func(tx *gorm.DB) error {
+		reply := tx.Unscoped().Where("severity = ? and "+lastSeen+" < ?", severity, before).Delete(&Event{})
+		deleted = reply.RowsAffected
+		return reply.Error
+	})
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		r.log.Infof("Deleted %d %s events older than %s", deleted, severity, age)
+	}
+	return nil
+}
+
+func (r *Retention) deleteExceeding(severity string, count int) error {
+	if count <= 0 {
+		return nil
+	}
+	var clusterIDs []string
+	if err := r.db.Model(&Event{}).Where("severity = ?", severity).Group("cluster_id").
+		Having("count(*) > ?", count).Pluck("cluster_id", &clusterIDs).Error; err != nil {
+		return err
+	}
+	for _, clusterID := range clusterIDs {
+		// the oldest event that is kept, the events that precede it are deleted
+		var oldest Event
+		if err := r.db.Where("cluster_id = ? and severity = ?", clusterID, severity).
+			Order(lastSeen + " desc, id desc").Offset(count - 1).Take(&oldest).Error; err != nil {
+			return err
+		}
+		t := oldest.LastSeenAt
+		if swag.IsZero(t) {
+			t = *oldest.EventTime
+		}
+		t = utc(t)
+		var deleted int64
+		err := leader.InTransaction(r.db, r.leaderElector, func(tx *gorm.DB) error {
+			reply := tx.Unscoped().Where("cluster_id = ? and severity = ?", clusterID, severity).
+				Where(lastSeen+" < ? or ("+lastSeen+" = ? and id < ?)", t, t, oldest.ID).Delete(&Event{})
+			deleted = reply.RowsAffected
+			return reply.Error
+		})
+		if err != nil {
+			return err
+		}
+		r.log.Infof("Deleted %d %s events of cluster %s exceeding %d", deleted, severity, clusterID, count)
+	}
+	return nil
+}
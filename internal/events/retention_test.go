@@ -0,0 +1,87 @@
+package events_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/internal/events"
+	"github.com/openshift/assisted-service/models"
+	"github.com/openshift/assisted-service/pkg/leader"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("Event retention", func() {
+	var (
+		db        *gorm.DB
+		handler   *events.Events
+		dbName    = "events_retention_test"
+		cluster1  = strfmt.UUID("46a8d745-dfce-4fd8-9df0-549ee8eabb3d")
+		cluster2  = strfmt.UUID("60415d9c-7c44-4978-89f5-53d510b03a47")
+		log       = logrus.WithField("pkg", "events")
+		start     = time.Now().Add(-10 * time.Hour)
+		addEvents = func(clusterID strfmt.UUID, severity string, n int) {
+			for i := 0; i < n; i++ {
+				handler.AddEvent(context.Background(), clusterID, nil, severity, fmt.Sprintf("%s event %d", severity, i),
+					start.Add(time.Duration(i)*time.Hour), "test.event", nil)
+			}
+		}
+		messages = func(clusterID strfmt.UUID, severity string) []string {
+			evs, err := handler.QueryEvents(clusterID, &events.Query{Severities: []string{severity}})
+			Expect(err).ShouldNot(HaveOccurred())
+			ret := make([]string, len(evs))
+			for i, ev := range evs {
+				ret[i] = *ev.Message
+			}
+			return ret
+		}
+	)
+
+	BeforeEach(func() {
+		db = common.PrepareTestDB(dbName, &events.Event{})
+		handler = events.New(db, log)
+		addEvents(cluster1, models.EventSeverityInfo, 5)
+		addEvents(cluster1, models.EventSeverityError, 5)
+		addEvents(cluster2, models.EventSeverityInfo, 2)
+	})
+
+	AfterEach(func() {
+		common.DeleteTestDB(db, dbName)
+	})
+
+	It("deletes the events older than the max age of their severity", func() {
+		cfg := events.RetentionConfig{MaxAge: map[string]time.Duration{models.EventSeverityInfo: 8 * time.Hour}}
+		events.NewRetention(cfg, db, log, &leader.DummyElector{}).RetentionTask()
+
+		Expect(messages(cluster1, models.EventSeverityInfo)).To(Equal([]string{"info event 3", "info event 4"}))
+		Expect(messages(cluster1, models.EventSeverityError)).To(HaveLen(5))
+		Expect(messages(cluster2, models.EventSeverityInfo)).To(BeEmpty())
+	})
+
+	It("keeps the newest events of each cluster up to the max count of their severity", func() {
+		cfg := events.RetentionConfig{MaxCount: map[string]int{models.EventSeverityInfo: 2}}
+		events.NewRetention(cfg, db, log, &leader.DummyElector{}).RetentionTask()
+
+		Expect(messages(cluster1, models.EventSeverityInfo)).To(Equal([]string{"info event 3", "info event 4"}))
+		Expect(messages(cluster1, models.EventSeverityError)).To(HaveLen(5))
+		Expect(messages(cluster2, models.EventSeverityInfo)).To(HaveLen(2))
+	})
+
+	It("ages and orders the events by their last occurrence", func() {
+		handler.AddEvent(context.Background(), cluster2, nil, models.EventSeverityInfo, "info event 1", time.Now(), "test.event", nil)
+		cfg := events.RetentionConfig{
+			MaxAge:   map[string]time.Duration{models.EventSeverityInfo: 8 * time.Hour},
+			MaxCount: map[string]int{models.EventSeverityError: 1},
+		}
+		handler.AddEvent(context.Background(), cluster1, nil, models.EventSeverityError, "error event 4", time.Now(), "test.event", nil)
+		events.NewRetention(cfg, db, log, &leader.DummyElector{}).RetentionTask()
+
+		Expect(messages(cluster2, models.EventSeverityInfo)).To(Equal([]string{"info event 1"}))
+		Expect(messages(cluster1, models.EventSeverityError)).To(Equal([]string{"error event 4"}))
+	})
+})
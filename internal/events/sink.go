@@ -0,0 +1,142 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+type SinksConfig struct {
+	// Number of events each sink holds while it is busy, events that don't fit are dropped
+	BufferSize int `envconfig:"EVENT_SINK_BUFFER_SIZE" default:"1000"`
+	// Timeout of sending a single event
+	Timeout time.Duration `envconfig:"EVENT_SINK_TIMEOUT" default:"10s"`
+	// URL that events are posted to as CloudEvents, disabled when empty
+	CloudEventsURL string `envconfig:"EVENT_SINK_CLOUDEVENTS_URL" default:""`
+	// Source attribute of the CloudEvents
+	CloudEventsSource string `envconfig:"EVENT_SINK_CLOUDEVENTS_SOURCE" default:"assisted-service"`
+	// Syslog server that events are sent to as RFC5424 messages, as udp://host:port or tcp://host:port,
+	// disabled when empty
+	SyslogAddress string `envconfig:"EVENT_SINK_SYSLOG_ADDRESS" default:""`
+	// APP-NAME of the syslog messages
+	SyslogAppName string `envconfig:"EVENT_SINK_SYSLOG_APP_NAME" default:"assisted-service"`
+	// File that events are appended to as JSON lines, disabled when empty
+	FilePath string `envconfig:"EVENT_SINK_FILE_PATH" default:""`
+	// Size in bytes that the file is rotated at
+	FileMaxSize int64 `envconfig:"EVENT_SINK_FILE_MAX_SIZE" default:"104857600"`
+	// Number of rotated files that are kept
+	FileMaxBackups int `envconfig:"EVENT_SINK_FILE_MAX_BACKUPS" default:"5"`
+}
+
+// Sink receives the events that this service writes, after they are committed to the database
+type Sink interface {
+	// Name identifies the sink in logs
+	Name() string
+	Send(ev *Event) error
+	Close() error
+}
+
+// NewSinks returns the sinks that are enabled by the configuration, they buffer the events so sending to them
+// never blocks
+func NewSinks(cfg SinksConfig, log logrus.FieldLogger) ([]Sink, error) {
+	var sinks []Sink
+	if cfg.CloudEventsURL != "" {
+		s, err := newCloudEventsSink(cfg)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+	if cfg.SyslogAddress != "" {
+		s, err := newSyslogSink(cfg)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+	if cfg.FilePath != "" {
+		s, err := newFileSink(cfg)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+	for i, s := range sinks {
+		log.Infof("Sending events to the %s sink", s.Name())
+		sinks[i] = newBufferedSink(s, cfg.BufferSize, log)
+	}
+	return sinks, nil
+}
+
+// bufferedSink sends the events to its sink from a goroutine, so a slow sink delays neither the writer of the
+// event nor the other sinks
+type bufferedSink struct {
+	sink Sink
+	log  logrus.FieldLogger
+	ch   chan *Event
+	done chan struct{}
+
+	mu      sync.Mutex
+	closed  bool
+	dropped int
+}
+
+func newBufferedSink(sink Sink, bufferSize int, log logrus.FieldLogger) *bufferedSink {
+	b := &bufferedSink{
+		sink: sink,
+		log:  log.WithField("sink", sink.Name()),
+		ch:   make(chan *Event, bufferSize),
+		done: make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+func (b *bufferedSink) run() {
+	defer close(b.done)
+	for ev := range b.ch {
+		if err := b.sink.Send(ev); err != nil {
+			b.log.WithError(err).Warnf("Failed to send event %d", ev.ID)
+		}
+	}
+}
+
+func (b *bufferedSink) Name() string {
+	return b.sink.Name()
+}
+
+// Send queues the event, it drops the event when the buffer is full
+func (b *bufferedSink) Send(ev *Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return errors.Errorf("%s sink is closed", b.sink.Name())
+	}
+	select {
+	case b.ch <- ev:
+		if b.dropped > 0 {
+			b.log.Warnf("Dropped %d events while the sink was busy", b.dropped)
+			b.dropped = 0
+		}
+	default:
+		if b.dropped == 0 {
+			b.log.Warn("The sink is busy, dropping events")
+		}
+		b.dropped++
+	}
+	return nil
+}
+
+// Close sends the buffered events and closes the sink
+func (b *bufferedSink) Close() error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.ch)
+	}
+	b.mu.Unlock()
+	<-b.done
+	return b.sink.Close()
+}
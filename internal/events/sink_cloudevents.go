@@ -0,0 +1,109 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/openshift/assisted-service/models"
+	"github.com/pkg/errors"
+)
+
+const (
+	cloudEventsContentType = "application/cloudevents+json"
+	cloudEventsSpecVersion = "1.0"
+	// the type of a CloudEvent is this prefix followed by the code of the event
+	cloudEventsTypePrefix = "com.redhat.assisted-service."
+)
+
+// cloudEvent is the structured mode JSON representation of a CloudEvent
+type cloudEvent struct {
+	SpecVersion     string        `json:"specversion"`
+	ID              string        `json:"id"`
+	Source          string        `json:"source"`
+	Type            string        `json:"type"`
+	Subject         string        `json:"subject,omitempty"`
+	Time            string        `json:"time,omitempty"`
+	DataContentType string        `json:"datacontenttype"`
+	Data            *models.Event `json:"data"`
+}
+
+// cloudEventsSink posts the events in the CloudEvents HTTP structured content mode
+type cloudEventsSink struct {
+	url    string
+	source string
+	client *http.Client
+}
+
+func newCloudEventsSink(cfg SinksConfig) (*cloudEventsSink, error) {
+	u, err := url.Parse(cfg.CloudEventsURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.Errorf("CloudEvents sink URL %s is not an HTTP URL", cfg.CloudEventsURL)
+	}
+	return &cloudEventsSink{
+		url:    cfg.CloudEventsURL,
+		source: cfg.CloudEventsSource,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+func (s *cloudEventsSink) Name() string {
+	return "CloudEvents"
+}
+
+func toCloudEvent(ev *Event, source string) *cloudEvent {
+	code := ev.Code
+	if code == "" {
+		code = "event"
+	}
+	ce := &cloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              strconv.FormatUint(uint64(ev.ID), 10),
+		Source:          source,
+		Type:            cloudEventsTypePrefix + code,
+		DataContentType: "application/json",
+		Data:            toAPIEvent(ev),
+	}
+	if ev.ClusterID != nil {
+		ce.Subject = fmt.Sprintf("clusters/%s", *ev.ClusterID)
+		if ev.HostID != "" {
+			ce.Subject += fmt.Sprintf("/hosts/%s", ev.HostID)
+		}
+	}
+	if ev.EventTime != nil {
+		ce.Time = utc(*ev.EventTime).String()
+	}
+	return ce
+}
+
+func (s *cloudEventsSink) Send(ev *Event) error {
+	body, err := json.Marshal(toCloudEvent(ev, s.source))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", cloudEventsContentType)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("CloudEvents endpoint replied %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *cloudEventsSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,116 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// fileSink appends the events as JSON lines to a file that it rotates by size, the rotated files are named by
+// appending .1 to .N to the path of the file, .1 being the most recent
+type fileSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	file *os.File
+	size int64
+}
+
+func newFileSink(cfg SinksConfig) (*fileSink, error) {
+	if cfg.FileMaxSize <= 0 {
+		return nil, errors.Errorf("invalid event sink file size %d", cfg.FileMaxSize)
+	}
+	s := &fileSink{
+		path:       cfg.FilePath,
+		maxSize:    cfg.FileMaxSize,
+		maxBackups: cfg.FileMaxBackups,
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create the directory of event sink file %s", s.path)
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *fileSink) Name() string {
+	return "file"
+}
+
+func (s *fileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open event sink file %s", s.path)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return errors.Wrapf(err, "failed to stat event sink file %s", s.path)
+	}
+	s.file = f
+	s.size = info.Size()
+	return nil
+}
+
+func (s *fileSink) backup(i int) string {
+	return fmt.Sprintf("%s.%d", s.path, i)
+}
+
+func (s *fileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	s.file = nil
+	if s.maxBackups > 0 {
+		if err := os.Remove(s.backup(s.maxBackups)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for i := s.maxBackups - 1; i > 0; i-- {
+			if err := os.Rename(s.backup(i), s.backup(i+1)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(s.path, s.backup(1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(s.path); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+func (s *fileSink) Send(ev *Event) error {
+	line, err := json.Marshal(toAPIEvent(ev))
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	if s.file == nil {
+		// a previous rotation failed to open the new file
+		if err = s.open(); err != nil {
+			return err
+		}
+	}
+	if s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		if err = s.rotate(); err != nil {
+			return errors.Wrapf(err, "failed to rotate event sink file %s", s.path)
+		}
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	return err
+}
+
+func (s *fileSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
@@ -0,0 +1,137 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/swag"
+	"github.com/openshift/assisted-service/models"
+	"github.com/pkg/errors"
+)
+
+const (
+	// local0
+	syslogFacility = 16
+	// length limits of the HOSTNAME and MSGID fields of RFC5424
+	syslogMaxHostname = 255
+	syslogMaxMsgID    = 32
+	// RFC5424 timestamps have at most 6 digits of fractions of seconds
+	syslogTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+var syslogSeverities = map[string]int{
+	models.EventSeverityCritical: 2,
+	models.EventSeverityError:    3,
+	models.EventSeverityWarning:  4,
+	models.EventSeverityInfo:     6,
+}
+
+// syslogSink sends the events as RFC5424 messages whose MSG is the JSON of the event, over UDP or over TCP with
+// the octet counting framing of RFC6587
+type syslogSink struct {
+	network  string
+	address  string
+	appName  string
+	hostname string
+	timeout  time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func newSyslogSink(cfg SinksConfig) (*syslogSink, error) {
+	u, err := url.Parse(cfg.SyslogAddress)
+	if err != nil || (u.Scheme != "udp" && u.Scheme != "tcp") || u.Host == "" {
+		return nil, errors.Errorf("syslog sink address %s is not a udp:// or tcp:// address", cfg.SyslogAddress)
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	if len(hostname) > syslogMaxHostname {
+		hostname = hostname[:syslogMaxHostname]
+	}
+	return &syslogSink{
+		network:  u.Scheme,
+		address:  u.Host,
+		appName:  cfg.SyslogAppName,
+		hostname: hostname,
+		timeout:  cfg.Timeout,
+	}, nil
+}
+
+func (s *syslogSink) Name() string {
+	return "syslog"
+}
+
+func (s *syslogSink) format(ev *Event) ([]byte, error) {
+	data, err := json.Marshal(toAPIEvent(ev))
+	if err != nil {
+		return nil, err
+	}
+	severity, ok := syslogSeverities[swag.StringValue(ev.Severity)]
+	if !ok {
+		severity = syslogSeverities[models.EventSeverityInfo]
+	}
+	timestamp := "-"
+	if ev.EventTime != nil {
+		timestamp = time.Time(*ev.EventTime).UTC().Format(syslogTimeFormat)
+	}
+	msgID := ev.Code
+	if msgID == "" {
+		msgID = "-"
+	} else if len(msgID) > syslogMaxMsgID {
+		msgID = msgID[:syslogMaxMsgID]
+	}
+	msg := fmt.Sprintf("<%d>1 %s %s %s %d %s - %s",
+		syslogFacility*8+severity, timestamp, s.hostname, s.appName, os.Getpid(), msgID, data)
+	if s.network == "tcp" {
+		msg = fmt.Sprintf("%d %s", len(msg), msg)
+	}
+	return []byte(msg), nil
+}
+
+func (s *syslogSink) Send(ev *Event) error {
+	msg, err := s.format(ev)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// a TCP connection that the server closed fails on the next write, so the message is retried once on a new one
+	for i := 0; ; i++ {
+		if s.conn == nil {
+			if s.conn, err = net.DialTimeout(s.network, s.address, s.timeout); err != nil {
+				s.conn = nil
+				return err
+			}
+		}
+		if err = s.conn.SetWriteDeadline(time.Now().Add(s.timeout)); err == nil {
+			_, err = s.conn.Write(msg)
+		}
+		if err == nil {
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+		if i > 0 {
+			return err
+		}
+	}
+}
+
+func (s *syslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
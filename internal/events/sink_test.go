@@ -0,0 +1,207 @@
+package events_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/internal/events"
+	"github.com/openshift/assisted-service/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("Event sinks", func() {
+	var (
+		db        *gorm.DB
+		dbName    = "events_sink_test"
+		clusterID = strfmt.UUID("46a8d745-dfce-4fd8-9df0-549ee8eabb3d")
+		hostID    = strfmt.UUID("1e45d128-4a69-4e71-9b50-a0c627217f3e")
+		log       = logrus.WithField("pkg", "events")
+		cfg       events.SinksConfig
+	)
+
+	BeforeEach(func() {
+		db = common.PrepareTestDB(dbName, &events.Event{})
+		cfg = events.SinksConfig{
+			BufferSize:        10,
+			Timeout:           time.Second,
+			CloudEventsSource: "test-service",
+			SyslogAppName:     "test-service",
+			FileMaxSize:       1024 * 1024,
+			FileMaxBackups:    2,
+		}
+	})
+
+	AfterEach(func() {
+		common.DeleteTestDB(db, dbName)
+	})
+
+	// addEvent writes an event through a handler with the sinks of cfg and closes the sinks, which sends the
+	// buffered events
+	addEvent := func(msg string) {
+		sinks, err := events.NewSinks(cfg, log)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(sinks).To(HaveLen(1))
+		handler := events.New(db, log, sinks...)
+		handler.AddEvent(context.Background(), clusterID, &hostID, models.EventSeverityWarning, msg, time.Now(),
+			events.HostStatusChanged, map[string]interface{}{"to": models.HostStatusInsufficient})
+		handler.Close()
+	}
+
+	It("has no sinks by default", func() {
+		sinks, err := events.NewSinks(cfg, log)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(sinks).To(BeEmpty())
+	})
+
+	It("fails on invalid addresses", func() {
+		cfg.CloudEventsURL = "ftp://example.com"
+		_, err := events.NewSinks(cfg, log)
+		Expect(err).Should(HaveOccurred())
+
+		cfg.CloudEventsURL = ""
+		cfg.SyslogAddress = "example.com:514"
+		_, err = events.NewSinks(cfg, log)
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("posts CloudEvents", func() {
+		var contentType string
+		var body map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			contentType = r.Header.Get("Content-Type")
+			Expect(json.NewDecoder(r.Body).Decode(&body)).ShouldNot(HaveOccurred())
+		}))
+		defer server.Close()
+		cfg.CloudEventsURL = server.URL
+
+		addEvent("the event")
+
+		Expect(contentType).To(Equal("application/cloudevents+json"))
+		Expect(body["specversion"]).To(Equal("1.0"))
+		Expect(body["source"]).To(Equal("test-service"))
+		Expect(body["type"]).To(Equal("com.redhat.assisted-service.host.status_changed"))
+		Expect(body["subject"]).To(Equal("clusters/" + clusterID.String() + "/hosts/" + hostID.String()))
+		Expect(body["id"]).NotTo(BeEmpty())
+		data := body["data"].(map[string]interface{})
+		Expect(data["message"]).To(Equal("the event"))
+		Expect(data["severity"]).To(Equal(models.EventSeverityWarning))
+	})
+
+	It("sends RFC5424 syslog messages over UDP", func() {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		Expect(err).ShouldNot(HaveOccurred())
+		defer conn.Close()
+		cfg.SyslogAddress = "udp://" + conn.LocalAddr().String()
+
+		addEvent("the event")
+
+		buf := make([]byte, 4096)
+		Expect(conn.SetReadDeadline(time.Now().Add(5 * time.Second))).ShouldNot(HaveOccurred())
+		n, _, err := conn.ReadFrom(buf)
+		Expect(err).ShouldNot(HaveOccurred())
+		fields := strings.SplitN(string(buf[:n]), " ", 8)
+		// local0.warning
+		Expect(fields[0]).To(Equal("<132>1"))
+		Expect(fields[1]).To(MatchRegexp(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{6}Z$`))
+		Expect(fields[3]).To(Equal("test-service"))
+		Expect(fields[5]).To(Equal(events.HostStatusChanged))
+		Expect(fields[6]).To(Equal("-"))
+		var ev models.Event
+		Expect(json.Unmarshal([]byte(fields[7]), &ev)).ShouldNot(HaveOccurred())
+		Expect(*ev.Message).To(Equal("the event"))
+	})
+
+	It("sends octet counted syslog messages over TCP", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ShouldNot(HaveOccurred())
+		defer listener.Close()
+		cfg.SyslogAddress = "tcp://" + listener.Addr().String()
+		received := make(chan string, 1)
+		go func() {
+			defer GinkgoRecover()
+			conn, err := listener.Accept()
+			Expect(err).ShouldNot(HaveOccurred())
+			defer conn.Close()
+			r := bufio.NewReader(conn)
+			length, err := r.ReadString(' ')
+			Expect(err).ShouldNot(HaveOccurred())
+			n, err := strconv.Atoi(strings.TrimSpace(length))
+			Expect(err).ShouldNot(HaveOccurred())
+			msg := make([]byte, n)
+			_, err = io.ReadFull(r, msg)
+			Expect(err).ShouldNot(HaveOccurred())
+			received <- string(msg)
+		}()
+
+		addEvent("the event")
+
+		Eventually(received, 5*time.Second).Should(Receive(And(HavePrefix("<132>1 "), HaveSuffix("}"))))
+	})
+
+	It("appends JSON lines to a file that it rotates", func() {
+		dir, err := ioutil.TempDir("", "events")
+		Expect(err).ShouldNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+		cfg.FilePath = filepath.Join(dir, "events.log")
+		cfg.FileMaxSize = 400
+
+		for _, msg := range []string{"first", "second", "third", "fourth"} {
+			addEvent(msg)
+		}
+
+		lines := func(path string) []string {
+			b, err := ioutil.ReadFile(path)
+			Expect(err).ShouldNot(HaveOccurred())
+			return strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+		}
+		message := func(line string) string {
+			var ev models.Event
+			Expect(json.Unmarshal([]byte(line), &ev)).ShouldNot(HaveOccurred())
+			return *ev.Message
+		}
+		// each event takes more than half of the file size
+		Expect(message(lines(cfg.FilePath)[0])).To(Equal("fourth"))
+		Expect(message(lines(cfg.FilePath + ".1")[0])).To(Equal("third"))
+		Expect(message(lines(cfg.FilePath + ".2")[0])).To(Equal("second"))
+		_, err = os.Stat(cfg.FilePath + ".3")
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("sends the repeats of an event with their occurrences", func() {
+		dir, err := ioutil.TempDir("", "events")
+		Expect(err).ShouldNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+		cfg.FilePath = filepath.Join(dir, "events.log")
+
+		addEvent("flapping")
+		addEvent("flapping")
+
+		b, err := ioutil.ReadFile(cfg.FilePath)
+		Expect(err).ShouldNot(HaveOccurred())
+		lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+		Expect(lines).To(HaveLen(2))
+		var first, repeat models.Event
+		Expect(json.Unmarshal([]byte(lines[0]), &first)).ShouldNot(HaveOccurred())
+		Expect(json.Unmarshal([]byte(lines[1]), &repeat)).ShouldNot(HaveOccurred())
+		Expect(first.Occurrences).To(Equal(int64(1)))
+		Expect(repeat.Occurrences).To(Equal(int64(2)))
+		Expect(*repeat.Message).To(Equal("flapping"))
+		Expect(time.Time(*repeat.EventTime)).To(BeTemporally("==", time.Time(*first.EventTime)))
+	})
+})
@@ -0,0 +1,160 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/strfmt"
+	"github.com/openshift/assisted-service/internal/common"
+	logutil "github.com/openshift/assisted-service/pkg/log"
+	"github.com/pkg/errors"
+)
+
+type Config struct {
+	// Interval of checking for events that other replicas of the service wrote
+	StreamPollInterval time.Duration `envconfig:"EVENT_STREAM_POLL_INTERVAL" default:"2s"`
+	// Interval of the comments that keep idle streams open through proxies
+	StreamKeepAliveInterval time.Duration `envconfig:"EVENT_STREAM_KEEPALIVE_INTERVAL" default:"15s"`
+	// Events are streamed only once they are older than this delay, so an event that is committed after an
+	// event with a greater ID is not skipped
+	StreamSettleDelay time.Duration `envconfig:"EVENT_STREAM_SETTLE_DELAY" default:"1s"`
+}
+
+// Number of events read from the database at once while streaming
+const streamBatchSize = 100
+
+// broadcast wakes all its waiters at once by closing the channel they wait on
+type broadcast struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+func newBroadcast() *broadcast {
+	return &broadcast{ch: make(chan struct{})}
+}
+
+func (b *broadcast) wait() <-chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.ch
+}
+
+func (b *broadcast) notify() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	close(b.ch)
+	b.ch = make(chan struct{})
+}
+
+func parseLastEventID(lastEventID *string) (uint, error) {
+	if lastEventID == nil {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(*lastEventID, 10, 64)
+	if err != nil {
+		return 0, errors.Errorf("invalid Last-Event-ID %q", *lastEventID)
+	}
+	return uint(id), nil
+}
+
+// streamError writes the error as JSON since the text/event-stream producer can't write it
+func streamError(statusCode int32, err error) middleware.Responder {
+	return middleware.ResponderFunc(func(rw http.ResponseWriter, _ runtime.Producer) {
+		rw.Header().Set(runtime.HeaderContentType, runtime.JSONMime)
+		common.NewApiError(statusCode, err).WriteResponse(rw, runtime.JSONProducer())
+	})
+}
+
+// stream writes the events that follow lastEventID as Server-Sent Events until the client disconnects
+func (a *Api) stream(ctx context.Context, clusterID *strfmt.UUID, hostID *strfmt.UUID, lastEventID uint) middleware.Responder {
+	log := logutil.FromContext(ctx, a.log)
+	return middleware.ResponderFunc(func(rw http.ResponseWriter, _ runtime.Producer) {
+		flush := func() {
+			if f, ok := rw.(http.Flusher); ok {
+				f.Flush()
+			}
+		}
+		rw.Header().Set(runtime.HeaderContentType, "text/event-stream")
+		rw.Header().Set("Cache-Control", "no-cache")
+		// disables the buffering of nginx based proxies
+		rw.Header().Set("X-Accel-Buffering", "no")
+		rw.WriteHeader(http.StatusOK)
+		flush()
+
+		keepAlive := time.NewTicker(a.cfg.StreamKeepAliveInterval)
+		defer keepAlive.Stop()
+		for {
+			// taken before reading the events so an event written meanwhile wakes the stream
+			written := a.handler.Written()
+			wait, err := a.writeEvents(rw, clusterID, hostID, &lastEventID)
+			if err != nil {
+				log.WithError(err).Warn("Stopped streaming events")
+				return
+			}
+			flush()
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-written:
+			case <-timer.C:
+			case <-keepAlive.C:
+				if _, err = io.WriteString(rw, ": keepalive\n\n"); err != nil {
+					timer.Stop()
+					return
+				}
+			}
+			timer.Stop()
+		}
+	})
+}
+
+// writeEvents writes the settled events that follow lastEventID and advances it, it returns the time to wait
+// before checking for events again
+func (a *Api) writeEvents(w io.Writer, clusterID *strfmt.UUID, hostID *strfmt.UUID, lastEventID *uint) (time.Duration, error) {
+	for {
+		evs, err := a.handler.GetEventsAfter(clusterID, hostID, *lastEventID, streamBatchSize)
+		if err != nil {
+			return 0, err
+		}
+		settled := time.Now().Add(-a.cfg.StreamSettleDelay)
+		for _, ev := range evs {
+			if ev.CreatedAt.After(settled) {
+				if wait := ev.CreatedAt.Sub(settled); wait < a.cfg.StreamPollInterval {
+					return wait, nil
+				}
+				return a.cfg.StreamPollInterval, nil
+			}
+			if err = writeEvent(w, ev); err != nil {
+				return 0, err
+			}
+			*lastEventID = ev.ID
+		}
+		if len(evs) < streamBatchSize {
+			return a.cfg.StreamPollInterval, nil
+		}
+	}
+}
+
+func writeEvent(w io.Writer, ev *Event) error {
+	data, err := json.Marshal(toAPIEvent(ev))
+	if err != nil {
+		return err
+	}
+	if ev.Code != "" {
+		_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Code, data)
+	} else {
+		_, err = fmt.Fprintf(w, "id: %d\ndata: %s\n\n", ev.ID, data)
+	}
+	return err
+}
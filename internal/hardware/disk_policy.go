@@ -0,0 +1,166 @@
+package hardware
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/units"
+	"github.com/go-openapi/swag"
+	"github.com/openshift/assisted-service/models"
+	"github.com/pkg/errors"
+	"github.com/thoas/go-funk"
+)
+
+const (
+	DriveTypeHDD  = "HDD"
+	DriveTypeSSD  = "SSD"
+	DriveTypeNVMe = "NVMe"
+
+	SizePreferenceSmallest = "smallest"
+	SizePreferenceLargest  = "largest"
+)
+
+// The order of the drive types of the policies that don't set them, non-NVMe disks first and HDD before SSD
+var defaultDriveTypes = []string{DriveTypeHDD, DriveTypeSSD, DriveTypeNVMe}
+
+// DiskVerdict tells whether a disk can be the installation disk of its host and why not
+type DiskVerdict struct {
+	Disk *models.Disk
+	// Reason is why the disk was rejected, empty if it was accepted
+	Reason string
+}
+
+func (d DiskVerdict) Accepted() bool {
+	return d.Reason == ""
+}
+
+// ParseDiskSelectionPolicy returns the policy stored in a cluster, the empty policy if it has none
+func ParseDiskSelectionPolicy(policy string) (*models.DiskSelectionPolicy, error) {
+	var p models.DiskSelectionPolicy
+	if policy == "" {
+		return &p, nil
+	}
+	if err := json.Unmarshal([]byte(policy), &p); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the disk selection policy")
+	}
+	return &p, nil
+}
+
+// ValidateDiskSelectionPolicy checks the rules that the swagger validation of the policy doesn't
+func ValidateDiskSelectionPolicy(policy *models.DiskSelectionPolicy) error {
+	for _, pattern := range policy.ByPathPatterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return errors.Wrapf(err, "invalid by-path pattern %s", pattern)
+		}
+	}
+	return nil
+}
+
+// driveType is the drive type of the disk for the policies, NVMe disks report a type of HDD or SSD
+func driveType(disk *models.Disk) string {
+	if isNvme(disk.Name) {
+		return DriveTypeNVMe
+	}
+	return disk.DriveType
+}
+
+func driveTypes(policy *models.DiskSelectionPolicy) []string {
+	if len(policy.DriveTypes) == 0 {
+		return defaultDriveTypes
+	}
+	return policy.DriveTypes
+}
+
+// EvaluateDisks applies the policy to the disks. It returns the accepted disks, the most preferred first, and the
+// verdicts of all the disks in the order of the inventory.
+func EvaluateDisks(disks []*models.Disk, policy *models.DiskSelectionPolicy, minSizeRequiredInBytes int64) ([]*models.Disk, []DiskVerdict) {
+	if minSize := swag.Int64Value(policy.MinSizeGib) * int64(units.GiB); minSize > minSizeRequiredInBytes {
+		minSizeRequiredInBytes = minSize
+	}
+	var accepted []*models.Disk
+	verdicts := make([]DiskVerdict, 0, len(disks))
+	for _, disk := range disks {
+		reason := rejectionReason(disk, policy, minSizeRequiredInBytes)
+		verdicts = append(verdicts, DiskVerdict{Disk: disk, Reason: reason})
+		if reason == "" {
+			accepted = append(accepted, disk)
+		}
+	}
+
+	types := driveTypes(policy)
+	sort.SliceStable(accepted, func(i, j int) bool {
+		rank1 := funk.IndexOfString(types, driveType(accepted[i]))
+		rank2 := funk.IndexOfString(types, driveType(accepted[j]))
+		if rank1 != rank2 {
+			return rank1 < rank2
+		}
+		if policy.SizePreference == SizePreferenceLargest {
+			return accepted[i].SizeBytes > accepted[j].SizeBytes
+		}
+		return accepted[i].SizeBytes < accepted[j].SizeBytes
+	})
+	return accepted, verdicts
+}
+
+// rejectionReason returns why the disk can't be the installation disk, the requirements of any installation disk
+// are checked before the rules of the policy
+func rejectionReason(disk *models.Disk, policy *models.DiskSelectionPolicy, minSizeRequiredInBytes int64) string {
+	if reason := requirementsRejectionReason(disk, minSizeRequiredInBytes); reason != "" {
+		return reason
+	}
+	if !funk.ContainsString(driveTypes(policy), driveType(disk)) {
+		return fmt.Sprintf("drive type %s isn't allowed by the disk selection policy", driveType(disk))
+	}
+	if policy.ExcludeRemovable && (disk.Removable || strings.Contains(disk.ByPath, "-usb-")) {
+		return "removable disks aren't allowed by the disk selection policy"
+	}
+	if len(policy.ByPathPatterns) > 0 && !matchesByPath(disk, policy.ByPathPatterns) {
+		if disk.ByPath == "" {
+			return "the disk selection policy requires a by-path link"
+		}
+		return fmt.Sprintf("by-path link %s doesn't match the disk selection policy", disk.ByPath)
+	}
+	return ""
+}
+
+func requirementsRejectionReason(disk *models.Disk, minSizeRequiredInBytes int64) string {
+	if !funk.ContainsString([]string{DriveTypeHDD, DriveTypeSSD}, disk.DriveType) {
+		return fmt.Sprintf("drive type %s isn't supported", disk.DriveType)
+	}
+	if disk.SizeBytes < minSizeRequiredInBytes {
+		return fmt.Sprintf("size of %d GiB is below the required %d GiB", disk.SizeBytes/int64(units.GiB),
+			minSizeRequiredInBytes/int64(units.GiB))
+	}
+	return ""
+}
+
+func matchesByPath(disk *models.Disk, patterns []string) bool {
+	if disk.ByPath == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		name := disk.ByPath
+		if !strings.Contains(pattern, "/") {
+			name = filepath.Base(disk.ByPath)
+		}
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// DiskLabel names the disk in messages
+func DiskLabel(disk *models.Disk) string {
+	switch {
+	case disk.Name != "":
+		return disk.Name
+	case disk.Path != "":
+		return disk.Path
+	default:
+		return "unnamed disk"
+	}
+}
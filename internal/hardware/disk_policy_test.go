@@ -0,0 +1,151 @@
+package hardware
+
+import (
+	"encoding/json"
+
+	"github.com/alecthomas/units"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/google/uuid"
+	"github.com/kelseyhightower/envconfig"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/models"
+	"github.com/sirupsen/logrus"
+)
+
+var _ = Describe("disk selection policy", func() {
+	var (
+		disks    []*models.Disk
+		minBytes = int64(120 * units.GiB)
+	)
+
+	BeforeEach(func() {
+		disks = []*models.Disk{
+			{Name: "sr0", DriveType: "ODD", SizeBytes: int64(500 * units.GB)},
+			{Name: "sda", DriveType: "HDD", SizeBytes: int64(1000 * units.GB), ByPath: "/dev/disk/by-path/pci-0000:00:1f.2-ata-1"},
+			{Name: "sdb", DriveType: "SSD", SizeBytes: int64(250 * units.GB), ByPath: "/dev/disk/by-path/pci-0000:3b:00.0-scsi-0:0:1:0"},
+			{Name: "sdc", DriveType: "SSD", SizeBytes: int64(480 * units.GB), ByPath: "/dev/disk/by-path/pci-0000:3b:00.0-scsi-0:0:2:0"},
+			{Name: "sdd", DriveType: "SSD", SizeBytes: int64(240 * units.GB), ByPath: "/dev/disk/by-path/pci-0000:00:14.0-usb-0:1:1.0-scsi-0:0:0:0"},
+			{Name: "sde", DriveType: "HDD", SizeBytes: int64(2000 * units.GB), Removable: true},
+			{Name: "nvme0n1", DriveType: "SSD", SizeBytes: int64(800 * units.GB), ByPath: "/dev/disk/by-path/pci-0000:5e:00.0-nvme-1"},
+			{Name: "sdf", DriveType: "HDD", SizeBytes: int64(64 * units.GB)},
+		}
+	})
+
+	names := func(disks []*models.Disk) []string {
+		var ret []string
+		for _, disk := range disks {
+			ret = append(ret, disk.Name)
+		}
+		return ret
+	}
+
+	reasons := func(verdicts []DiskVerdict) map[string]string {
+		ret := make(map[string]string)
+		for _, verdict := range verdicts {
+			ret[verdict.Disk.Name] = verdict.Reason
+		}
+		return ret
+	}
+
+	It("prefers HDD then SSD then NVMe and the smallest disks by default", func() {
+		accepted, verdicts := EvaluateDisks(disks, &models.DiskSelectionPolicy{}, minBytes)
+		Expect(names(accepted)).To(Equal([]string{"sda", "sde", "sdd", "sdb", "sdc", "nvme0n1"}))
+		Expect(verdicts).To(HaveLen(len(disks)))
+		Expect(reasons(verdicts)).To(Equal(map[string]string{
+			"sr0":     "drive type ODD isn't supported",
+			"sda":     "",
+			"sdb":     "",
+			"sdc":     "",
+			"sdd":     "",
+			"sde":     "",
+			"nvme0n1": "",
+			"sdf":     "size of 59 GiB is below the required 120 GiB",
+		}))
+	})
+
+	It("orders by the drive types and the size preference of the policy", func() {
+		accepted, verdicts := EvaluateDisks(disks, &models.DiskSelectionPolicy{
+			DriveTypes:     []string{DriveTypeNVMe, DriveTypeSSD},
+			SizePreference: SizePreferenceLargest,
+		}, minBytes)
+		Expect(names(accepted)).To(Equal([]string{"nvme0n1", "sdc", "sdb", "sdd"}))
+		Expect(reasons(verdicts)["sda"]).To(Equal("drive type HDD isn't allowed by the disk selection policy"))
+	})
+
+	It("excludes removable and USB disks", func() {
+		accepted, verdicts := EvaluateDisks(disks, &models.DiskSelectionPolicy{ExcludeRemovable: true}, minBytes)
+		Expect(names(accepted)).To(Equal([]string{"sda", "sdb", "sdc", "nvme0n1"}))
+		Expect(reasons(verdicts)["sdd"]).To(Equal("removable disks aren't allowed by the disk selection policy"))
+		Expect(reasons(verdicts)["sde"]).To(Equal("removable disks aren't allowed by the disk selection policy"))
+	})
+
+	It("matches the by-path links with the patterns", func() {
+		accepted, verdicts := EvaluateDisks(disks, &models.DiskSelectionPolicy{
+			ByPathPatterns: []string{"pci-0000:3b:*", "/dev/disk/by-path/pci-0000:5e:00.0-*"},
+		}, minBytes)
+		Expect(names(accepted)).To(Equal([]string{"sdb", "sdc", "nvme0n1"}))
+		Expect(reasons(verdicts)["sda"]).To(Equal("by-path link /dev/disk/by-path/pci-0000:00:1f.2-ata-1 doesn't match the disk selection policy"))
+		Expect(reasons(verdicts)["sde"]).To(Equal("the disk selection policy requires a by-path link"))
+	})
+
+	It("requires the larger of the minimal sizes of the policy and of the cluster", func() {
+		accepted, verdicts := EvaluateDisks(disks, &models.DiskSelectionPolicy{MinSizeGib: swag.Int64(240)}, minBytes)
+		Expect(names(accepted)).To(Equal([]string{"sda", "sde", "sdc", "nvme0n1"}))
+		Expect(reasons(verdicts)["sdb"]).To(Equal("size of 232 GiB is below the required 240 GiB"))
+
+		accepted, _ = EvaluateDisks(disks, &models.DiskSelectionPolicy{MinSizeGib: swag.Int64(10)}, minBytes)
+		Expect(names(accepted)).To(ContainElement("sdd"))
+	})
+
+	It("checks the requirements before the rules of the policy", func() {
+		_, verdicts := EvaluateDisks(disks, &models.DiskSelectionPolicy{DriveTypes: []string{DriveTypeSSD}}, minBytes)
+		Expect(reasons(verdicts)["sr0"]).To(Equal("drive type ODD isn't supported"))
+		Expect(reasons(verdicts)["sdf"]).To(Equal("size of 59 GiB is below the required 120 GiB"))
+	})
+
+	It("parses the policy of a cluster", func() {
+		policy, err := ParseDiskSelectionPolicy("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*policy).To(Equal(models.DiskSelectionPolicy{}))
+		policy, err = ParseDiskSelectionPolicy(`{"drive_types":["SSD"],"exclude_removable":true,"min_size_gib":200}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(policy.DriveTypes).To(Equal([]string{DriveTypeSSD}))
+		Expect(policy.ExcludeRemovable).To(BeTrue())
+		Expect(swag.Int64Value(policy.MinSizeGib)).To(Equal(int64(200)))
+		_, err = ParseDiskSelectionPolicy("{")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects invalid by-path patterns", func() {
+		Expect(ValidateDiskSelectionPolicy(&models.DiskSelectionPolicy{ByPathPatterns: []string{"pci-0000:3b:*"}})).To(Succeed())
+		Expect(ValidateDiskSelectionPolicy(&models.DiskSelectionPolicy{ByPathPatterns: []string{"pci-[0000"}})).
+			To(MatchError(ContainSubstring("invalid by-path pattern pci-[0000")))
+	})
+
+	It("is evaluated for the valid disks of a host", func() {
+		var cfg ValidatorCfg
+		Expect(envconfig.Process("myapp", &cfg)).ShouldNot(HaveOccurred())
+		hwvalidator := NewValidator(logrus.New(), cfg)
+		hw, err := json.Marshal(&models.Inventory{Disks: disks})
+		Expect(err).NotTo(HaveOccurred())
+		id := strfmt.UUID(uuid.New().String())
+		host := &models.Host{ID: &id, Inventory: string(hw)}
+		cluster := &common.Cluster{Cluster: models.Cluster{
+			DiskSelectionPolicy: `{"drive_types":["SSD","NVMe"],"exclude_removable":true,"by_path_patterns":["pci-0000:3b:*"]}`,
+		}}
+
+		valid, err := hwvalidator.GetHostValidDisks(host, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(valid)).To(Equal([]string{"sdb", "sdc"}))
+		disk, err := hwvalidator.GetInstallationDisk(host, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(disk.Name).To(Equal("sdb"))
+
+		cluster.DiskSelectionPolicy = `{"by_path_patterns":["pci-0000:3c:*"]}`
+		_, err = hwvalidator.GetHostValidDisks(host, cluster)
+		Expect(err).To(HaveOccurred())
+	})
+})
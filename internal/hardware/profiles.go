@@ -0,0 +1,144 @@
+package hardware
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/alecthomas/units"
+	"github.com/openshift/assisted-service/models"
+	"github.com/pkg/errors"
+)
+
+// DefaultProfile is the profile of the clusters that didn't select one, its requirements are the ones of ValidatorCfg
+const DefaultProfile = "default"
+
+// Profile is a named set of minimal hardware requirements of the hosts of a cluster
+type Profile struct {
+	MinCPUCores       int64 `json:"min_cpu_cores"`
+	MinCPUCoresWorker int64 `json:"min_cpu_cores_worker"`
+	MinCPUCoresMaster int64 `json:"min_cpu_cores_master"`
+	MinRamGib         int64 `json:"min_ram_gib"`
+	MinRamGibWorker   int64 `json:"min_ram_gib_worker"`
+	MinRamGibMaster   int64 `json:"min_ram_gib_master"`
+	MinDiskSizeGb     int64 `json:"min_disk_size_gib"`
+}
+
+// The profiles defined by the service in addition to the default one
+var serviceProfiles = map[string]Profile{
+	// single board and other small boxes at the edge
+	"edge": {
+		MinCPUCores:       2,
+		MinCPUCoresWorker: 2,
+		MinCPUCoresMaster: 4,
+		MinRamGib:         8,
+		MinRamGibWorker:   8,
+		MinRamGibMaster:   12,
+		MinDiskSizeGb:     60,
+	},
+	// clusters that run heavy workloads
+	"compute": {
+		MinCPUCores:       8,
+		MinCPUCoresWorker: 16,
+		MinCPUCoresMaster: 8,
+		MinRamGib:         32,
+		MinRamGibWorker:   64,
+		MinRamGibMaster:   32,
+		MinDiskSizeGb:     240,
+	},
+}
+
+// ProfileOverrides are the profiles defined by the admin, as a JSON object from the name of the profile to its
+// requirements, e.g. {"edge": {"min_disk_size_gib": 40}, "gpu": {"min_ram_gib_worker": 128}}.
+// The requirements that aren't set are the ones of the service-defined profile of the same name, or of the default
+// profile for a new name.
+type ProfileOverrides map[string]json.RawMessage
+
+// Decode implements envconfig.Decoder
+func (o *ProfileOverrides) Decode(value string) error {
+	if value == "" {
+		return nil
+	}
+	overrides := ProfileOverrides{}
+	if err := json.Unmarshal([]byte(value), &overrides); err != nil {
+		return errors.Wrap(err, "failed to parse the hardware profiles")
+	}
+	for name, override := range overrides {
+		decoder := json.NewDecoder(bytes.NewReader(override))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&Profile{}); err != nil {
+			return errors.Wrapf(err, "invalid hardware profile %s", name)
+		}
+	}
+	*o = overrides
+	return nil
+}
+
+func (c *ValidatorCfg) defaultProfile() Profile {
+	return Profile{
+		MinCPUCores:       c.MinCPUCores,
+		MinCPUCoresWorker: c.MinCPUCoresWorker,
+		MinCPUCoresMaster: c.MinCPUCoresMaster,
+		MinRamGib:         c.MinRamGib,
+		MinRamGibWorker:   c.MinRamGibWorker,
+		MinRamGibMaster:   c.MinRamGibMaster,
+		MinDiskSizeGb:     c.MinDiskSizeGb,
+	}
+}
+
+// GetProfile returns the requirements of the profile, an empty name is the default profile
+func (c *ValidatorCfg) GetProfile(name string) (*Profile, error) {
+	if name == "" {
+		name = DefaultProfile
+	}
+	profile, defined := serviceProfiles[name]
+	if name == DefaultProfile || !defined {
+		profile = c.defaultProfile()
+	}
+	override, overridden := c.Profiles[name]
+	if name != DefaultProfile && !defined && !overridden {
+		return nil, errors.Errorf("unknown hardware profile %s, the profiles are %v", name, c.ProfileNames())
+	}
+	if overridden {
+		if err := json.Unmarshal(override, &profile); err != nil {
+			return nil, errors.Wrapf(err, "invalid hardware profile %s", name)
+		}
+	}
+	return &profile, nil
+}
+
+// ProfileNames returns the sorted names of all the profiles
+func (c *ValidatorCfg) ProfileNames() []string {
+	names := []string{DefaultProfile}
+	for name := range serviceProfiles {
+		names = append(names, name)
+	}
+	for name := range c.Profiles {
+		if _, defined := serviceProfiles[name]; !defined && name != DefaultProfile {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MinDiskSizeBytes is the minimal size of the installation disk, the size of the profile is in GiB
+func (p *Profile) MinDiskSizeBytes() int64 {
+	return p.MinDiskSizeGb * int64(units.GiB)
+}
+
+// RequirementsForRole returns the requirements of the profile for a host of the role
+func (p *Profile) RequirementsForRole(role models.HostRole) models.HostRequirementsRole {
+	if role == models.HostRoleMaster {
+		return models.HostRequirementsRole{
+			CPUCores:   p.MinCPUCoresMaster,
+			RAMGib:     p.MinRamGibMaster,
+			DiskSizeGb: p.MinDiskSizeGb,
+		}
+	}
+	return models.HostRequirementsRole{
+		CPUCores:   p.MinCPUCoresWorker,
+		RAMGib:     p.MinRamGibWorker,
+		DiskSizeGb: p.MinDiskSizeGb,
+	}
+}
@@ -1,23 +1,25 @@
 package hardware
 
 import (
-	"encoding/json"
-	"sort"
 	"strings"
 
 	"github.com/thoas/go-funk"
 
 	"github.com/sirupsen/logrus"
 
-	"github.com/alecthomas/units"
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/internal/hostutil"
 	"github.com/openshift/assisted-service/models"
 	"github.com/pkg/errors"
 )
 
 //go:generate mockgen -source=validator.go -package=hardware -destination=mock_validator.go
 type Validator interface {
-	GetHostValidDisks(host *models.Host) ([]*models.Disk, error)
-	GetHostRequirements(role models.HostRole) models.HostRequirementsRole
+	GetHostValidDisks(host *models.Host, cluster *common.Cluster) ([]*models.Disk, error)
+	GetHostRequirements(role models.HostRole, profile string) (models.HostRequirementsRole, error)
+	// GetInstallationDisk returns the disk the host is installed on, the one selected by the user or else the first
+	// valid disk
+	GetInstallationDisk(host *models.Host, cluster *common.Cluster) (*models.Disk, error)
 }
 
 func NewValidator(log logrus.FieldLogger, cfg ValidatorCfg) Validator {
@@ -36,6 +38,12 @@ type ValidatorCfg struct {
 	MinRamGibMaster               int64 `envconfig:"HW_VALIDATOR_MIN_RAM_GIB_MASTER" default:"16"`
 	MinDiskSizeGb                 int64 `envconfig:"HW_VALIDATOR_MIN_DISK_SIZE_GIB" default:"120"` // Env variable is GIB to not break infra
 	MaximumAllowedTimeDiffMinutes int64 `envconfig:"HW_VALIDATOR_MAX_TIME_DIFF_MINUTES" default:"4"`
+	// The maximal 99th percentile fsync latency of the installation disk of a master, 0 disables the check
+	MaxFsyncLatencyMs int64 `envconfig:"HW_VALIDATOR_MAX_FSYNC_LATENCY_MS" default:"0"`
+	// The minimal link speed of the machine network interface of a host, 0 disables the check
+	MinLinkSpeedMbps int64 `envconfig:"HW_VALIDATOR_MIN_LINK_SPEED_MBPS" default:"0"`
+	// The requirements above are the ones of the default profile, clusters can select other profiles
+	Profiles ProfileOverrides `envconfig:"HW_VALIDATOR_PROFILES" default:""`
 }
 
 type validator struct {
@@ -43,65 +51,109 @@ type validator struct {
 	log logrus.FieldLogger
 }
 
-func (v *validator) GetHostValidDisks(host *models.Host) ([]*models.Disk, error) {
-	var inventory models.Inventory
-	if err := json.Unmarshal([]byte(host.Inventory), &inventory); err != nil {
+func (v *validator) GetHostValidDisks(host *models.Host, cluster *common.Cluster) ([]*models.Disk, error) {
+	inventory, err := hostutil.UnmarshalInventory(host)
+	if err != nil {
 		return nil, err
 	}
-	disks := ListValidDisks(&inventory, gbToBytes(v.MinDiskSizeGb))
+	profile, err := v.GetProfile(cluster.HardwareProfile)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := ParseDiskSelectionPolicy(cluster.DiskSelectionPolicy)
+	if err != nil {
+		return nil, err
+	}
+	disks := ListValidDisks(inventory, policy, profile.MinDiskSizeBytes())
 	if len(disks) == 0 {
 		return nil, errors.Errorf("host %s doesn't have valid disks", host.ID)
 	}
 	return disks, nil
 }
 
-func gbToBytes(gb int64) int64 {
-	return gb * int64(units.GB)
-}
-
 func isNvme(name string) bool {
 	return strings.HasPrefix(name, "nvme")
 }
 
-func ListValidDisks(inventory *models.Inventory, minSizeRequiredInBytes int64) []*models.Disk {
-	var disks []*models.Disk
+// ValidateDisk returns why the disk can't be the installation disk, nil if it can
+func ValidateDisk(disk *models.Disk, minSizeRequiredInBytes int64) error {
+	if reason := requirementsRejectionReason(disk, minSizeRequiredInBytes); reason != "" {
+		return errors.Errorf("disk %s: %s", DiskLabel(disk), reason)
+	}
+	return nil
+}
+
+// FindDisk returns the disk of the inventory with the ID, which is its path, by-path link, WWN or serial
+func FindDisk(inventory *models.Inventory, id string) *models.Disk {
+	if id == "" {
+		return nil
+	}
 	for _, disk := range inventory.Disks {
-		if disk.SizeBytes >= minSizeRequiredInBytes && funk.ContainsString([]string{"HDD", "SSD"}, disk.DriveType) {
-			disks = append(disks, disk)
+		if funk.ContainsString([]string{disk.Path, "/dev/" + disk.Name, disk.ByPath, disk.Wwn, disk.Serial}, id) {
+			return disk
 		}
 	}
+	return nil
+}
 
-	// Sorting list by size increase
-	sort.Slice(disks, func(i, j int) bool {
-		isNvme1 := isNvme(disks[i].Name)
-		isNvme2 := isNvme(disks[j].Name)
-		if isNvme1 != isNvme2 {
-			return isNvme2
-		}
+// SelectedDisk returns the disk of the inventory with the ID if it can be the installation disk
+func SelectedDisk(inventory *models.Inventory, id string, minSizeRequiredInBytes int64) (*models.Disk, error) {
+	disk := FindDisk(inventory, id)
+	if disk == nil {
+		return nil, errors.Errorf("disk %s isn't in the inventory of the host", id)
+	}
+	if err := ValidateDisk(disk, minSizeRequiredInBytes); err != nil {
+		return nil, err
+	}
+	return disk, nil
+}
 
-		// HDD is before SSD
-		switch v := strings.Compare(disks[i].DriveType, disks[j].DriveType); v {
-		case 0:
-			return disks[i].SizeBytes < disks[j].SizeBytes
-		default:
-			return v < 0
-		}
-	})
+// InstallationDisk returns the disk the host is installed on, the disk with the ID selected by the user or else the
+// most preferred disk accepted by the disk selection policy
+func InstallationDisk(inventory *models.Inventory, id string, policy *models.DiskSelectionPolicy, minSizeRequiredInBytes int64) (*models.Disk, error) {
+	if id != "" {
+		return SelectedDisk(inventory, id, minSizeRequiredInBytes)
+	}
+	disks := ListValidDisks(inventory, policy, minSizeRequiredInBytes)
+	if len(disks) == 0 {
+		return nil, errors.New("none of the disks of the host is valid")
+	}
+	return disks[0], nil
+}
+
+// ListValidDisks returns the disks accepted by the disk selection policy, the most preferred first
+func ListValidDisks(inventory *models.Inventory, policy *models.DiskSelectionPolicy, minSizeRequiredInBytes int64) []*models.Disk {
+	disks, _ := EvaluateDisks(inventory.Disks, policy, minSizeRequiredInBytes)
 	return disks
 }
 
-func (v *validator) GetHostRequirements(role models.HostRole) models.HostRequirementsRole {
-	if role == models.HostRoleMaster {
-		return models.HostRequirementsRole{
-			CPUCores:   v.ValidatorCfg.MinCPUCoresMaster,
-			RAMGib:     v.ValidatorCfg.MinRamGibMaster,
-			DiskSizeGb: v.ValidatorCfg.MinDiskSizeGb,
-		}
-	} else {
-		return models.HostRequirementsRole{
-			CPUCores:   v.ValidatorCfg.MinCPUCoresWorker,
-			RAMGib:     v.ValidatorCfg.MinRamGibWorker,
-			DiskSizeGb: v.ValidatorCfg.MinDiskSizeGb,
+func (v *validator) GetHostRequirements(role models.HostRole, profile string) (models.HostRequirementsRole, error) {
+	p, err := v.GetProfile(profile)
+	if err != nil {
+		return models.HostRequirementsRole{}, err
+	}
+	return p.RequirementsForRole(role), nil
+}
+
+func (v *validator) GetInstallationDisk(host *models.Host, cluster *common.Cluster) (*models.Disk, error) {
+	inventory, err := hostutil.UnmarshalInventory(host)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := v.GetProfile(cluster.HardwareProfile)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := ParseDiskSelectionPolicy(cluster.DiskSelectionPolicy)
+	if err != nil {
+		return nil, err
+	}
+	disk, err := InstallationDisk(inventory, host.InstallationDiskID, policy, profile.MinDiskSizeBytes())
+	if err != nil {
+		if host.InstallationDiskID != "" {
+			return nil, errors.Wrapf(err, "the installation disk selected for host %s isn't valid", host.ID)
 		}
+		return nil, errors.Errorf("host %s doesn't have valid disks", host.ID)
 	}
+	return disk, nil
 }
@@ -2,6 +2,7 @@ package hardware
 
 import (
 	"encoding/json"
+	"os"
 	"testing"
 
 	"github.com/openshift/assisted-service/internal/common"
@@ -81,7 +82,7 @@ var _ = Describe("hardware_validator", func() {
 		hw, err := json.Marshal(&inventory)
 		Expect(err).NotTo(HaveOccurred())
 		host1.Inventory = string(hw)
-		disks, err := hwvalidator.GetHostValidDisks(host1)
+		disks, err := hwvalidator.GetHostValidDisks(host1, cluster)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(disks[0].Name).Should(Equal("sdh"))
 		Expect(len(disks)).Should(Equal(5))
@@ -109,11 +110,175 @@ var _ = Describe("hardware_validator", func() {
 		hw, err := json.Marshal(&inventory)
 		Expect(err).NotTo(HaveOccurred())
 		host1.Inventory = string(hw)
-		disks, err := hwvalidator.GetHostValidDisks(host1)
+		disks, err := hwvalidator.GetHostValidDisks(host1, cluster)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(disks[0].Name).Should(Equal("xvda"))
 		Expect(len(disks)).Should(Equal(1))
 	})
+
+	It("validate_disk_size_of_cluster_profile", func() {
+		inventory.Disks = []*models.Disk{{DriveType: "SSD", Name: "sda", SizeBytes: int64(64 * units.GiB)}}
+		hw, err := json.Marshal(&inventory)
+		Expect(err).NotTo(HaveOccurred())
+		host1.Inventory = string(hw)
+		_, err = hwvalidator.GetHostValidDisks(host1, cluster)
+		Expect(err).To(HaveOccurred())
+		cluster.HardwareProfile = "edge"
+		disks, err := hwvalidator.GetHostValidDisks(host1, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(disks).To(HaveLen(1))
+		cluster.HardwareProfile = "unknown"
+		_, err = hwvalidator.GetHostValidDisks(host1, cluster)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("installation disk", func() {
+	var (
+		hwvalidator Validator
+		host        *models.Host
+		cluster     *common.Cluster
+	)
+
+	BeforeEach(func() {
+		var cfg ValidatorCfg
+		Expect(envconfig.Process("myapp", &cfg)).ShouldNot(HaveOccurred())
+		hwvalidator = NewValidator(logrus.New(), cfg)
+		id := strfmt.UUID(uuid.New().String())
+		clusterID := strfmt.UUID(uuid.New().String())
+		inventory := &models.Inventory{
+			Disks: []*models.Disk{
+				{DriveType: "ODD", Name: "sr0", Path: "/dev/sr0", SizeBytes: int64(200 * units.GB)},
+				{DriveType: "HDD", Name: "sda", Path: "/dev/sda", SizeBytes: int64(64 * units.GiB), Serial: "S3EVNX0K"},
+				{DriveType: "SSD", Name: "sdb", Path: "/dev/sdb", SizeBytes: int64(200 * units.GB),
+					ByPath: "/dev/disk/by-path/pci-0000:00:1f.2-ata-2", Wwn: "0x5000c500a0b1c2d3"},
+				{DriveType: "HDD", Name: "sdc", Path: "/dev/sdc", SizeBytes: int64(300 * units.GB), Serial: "ZA1DKQ7P"},
+			},
+		}
+		hw, err := json.Marshal(inventory)
+		Expect(err).NotTo(HaveOccurred())
+		host = &models.Host{ID: &id, ClusterID: clusterID, Inventory: string(hw)}
+		cluster = &common.Cluster{Cluster: models.Cluster{ID: &clusterID}}
+	})
+
+	It("is the first valid disk when none is selected", func() {
+		disk, err := hwvalidator.GetInstallationDisk(host, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(disk.Name).To(Equal("sdc"))
+	})
+
+	It("is the first disk accepted by the disk selection policy when none is selected", func() {
+		cluster.DiskSelectionPolicy = `{"drive_types":["SSD"]}`
+		disk, err := hwvalidator.GetInstallationDisk(host, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(disk.Name).To(Equal("sdb"))
+		cluster.DiskSelectionPolicy = `{"min_size_gib":500}`
+		_, err = hwvalidator.GetInstallationDisk(host, cluster)
+		Expect(err).To(MatchError(ContainSubstring("doesn't have valid disks")))
+	})
+
+	It("is the selected disk", func() {
+		for _, id := range []string{"/dev/sdb", "/dev/disk/by-path/pci-0000:00:1f.2-ata-2", "0x5000c500a0b1c2d3"} {
+			host.InstallationDiskID = id
+			disk, err := hwvalidator.GetInstallationDisk(host, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(disk.Name).To(Equal("sdb"), id)
+		}
+		host.InstallationDiskID = "ZA1DKQ7P"
+		disk, err := hwvalidator.GetInstallationDisk(host, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(disk.Name).To(Equal("sdc"))
+	})
+
+	It("fails when the selected disk isn't valid", func() {
+		for id, reason := range map[string]string{
+			"/dev/sdz": "disk /dev/sdz isn't in the inventory of the host",
+			"/dev/sr0": "disk sr0: drive type ODD isn't supported",
+			"S3EVNX0K": "disk sda: size of 64 GiB is below the required 120 GiB",
+		} {
+			host.InstallationDiskID = id
+			_, err := hwvalidator.GetInstallationDisk(host, cluster)
+			Expect(err).To(MatchError(ContainSubstring(reason)))
+		}
+	})
+
+	It("checks the size with the profile of the cluster", func() {
+		host.InstallationDiskID = "/dev/sda"
+		cluster.HardwareProfile = "edge"
+		disk, err := hwvalidator.GetInstallationDisk(host, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(disk.Name).To(Equal("sda"))
+	})
+})
+
+var _ = Describe("hardware profiles", func() {
+	var cfg ValidatorCfg
+
+	BeforeEach(func() {
+		cfg = ValidatorCfg{}
+		Expect(envconfig.Process("myapp", &cfg)).ShouldNot(HaveOccurred())
+	})
+
+	It("requirements of the default profile are the configured ones", func() {
+		hwvalidator := NewValidator(logrus.New(), cfg)
+		for _, profile := range []string{"", DefaultProfile} {
+			reqs, err := hwvalidator.GetHostRequirements(models.HostRoleMaster, profile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reqs).To(Equal(models.HostRequirementsRole{CPUCores: 4, RAMGib: 16, DiskSizeGb: 120}))
+			reqs, err = hwvalidator.GetHostRequirements(models.HostRoleWorker, profile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reqs).To(Equal(models.HostRequirementsRole{CPUCores: 2, RAMGib: 8, DiskSizeGb: 120}))
+		}
+	})
+
+	It("requirements of a service profile", func() {
+		reqs, err := NewValidator(logrus.New(), cfg).GetHostRequirements(models.HostRoleMaster, "edge")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reqs).To(Equal(models.HostRequirementsRole{CPUCores: 4, RAMGib: 12, DiskSizeGb: 60}))
+	})
+
+	It("unknown profile", func() {
+		_, err := NewValidator(logrus.New(), cfg).GetHostRequirements(models.HostRoleMaster, "gpu")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unknown hardware profile gpu"))
+	})
+
+	It("admin profiles override the service ones", func() {
+		Expect(cfg.Profiles.Decode(`{"edge": {"min_disk_size_gib": 40}, "gpu": {"min_ram_gib_worker": 128},
+			"default": {"min_cpu_cores_master": 6}}`)).To(Succeed())
+		Expect(cfg.ProfileNames()).To(Equal([]string{"compute", "default", "edge", "gpu"}))
+
+		profile, err := cfg.GetProfile("edge")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(profile.MinDiskSizeGb).To(Equal(int64(40)))
+		Expect(profile.MinRamGibMaster).To(Equal(int64(12)))
+
+		profile, err = cfg.GetProfile("gpu")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(profile.MinRamGibWorker).To(Equal(int64(128)))
+		Expect(profile.MinDiskSizeGb).To(Equal(cfg.MinDiskSizeGb))
+
+		profile, err = cfg.GetProfile("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(profile.MinCPUCoresMaster).To(Equal(int64(6)))
+		Expect(profile.MinCPUCoresWorker).To(Equal(cfg.MinCPUCoresWorker))
+	})
+
+	It("admin profiles are read from the environment", func() {
+		Expect(os.Setenv("HW_VALIDATOR_PROFILES", `{"gpu": {"min_ram_gib_worker": 128}}`)).To(Succeed())
+		defer os.Unsetenv("HW_VALIDATOR_PROFILES")
+		cfg = ValidatorCfg{}
+		Expect(envconfig.Process("myapp", &cfg)).ShouldNot(HaveOccurred())
+		reqs, err := NewValidator(logrus.New(), cfg).GetHostRequirements(models.HostRoleWorker, "gpu")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reqs.RAMGib).To(Equal(int64(128)))
+	})
+
+	It("invalid admin profiles", func() {
+		Expect(cfg.Profiles.Decode(`{"gpu": [1]}`)).NotTo(Succeed())
+		Expect(cfg.Profiles.Decode(`{"gpu": {"min_ram": 128}}`)).NotTo(Succeed())
+		Expect(cfg.Profiles.Decode(`gpu`)).NotTo(Succeed())
+	})
 })
 
 func isBlockDeviceNameInlist(disks []*models.Disk, name string) bool {
@@ -0,0 +1,77 @@
+package history
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/models"
+	"github.com/openshift/assisted-service/pkg/auth"
+	"github.com/openshift/assisted-service/pkg/requestid"
+	"github.com/openshift/assisted-service/restapi"
+	"gorm.io/gorm"
+)
+
+// Transition is the status of an entity before a transition of the state machine, which is recorded when the
+// status or the status info change
+type Transition struct {
+	Type           string
+	FromStatus     string
+	FromStatusInfo string
+}
+
+// RecordHostTransition records the transition of the host to its current status in the db or transaction
+func RecordHostTransition(ctx context.Context, db *gorm.DB, host *models.Host, t *Transition) error {
+	if !t.changed(host.Status, host.StatusInfo) {
+		return nil
+	}
+	st := t.toStateTransition(ctx, host.ClusterID, models.StateTransitionEntityKindHost, host.Status, host.StatusInfo)
+	st.HostID = *host.ID
+	st.ValidationsInfo = host.ValidationsInfo
+	return db.Create(st).Error
+}
+
+// RecordClusterTransition records the transition of the cluster to its current status in the db or transaction
+func RecordClusterTransition(ctx context.Context, db *gorm.DB, cluster *common.Cluster, t *Transition) error {
+	if !t.changed(cluster.Status, cluster.StatusInfo) {
+		return nil
+	}
+	st := t.toStateTransition(ctx, *cluster.ID, models.StateTransitionEntityKindCluster, cluster.Status, cluster.StatusInfo)
+	st.ValidationsInfo = cluster.ValidationsInfo
+	return db.Create(st).Error
+}
+
+// DeleteClusterTransitions deletes the transitions of the cluster and its hosts
+func DeleteClusterTransitions(db *gorm.DB, clusterID strfmt.UUID) error {
+	return db.Where("cluster_id = ?", clusterID.String()).Delete(&models.StateTransition{}).Error
+}
+
+func (t *Transition) changed(status *string, statusInfo *string) bool {
+	return swag.StringValue(status) != t.FromStatus || swag.StringValue(statusInfo) != t.FromStatusInfo
+}
+
+func (t *Transition) toStateTransition(ctx context.Context, clusterID strfmt.UUID, kind string, status *string,
+	statusInfo *string) *models.StateTransition {
+	now := strfmt.DateTime(time.Now().UTC())
+	return &models.StateTransition{
+		ClusterID:      &clusterID,
+		EntityKind:     swag.String(kind),
+		FromStatus:     t.FromStatus,
+		ToStatus:       swag.String(swag.StringValue(status)),
+		TransitionType: t.Type,
+		Reason:         swag.StringValue(statusInfo),
+		RequestID:      strfmt.UUID(requestid.FromContext(ctx)),
+		Actor:          actorFromContext(ctx),
+		TransitionTime: &now,
+	}
+}
+
+// actorFromContext returns the user of the request, the monitors of the service run without one
+func actorFromContext(ctx context.Context) string {
+	if ctx.Value(restapi.AuthKey) == nil {
+		return ""
+	}
+	return auth.UserNameFromContext(ctx)
+}
@@ -0,0 +1,86 @@
+package history
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/strfmt"
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/internal/identity"
+	"github.com/openshift/assisted-service/models"
+	logutil "github.com/openshift/assisted-service/pkg/log"
+	"github.com/openshift/assisted-service/restapi"
+	operations "github.com/openshift/assisted-service/restapi/operations/history"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var _ restapi.HistoryAPI = &Api{}
+
+type Api struct {
+	db  *gorm.DB
+	log logrus.FieldLogger
+}
+
+func NewApi(db *gorm.DB, log logrus.FieldLogger) *Api {
+	return &Api{
+		db:  db,
+		log: log,
+	}
+}
+
+func (a *Api) ListClusterTransitions(ctx context.Context, params operations.ListClusterTransitionsParams) middleware.Responder {
+	log := logutil.FromContext(ctx, a.log)
+	if err := a.verifyCluster(ctx, params.ClusterID); err != nil {
+		return common.GenerateErrorResponder(err)
+	}
+	transitions, err := a.list(params.ClusterID, "", params.Since)
+	if err != nil {
+		log.WithError(err).Errorf("failed to list the transitions of cluster %s", params.ClusterID)
+		return common.NewApiError(http.StatusInternalServerError, err)
+	}
+	return operations.NewListClusterTransitionsOK().WithPayload(transitions)
+}
+
+func (a *Api) ListHostTransitions(ctx context.Context, params operations.ListHostTransitionsParams) middleware.Responder {
+	log := logutil.FromContext(ctx, a.log)
+	if err := a.verifyCluster(ctx, params.ClusterID); err != nil {
+		return common.GenerateErrorResponder(err)
+	}
+	transitions, err := a.list(params.ClusterID, params.HostID, params.Since)
+	if err != nil {
+		log.WithError(err).Errorf("failed to list the transitions of host %s of cluster %s", params.HostID, params.ClusterID)
+		return common.NewApiError(http.StatusInternalServerError, err)
+	}
+	return operations.NewListHostTransitionsOK().WithPayload(transitions)
+}
+
+// verifyCluster returns a not found error when the user doesn't have the cluster, the transitions of deleted
+// clusters are kept until they are permanently deleted
+func (a *Api) verifyCluster(ctx context.Context, clusterID strfmt.UUID) error {
+	var cluster common.Cluster
+	err := a.db.Unscoped().Select("id").Take(&cluster, identity.AddUserFilter(ctx, "id = ?"), clusterID.String()).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return common.NewApiError(http.StatusNotFound, errors.Errorf("cluster %s not found", clusterID))
+	}
+	if err != nil {
+		logutil.FromContext(ctx, a.log).WithError(err).Errorf("failed to get cluster %s", clusterID)
+		return common.NewApiError(http.StatusInternalServerError, err)
+	}
+	return nil
+}
+
+func (a *Api) list(clusterID strfmt.UUID, hostID strfmt.UUID, since *strfmt.DateTime) (models.StateTransitionList, error) {
+	db := a.db.Where("cluster_id = ? and host_id = ?", clusterID.String(), hostID.String())
+	if since != nil {
+		db = db.Where("transition_time >= ?", strfmt.DateTime(time.Time(*since).UTC()))
+	}
+	transitions := models.StateTransitionList{}
+	if err := db.Order("transition_time, id").Find(&transitions).Error; err != nil {
+		return nil, err
+	}
+	return transitions, nil
+}
@@ -0,0 +1,311 @@
+package history_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/internal/history"
+	"github.com/openshift/assisted-service/models"
+	"github.com/openshift/assisted-service/pkg/ocm"
+	"github.com/openshift/assisted-service/pkg/requestid"
+	"github.com/openshift/assisted-service/restapi"
+	operations "github.com/openshift/assisted-service/restapi/operations/history"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("History", func() {
+	var (
+		db        *gorm.DB
+		api       *history.Api
+		ctx       = context.Background()
+		dbName    = "history_test"
+		clusterID strfmt.UUID
+		hostID    strfmt.UUID
+		cluster   *common.Cluster
+		host      *models.Host
+	)
+
+	BeforeEach(func() {
+		db = common.PrepareTestDB(dbName)
+		api = history.NewApi(db, logrus.WithField("pkg", "history"))
+		clusterID = strfmt.UUID(uuid.New().String())
+		hostID = strfmt.UUID(uuid.New().String())
+		cluster = &common.Cluster{Cluster: models.Cluster{
+			ID:         &clusterID,
+			Status:     swag.String(models.ClusterStatusInsufficient),
+			StatusInfo: swag.String("Cluster is not ready"),
+		}}
+		Expect(db.Create(cluster).Error).ShouldNot(HaveOccurred())
+		host = &models.Host{
+			ID:              &hostID,
+			ClusterID:       clusterID,
+			Status:          swag.String(models.HostStatusInsufficient),
+			StatusInfo:      swag.String("Host does not meet the minimum hardware requirements"),
+			ValidationsInfo: `{"hardware":[{"id":"has-min-memory","status":"failure"}]}`,
+		}
+	})
+
+	AfterEach(func() {
+		common.DeleteTestDB(db, dbName)
+	})
+
+	list := func(responder middleware.Responder) models.StateTransitionList {
+		switch reply := responder.(type) {
+		case *operations.ListClusterTransitionsOK:
+			return reply.Payload
+		case *operations.ListHostTransitionsOK:
+			return reply.Payload
+		}
+		Fail("unexpected reply")
+		return nil
+	}
+
+	It("records the transitions that change the status or the status info", func() {
+		reqCtx := requestid.ToContext(context.WithValue(ctx, restapi.AuthKey, &ocm.AuthPayload{Username: "jdoe"}), "10b3b7ad-ed44-4a4d-8c2b-3c4f6d3bd3c5")
+		Expect(history.RecordHostTransition(reqCtx, db, host, &history.Transition{
+			Type:       "RefreshHost",
+			FromStatus: models.HostStatusKnown,
+		})).ShouldNot(HaveOccurred())
+		By("skipping a transition that changes nothing")
+		Expect(history.RecordHostTransition(ctx, db, host, &history.Transition{
+			Type:           "RefreshHost",
+			FromStatus:     models.HostStatusInsufficient,
+			FromStatusInfo: swag.StringValue(host.StatusInfo),
+		})).ShouldNot(HaveOccurred())
+		Expect(history.RecordClusterTransition(ctx, db, cluster, &history.Transition{
+			Type:       "RefreshStatus",
+			FromStatus: models.ClusterStatusReady,
+		})).ShouldNot(HaveOccurred())
+
+		transitions := list(api.ListHostTransitions(ctx, operations.ListHostTransitionsParams{ClusterID: clusterID, HostID: hostID}))
+		Expect(transitions).To(HaveLen(1))
+		t := transitions[0]
+		Expect(*t.EntityKind).To(Equal(models.StateTransitionEntityKindHost))
+		Expect(t.TransitionType).To(Equal("RefreshHost"))
+		Expect(t.FromStatus).To(Equal(models.HostStatusKnown))
+		Expect(*t.ToStatus).To(Equal(models.HostStatusInsufficient))
+		Expect(t.Reason).To(Equal(swag.StringValue(host.StatusInfo)))
+		Expect(t.ValidationsInfo).To(Equal(host.ValidationsInfo))
+		Expect(t.RequestID.String()).To(Equal("10b3b7ad-ed44-4a4d-8c2b-3c4f6d3bd3c5"))
+		Expect(t.Actor).To(Equal("jdoe"))
+
+		transitions = list(api.ListClusterTransitions(ctx, operations.ListClusterTransitionsParams{ClusterID: clusterID}))
+		Expect(transitions).To(HaveLen(1))
+		Expect(*transitions[0].EntityKind).To(Equal(models.StateTransitionEntityKindCluster))
+		Expect(transitions[0].FromStatus).To(Equal(models.ClusterStatusReady))
+		Expect(transitions[0].Actor).To(BeEmpty())
+	})
+
+	It("lists the transitions since a time, oldest first", func() {
+		for _, from := range []string{models.HostStatusDiscovering, models.HostStatusKnown, models.HostStatusDisconnected} {
+			Expect(history.RecordHostTransition(ctx, db, host, &history.Transition{FromStatus: from})).ShouldNot(HaveOccurred())
+		}
+		Expect(db.Model(&models.StateTransition{}).Where("from_status = ?", models.HostStatusDiscovering).
+			Update("transition_time", strfmt.DateTime(time.Now().Add(-time.Hour).UTC())).Error).ShouldNot(HaveOccurred())
+
+		transitions := list(api.ListHostTransitions(ctx, operations.ListHostTransitionsParams{ClusterID: clusterID, HostID: hostID}))
+		Expect(transitions).To(HaveLen(3))
+		Expect(transitions[0].FromStatus).To(Equal(models.HostStatusDiscovering))
+		Expect(transitions[2].FromStatus).To(Equal(models.HostStatusDisconnected))
+
+		since := strfmt.DateTime(time.Now().Add(-time.Minute))
+		transitions = list(api.ListHostTransitions(ctx, operations.ListHostTransitionsParams{ClusterID: clusterID, HostID: hostID, Since: &since}))
+		Expect(transitions).To(HaveLen(2))
+	})
+
+	It("fails on a cluster that does not exist", func() {
+		reply := api.ListClusterTransitions(ctx, operations.ListClusterTransitionsParams{ClusterID: strfmt.UUID(uuid.New().String())})
+		Expect(reply).To(BeAssignableToTypeOf(&common.ApiErrorResponse{}))
+		Expect(reply.(*common.ApiErrorResponse).StatusCode()).To(Equal(int32(http.StatusNotFound)))
+	})
+
+	It("deletes the transitions of a cluster", func() {
+		Expect(history.RecordHostTransition(ctx, db, host, &history.Transition{})).ShouldNot(HaveOccurred())
+		Expect(history.DeleteClusterTransitions(db, clusterID)).ShouldNot(HaveOccurred())
+		Expect(list(api.ListHostTransitions(ctx, operations.ListHostTransitionsParams{ClusterID: clusterID, HostID: hostID}))).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Installation timeline", func() {
+	var (
+		db        *gorm.DB
+		api       *history.Api
+		ctx       = context.Background()
+		dbName    = "history_timeline_test"
+		clusterID strfmt.UUID
+		cluster   *common.Cluster
+		bootstrap *models.Host
+		master    *models.Host
+		startedAt time.Time
+	)
+
+	newHost := func(hostname string, isBootstrap bool) *models.Host {
+		id := strfmt.UUID(uuid.New().String())
+		h := &models.Host{
+			ID:                &id,
+			ClusterID:         clusterID,
+			Status:            swag.String(models.HostStatusInstalling),
+			StatusInfo:        swag.String("Installation is in progress"),
+			Role:              models.HostRoleMaster,
+			Bootstrap:         isBootstrap,
+			RequestedHostname: hostname,
+		}
+		Expect(db.Create(h).Error).ShouldNot(HaveOccurred())
+		return h
+	}
+
+	// transition records a transition of the host, or of the cluster when the host is nil, minutes after the
+	// installation started
+	transition := func(h *models.Host, minutes int, transitionType string, status string, statusInfo string) {
+		at := strfmt.DateTime(startedAt.Add(time.Duration(minutes) * time.Minute))
+		st := &models.StateTransition{
+			ClusterID:      &clusterID,
+			EntityKind:     swag.String(models.StateTransitionEntityKindCluster),
+			ToStatus:       swag.String(status),
+			TransitionType: transitionType,
+			Reason:         statusInfo,
+			TransitionTime: &at,
+		}
+		if h != nil {
+			st.HostID = *h.ID
+			st.EntityKind = swag.String(models.StateTransitionEntityKindHost)
+		}
+		Expect(db.Create(st).Error).ShouldNot(HaveOccurred())
+	}
+
+	stage := func(h *models.Host, minutes int, stage models.HostStage) {
+		status := models.HostStatusInstallingInProgress
+		if stage == models.HostStageDone {
+			status = models.HostStatusInstalled
+		}
+		transition(h, minutes, history.TransitionTypeInstallProgress, status, string(stage))
+	}
+
+	getTimeline := func() *models.InstallationTimeline {
+		reply := api.GetClusterInstallationTimeline(ctx, operations.GetClusterInstallationTimelineParams{ClusterID: clusterID})
+		Expect(reply).To(BeAssignableToTypeOf(&operations.GetClusterInstallationTimelineOK{}))
+		return reply.(*operations.GetClusterInstallationTimelineOK).Payload
+	}
+
+	names := func(stages []*models.TimelineStage) []string {
+		ret := make([]string, len(stages))
+		for i, s := range stages {
+			ret[i] = swag.StringValue(s.Name)
+		}
+		return ret
+	}
+
+	BeforeEach(func() {
+		db = common.PrepareTestDB(dbName)
+		api = history.NewApi(db, logrus.WithField("pkg", "history"))
+		clusterID = strfmt.UUID(uuid.New().String())
+		startedAt = time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+		cluster = &common.Cluster{Cluster: models.Cluster{
+			ID:               &clusterID,
+			Status:           swag.String(models.ClusterStatusInstalling),
+			CreatedAt:        strfmt.DateTime(startedAt.Add(-time.Hour)),
+			InstallStartedAt: strfmt.DateTime(startedAt),
+		}}
+		Expect(db.Create(cluster).Error).ShouldNot(HaveOccurred())
+		bootstrap = newHost("bootstrap", true)
+		master = newHost("master", false)
+
+		transition(nil, 0, "PrepareForInstallation", models.ClusterStatusPreparingForInstallation, "Preparing cluster for installation")
+		transition(nil, 1, "Install", models.ClusterStatusInstalling, "Installation in progress")
+		stage(bootstrap, 2, models.HostStageStartingInstallation)
+		stage(master, 2, models.HostStageStartingInstallation)
+		stage(bootstrap, 3, models.HostStageWritingImageToDisk)
+		stage(master, 4, models.HostStageWritingImageToDisk)
+		stage(master, 10, models.HostStageRebooting)
+		stage(bootstrap, 12, models.HostStageWaitingForControlPlane)
+		stage(master, 15, models.HostStageConfiguring)
+		stage(master, 30, models.HostStageJoined)
+		stage(bootstrap, 31, models.HostStageRebooting)
+	})
+
+	AfterEach(func() {
+		common.DeleteTestDB(db, dbName)
+	})
+
+	It("returns the stages of an installation in progress", func() {
+		timeline := getTimeline()
+		Expect(timeline.InstallCompletedAt).To(BeZero())
+		Expect(timeline.DurationSeconds).To(BeNumerically(">=", time.Hour.Seconds()))
+		Expect(names(timeline.Stages)).To(Equal([]string{models.ClusterStatusPreparingForInstallation, models.ClusterStatusInstalling}))
+		Expect(timeline.Stages[0].DurationSeconds).To(Equal(time.Minute.Seconds()))
+		Expect(timeline.Stages[1].FinishedAt).To(BeZero())
+
+		Expect(timeline.Hosts).To(HaveLen(2))
+		Expect(*timeline.Hosts[0].HostID).To(Equal(*bootstrap.ID))
+		Expect(names(timeline.Hosts[0].Stages)).To(Equal([]string{
+			string(models.HostStageStartingInstallation), string(models.HostStageWritingImageToDisk),
+			string(models.HostStageWaitingForControlPlane), string(models.HostStageRebooting),
+		}))
+		Expect(timeline.Hosts[0].Stages[1].DurationSeconds).To(Equal((9 * time.Minute).Seconds()))
+		Expect(timeline.Hosts[0].Stages[3].FinishedAt).To(BeZero())
+		Expect(timeline.Hosts[1].Stages[4].HostID).To(Equal(*master.ID))
+	})
+
+	It("returns the critical path of a completed installation", func() {
+		stage(master, 40, models.HostStageDone)
+		stage(bootstrap, 45, models.HostStageConfiguring)
+		stage(bootstrap, 50, models.HostStageDone)
+		transition(nil, 50, "RefreshStatus", models.ClusterStatusFinalizing, "Finalizing cluster installation")
+		transition(nil, 55, "RefreshStatus", models.ClusterStatusInstalled, "installed")
+		Expect(db.Model(&common.Cluster{}).Where("id = ?", clusterID.String()).Updates(map[string]interface{}{
+			"status":               models.ClusterStatusInstalled,
+			"install_completed_at": strfmt.DateTime(startedAt.Add(55 * time.Minute)),
+		}).Error).ShouldNot(HaveOccurred())
+
+		timeline := getTimeline()
+		Expect(timeline.DurationSeconds).To(Equal((55 * time.Minute).Seconds()))
+		Expect(names(timeline.Stages)).To(Equal([]string{
+			models.ClusterStatusPreparingForInstallation, models.ClusterStatusInstalling, models.ClusterStatusFinalizing,
+		}))
+		Expect(timeline.Stages[2].DurationSeconds).To(Equal((5 * time.Minute).Seconds()))
+		Expect(timeline.Hosts[0].Stages[4].DurationSeconds).To(Equal((5 * time.Minute).Seconds()))
+
+		By("skipping the stage the bootstrap waits for the control plane in")
+		path := make([]string, len(timeline.CriticalPath))
+		for i, s := range timeline.CriticalPath {
+			h := "bootstrap"
+			if s.HostID == *master.ID {
+				h = "master"
+			}
+			path[i] = fmt.Sprintf("%s: %s", h, swag.StringValue(s.Name))
+		}
+		Expect(path).To(Equal([]string{
+			"master: Starting installation",
+			"master: Writing image to disk",
+			"master: Rebooting",
+			"master: Configuring",
+			"master: Joined",
+			"bootstrap: Rebooting",
+			"bootstrap: Configuring",
+		}))
+	})
+
+	It("fails on a cluster that hasn't started installing", func() {
+		Expect(db.Model(&common.Cluster{}).Where("id = ?", clusterID.String()).
+			Update("install_started_at", strfmt.DateTime(startedAt.Add(-2*time.Hour))).Error).ShouldNot(HaveOccurred())
+		reply := api.GetClusterInstallationTimeline(ctx, operations.GetClusterInstallationTimelineParams{ClusterID: clusterID})
+		Expect(reply).To(BeAssignableToTypeOf(&common.ApiErrorResponse{}))
+		Expect(reply.(*common.ApiErrorResponse).StatusCode()).To(Equal(int32(http.StatusConflict)))
+	})
+})
+
+func TestHistory(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "History test Suite")
+}
@@ -0,0 +1,241 @@
+package history
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/internal/hostutil"
+	"github.com/openshift/assisted-service/internal/identity"
+	"github.com/openshift/assisted-service/models"
+	logutil "github.com/openshift/assisted-service/pkg/log"
+	operations "github.com/openshift/assisted-service/restapi/operations/history"
+	"github.com/pkg/errors"
+	"github.com/thoas/go-funk"
+	"gorm.io/gorm"
+)
+
+// The status changes of the installation progress that the hosts report don't run the state machine, they are
+// recorded as this kind of transition
+const TransitionTypeInstallProgress = "InstallProgress"
+
+var (
+	// The statuses of a cluster that is installing, any other status ends the installation
+	clusterInstallingStatuses = []string{
+		models.ClusterStatusPreparingForInstallation, models.ClusterStatusInstalling,
+		models.ClusterStatusInstallingPendingUserAction, models.ClusterStatusFinalizing,
+	}
+	// The statuses of a host that is installing, any other status ends the installation of the host
+	hostInstallingStatuses = []string{
+		models.HostStatusPreparingForInstallation, models.HostStatusInstalling,
+		models.HostStatusInstallingInProgress, models.HostStatusInstallingPendingUserAction,
+	}
+	// The stages of a host that wait for other hosts, they are not on the critical path
+	waitingStages = []string{
+		string(models.HostStageStartWaitingForControlPlane), string(models.HostStageWaitingForControlPlane),
+		string(models.HostStageWaitingForIgnition),
+	}
+)
+
+func (a *Api) GetClusterInstallationTimeline(ctx context.Context, params operations.GetClusterInstallationTimelineParams) middleware.Responder {
+	log := logutil.FromContext(ctx, a.log)
+	var cluster common.Cluster
+	err := a.db.Preload("Hosts").Take(&cluster, identity.AddUserFilter(ctx, "id = ?"), params.ClusterID.String()).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return common.NewApiError(http.StatusNotFound, errors.Errorf("cluster %s not found", params.ClusterID))
+	}
+	if err != nil {
+		log.WithError(err).Errorf("failed to get cluster %s", params.ClusterID)
+		return common.NewApiError(http.StatusInternalServerError, err)
+	}
+	if !time.Time(cluster.InstallStartedAt).After(time.Time(cluster.CreatedAt)) {
+		return common.NewApiError(http.StatusConflict, errors.Errorf("cluster %s hasn't started installing", params.ClusterID))
+	}
+	var transitions []*models.StateTransition
+	if err = a.db.Where("cluster_id = ? and transition_time >= ?", params.ClusterID.String(),
+		strfmt.DateTime(time.Time(cluster.InstallStartedAt).UTC())).Order("transition_time, id").Find(&transitions).Error; err != nil {
+		log.WithError(err).Errorf("failed to list the transitions of cluster %s", params.ClusterID)
+		return common.NewApiError(http.StatusInternalServerError, err)
+	}
+	return operations.NewGetClusterInstallationTimelineOK().WithPayload(buildTimeline(&cluster, transitions, time.Now()))
+}
+
+// buildTimeline builds the timeline of the last installation of the cluster from the transitions that occurred
+// since it started
+func buildTimeline(cluster *common.Cluster, transitions []*models.StateTransition, now time.Time) *models.InstallationTimeline {
+	startedAt := cluster.InstallStartedAt
+	timeline := &models.InstallationTimeline{
+		ClusterID:        cluster.ID,
+		Status:           swag.StringValue(cluster.Status),
+		InstallStartedAt: &startedAt,
+		Stages:           clusterStages(transitions),
+		Hosts:            make([]*models.HostInstallationTimeline, 0, len(cluster.Hosts)),
+	}
+	end := now
+	if time.Time(cluster.InstallCompletedAt).After(time.Time(startedAt)) {
+		timeline.InstallCompletedAt = cluster.InstallCompletedAt
+		end = time.Time(cluster.InstallCompletedAt)
+	} else if !funk.ContainsString(clusterInstallingStatuses, swag.StringValue(cluster.Status)) &&
+		len(timeline.Stages) > 0 && !time.Time(timeline.Stages[len(timeline.Stages)-1].FinishedAt).IsZero() {
+		// the installation was stopped without completing, like by a reset
+		end = time.Time(timeline.Stages[len(timeline.Stages)-1].FinishedAt)
+	}
+	timeline.DurationSeconds = end.Sub(time.Time(startedAt)).Seconds()
+
+	for _, h := range cluster.Hosts {
+		timeline.Hosts = append(timeline.Hosts, &models.HostInstallationTimeline{
+			HostID:    h.ID,
+			Hostname:  hostutil.GetHostnameForMsg(h),
+			Role:      h.Role,
+			Bootstrap: h.Bootstrap,
+			Status:    swag.StringValue(h.Status),
+			Stages:    hostStages(h, transitions, time.Time(startedAt)),
+		})
+	}
+	sort.SliceStable(timeline.Hosts, func(i, j int) bool {
+		hi, hj := timeline.Hosts[i], timeline.Hosts[j]
+		if hi.Bootstrap != hj.Bootstrap {
+			return hi.Bootstrap
+		}
+		if hi.Role != hj.Role {
+			return hi.Role < hj.Role
+		}
+		return hi.Hostname < hj.Hostname
+	})
+
+	var hostStagesList []*models.TimelineStage
+	for _, ht := range timeline.Hosts {
+		hostStagesList = append(hostStagesList, ht.Stages...)
+	}
+	setDurations(timeline.Stages, now)
+	setDurations(hostStagesList, now)
+	timeline.CriticalPath = criticalPath(hostStagesList, end, now)
+	return timeline
+}
+
+// clusterStages returns the statuses the cluster went through until it left the installing statuses
+func clusterStages(transitions []*models.StateTransition) []*models.TimelineStage {
+	b := stagesBuilder{stages: make([]*models.TimelineStage, 0)}
+	for _, t := range transitions {
+		if t.HostID != "" {
+			continue
+		}
+		status := swag.StringValue(t.ToStatus)
+		if !funk.ContainsString(clusterInstallingStatuses, status) {
+			b.finish(*t.TransitionTime)
+			break
+		}
+		b.start(status, "", *t.TransitionTime)
+	}
+	return b.stages
+}
+
+// hostStages returns the installation stages the host reported until it left the installing statuses
+func hostStages(h *models.Host, transitions []*models.StateTransition, startedAt time.Time) []*models.TimelineStage {
+	b := stagesBuilder{stages: make([]*models.TimelineStage, 0)}
+	for _, t := range transitions {
+		if t.HostID != *h.ID {
+			continue
+		}
+		if t.TransitionType == TransitionTypeInstallProgress {
+			// the reason of a failure is followed by its progress info
+			stage := strings.SplitN(t.Reason, " - ", 2)[0]
+			if stage == string(models.HostStageDone) || stage == string(models.HostStageFailed) {
+				b.finish(*t.TransitionTime)
+				break
+			}
+			b.start(stage, *h.ID, *t.TransitionTime)
+		} else if !funk.ContainsString(hostInstallingStatuses, swag.StringValue(t.ToStatus)) {
+			b.finish(*t.TransitionTime)
+			break
+		}
+	}
+	// the stages of hosts that installed before the transitions were recorded are known only from their progress
+	if len(b.stages) == 0 && h.Progress != nil && h.Progress.CurrentStage != "" &&
+		!time.Time(h.Progress.StageStartedAt).Before(startedAt) {
+		switch h.Progress.CurrentStage {
+		case models.HostStageDone, models.HostStageFailed:
+		default:
+			b.start(string(h.Progress.CurrentStage), *h.ID, h.Progress.StageStartedAt)
+			if !funk.ContainsString(hostInstallingStatuses, swag.StringValue(h.Status)) {
+				b.finish(h.Progress.StageUpdatedAt)
+			}
+		}
+	}
+	return b.stages
+}
+
+type stagesBuilder struct {
+	stages []*models.TimelineStage
+}
+
+// start starts a stage and finishes the previous one, unless they are the same
+func (b *stagesBuilder) start(name string, hostID strfmt.UUID, at strfmt.DateTime) {
+	if len(b.stages) > 0 && swag.StringValue(b.stages[len(b.stages)-1].Name) == name {
+		return
+	}
+	b.finish(at)
+	startedAt := at
+	b.stages = append(b.stages, &models.TimelineStage{Name: swag.String(name), HostID: hostID, StartedAt: &startedAt})
+}
+
+func (b *stagesBuilder) finish(at strfmt.DateTime) {
+	if len(b.stages) > 0 && time.Time(b.stages[len(b.stages)-1].FinishedAt).IsZero() {
+		b.stages[len(b.stages)-1].FinishedAt = at
+	}
+}
+
+func stageEnd(stage *models.TimelineStage, now time.Time) time.Time {
+	if time.Time(stage.FinishedAt).IsZero() {
+		return now
+	}
+	return time.Time(stage.FinishedAt)
+}
+
+func setDurations(stages []*models.TimelineStage, now time.Time) {
+	for _, stage := range stages {
+		stage.DurationSeconds = stageEnd(stage, now).Sub(time.Time(*stage.StartedAt)).Seconds()
+	}
+}
+
+// criticalPath walks back from the end of the installation, each time taking the stage that ended last before
+// the previous one started, skipping the stages that wait for other hosts. Of the stages that end together, the
+// one of the host of the previous stage is taken
+func criticalPath(stages []*models.TimelineStage, end time.Time, now time.Time) []*models.TimelineStage {
+	path := make([]*models.TimelineStage, 0)
+	cursor := end
+	for {
+		var (
+			next    *models.TimelineStage
+			nextEnd time.Time
+		)
+		for _, stage := range stages {
+			if funk.ContainsString(waitingStages, swag.StringValue(stage.Name)) || !time.Time(*stage.StartedAt).Before(cursor) {
+				continue
+			}
+			stageEnd := stageEnd(stage, now)
+			if stageEnd.After(cursor) {
+				stageEnd = cursor
+			}
+			if next == nil || stageEnd.After(nextEnd) ||
+				(stageEnd.Equal(nextEnd) && len(path) > 0 && stage.HostID == path[len(path)-1].HostID) {
+				next, nextEnd = stage, stageEnd
+			}
+		}
+		if next == nil {
+			break
+		}
+		path = append(path, next)
+		cursor = time.Time(*next.StartedAt)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
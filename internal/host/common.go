@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/openshift/assisted-service/internal/hostutil"
+	"github.com/openshift/assisted-service/internal/webhooks"
 
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
@@ -82,13 +83,22 @@ func updateHostStatus(ctx context.Context, log logrus.FieldLogger, db *gorm.DB,
 		if statusInfo != "" {
 			msg += fmt.Sprintf(" (%s)", statusInfo)
 		}
-		eventsHandler.AddEvent(ctx, clusterId, &hostId, hostutil.GetEventSeverityFromHostStatus(newStatus), msg, time.Now())
+		eventsHandler.AddEvent(ctx, clusterId, &hostId, hostutil.GetEventSeverityFromHostStatus(newStatus), msg, time.Now(),
+			events.HostStatusChanged, map[string]interface{}{"from": srcStatus, "to": newStatus, "status_info": statusInfo})
 		log.Infof("host %s from cluster %s has been updated with the following updates %+v", hostId, clusterId, extra)
 	}
 
 	return host, nil
 }
 
+// notifyStatusChange queues the webhook deliveries of the status change of the host, if its status changed
+func notifyStatusChange(ctx context.Context, db *gorm.DB, notifier webhooks.Notifier, host *models.Host, srcStatus string) error {
+	if swag.StringValue(host.Status) == srcStatus {
+		return nil
+	}
+	return notifier.HostStatusChanged(ctx, db, host, srcStatus)
+}
+
 func refreshHostStageUpdateTime(
 	log logrus.FieldLogger,
 	db *gorm.DB,
@@ -50,7 +50,8 @@ var _ = Describe("update_host_state", func() {
 		It("change_status", func() {
 			mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, host.ID, models.EventSeverityInfo,
 				fmt.Sprintf("Host %s: updated status from \"status\" to \"newStatus\" (newStatusInfo)", host.ID.String()),
-				gomock.Any())
+				gomock.Any(), events.HostStatusChanged,
+				map[string]interface{}{"from": defaultStatus, "to": newStatus, "status_info": newStatusInfo})
 			returnedHost, err = updateHostStatus(ctx, getTestLog(), db, mockEvents, host.ClusterID, *host.ID, defaultStatus,
 				newStatus, newStatusInfo)
 			Expect(err).ShouldNot(HaveOccurred())
@@ -132,7 +133,8 @@ var _ = Describe("update_host_state", func() {
 		It("new_status_new_stage", func() {
 			mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, host.ID, models.EventSeverityInfo,
 				fmt.Sprintf("Host %s: updated status from \"status\" to \"newStatus\" (newStatusInfo)", host.ID.String()),
-				gomock.Any())
+				gomock.Any(), events.HostStatusChanged,
+				map[string]interface{}{"from": defaultStatus, "to": newStatus, "status_info": newStatusInfo})
 			returnedHost, err = updateHostProgress(ctx, getTestLog(), db, mockEvents, host.ClusterID, *host.ID, *host.Status, newStatus, newStatusInfo,
 				host.Progress.CurrentStage, defaultProgressStage, "")
 			Expect(err).ShouldNot(HaveOccurred())
@@ -0,0 +1,76 @@
+package host
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/internal/hardware"
+	"github.com/openshift/assisted-service/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type diskSpeedCheckCmd struct {
+	baseCmd
+	db                  *gorm.DB
+	hwValidator         hardware.Validator
+	hwValidatorCfg      *hardware.ValidatorCfg
+	diskSpeedCheckImage string
+}
+
+func NewDiskSpeedCheckCmd(log logrus.FieldLogger, db *gorm.DB, hwValidator hardware.Validator, hwValidatorCfg *hardware.ValidatorCfg,
+	diskSpeedCheckImage string) *diskSpeedCheckCmd {
+	return &diskSpeedCheckCmd{
+		baseCmd:             baseCmd{log: log},
+		db:                  db,
+		hwValidator:         hwValidator,
+		hwValidatorCfg:      hwValidatorCfg,
+		diskSpeedCheckImage: diskSpeedCheckImage,
+	}
+}
+
+// GetSteps checks the speed of the installation disk of the master candidates, once for every installation disk,
+// unless the disk speed validation is disabled
+func (c *diskSpeedCheckCmd) GetSteps(ctx context.Context, host *models.Host) ([]*models.Step, error) {
+	if c.hwValidatorCfg.MaxFsyncLatencyMs == 0 || host.Role == models.HostRoleWorker || host.Inventory == "" {
+		return nil, nil
+	}
+	var cluster common.Cluster
+	if err := c.db.First(&cluster, "id = ?", host.ClusterID).Error; err != nil {
+		c.log.WithError(err).Errorf("failed to fetch cluster %s", host.ClusterID)
+		return nil, err
+	}
+	disk, err := c.hwValidator.GetInstallationDisk(host, &cluster)
+	if err != nil {
+		// the validations of the host tell why it has no installation disk
+		return nil, nil
+	}
+	path := GetDeviceFullName(disk.Name)
+	if host.DiskSpeed != "" {
+		var response models.DiskSpeedCheckResponse
+		if err = json.Unmarshal([]byte(host.DiskSpeed), &response); err == nil && response.Path == path {
+			return nil, nil
+		}
+	}
+
+	requestBytes, err := json.Marshal(models.DiskSpeedCheckRequest{Path: &path})
+	if err != nil {
+		c.log.WithError(err).Errorf("failed to marshal DiskSpeedCheckRequest")
+		return nil, err
+	}
+	step := &models.Step{
+		StepType: models.StepTypeDiskSpeedCheck,
+		Command:  "podman",
+		Args: []string{
+			"run", "--privileged", "--net=host", "--rm", "--quiet",
+			"-v", "/dev:/dev:rw",
+			"-v", "/var/log:/var/log",
+			"-v", "/run/systemd/journal/socket:/run/systemd/journal/socket",
+			c.diskSpeedCheckImage,
+			"disk_speed_check",
+			string(requestBytes),
+		},
+	}
+	return []*models.Step{step}, nil
+}
@@ -0,0 +1,110 @@
+package host
+
+import (
+	"context"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/internal/hardware"
+	"github.com/openshift/assisted-service/models"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("diskspeedcheckcmd", func() {
+	ctx := context.Background()
+	var host models.Host
+	var db *gorm.DB
+	var ctrl *gomock.Controller
+	var mockValidator *hardware.MockValidator
+	var diskSpeedCheckCmd *diskSpeedCheckCmd
+	var hwValidatorCfg *hardware.ValidatorCfg
+	var id, clusterID strfmt.UUID
+	dbName := "diskspeedcheckcmd"
+
+	BeforeEach(func() {
+		db = common.PrepareTestDB(dbName)
+		ctrl = gomock.NewController(GinkgoT())
+		mockValidator = hardware.NewMockValidator(ctrl)
+		hwValidatorCfg = &hardware.ValidatorCfg{MaxFsyncLatencyMs: 10}
+		diskSpeedCheckCmd = NewDiskSpeedCheckCmd(getTestLog(), db, mockValidator, hwValidatorCfg, "quay.io/ocpmetal/assisted-installer-agent:latest")
+
+		id = strfmt.UUID(uuid.New().String())
+		clusterID = strfmt.UUID(uuid.New().String())
+		host = getTestHost(id, clusterID, models.HostStatusKnown)
+		host.Role = models.HostRoleMaster
+		Expect(db.Create(&host).Error).ShouldNot(HaveOccurred())
+		cluster := common.Cluster{Cluster: models.Cluster{ID: &clusterID}}
+		Expect(db.Create(&cluster).Error).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+		common.DeleteTestDB(db, dbName)
+	})
+
+	It("get_step", func() {
+		mockValidator.EXPECT().GetInstallationDisk(gomock.Any(), gomock.Any()).Return(&models.Disk{Name: "sdb"}, nil).Times(1)
+		stepReply, stepErr := diskSpeedCheckCmd.GetSteps(ctx, &host)
+		Expect(stepErr).ShouldNot(HaveOccurred())
+		Expect(stepReply).To(HaveLen(1))
+		Expect(stepReply[0].StepType).To(Equal(models.StepTypeDiskSpeedCheck))
+		Expect(stepReply[0].Args[len(stepReply[0].Args)-1]).Should(Equal(`{"path":"/dev/sdb"}`))
+	})
+
+	It("get_step_of_auto_assign_host", func() {
+		host.Role = models.HostRoleAutoAssign
+		mockValidator.EXPECT().GetInstallationDisk(gomock.Any(), gomock.Any()).Return(&models.Disk{Name: "sdb"}, nil).Times(1)
+		stepReply, stepErr := diskSpeedCheckCmd.GetSteps(ctx, &host)
+		Expect(stepErr).ShouldNot(HaveOccurred())
+		Expect(stepReply).To(HaveLen(1))
+	})
+
+	It("get_step_of_another_installation_disk", func() {
+		host.DiskSpeed = `{"path":"/dev/sda","fsync_latency_p99_ms":2.5}`
+		mockValidator.EXPECT().GetInstallationDisk(gomock.Any(), gomock.Any()).Return(&models.Disk{Name: "sdb"}, nil).Times(1)
+		stepReply, stepErr := diskSpeedCheckCmd.GetSteps(ctx, &host)
+		Expect(stepErr).ShouldNot(HaveOccurred())
+		Expect(stepReply).To(HaveLen(1))
+	})
+
+	It("no_step_when_the_installation_disk_was_checked", func() {
+		host.DiskSpeed = `{"path":"/dev/sdb","fsync_latency_p99_ms":2.5}`
+		mockValidator.EXPECT().GetInstallationDisk(gomock.Any(), gomock.Any()).Return(&models.Disk{Name: "sdb"}, nil).Times(1)
+		stepReply, stepErr := diskSpeedCheckCmd.GetSteps(ctx, &host)
+		Expect(stepErr).ShouldNot(HaveOccurred())
+		Expect(stepReply).To(BeNil())
+	})
+
+	It("no_step_for_worker", func() {
+		host.Role = models.HostRoleWorker
+		stepReply, stepErr := diskSpeedCheckCmd.GetSteps(ctx, &host)
+		Expect(stepErr).ShouldNot(HaveOccurred())
+		Expect(stepReply).To(BeNil())
+	})
+
+	It("no_step_when_the_disk_speed_validation_is_disabled", func() {
+		hwValidatorCfg.MaxFsyncLatencyMs = 0
+		stepReply, stepErr := diskSpeedCheckCmd.GetSteps(ctx, &host)
+		Expect(stepErr).ShouldNot(HaveOccurred())
+		Expect(stepReply).To(BeNil())
+	})
+
+	It("no_step_without_installation_disk", func() {
+		mockValidator.EXPECT().GetInstallationDisk(gomock.Any(), gomock.Any()).Return(nil, errors.New("no valid disk")).Times(1)
+		stepReply, stepErr := diskSpeedCheckCmd.GetSteps(ctx, &host)
+		Expect(stepErr).ShouldNot(HaveOccurred())
+		Expect(stepReply).To(BeNil())
+	})
+
+	It("get_step_unknown_cluster_id", func() {
+		host.ClusterID = strfmt.UUID(uuid.New().String())
+		stepReply, stepErr := diskSpeedCheckCmd.GetSteps(ctx, &host)
+		Expect(stepReply).To(BeNil())
+		Expect(stepErr).Should(HaveOccurred())
+	})
+})
@@ -7,6 +7,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/openshift/assisted-service/internal/hostutil"
 	"github.com/openshift/assisted-service/models"
 	"github.com/pkg/errors"
 )
@@ -24,8 +25,7 @@ func NewFreeAddressesCmd(log logrus.FieldLogger, freeAddressesImage string) *fre
 }
 
 func (f *freeAddressesCmd) prepareParam(host *models.Host) (string, error) {
-	var inventory models.Inventory
-	err := json.Unmarshal([]byte(host.Inventory), &inventory)
+	inventory, err := hostutil.UnmarshalInventory(host)
 	if err != nil {
 		f.log.WithError(err).Warn("Inventory parse")
 		return "", err
@@ -11,6 +11,7 @@ import (
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
 	"github.com/openshift/assisted-service/internal/hostutil"
+	"github.com/openshift/assisted-service/internal/webhooks"
 	"github.com/openshift/assisted-service/pkg/leader"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -20,6 +21,7 @@ import (
 	"github.com/openshift/assisted-service/internal/common"
 	"github.com/openshift/assisted-service/internal/events"
 	"github.com/openshift/assisted-service/internal/hardware"
+	"github.com/openshift/assisted-service/internal/history"
 	"github.com/openshift/assisted-service/internal/metrics"
 	"github.com/openshift/assisted-service/models"
 	logutil "github.com/openshift/assisted-service/pkg/log"
@@ -74,6 +76,8 @@ type Config struct {
 	EnableAutoReset  bool          `envconfig:"ENABLE_AUTO_RESET" default:"false"`
 	ResetTimeout     time.Duration `envconfig:"RESET_CLUSTER_TIMEOUT" default:"3m"`
 	MonitorBatchSize int           `envconfig:"HOST_MONITOR_BATCH_SIZE" default:"100"`
+	// Number of clusters whose hosts the monitor refreshes concurrently, 0 selects 8, or 1 on sqlite
+	MonitorWorkers int `envconfig:"HOST_MONITOR_WORKERS" default:"0"`
 }
 
 //go:generate mockgen -source=host.go -package=host -aux_files=github.com/openshift/assisted-service/internal/host=instructionmanager.go -destination=mock_host_api.go
@@ -86,11 +90,14 @@ type API interface {
 	UpdateInstallProgress(ctx context.Context, h *models.Host, progress *models.HostProgress) error
 	RefreshStatus(ctx context.Context, h *models.Host, db *gorm.DB) error
 	SetBootstrap(ctx context.Context, h *models.Host, isbootstrap bool, db *gorm.DB) error
-	UpdateConnectivityReport(ctx context.Context, h *models.Host, connectivityReport string) error
-	UpdateApiVipConnectivityReport(ctx context.Context, h *models.Host, connectivityReport string) error
+	UpdateConnectivityReport(ctx context.Context, h *models.Host, connectivityReport string, db *gorm.DB) error
+	UpdateApiVipConnectivityReport(ctx context.Context, h *models.Host, connectivityReport string, db *gorm.DB) error
+	UpdateDiskSpeedReport(ctx context.Context, h *models.Host, diskSpeedReport string, db *gorm.DB) error
 	HostMonitoring()
 	UpdateRole(ctx context.Context, h *models.Host, role models.HostRole, db *gorm.DB) error
 	UpdateHostname(ctx context.Context, h *models.Host, hostname string, db *gorm.DB) error
+	// Select the disk the host is installed on, an empty disk ID lets the service select it
+	UpdateInstallationDisk(ctx context.Context, h *models.Host, diskID string, db *gorm.DB) error
 	CancelInstallation(ctx context.Context, h *models.Host, reason string, db *gorm.DB) *common.ApiErrorResponse
 	IsRequireUserActionReset(h *models.Host) bool
 	ResetHost(ctx context.Context, h *models.Host, reason string, db *gorm.DB) *common.ApiErrorResponse
@@ -102,7 +109,7 @@ type API interface {
 	// Install host - db is optional, for transactions
 	Install(ctx context.Context, h *models.Host, db *gorm.DB) error
 	// Set a new inventory information
-	UpdateInventory(ctx context.Context, h *models.Host, inventory string) error
+	UpdateInventory(ctx context.Context, h *models.Host, inventory string, db *gorm.DB) error
 	GetStagesByRole(role models.HostRole, isbootstrap bool) []models.HostStage
 	IsInstallable(h *models.Host) bool
 	PrepareForInstallation(ctx context.Context, h *models.Host, db *gorm.DB) error
@@ -110,8 +117,9 @@ type API interface {
 	AutoAssignRole(ctx context.Context, h *models.Host, db *gorm.DB) error
 	IsValidMasterCandidate(h *models.Host, db *gorm.DB, log logrus.FieldLogger) (bool, error)
 	SetUploadLogsAt(ctx context.Context, h *models.Host, db *gorm.DB) error
-	GetHostRequirements(role models.HostRole) models.HostRequirementsRole
-	PermanentHostsDeletion(olderThen strfmt.DateTime) error
+	GetHostRequirements(role models.HostRole, profile string) (models.HostRequirementsRole, error)
+	// Delete the hosts that were deleted before olderThen, only while leaderElector is still the leader
+	PermanentHostsDeletion(olderThen strfmt.DateTime, leaderElector leader.Leader) error
 }
 
 type Manager struct {
@@ -119,38 +127,44 @@ type Manager struct {
 	db             *gorm.DB
 	instructionApi InstructionApi
 	hwValidator    hardware.Validator
+	hwValidatorCfg *hardware.ValidatorCfg
 	eventsHandler  events.Handler
-	sm             stateswitch.StateMachine
+	sm             *stateMachine
 	rp             *refreshPreprocessor
 	metricApi      metrics.API
 	Config         Config
 	leaderElector  leader.Leader
+	webhooks       webhooks.Notifier
 }
 
 func NewManager(log logrus.FieldLogger, db *gorm.DB, eventsHandler events.Handler, hwValidator hardware.Validator, instructionApi InstructionApi,
-	hwValidatorCfg *hardware.ValidatorCfg, metricApi metrics.API, config *Config, leaderElector leader.ElectorInterface) *Manager {
+	hwValidatorCfg *hardware.ValidatorCfg, metricApi metrics.API, config *Config, leaderElector leader.Leader,
+	webhooksNotifier webhooks.Notifier) *Manager {
 	th := &transitionHandler{
 		db:            db,
 		log:           log,
 		eventsHandler: eventsHandler,
+		webhooks:      webhooksNotifier,
 	}
 	return &Manager{
 		log:            log,
 		db:             db,
 		instructionApi: instructionApi,
 		hwValidator:    hwValidator,
+		hwValidatorCfg: hwValidatorCfg,
 		eventsHandler:  eventsHandler,
 		sm:             NewHostStateMachine(th),
 		rp:             newRefreshPreprocessor(log, hwValidatorCfg),
 		metricApi:      metricApi,
 		Config:         *config,
 		leaderElector:  leaderElector,
+		webhooks:       webhooksNotifier,
 	}
 }
 
 func (m *Manager) RegisterHost(ctx context.Context, h *models.Host) error {
 	var host models.Host
-	err := m.db.First(&host, "id = ? and cluster_id = ?", *h.ID, h.ClusterID).Error
+	err := m.db.WithContext(ctx).First(&host, "id = ? and cluster_id = ?", *h.ID, h.ClusterID).Error
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return err
 	}
@@ -160,14 +174,14 @@ func (m *Manager) RegisterHost(ctx context.Context, h *models.Host) error {
 		pHost = h
 	}
 
-	return m.sm.Run(TransitionTypeRegisterHost, newStateHost(pHost), &TransitionArgsRegisterHost{
+	return m.sm.Run(ctx, TransitionTypeRegisterHost, newStateHost(pHost), &TransitionArgsRegisterHost{
 		ctx:                   ctx,
 		discoveryAgentVersion: h.DiscoveryAgentVersion,
 	})
 }
 
 func (m *Manager) RegisterInstalledOCPHost(ctx context.Context, h *models.Host, db *gorm.DB) error {
-	return m.sm.Run(TransitionTypeRegisterInstalledHost, newStateHost(h), &TransitionArgsRegisterInstalledHost{
+	return m.sm.Run(ctx, TransitionTypeRegisterInstalledHost, newStateHost(h), &TransitionArgsRegisterInstalledHost{
 		ctx: ctx,
 		db:  db,
 	})
@@ -176,7 +190,7 @@ func (m *Manager) RegisterInstalledOCPHost(ctx context.Context, h *models.Host,
 func (m *Manager) HandleInstallationFailure(ctx context.Context, h *models.Host) error {
 
 	lastStatusUpdateTime := h.StatusUpdatedAt
-	err := m.sm.Run(TransitionTypeHostInstallationFailed, newStateHost(h), &TransitionArgsHostInstallationFailed{
+	err := m.sm.Run(ctx, TransitionTypeHostInstallationFailed, newStateHost(h), &TransitionArgsHostInstallationFailed{
 		ctx:    ctx,
 		reason: "installation command failed",
 	})
@@ -187,7 +201,7 @@ func (m *Manager) HandleInstallationFailure(ctx context.Context, h *models.Host)
 	return err
 }
 
-func (m *Manager) UpdateInventory(ctx context.Context, h *models.Host, inventory string) error {
+func (m *Manager) UpdateInventory(ctx context.Context, h *models.Host, inventory string, db *gorm.DB) error {
 	hostStatus := swag.StringValue(h.Status)
 	allowedStatuses := []string{
 		models.HostStatusDiscovering, models.HostStatusKnown, models.HostStatusDisconnected,
@@ -199,14 +213,27 @@ func (m *Manager) UpdateInventory(ctx context.Context, h *models.Host, inventory
 				hostStatus, allowedStatuses))
 	}
 	h.Inventory = inventory
-	return m.db.Model(h).Update("inventory", inventory).Error
+	parsed, err := hostutil.UnmarshalInventory(h)
+	if err != nil {
+		m.log.WithError(err).Warnf("Failed to parse inventory of host %s", h.ID)
+	}
+	cdb := m.db.WithContext(ctx)
+	if db != nil {
+		cdb = db
+	}
+	return cdb.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(h).Update("inventory", inventory).Error; err != nil {
+			return err
+		}
+		return replaceInventoryTables(tx, h.ClusterID, *h.ID, parsed)
+	})
 }
 
 func (m *Manager) RefreshStatus(ctx context.Context, h *models.Host, db *gorm.DB) error {
 	if db == nil {
-		db = m.db
+		db = m.db.WithContext(ctx)
 	}
-	vc, err := newValidationContext(h, db)
+	vc, err := newValidationContext(h, db, m.hwValidatorCfg, m.log)
 	if err != nil {
 		return err
 	}
@@ -214,10 +241,11 @@ func (m *Manager) RefreshStatus(ctx context.Context, h *models.Host, db *gorm.DB
 	if err != nil {
 		return err
 	}
-	err = m.sm.Run(TransitionTypeRefresh, newStateHost(h), &TransitionArgsRefreshHost{
+	err = m.sm.Run(ctx, TransitionTypeRefresh, newStateHost(h), &TransitionArgsRefreshHost{
 		ctx:               ctx,
 		db:                db,
 		eventHandler:      m.eventsHandler,
+		metricApi:         m.metricApi,
 		conditions:        conditions,
 		validationResults: validationsResults,
 	})
@@ -228,25 +256,25 @@ func (m *Manager) RefreshStatus(ctx context.Context, h *models.Host, db *gorm.DB
 }
 
 func (m *Manager) Install(ctx context.Context, h *models.Host, db *gorm.DB) error {
-	cdb := m.db
+	cdb := m.db.WithContext(ctx)
 	if db != nil {
 		cdb = db
 	}
-	return m.sm.Run(TransitionTypeInstallHost, newStateHost(h), &TransitionArgsInstallHost{
+	return m.sm.Run(ctx, TransitionTypeInstallHost, newStateHost(h), &TransitionArgsInstallHost{
 		ctx: ctx,
 		db:  cdb,
 	})
 }
 
 func (m *Manager) EnableHost(ctx context.Context, h *models.Host, db *gorm.DB) error {
-	return m.sm.Run(TransitionTypeEnableHost, newStateHost(h), &TransitionArgsEnableHost{
+	return m.sm.Run(ctx, TransitionTypeEnableHost, newStateHost(h), &TransitionArgsEnableHost{
 		ctx: ctx,
 		db:  db,
 	})
 }
 
 func (m *Manager) DisableHost(ctx context.Context, h *models.Host, db *gorm.DB) error {
-	return m.sm.Run(TransitionTypeDisableHost, newStateHost(h), &TransitionArgsDisableHost{
+	return m.sm.Run(ctx, TransitionTypeDisableHost, newStateHost(h), &TransitionArgsDisableHost{
 		ctx: ctx,
 		db:  db,
 	})
@@ -289,34 +317,51 @@ func (m *Manager) UpdateInstallProgress(ctx context.Context, h *models.Host, pro
 
 	statusInfo := string(progress.CurrentStage)
 
-	var err error
-	switch progress.CurrentStage {
-	case models.HostStageDone:
-		_, err = updateHostProgress(ctx, logutil.FromContext(ctx, m.log), m.db, m.eventsHandler, h.ClusterID, *h.ID,
-			swag.StringValue(h.Status), models.HostStatusInstalled, statusInfo,
-			previousProgress.CurrentStage, progress.CurrentStage, progress.ProgressInfo)
-	case models.HostStageFailed:
-		// Keeps the last progress
-
-		if progress.ProgressInfo != "" {
-			statusInfo += fmt.Sprintf(" - %s", progress.ProgressInfo)
+	// the webhook deliveries and the history of the status change are written in its transaction
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var (
+			updatedHost *models.Host
+			err         error
+		)
+		switch progress.CurrentStage {
+		case models.HostStageDone:
+			updatedHost, err = updateHostProgress(ctx, logutil.FromContext(ctx, m.log), tx, m.eventsHandler, h.ClusterID, *h.ID,
+				swag.StringValue(h.Status), models.HostStatusInstalled, statusInfo,
+				previousProgress.CurrentStage, progress.CurrentStage, progress.ProgressInfo)
+		case models.HostStageFailed:
+			// Keeps the last progress
+
+			if progress.ProgressInfo != "" {
+				statusInfo += fmt.Sprintf(" - %s", progress.ProgressInfo)
+			}
+
+			updatedHost, err = updateHostStatus(ctx, logutil.FromContext(ctx, m.log), tx, m.eventsHandler, h.ClusterID, *h.ID,
+				swag.StringValue(h.Status), models.HostStatusError, statusInfo)
+		case models.HostStageRebooting:
+			if swag.StringValue(h.Kind) == models.HostKindAddToExistingClusterHost {
+				updatedHost, err = updateHostProgress(ctx, logutil.FromContext(ctx, m.log), tx, m.eventsHandler, h.ClusterID, *h.ID,
+					swag.StringValue(h.Status), models.HostStatusAddedToExistingCluster, statusInfo,
+					h.Progress.CurrentStage, progress.CurrentStage, progress.ProgressInfo)
+				break
+			}
+			fallthrough
+		default:
+			updatedHost, err = updateHostProgress(ctx, logutil.FromContext(ctx, m.log), tx, m.eventsHandler, h.ClusterID, *h.ID,
+				swag.StringValue(h.Status), models.HostStatusInstallingInProgress, statusInfo,
+				previousProgress.CurrentStage, progress.CurrentStage, progress.ProgressInfo)
 		}
-
-		_, err = updateHostStatus(ctx, logutil.FromContext(ctx, m.log), m.db, m.eventsHandler, h.ClusterID, *h.ID,
-			swag.StringValue(h.Status), models.HostStatusError, statusInfo)
-	case models.HostStageRebooting:
-		if swag.StringValue(h.Kind) == models.HostKindAddToExistingClusterHost {
-			_, err = updateHostProgress(ctx, logutil.FromContext(ctx, m.log), m.db, m.eventsHandler, h.ClusterID, *h.ID,
-				swag.StringValue(h.Status), models.HostStatusAddedToExistingCluster, statusInfo,
-				h.Progress.CurrentStage, progress.CurrentStage, progress.ProgressInfo)
-			break
+		if err != nil {
+			return err
 		}
-		fallthrough
-	default:
-		_, err = updateHostProgress(ctx, logutil.FromContext(ctx, m.log), m.db, m.eventsHandler, h.ClusterID, *h.ID,
-			swag.StringValue(h.Status), models.HostStatusInstallingInProgress, statusInfo,
-			previousProgress.CurrentStage, progress.CurrentStage, progress.ProgressInfo)
-	}
+		if err = notifyStatusChange(ctx, tx, m.webhooks, updatedHost, swag.StringValue(h.Status)); err != nil {
+			return err
+		}
+		return history.RecordHostTransition(ctx, tx, updatedHost, &history.Transition{
+			Type:           history.TransitionTypeInstallProgress,
+			FromStatus:     swag.StringValue(h.Status),
+			FromStatusInfo: swag.StringValue(h.StatusInfo),
+		})
+	})
 	m.reportInstallationMetrics(ctx, h, previousProgress, progress.CurrentStage)
 	return err
 }
@@ -328,7 +373,7 @@ func (m *Manager) SetBootstrap(ctx context.Context, h *models.Host, isbootstrap
 			return errors.Wrapf(err, "failed to set bootstrap to host %s", h.ID.String())
 		}
 		m.eventsHandler.AddEvent(ctx, h.ClusterID, h.ID, models.EventSeverityInfo,
-			fmt.Sprintf("Host %s: set as bootstrap", hostutil.GetHostnameForMsg(h)), time.Now())
+			fmt.Sprintf("Host %s: set as bootstrap", hostutil.GetHostnameForMsg(h)), time.Now(), events.HostBootstrapSet, nil)
 	}
 	return nil
 }
@@ -341,9 +386,13 @@ func (m *Manager) SetUploadLogsAt(ctx context.Context, h *models.Host, db *gorm.
 	return nil
 }
 
-func (m *Manager) UpdateConnectivityReport(ctx context.Context, h *models.Host, connectivityReport string) error {
+func (m *Manager) UpdateConnectivityReport(ctx context.Context, h *models.Host, connectivityReport string, db *gorm.DB) error {
+	cdb := m.db.WithContext(ctx)
+	if db != nil {
+		cdb = db
+	}
 	if h.Connectivity != connectivityReport {
-		err := m.db.Model(h).Update("connectivity", connectivityReport).Error
+		err := cdb.Model(h).Update("connectivity", connectivityReport).Error
 		if err != nil {
 			return errors.Wrapf(err, "failed to set connectivity to host %s", h.ID.String())
 		}
@@ -351,17 +400,34 @@ func (m *Manager) UpdateConnectivityReport(ctx context.Context, h *models.Host,
 	return nil
 }
 
-func (m *Manager) UpdateApiVipConnectivityReport(ctx context.Context, h *models.Host, apiVipConnectivityReport string) error {
+func (m *Manager) UpdateApiVipConnectivityReport(ctx context.Context, h *models.Host, apiVipConnectivityReport string, db *gorm.DB) error {
+	cdb := m.db.WithContext(ctx)
+	if db != nil {
+		cdb = db
+	}
 	if h.APIVipConnectivity != apiVipConnectivityReport {
-		if err := m.db.Model(h).Update("api_vip_connectivity", apiVipConnectivityReport).Error; err != nil {
+		if err := cdb.Model(h).Update("api_vip_connectivity", apiVipConnectivityReport).Error; err != nil {
 			return errors.Wrapf(err, "failed to set api_vip_connectivity to host %s", h.ID.String())
 		}
 	}
 	return nil
 }
 
+func (m *Manager) UpdateDiskSpeedReport(ctx context.Context, h *models.Host, diskSpeedReport string, db *gorm.DB) error {
+	cdb := m.db.WithContext(ctx)
+	if db != nil {
+		cdb = db
+	}
+	if h.DiskSpeed != diskSpeedReport {
+		if err := cdb.Model(h).Update("disk_speed", diskSpeedReport).Error; err != nil {
+			return errors.Wrapf(err, "failed to set disk_speed to host %s", h.ID.String())
+		}
+	}
+	return nil
+}
+
 func (m *Manager) UpdateRole(ctx context.Context, h *models.Host, role models.HostRole, db *gorm.DB) error {
-	cdb := m.db
+	cdb := m.db.WithContext(ctx)
 	if db != nil {
 		cdb = db
 	}
@@ -381,24 +447,46 @@ func (m *Manager) UpdateHostname(ctx context.Context, h *models.Host, hostname s
 	}
 
 	h.RequestedHostname = hostname
-	cdb := m.db
+	cdb := m.db.WithContext(ctx)
 	if db != nil {
 		cdb = db
 	}
 	return cdb.Model(h).Update("requested_hostname", hostname).Error
 }
 
+func (m *Manager) UpdateInstallationDisk(ctx context.Context, h *models.Host, diskID string, db *gorm.DB) error {
+	hostStatus := swag.StringValue(h.Status)
+	allowedStatuses := []string{
+		models.HostStatusDiscovering, models.HostStatusKnown, models.HostStatusDisconnected,
+		models.HostStatusInsufficient, models.HostStatusPendingForInput,
+	}
+	if !funk.ContainsString(allowedStatuses, hostStatus) {
+		return common.NewApiError(http.StatusConflict,
+			errors.Errorf("Host is in %s state, installation disk can be set only in one of %s states",
+				hostStatus, allowedStatuses))
+	}
+
+	h.InstallationDiskID = diskID
+	cdb := m.db.WithContext(ctx)
+	if db != nil {
+		cdb = db
+	}
+	return cdb.Model(h).Update("installation_disk_id", diskID).Error
+}
+
 func (m *Manager) CancelInstallation(ctx context.Context, h *models.Host, reason string, db *gorm.DB) *common.ApiErrorResponse {
 	eventSeverity := models.EventSeverityInfo
 	eventInfo := fmt.Sprintf("Installation canceled for host %s", hostutil.GetHostnameForMsg(h))
+	eventCode := events.HostInstallCancelled
+	var eventProps map[string]interface{}
 	shouldAddEvent := true
 	defer func() {
 		if shouldAddEvent {
-			m.eventsHandler.AddEvent(ctx, h.ClusterID, h.ID, eventSeverity, eventInfo, time.Now())
+			m.eventsHandler.AddEvent(ctx, h.ClusterID, h.ID, eventSeverity, eventInfo, time.Now(), eventCode, eventProps)
 		}
 	}()
 
-	err := m.sm.Run(TransitionTypeCancelInstallation, newStateHost(h), &TransitionArgsCancelInstallation{
+	err := m.sm.Run(ctx, TransitionTypeCancelInstallation, newStateHost(h), &TransitionArgsCancelInstallation{
 		ctx:    ctx,
 		reason: reason,
 		db:     db,
@@ -406,6 +494,8 @@ func (m *Manager) CancelInstallation(ctx context.Context, h *models.Host, reason
 	if err != nil {
 		eventSeverity = models.EventSeverityError
 		eventInfo = fmt.Sprintf("Failed to cancel installation of host %s: %s", hostutil.GetHostnameForMsg(h), err.Error())
+		eventCode = events.HostInstallCancelFailed
+		eventProps = map[string]interface{}{"error": err.Error()}
 		return common.NewApiError(http.StatusConflict, err)
 	} else if swag.StringValue(h.Status) == models.HostStatusDisabled {
 		shouldAddEvent = false
@@ -433,10 +523,12 @@ func (m *Manager) IsRequireUserActionReset(h *models.Host) bool {
 func (m *Manager) ResetHost(ctx context.Context, h *models.Host, reason string, db *gorm.DB) *common.ApiErrorResponse {
 	eventSeverity := models.EventSeverityInfo
 	eventInfo := fmt.Sprintf("Installation reset for host %s", hostutil.GetHostnameForMsg(h))
+	eventCode := events.HostInstallReset
+	var eventProps map[string]interface{}
 	shouldAddEvent := true
 	defer func() {
 		if shouldAddEvent {
-			m.eventsHandler.AddEvent(ctx, h.ClusterID, h.ID, eventSeverity, eventInfo, time.Now())
+			m.eventsHandler.AddEvent(ctx, h.ClusterID, h.ID, eventSeverity, eventInfo, time.Now(), eventCode, eventProps)
 		}
 	}()
 
@@ -458,9 +550,11 @@ func (m *Manager) ResetHost(ctx context.Context, h *models.Host, reason string,
 		}
 	}
 
-	if err := m.sm.Run(transitionType, newStateHost(h), transitionArgs); err != nil {
+	if err := m.sm.Run(ctx, transitionType, newStateHost(h), transitionArgs); err != nil {
 		eventSeverity = models.EventSeverityError
 		eventInfo = fmt.Sprintf("Failed to reset installation of host %s. Error: %s", hostutil.GetHostnameForMsg(h), err.Error())
+		eventCode = events.HostInstallResetFailed
+		eventProps = map[string]interface{}{"error": err.Error()}
 		return common.NewApiError(http.StatusConflict, err)
 	} else if swag.StringValue(h.Status) == models.HostStatusDisabled {
 		shouldAddEvent = false
@@ -471,20 +565,24 @@ func (m *Manager) ResetHost(ctx context.Context, h *models.Host, reason string,
 func (m *Manager) ResetPendingUserAction(ctx context.Context, h *models.Host, db *gorm.DB) error {
 	eventSeverity := models.EventSeverityInfo
 	eventInfo := fmt.Sprintf("User action is required in order to complete installation reset for host %s", hostutil.GetHostnameForMsg(h))
+	eventCode := events.HostResetPendingUserAction
+	var eventProps map[string]interface{}
 	shouldAddEvent := true
 	defer func() {
 		if shouldAddEvent {
-			m.eventsHandler.AddEvent(ctx, h.ClusterID, h.ID, eventSeverity, eventInfo, time.Now())
+			m.eventsHandler.AddEvent(ctx, h.ClusterID, h.ID, eventSeverity, eventInfo, time.Now(), eventCode, eventProps)
 		}
 	}()
 
-	err := m.sm.Run(TransitionTypeResettingPendingUserAction, newStateHost(h), &TransitionResettingPendingUserAction{
+	err := m.sm.Run(ctx, TransitionTypeResettingPendingUserAction, newStateHost(h), &TransitionResettingPendingUserAction{
 		ctx: ctx,
 		db:  db,
 	})
 	if err != nil {
 		eventSeverity = models.EventSeverityError
 		eventInfo = fmt.Sprintf("Failed to set status of host %s to reset-pending-user-action. Error: %s", hostutil.GetHostnameForMsg(h), err.Error())
+		eventCode = events.HostResetPendingUserActionFailed
+		eventProps = map[string]interface{}{"error": err.Error()}
 		return err
 	} else if swag.StringValue(h.Status) == models.HostStatusDisabled {
 		shouldAddEvent = false
@@ -512,7 +610,7 @@ func (m *Manager) IsInstallable(h *models.Host) bool {
 }
 
 func (m *Manager) PrepareForInstallation(ctx context.Context, h *models.Host, db *gorm.DB) error {
-	return m.sm.Run(TransitionTypePrepareForInstallation, newStateHost(h), &TransitionArgsPrepareForInstallation{
+	return m.sm.Run(ctx, TransitionTypePrepareForInstallation, newStateHost(h), &TransitionArgsPrepareForInstallation{
 		ctx: ctx,
 		db:  db,
 	})
@@ -522,17 +620,13 @@ func (m *Manager) reportInstallationMetrics(ctx context.Context, h *models.Host,
 	log := logutil.FromContext(ctx, m.log)
 	//get openshift version from cluster
 	var cluster common.Cluster
-	err := m.db.First(&cluster, "id = ?", h.ClusterID).Error
+	err := m.db.WithContext(ctx).First(&cluster, "id = ?", h.ClusterID).Error
 	if err != nil {
 		log.WithError(err).Errorf("not reporting installation metrics - failed to find cluster %s", h.ClusterID)
 		return
 	}
 	//get the boot disk
-	var boot *models.Disk
-	disks, err := m.hwValidator.GetHostValidDisks(h)
-	if err == nil && len(disks) > 0 {
-		boot = disks[0]
-	}
+	boot, _ := m.hwValidator.GetInstallationDisk(h, &cluster)
 	m.metricApi.ReportHostInstallationMetrics(log, cluster.OpenshiftVersion, h.ClusterID, cluster.EmailDomain, boot, h, previousProgress, CurrentStage)
 }
 
@@ -585,7 +679,7 @@ func (m *Manager) selectRole(ctx context.Context, h *models.Host, db *gorm.DB) (
 
 	if mastersCount < common.MinMasterHostsNeededForInstallation {
 		h.Role = models.HostRoleMaster
-		vc, err := newValidationContext(h, db)
+		vc, err := newValidationContext(h, db, m.hwValidatorCfg, m.log)
 		if err != nil {
 			log.WithError(err).Errorf("failed to create new validation context for host %s", h.ID.String())
 			return autoSelectedRole, err
@@ -609,7 +703,7 @@ func (m *Manager) IsValidMasterCandidate(h *models.Host, db *gorm.DB, log logrus
 	}
 
 	h.Role = models.HostRoleMaster
-	vc, err := newValidationContext(h, db)
+	vc, err := newValidationContext(h, db, m.hwValidatorCfg, m.log)
 	if err != nil {
 		log.WithError(err).Errorf("failed to create new validation context for host %s", h.ID.String())
 		return false, err
@@ -629,23 +723,24 @@ func (m *Manager) IsValidMasterCandidate(h *models.Host, db *gorm.DB, log logrus
 }
 
 func (m *Manager) canBeMaster(conditions map[validationID]bool) bool {
-	if conditions[HasCPUCoresForRole] && conditions[HasMemoryForRole] {
+	if conditions[HasCPUCoresForRole] && conditions[HasMemoryForRole] && conditions[IsDiskSpeedValid] {
 		return true
 	}
 	return false
 }
 
-func (m *Manager) GetHostRequirements(role models.HostRole) models.HostRequirementsRole {
-	return m.hwValidator.GetHostRequirements(role)
+func (m *Manager) GetHostRequirements(role models.HostRole, profile string) (models.HostRequirementsRole, error) {
+	return m.hwValidator.GetHostRequirements(role, profile)
 }
 
-func (m Manager) PermanentHostsDeletion(olderThen strfmt.DateTime) error {
-	var hosts []*models.Host
-	db := m.db.Unscoped()
-	if reply := db.Where("deleted_at < ?", olderThen).Delete(&hosts); reply.Error != nil {
-		return reply.Error
-	} else if reply.RowsAffected > 0 {
-		m.log.Debugf("Deleted %s hosts from db", reply.RowsAffected)
-	}
-	return nil
+func (m Manager) PermanentHostsDeletion(olderThen strfmt.DateTime, leaderElector leader.Leader) error {
+	return leader.InTransaction(m.db, leaderElector, func(tx *gorm.DB) error {
+		var hosts []*models.Host
+		if reply := tx.Unscoped().Where("deleted_at < ?", olderThen).Delete(&hosts); reply.Error != nil {
+			return reply.Error
+		} else if reply.RowsAffected > 0 {
+			m.log.Debugf("Deleted %d hosts from db", reply.RowsAffected)
+		}
+		return deleteOrphanInventoryTables(tx)
+	})
 }
@@ -19,6 +19,7 @@ import (
 	"github.com/openshift/assisted-service/internal/events"
 	"github.com/openshift/assisted-service/internal/hostutil"
 	"github.com/openshift/assisted-service/internal/metrics"
+	"github.com/openshift/assisted-service/internal/webhooks"
 	"github.com/openshift/assisted-service/models"
 	"github.com/openshift/assisted-service/pkg/leader"
 	"github.com/sirupsen/logrus"
@@ -51,7 +52,7 @@ var _ = Describe("update_role", func() {
 	BeforeEach(func() {
 		dummy := &leader.DummyElector{}
 		db = common.PrepareTestDB(dbName, &events.Event{})
-		state = NewManager(getTestLog(), db, nil, nil, nil, createValidatorCfg(), nil, defaultConfig, dummy)
+		state = NewManager(getTestLog(), db, nil, nil, nil, createValidatorCfg(), nil, defaultConfig, dummy, &webhooks.DummyNotifier{})
 		id = strfmt.UUID(uuid.New().String())
 		clusterID = strfmt.UUID(uuid.New().String())
 	})
@@ -188,7 +189,7 @@ var _ = Describe("update_progress", func() {
 		mockEvents = events.NewMockHandler(ctrl)
 		mockMetric = metrics.NewMockAPI(ctrl)
 		dummy := &leader.DummyElector{}
-		state = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(), mockMetric, defaultConfig, dummy)
+		state = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(), mockMetric, defaultConfig, dummy, &webhooks.DummyNotifier{})
 		id := strfmt.UUID(uuid.New().String())
 		clusterId := strfmt.UUID(uuid.New().String())
 		host = getTestHost(id, clusterId, "")
@@ -214,7 +215,7 @@ var _ = Describe("update_progress", func() {
 				progress.CurrentStage = defaultProgressStage
 				mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, host.ID, models.EventSeverityInfo,
 					fmt.Sprintf("Host %s: updated status from \"installing\" to \"installing-in-progress\" (default progress stage)", host.ID.String()),
-					gomock.Any())
+					gomock.Any(), gomock.Any(), gomock.Any())
 				Expect(state.UpdateInstallProgress(ctx, &host, &progress)).ShouldNot(HaveOccurred())
 				hostFromDB = getHost(*host.ID, host.ClusterID, db)
 				Expect(*hostFromDB.Status).Should(Equal(models.HostStatusInstallingInProgress))
@@ -224,7 +225,7 @@ var _ = Describe("update_progress", func() {
 				progress.CurrentStage = defaultProgressStage
 				mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, host.ID, models.EventSeverityInfo,
 					fmt.Sprintf("Host %s: updated status from \"installing\" to \"installing-in-progress\" (default progress stage)", host.ID.String()),
-					gomock.Any())
+					gomock.Any(), gomock.Any(), gomock.Any())
 				Expect(state.UpdateInstallProgress(ctx, &host, &progress)).ShouldNot(HaveOccurred())
 				hostFromDB = getHost(*host.ID, host.ClusterID, db)
 				Expect(*hostFromDB.Status).Should(Equal(models.HostStatusInstallingInProgress))
@@ -241,7 +242,7 @@ var _ = Describe("update_progress", func() {
 				progress.ProgressInfo = "20%"
 				mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, host.ID, models.EventSeverityInfo,
 					fmt.Sprintf("Host %s: updated status from \"installing\" to \"installing-in-progress\" (Writing image to disk)", host.ID.String()),
-					gomock.Any())
+					gomock.Any(), gomock.Any(), gomock.Any())
 				Expect(state.UpdateInstallProgress(ctx, &host, &progress)).ShouldNot(HaveOccurred())
 				hostFromDB = getHost(*host.ID, host.ClusterID, db)
 
@@ -252,7 +253,7 @@ var _ = Describe("update_progress", func() {
 				progress.CurrentStage = models.HostStageDone
 				mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, host.ID, models.EventSeverityInfo,
 					fmt.Sprintf("Host %s: updated status from \"installing\" to \"installed\" (Done)", host.ID.String()),
-					gomock.Any())
+					gomock.Any(), gomock.Any(), gomock.Any())
 				Expect(state.UpdateInstallProgress(ctx, &host, &progress)).ShouldNot(HaveOccurred())
 				hostFromDB = getHost(*host.ID, host.ClusterID, db)
 
@@ -272,7 +273,7 @@ var _ = Describe("update_progress", func() {
 				progress.ProgressInfo = "reason"
 				mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, host.ID, models.EventSeverityError,
 					fmt.Sprintf("Host %s: updated status from \"installing\" to \"error\" (Failed - reason)", host.ID.String()),
-					gomock.Any())
+					gomock.Any(), gomock.Any(), gomock.Any())
 				Expect(state.UpdateInstallProgress(ctx, &host, &progress)).ShouldNot(HaveOccurred())
 				hostFromDB = getHost(*host.ID, host.ClusterID, db)
 
@@ -286,7 +287,7 @@ var _ = Describe("update_progress", func() {
 				mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, host.ID, models.EventSeverityError,
 					fmt.Sprintf("Host %s: updated status from \"installing\" to \"error\" "+
 						"(Failed)", host.ID.String()),
-					gomock.Any())
+					gomock.Any(), gomock.Any(), gomock.Any())
 				Expect(state.UpdateInstallProgress(ctx, &host, &progress)).ShouldNot(HaveOccurred())
 				hostFromDB = getHost(*host.ID, host.ClusterID, db)
 				Expect(*hostFromDB.Status).Should(Equal(models.HostStatusError))
@@ -300,7 +301,7 @@ var _ = Describe("update_progress", func() {
 					mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, host.ID, models.EventSeverityInfo,
 						fmt.Sprintf("Host %s: updated status from \"installing\" to \"installing-in-progress\" "+
 							"(Writing image to disk)", host.ID.String()),
-						gomock.Any())
+						gomock.Any(), gomock.Any(), gomock.Any())
 					Expect(state.UpdateInstallProgress(ctx, &host, &progress)).ShouldNot(HaveOccurred())
 					hostFromDB = getHost(*host.ID, host.ClusterID, db)
 					Expect(*hostFromDB.Status).Should(Equal(models.HostStatusInstallingInProgress))
@@ -318,7 +319,7 @@ var _ = Describe("update_progress", func() {
 					mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, host.ID, models.EventSeverityError,
 						fmt.Sprintf("Host %s: updated status from \"installing-in-progress\" to \"error\" "+
 							"(Failed - reason)", host.ID.String()),
-						gomock.Any())
+						gomock.Any(), gomock.Any(), gomock.Any())
 					Expect(state.UpdateInstallProgress(ctx, hostFromDB, &newProgress)).ShouldNot(HaveOccurred())
 					hostFromDB = getHost(*host.ID, host.ClusterID, db)
 					Expect(*hostFromDB.Status).Should(Equal(models.HostStatusError))
@@ -348,7 +349,7 @@ var _ = Describe("update_progress", func() {
 					mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, host.ID, models.EventSeverityInfo,
 						fmt.Sprintf("Host %s: updated status from \"installing\" to \"installing-in-progress\" "+
 							"(Writing image to disk)", host.ID.String()),
-						gomock.Any())
+						gomock.Any(), gomock.Any(), gomock.Any())
 					Expect(state.UpdateInstallProgress(ctx, &host, &progress)).ShouldNot(HaveOccurred())
 					verifyDb()
 				})
@@ -360,7 +361,7 @@ var _ = Describe("update_progress", func() {
 					mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, host.ID, models.EventSeverityInfo,
 						fmt.Sprintf("Host %s: updated status from \"installing\" to \"installing-in-progress\" "+
 							"(Writing image to disk)", host.ID.String()),
-						gomock.Any())
+						gomock.Any(), gomock.Any(), gomock.Any())
 					Expect(state.UpdateInstallProgress(ctx, hostFromDB, &newProgress)).Should(HaveOccurred())
 					verifyDb()
 				})
@@ -407,7 +408,7 @@ var _ = Describe("cancel installation", func() {
 		db = common.PrepareTestDB(dbName, &events.Event{})
 		eventsHandler = events.New(db, logrus.New())
 		dummy := &leader.DummyElector{}
-		state = NewManager(getTestLog(), db, eventsHandler, nil, nil, nil, nil, defaultConfig, dummy)
+		state = NewManager(getTestLog(), db, eventsHandler, nil, nil, nil, nil, defaultConfig, dummy, &webhooks.DummyNotifier{})
 		id := strfmt.UUID(uuid.New().String())
 		clusterId := strfmt.UUID(uuid.New().String())
 		h = getTestHost(id, clusterId, models.HostStatusDiscovering)
@@ -487,7 +488,7 @@ var _ = Describe("reset host", func() {
 		eventsHandler = events.New(db, logrus.New())
 		config = *defaultConfig
 		dummy := &leader.DummyElector{}
-		state = NewManager(getTestLog(), db, eventsHandler, nil, nil, nil, nil, &config, dummy)
+		state = NewManager(getTestLog(), db, eventsHandler, nil, nil, nil, nil, &config, dummy, &webhooks.DummyNotifier{})
 	})
 
 	Context("reset installation", func() {
@@ -652,6 +653,7 @@ func defaultInventory() string {
 				IPV4Addresses: []string{
 					"1.2.3.4/24",
 				},
+				HasCarrier: true,
 			},
 		},
 		Disks: []*models.Disk{
@@ -678,6 +680,7 @@ func insufficientHWInventory() string {
 				IPV4Addresses: []string{
 					"1.2.3.4/24",
 				},
+				HasCarrier: true,
 			},
 		},
 		Memory:       &models.Memory{PhysicalBytes: 130},
@@ -703,6 +706,7 @@ func inventoryWithUnauthorizedVendor() string {
 				IPV4Addresses: []string{
 					"1.2.3.4/24",
 				},
+				HasCarrier: true,
 			},
 		},
 		Memory:       &models.Memory{PhysicalBytes: gibToBytes(16)},
@@ -730,6 +734,7 @@ func workerInventory() string {
 				IPV4Addresses: []string{
 					"1.2.3.4/24",
 				},
+				HasCarrier: true,
 			},
 		},
 		Memory:       &models.Memory{PhysicalBytes: gibToBytes(8)},
@@ -759,6 +764,7 @@ func masterInventoryWithHostname(hostname string) string {
 				IPV4Addresses: []string{
 					"1.2.3.4/24",
 				},
+				HasCarrier: true,
 			},
 		},
 		Memory:       &models.Memory{PhysicalBytes: gibToBytes(16)},
@@ -784,7 +790,7 @@ var _ = Describe("UpdateInventory", func() {
 	BeforeEach(func() {
 		db = common.PrepareTestDB(dbName, &events.Event{})
 		dummy := &leader.DummyElector{}
-		hapi = NewManager(getTestLog(), db, nil, nil, nil, createValidatorCfg(), nil, defaultConfig, dummy)
+		hapi = NewManager(getTestLog(), db, nil, nil, nil, createValidatorCfg(), nil, defaultConfig, dummy, &webhooks.DummyNotifier{})
 		hostId = strfmt.UUID(uuid.New().String())
 		clusterId = strfmt.UUID(uuid.New().String())
 	})
@@ -877,10 +883,111 @@ var _ = Describe("UpdateInventory", func() {
 				host.Inventory = defaultInventory()
 
 				Expect(db.Create(&host).Error).ShouldNot(HaveOccurred())
-				t.validation(hapi.UpdateInventory(ctx, &host, newInventory))
+				t.validation(hapi.UpdateInventory(ctx, &host, newInventory, nil))
 			})
 		}
 	})
+
+	Context("inventory tables", func() {
+		toJSON := func(inventory *models.Inventory) string {
+			b, err := json.Marshal(inventory)
+			Expect(err).ShouldNot(HaveOccurred())
+			return string(b)
+		}
+		nvmeInventory := &models.Inventory{
+			Disks: []*models.Disk{
+				{Name: "nvme0n1", DriveType: "SSD", SizeBytes: 256 * 1024 * 1024 * 1024},
+				{Name: "sda", DriveType: "HDD", SizeBytes: 512 * 1024 * 1024 * 1024},
+			},
+			Interfaces: []*models.Interface{
+				{
+					Name:          "eth0",
+					MacAddress:    "52:54:00:aa:bb:cc",
+					Mtu:           1500,
+					SpeedMbps:     1000,
+					HasCarrier:    true,
+					IPV4Addresses: []string{"192.168.126.10/24"},
+					IPV6Addresses: []string{"fe80::1/64"},
+				},
+			},
+		}
+
+		BeforeEach(func() {
+			host = getTestHost(hostId, clusterId, models.HostStatusDiscovering)
+			Expect(db.Create(&host).Error).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			common.DeleteTestDB(db, dbName)
+		})
+
+		It("saves the disks, interfaces and addresses", func() {
+			Expect(hapi.UpdateInventory(ctx, &host, toJSON(nvmeInventory), nil)).ShouldNot(HaveOccurred())
+
+			var disks []*common.HostDisk
+			Expect(db.Where("host_id = ?", hostId).Order("name").Find(&disks).Error).ShouldNot(HaveOccurred())
+			Expect(disks).To(HaveLen(2))
+			Expect(disks[0].Name).To(Equal("nvme0n1"))
+			Expect(disks[0].ClusterID).To(Equal(clusterId))
+			Expect(disks[1].DriveType).To(Equal("HDD"))
+
+			var interfaces []*common.HostInterface
+			Expect(db.Where("host_id = ?", hostId).Find(&interfaces).Error).ShouldNot(HaveOccurred())
+			Expect(interfaces).To(HaveLen(1))
+			Expect(interfaces[0].MacAddress).To(Equal("52:54:00:aa:bb:cc"))
+			Expect(interfaces[0].SpeedMbps).To(Equal(int64(1000)))
+
+			var addresses []*common.HostAddress
+			Expect(db.Where("host_id = ?", hostId).Order("address").Find(&addresses).Error).ShouldNot(HaveOccurred())
+			Expect(addresses).To(HaveLen(2))
+			Expect(addresses[0].Address).To(Equal("192.168.126.10/24"))
+			Expect(addresses[0].IPV6).To(BeFalse())
+			Expect(addresses[1].IPV6).To(BeTrue())
+		})
+
+		It("finds the hosts by their disks", func() {
+			otherHostId := strfmt.UUID(uuid.New().String())
+			otherHost := getTestHost(otherHostId, clusterId, models.HostStatusDiscovering)
+			Expect(db.Create(&otherHost).Error).ShouldNot(HaveOccurred())
+			Expect(hapi.UpdateInventory(ctx, &host, toJSON(nvmeInventory), nil)).ShouldNot(HaveOccurred())
+			Expect(hapi.UpdateInventory(ctx, &otherHost, defaultInventory(), nil)).ShouldNot(HaveOccurred())
+
+			var hosts []*models.Host
+			Expect(db.Where("cluster_id = ? and exists (?)", clusterId, db.Model(&common.HostDisk{}).Select("1").
+				Where("host_disks.host_id = hosts.id and host_disks.cluster_id = hosts.cluster_id and host_disks.name like ?", "nvme%")).
+				Find(&hosts).Error).ShouldNot(HaveOccurred())
+			Expect(hosts).To(HaveLen(1))
+			Expect(*hosts[0].ID).To(Equal(hostId))
+		})
+
+		It("replaces the rows of the previous inventory", func() {
+			Expect(hapi.UpdateInventory(ctx, &host, toJSON(nvmeInventory), nil)).ShouldNot(HaveOccurred())
+			Expect(hapi.UpdateInventory(ctx, &host, defaultInventory(), nil)).ShouldNot(HaveOccurred())
+
+			var disks []*common.HostDisk
+			Expect(db.Where("host_id = ?", hostId).Find(&disks).Error).ShouldNot(HaveOccurred())
+			Expect(disks).To(HaveLen(1))
+			Expect(disks[0].Name).To(Equal(defaultDisk.Name))
+		})
+
+		It("deletes the rows of permanently deleted hosts", func() {
+			otherHostId := strfmt.UUID(uuid.New().String())
+			otherHost := getTestHost(otherHostId, clusterId, models.HostStatusDiscovering)
+			Expect(db.Create(&otherHost).Error).ShouldNot(HaveOccurred())
+			Expect(hapi.UpdateInventory(ctx, &host, toJSON(nvmeInventory), nil)).ShouldNot(HaveOccurred())
+			Expect(hapi.UpdateInventory(ctx, &otherHost, toJSON(nvmeInventory), nil)).ShouldNot(HaveOccurred())
+			Expect(db.Delete(&host).Error).ShouldNot(HaveOccurred())
+
+			Expect(hapi.PermanentHostsDeletion(strfmt.DateTime(time.Now().Add(time.Hour)), &leader.DummyElector{})).ShouldNot(HaveOccurred())
+			var count int64
+			for _, table := range []interface{}{&common.HostDisk{}, &common.HostInterface{}, &common.HostAddress{}} {
+				Expect(db.Model(table).Where("host_id = ?", hostId).Count(&count).Error).ShouldNot(HaveOccurred())
+				Expect(count).To(BeZero())
+			}
+			Expect(db.Model(&common.HostDisk{}).Where("host_id = ?", otherHostId).Count(&count).Error).ShouldNot(HaveOccurred())
+			Expect(count).To(Equal(int64(2)))
+		})
+	})
 })
 
 var _ = Describe("Update hostname", func() {
@@ -896,7 +1003,7 @@ var _ = Describe("Update hostname", func() {
 	BeforeEach(func() {
 		db = common.PrepareTestDB(dbName, &events.Event{})
 		dummy := &leader.DummyElector{}
-		hapi = NewManager(getTestLog(), db, nil, nil, nil, createValidatorCfg(), nil, defaultConfig, dummy)
+		hapi = NewManager(getTestLog(), db, nil, nil, nil, createValidatorCfg(), nil, defaultConfig, dummy, &webhooks.DummyNotifier{})
 		hostId = strfmt.UUID(uuid.New().String())
 		clusterId = strfmt.UUID(uuid.New().String())
 	})
@@ -992,6 +1099,57 @@ var _ = Describe("Update hostname", func() {
 	})
 })
 
+var _ = Describe("Update installation disk", func() {
+	var (
+		ctx               = context.Background()
+		hapi              API
+		db                *gorm.DB
+		hostId, clusterId strfmt.UUID
+		host              models.Host
+		dbName            = "update_installation_disk"
+	)
+
+	BeforeEach(func() {
+		db = common.PrepareTestDB(dbName, &events.Event{})
+		dummy := &leader.DummyElector{}
+		hapi = NewManager(getTestLog(), db, nil, nil, nil, createValidatorCfg(), nil, defaultConfig, dummy, &webhooks.DummyNotifier{})
+		hostId = strfmt.UUID(uuid.New().String())
+		clusterId = strfmt.UUID(uuid.New().String())
+	})
+
+	AfterEach(func() {
+		common.DeleteTestDB(db, dbName)
+	})
+
+	for _, srcState := range []string{models.HostStatusDiscovering, models.HostStatusKnown, models.HostStatusDisconnected,
+		models.HostStatusInsufficient, models.HostStatusPendingForInput} {
+		srcState := srcState
+		It(fmt.Sprintf("selects the disk in %s state", srcState), func() {
+			host = getTestHost(hostId, clusterId, srcState)
+			Expect(db.Create(&host).Error).ShouldNot(HaveOccurred())
+			Expect(hapi.UpdateInstallationDisk(ctx, &host, "/dev/disk/by-path/pci-0000:00:1f.2-ata-1", db)).ShouldNot(HaveOccurred())
+			Expect(getHost(hostId, clusterId, db).InstallationDiskID).To(Equal("/dev/disk/by-path/pci-0000:00:1f.2-ata-1"))
+
+			Expect(hapi.UpdateInstallationDisk(ctx, &host, "", db)).ShouldNot(HaveOccurred())
+			Expect(getHost(hostId, clusterId, db).InstallationDiskID).To(BeEmpty())
+		})
+	}
+
+	for _, srcState := range []string{models.HostStatusDisabled, models.HostStatusPreparingForInstallation,
+		models.HostStatusInstalling, models.HostStatusInstallingInProgress, models.HostStatusInstalled,
+		models.HostStatusError, models.HostStatusResetting} {
+		srcState := srcState
+		It(fmt.Sprintf("doesn't select the disk in %s state", srcState), func() {
+			host = getTestHost(hostId, clusterId, srcState)
+			Expect(db.Create(&host).Error).ShouldNot(HaveOccurred())
+			err := hapi.UpdateInstallationDisk(ctx, &host, "/dev/sda", db)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*common.ApiErrorResponse).StatusCode()).To(Equal(int32(http.StatusConflict)))
+			Expect(getHost(hostId, clusterId, db).InstallationDiskID).To(BeEmpty())
+		})
+	}
+})
+
 var _ = Describe("SetBootstrap", func() {
 	var (
 		ctx               = context.Background()
@@ -1009,7 +1167,7 @@ var _ = Describe("SetBootstrap", func() {
 		ctrl = gomock.NewController(GinkgoT())
 		mockEvents = events.NewMockHandler(ctrl)
 		dummy := &leader.DummyElector{}
-		hapi = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(), nil, defaultConfig, dummy)
+		hapi = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(), nil, defaultConfig, dummy, &webhooks.DummyNotifier{})
 		hostId = strfmt.UUID(uuid.New().String())
 		clusterId = strfmt.UUID(uuid.New().String())
 
@@ -1037,7 +1195,7 @@ var _ = Describe("SetBootstrap", func() {
 			if t.IsBootstrap {
 				mockEvents.EXPECT().AddEvent(gomock.Any(), clusterId, &hostId, models.EventSeverityInfo,
 					fmt.Sprintf("Host %s: set as bootstrap", host.ID.String()),
-					gomock.Any())
+					gomock.Any(), gomock.Any(), gomock.Any())
 
 			}
 			Expect(hapi.SetBootstrap(ctx, &host, t.IsBootstrap, db)).ShouldNot(HaveOccurred())
@@ -1066,7 +1224,7 @@ var _ = Describe("PrepareForInstallation", func() {
 		ctrl = gomock.NewController(GinkgoT())
 		mockEvents = events.NewMockHandler(ctrl)
 		dummy := &leader.DummyElector{}
-		hapi = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(), nil, defaultConfig, dummy)
+		hapi = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(), nil, defaultConfig, dummy, &webhooks.DummyNotifier{})
 		hostId = strfmt.UUID(uuid.New().String())
 		clusterId = strfmt.UUID(uuid.New().String())
 	})
@@ -1075,7 +1233,7 @@ var _ = Describe("PrepareForInstallation", func() {
 		host = getTestHost(hostId, clusterId, models.HostStatusKnown)
 		mockEvents.EXPECT().AddEvent(gomock.Any(), clusterId, &hostId, models.EventSeverityInfo,
 			fmt.Sprintf("Host %s: updated status from \"known\" to \"preparing-for-installation\" (Host is preparing for installation)", host.ID.String()),
-			gomock.Any())
+			gomock.Any(), gomock.Any(), gomock.Any())
 		Expect(db.Create(&host).Error).ShouldNot(HaveOccurred())
 		Expect(hapi.PrepareForInstallation(ctx, &host, db)).NotTo(HaveOccurred())
 		h := getHost(hostId, clusterId, db)
@@ -1139,7 +1297,7 @@ var _ = Describe("AutoAssignRole", func() {
 			createValidatorCfg(),
 			nil,
 			defaultConfig,
-			dummy,
+			dummy, &webhooks.DummyNotifier{},
 		)
 		Expect(db.Create(&common.Cluster{Cluster: models.Cluster{ID: &clusterId}}).Error).ShouldNot(HaveOccurred())
 	})
@@ -1228,6 +1386,42 @@ var _ = Describe("AutoAssignRole", func() {
 		Expect(hapi.AutoAssignRole(ctx, &h, db)).ShouldNot(HaveOccurred())
 		Expect(getHost(*h.ID, clusterId, db).Role).Should(Equal(models.HostRoleWorker))
 	})
+	Context("disk speed", func() {
+		BeforeEach(func() {
+			cfg := createValidatorCfg()
+			cfg.MaxFsyncLatencyMs = 10
+			hapi = NewManager(getTestLog(), db, nil, nil, nil, cfg, nil, defaultConfig, &leader.DummyElector{},
+				&webhooks.DummyNotifier{})
+		})
+
+		autoAssign := func(diskSpeed string) models.HostRole {
+			var inventory models.Inventory
+			Expect(json.Unmarshal([]byte(masterInventory()), &inventory)).To(Succeed())
+			inventory.Disks[0].Name = "sda"
+			b, err := json.Marshal(&inventory)
+			Expect(err).ToNot(HaveOccurred())
+			h := getTestHost(strfmt.UUID(uuid.New().String()), clusterId, models.HostStatusKnown)
+			h.Inventory = string(b)
+			h.Role = models.HostRoleAutoAssign
+			h.DiskSpeed = diskSpeed
+			Expect(db.Create(&h).Error).ShouldNot(HaveOccurred())
+			Expect(hapi.AutoAssignRole(ctx, &h, db)).ShouldNot(HaveOccurred())
+			return getHost(*h.ID, clusterId, db).Role
+		}
+
+		It("selects a host with a fast installation disk as master", func() {
+			Expect(autoAssign(`{"path":"/dev/sda","fsync_latency_p99_ms":4.2}`)).Should(Equal(models.HostRoleMaster))
+		})
+
+		It("doesn't select a host with a slow installation disk as master", func() {
+			Expect(autoAssign(`{"path":"/dev/sda","fsync_latency_p99_ms":24.7}`)).Should(Equal(models.HostRoleWorker))
+		})
+
+		It("doesn't select a host whose installation disk wasn't checked as master", func() {
+			Expect(autoAssign("")).Should(Equal(models.HostRoleWorker))
+			Expect(autoAssign(`{"path":"/dev/sdb","fsync_latency_p99_ms":4.2}`)).Should(Equal(models.HostRoleWorker))
+		})
+	})
 })
 
 var _ = Describe("IsValidMasterCandidate", func() {
@@ -1250,7 +1444,7 @@ var _ = Describe("IsValidMasterCandidate", func() {
 			createValidatorCfg(),
 			nil,
 			defaultConfig,
-			dummy,
+			dummy, &webhooks.DummyNotifier{},
 		)
 		Expect(db.Create(&common.Cluster{Cluster: models.Cluster{ID: &clusterId}}).Error).ShouldNot(HaveOccurred())
 	})
@@ -103,7 +103,7 @@ func (i *installCmd) GetSteps(ctx context.Context, host *models.Host) ([]*models
 		data["SERVICE_IPS"] = strings.TrimSpace(i.instructionConfig.ServiceIPs)
 	}
 
-	bootdevice, err := getBootDevice(i.log, i.hwValidator, *host)
+	bootdevice, err := getBootDevice(i.log, i.hwValidator, *host, &cluster)
 	if err != nil {
 		return nil, err
 	}
@@ -132,14 +132,13 @@ func (i *installCmd) hasCACert() bool {
 	return i.instructionConfig.ServiceCACertPath != ""
 }
 
-func getBootDevice(log logrus.FieldLogger, hwValidator hardware.Validator, host models.Host) (string, error) {
-	disks, err := hwValidator.GetHostValidDisks(&host)
-	if err != nil || len(disks) == 0 {
-		err := errors.Errorf("Failed to get valid disks on host with id %s", host.ID)
-		log.Errorf("Failed to get valid disks on host with id %s", host.ID)
-		return "", err
+func getBootDevice(log logrus.FieldLogger, hwValidator hardware.Validator, host models.Host, cluster *common.Cluster) (string, error) {
+	disk, err := hwValidator.GetInstallationDisk(&host, cluster)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to get the installation disk of host with id %s", host.ID)
+		return "", errors.Wrapf(err, "Failed to get the installation disk of host with id %s", host.ID)
 	}
-	return GetDeviceFullName(disks[0].Name), nil
+	return GetDeviceFullName(disk.Name), nil
 }
 
 func GetDeviceFullName(name string) string {
@@ -64,12 +64,13 @@ var _ = Describe("installcmd", func() {
 
 	Context("negative", func() {
 		It("get_step_one_master", func() {
-			mockValidator.EXPECT().GetHostValidDisks(gomock.Any()).Return(nil, errors.New("error")).Times(1)
+			mockValidator.EXPECT().GetInstallationDisk(gomock.Any(), gomock.Any()).Return(nil, errors.New("error")).Times(1)
 		})
 
-		It("get_step_one_master_no_disks", func() {
-			var emptydisks []*models.Disk
-			mockValidator.EXPECT().GetHostValidDisks(gomock.Any()).Return(emptydisks, nil).Times(1)
+		It("get_step_one_master_invalid_installation_disk", func() {
+			Expect(db.Model(&host).Update("installation_disk_id", "/dev/sdz").Error).ShouldNot(HaveOccurred())
+			mockValidator.EXPECT().GetInstallationDisk(gomock.Any(), gomock.Any()).
+				Return(nil, errors.New("disk /dev/sdz isn't in the inventory of the host")).Times(1)
 		})
 
 		AfterEach(func() {
@@ -83,7 +84,7 @@ var _ = Describe("installcmd", func() {
 	})
 
 	It("get_step_one_master_success", func() {
-		mockValidator.EXPECT().GetHostValidDisks(gomock.Any()).Return(disks, nil).Times(1)
+		mockValidator.EXPECT().GetInstallationDisk(gomock.Any(), gomock.Any()).Return(disks[0], nil).Times(1)
 		stepReply, stepErr = installCmd.GetSteps(ctx, &host)
 		postvalidation(false, false, stepReply[0], stepErr, models.HostRoleMaster)
 		validateInstallCommand(stepReply[0], models.HostRoleMaster, string(clusterId), string(*host.ID), "")
@@ -93,11 +94,21 @@ var _ = Describe("installcmd", func() {
 		Expect(hostFromDb.InstallationDiskPath).Should(Equal(GetDeviceFullName(disks[0].Name)))
 	})
 
+	It("get_step_one_master_selected_disk", func() {
+		mockValidator.EXPECT().GetInstallationDisk(gomock.Any(), gomock.Any()).Return(disks[1], nil).Times(1)
+		stepReply, stepErr = installCmd.GetSteps(ctx, &host)
+		postvalidation(false, false, stepReply[0], stepErr, models.HostRoleMaster)
+
+		hostFromDb := getHost(*host.ID, clusterId, db)
+		Expect(hostFromDb.InstallationDiskPath).Should(Equal(GetDeviceFullName(disks[1].Name)))
+		Expect(stepReply[0].Args[1]).Should(ContainSubstring("--boot-device /dev/sda"))
+	})
+
 	It("get_step_three_master_success", func() {
 
 		host2 := createHostInDb(db, clusterId, models.HostRoleMaster, false, "")
 		host3 := createHostInDb(db, clusterId, models.HostRoleMaster, true, "some_hostname")
-		mockValidator.EXPECT().GetHostValidDisks(gomock.Any()).Return(disks, nil).Times(3)
+		mockValidator.EXPECT().GetInstallationDisk(gomock.Any(), gomock.Any()).Return(disks[0], nil).Times(3)
 		stepReply, stepErr = installCmd.GetSteps(ctx, &host)
 		postvalidation(false, false, stepReply[0], stepErr, models.HostRoleMaster)
 		validateInstallCommand(stepReply[0], models.HostRoleMaster, string(clusterId), string(*host.ID), "")
@@ -129,19 +140,19 @@ var _ = Describe("installcmd arguments", func() {
 		controller *gomock.Controller
 	)
 
-	BeforeSuite(func() {
+	BeforeEach(func() {
 		db = common.PrepareTestDB(dbName)
 		cluster := createClusterInDb(db)
 		host = createHostInDb(db, *cluster.ID, models.HostRoleMaster, false, "")
 		disks := []*models.Disk{{Name: "Disk1"}}
 		controller = gomock.NewController(GinkgoT())
 		validator = hardware.NewMockValidator(controller)
-		validator.EXPECT().GetHostValidDisks(gomock.Any()).Return(disks, nil).AnyTimes()
+		validator.EXPECT().GetInstallationDisk(gomock.Any(), gomock.Any()).Return(disks[0], nil).AnyTimes()
 	})
 
-	AfterSuite(func() {
+	AfterEach(func() {
 		controller.Finish()
-
+		common.DeleteTestDB(db, dbName)
 	})
 
 	Context("configuration_params", func() {
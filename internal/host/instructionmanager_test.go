@@ -45,7 +45,7 @@ var _ = Describe("instructionmanager", func() {
 		mockEvents = events.NewMockHandler(ctrl)
 		hwValidator = hardware.NewMockValidator(ctrl)
 		cnValidator = connectivity.NewMockValidator(ctrl)
-		instMng = NewInstructionManager(getTestLog(), db, hwValidator, instructionConfig, cnValidator)
+		instMng = NewInstructionManager(getTestLog(), db, hwValidator, &hardware.ValidatorCfg{MaxFsyncLatencyMs: 10}, instructionConfig, cnValidator)
 		hostId = strfmt.UUID(uuid.New().String())
 		clusterId = strfmt.UUID(uuid.New().String())
 		host = getTestHost(hostId, clusterId, "unknown invalid state")
@@ -72,7 +72,8 @@ var _ = Describe("instructionmanager", func() {
 			})
 			It("known", func() {
 				checkStepsByState(models.HostStatusKnown, &host, db, mockEvents, instMng, hwValidator, cnValidator, ctx,
-					[]models.StepType{models.StepTypeConnectivityCheck, models.StepTypeFreeNetworkAddresses, models.StepTypeInventory})
+					[]models.StepType{models.StepTypeConnectivityCheck, models.StepTypeFreeNetworkAddresses, models.StepTypeInventory,
+						models.StepTypeDiskSpeedCheck})
 			})
 			It("disconnected", func() {
 				checkStepsByState(models.HostStatusDisconnected, &host, db, mockEvents, instMng, hwValidator, cnValidator, ctx,
@@ -80,7 +81,8 @@ var _ = Describe("instructionmanager", func() {
 			})
 			It("insufficient", func() {
 				checkStepsByState(models.HostStatusInsufficient, &host, db, mockEvents, instMng, hwValidator, cnValidator, ctx,
-					[]models.StepType{models.StepTypeInventory, models.StepTypeConnectivityCheck, models.StepTypeFreeNetworkAddresses})
+					[]models.StepType{models.StepTypeInventory, models.StepTypeConnectivityCheck, models.StepTypeFreeNetworkAddresses,
+						models.StepTypeDiskSpeedCheck})
 			})
 			It("pending-for-input", func() {
 				checkStepsByState(models.HostStatusPendingForInput, &host, db, mockEvents, instMng, hwValidator, cnValidator, ctx,
@@ -128,7 +130,8 @@ var _ = Describe("instructionmanager", func() {
 			})
 			It("known", func() {
 				checkStepsByState(models.HostStatusKnown, &host, db, mockEvents, instMng, hwValidator, cnValidator, ctx,
-					[]models.StepType{models.StepTypeConnectivityCheck, models.StepTypeFreeNetworkAddresses, models.StepTypeDhcpLeaseAllocate, models.StepTypeInventory})
+					[]models.StepType{models.StepTypeConnectivityCheck, models.StepTypeFreeNetworkAddresses, models.StepTypeDhcpLeaseAllocate, models.StepTypeInventory,
+						models.StepTypeDiskSpeedCheck})
 			})
 			It("disconnected", func() {
 				checkStepsByState(models.HostStatusDisconnected, &host, db, mockEvents, instMng, hwValidator, cnValidator, ctx,
@@ -136,7 +139,8 @@ var _ = Describe("instructionmanager", func() {
 			})
 			It("insufficient", func() {
 				checkStepsByState(models.HostStatusInsufficient, &host, db, mockEvents, instMng, hwValidator, cnValidator, ctx,
-					[]models.StepType{models.StepTypeInventory, models.StepTypeConnectivityCheck, models.StepTypeFreeNetworkAddresses, models.StepTypeDhcpLeaseAllocate})
+					[]models.StepType{models.StepTypeInventory, models.StepTypeConnectivityCheck, models.StepTypeFreeNetworkAddresses, models.StepTypeDhcpLeaseAllocate,
+						models.StepTypeDiskSpeedCheck})
 			})
 			It("pending-for-input", func() {
 				checkStepsByState(models.HostStatusPendingForInput, &host, db, mockEvents, instMng, hwValidator, cnValidator, ctx,
@@ -179,7 +183,7 @@ func checkStepsByState(state string, host *models.Host, db *gorm.DB, mockEvents
 	instMng *InstructionManager, mockValidator *hardware.MockValidator, mockConnectivity *connectivity.MockValidator,
 	ctx context.Context, expectedStepTypes []models.StepType) {
 
-	mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, host.ID, hostutil.GetEventSeverityFromHostStatus(state), gomock.Any(), gomock.Any())
+	mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, host.ID, hostutil.GetEventSeverityFromHostStatus(state), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 	updateReply, updateErr := updateHostStatus(ctx, getTestLog(), db, mockEvents, host.ClusterID, *host.ID, *host.Status, state, "")
 	ExpectWithOffset(1, updateErr).ShouldNot(HaveOccurred())
 	ExpectWithOffset(1, updateReply).ShouldNot(BeNil())
@@ -191,7 +195,7 @@ func checkStepsByState(state string, host *models.Host, db *gorm.DB, mockEvents
 		{DriveType: "disk", Name: "sda", SizeBytes: validDiskSize},
 		{DriveType: "disk", Name: "sdh", SizeBytes: validDiskSize},
 	}
-	mockValidator.EXPECT().GetHostValidDisks(gomock.Any()).Return(disks, nil).AnyTimes()
+	mockValidator.EXPECT().GetInstallationDisk(gomock.Any(), gomock.Any()).Return(disks[0], nil).AnyTimes()
 	if funk.Contains(expectedStepTypes, models.StepTypeConnectivityCheck) {
 		mockConnectivity.EXPECT().GetHostValidInterfaces(gomock.Any()).Return([]*models.Interface{
 			{
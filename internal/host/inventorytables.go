@@ -0,0 +1,99 @@
+package host
+
+import (
+	"github.com/go-openapi/strfmt"
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/models"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+var inventoryTables = map[string]interface{}{
+	"host_disks":      &common.HostDisk{},
+	"host_interfaces": &common.HostInterface{},
+	"host_addresses":  &common.HostAddress{},
+}
+
+// replaceInventoryTables replaces the disks, interfaces and addresses of the host with the ones of the given
+// inventory, a nil inventory just deletes them
+func replaceInventoryTables(tx *gorm.DB, clusterID, hostID strfmt.UUID, inventory *models.Inventory) error {
+	for _, table := range inventoryTables {
+		if err := tx.Where("cluster_id = ? and host_id = ?", clusterID, hostID).Delete(table).Error; err != nil {
+			return errors.Wrapf(err, "failed to delete inventory of host %s", hostID)
+		}
+	}
+	if inventory == nil {
+		return nil
+	}
+
+	disks := make([]*common.HostDisk, 0, len(inventory.Disks))
+	for _, d := range inventory.Disks {
+		disks = append(disks, &common.HostDisk{
+			ClusterID: clusterID,
+			HostID:    hostID,
+			Name:      d.Name,
+			Path:      d.Path,
+			ByPath:    d.ByPath,
+			DriveType: d.DriveType,
+			SizeBytes: d.SizeBytes,
+			Vendor:    d.Vendor,
+			Model:     d.Model,
+			Serial:    d.Serial,
+			Wwn:       d.Wwn,
+			Hctl:      d.Hctl,
+			Bootable:  d.Bootable,
+			Removable: d.Removable,
+		})
+	}
+	interfaces := make([]*common.HostInterface, 0, len(inventory.Interfaces))
+	var addresses []*common.HostAddress
+	for _, intf := range inventory.Interfaces {
+		interfaces = append(interfaces, &common.HostInterface{
+			ClusterID:   clusterID,
+			HostID:      hostID,
+			Name:        intf.Name,
+			MacAddress:  intf.MacAddress,
+			Mtu:         intf.Mtu,
+			SpeedMbps:   intf.SpeedMbps,
+			HasCarrier:  intf.HasCarrier,
+			Vendor:      intf.Vendor,
+			Product:     intf.Product,
+			Biosdevname: intf.Biosdevname,
+		})
+		for _, a := range intf.IPV4Addresses {
+			addresses = append(addresses, &common.HostAddress{ClusterID: clusterID, HostID: hostID, InterfaceName: intf.Name, Address: a})
+		}
+		for _, a := range intf.IPV6Addresses {
+			addresses = append(addresses, &common.HostAddress{ClusterID: clusterID, HostID: hostID, InterfaceName: intf.Name, Address: a, IPV6: true})
+		}
+	}
+
+	if len(disks) > 0 {
+		if err := tx.Create(disks).Error; err != nil {
+			return errors.Wrapf(err, "failed to save disks of host %s", hostID)
+		}
+	}
+	if len(interfaces) > 0 {
+		if err := tx.Create(interfaces).Error; err != nil {
+			return errors.Wrapf(err, "failed to save interfaces of host %s", hostID)
+		}
+	}
+	if len(addresses) > 0 {
+		if err := tx.Create(addresses).Error; err != nil {
+			return errors.Wrapf(err, "failed to save addresses of host %s", hostID)
+		}
+	}
+	return nil
+}
+
+// deleteOrphanInventoryTables deletes the inventory rows of hosts that were permanently deleted
+func deleteOrphanInventoryTables(db *gorm.DB) error {
+	for name, table := range inventoryTables {
+		hosts := db.Unscoped().Model(&models.Host{}).Select("1").
+			Where("hosts.id = " + name + ".host_id and hosts.cluster_id = " + name + ".cluster_id")
+		if err := db.Where("not exists (?)", hosts).Delete(table).Error; err != nil {
+			return errors.Wrapf(err, "failed to delete %s of deleted hosts", name)
+		}
+	}
+	return nil
+}
@@ -2,19 +2,31 @@ package host
 
 import (
 	"context"
+	"time"
 
 	"github.com/openshift/assisted-service/models"
+	dbPkg "github.com/openshift/assisted-service/pkg/db"
 	"github.com/openshift/assisted-service/pkg/requestid"
+	"github.com/openshift/assisted-service/pkg/shard"
+	"github.com/openshift/assisted-service/pkg/transaction"
+	"github.com/openshift/assisted-service/pkg/workerpool"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
+const defaultMonitorWorkers = 8
+
 func (m *Manager) HostMonitoring() {
-	if !m.leaderElector.IsLeader() {
+	monitorShard := shard.Of(m.leaderElector)
+	if !monitorShard.Active() {
 		m.log.Debugf("Not a leader, exiting HostMonitoring")
 		return
 	}
 	m.log.Debugf("Running HostMonitoring")
 	var (
 		offset    int
+		monitored int
+		startedAt = time.Now()
 		limit     = m.Config.MonitorBatchSize
 		requestID = requestid.NewID()
 		ctx       = requestid.ToContext(context.Background(), requestID)
@@ -32,6 +44,8 @@ func (m *Manager) HostMonitoring() {
 		models.HostStatusInstallingInProgress,
 		models.HostStatusInstalled,
 	}
+	pool := workerpool.New(dbPkg.Workers(m.db, m.Config.MonitorWorkers, defaultMonitorWorkers))
+	defer pool.Wait()
 	for {
 		//for offset = 0; offset < count; offset += limit {
 		hosts := make([]*models.Host, 0, limit)
@@ -44,14 +58,38 @@ func (m *Manager) HostMonitoring() {
 			break
 		}
 		for _, host := range hosts {
-			if !m.leaderElector.IsLeader() {
+			if !monitorShard.Active() {
 				m.log.Debugf("Not a leader, exiting HostMonitoring")
 				return
 			}
-			if err := m.RefreshStatus(ctx, host, m.db); err != nil {
-				log.WithError(err).Errorf("failed to refresh host %s state", *host.ID)
+			if !monitorShard.Owns(host.ClusterID) {
+				continue
 			}
+			monitored++
+			host := host
+			// the hosts of a cluster are refreshed one after the other, in the order of the query
+			pool.Submit(host.ClusterID.String(), func() {
+				m.monitorHost(ctx, log, host)
+			})
 		}
 		offset += limit
 	}
+	pool.Wait()
+	m.metricApi.MonitoringCycle(shard.MonitorHosts, time.Since(startedAt))
+	monitorShard.PassCompleted(shard.MonitorHosts, startedAt, monitored)
+}
+
+func (m *Manager) monitorHost(ctx context.Context, log logrus.FieldLogger, host *models.Host) {
+	// hold the cluster lock, so the refresh won't race with concurrent updates of the cluster.
+	// The writes of the monitor don't change the resource versions the users edit by.
+	err := transaction.InClusterTransaction(ctx, dbPkg.SkipResourceVersion(m.db), host.ClusterID, func(ctx context.Context, tx *gorm.DB) error {
+		// reload the host, it might have been changed while waiting for the lock
+		if err := tx.Take(host, "id = ? and cluster_id = ?", host.ID.String(), host.ClusterID.String()).Error; err != nil {
+			return err
+		}
+		return m.RefreshStatus(ctx, host, tx)
+	})
+	if err != nil {
+		log.WithError(err).Errorf("failed to refresh host %s state", *host.ID)
+	}
 }
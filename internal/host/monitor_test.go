@@ -14,8 +14,11 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/openshift/assisted-service/internal/common"
 	"github.com/openshift/assisted-service/internal/events"
+	"github.com/openshift/assisted-service/internal/metrics"
+	"github.com/openshift/assisted-service/internal/webhooks"
 	"github.com/openshift/assisted-service/models"
 	"github.com/openshift/assisted-service/pkg/leader"
+	"github.com/openshift/assisted-service/pkg/shard"
 	"gorm.io/gorm"
 )
 
@@ -32,11 +35,18 @@ var _ = Describe("monitor_disconnection", func() {
 
 	BeforeEach(func() {
 		db = common.PrepareTestDB(dbName, &events.Event{})
+		// The monitor refreshes every host in the database, so hosts left behind by other specs in the shared
+		// in-memory database would emit events that are not expected here
+		Expect(db.Exec("DELETE FROM hosts").Error).ShouldNot(HaveOccurred())
+		Expect(db.Exec("DELETE FROM clusters").Error).ShouldNot(HaveOccurred())
 		ctrl = gomock.NewController(GinkgoT())
 		mockEvents = events.NewMockHandler(ctrl)
+		mockMetric := metrics.NewMockAPI(ctrl)
+		mockMetric.EXPECT().MonitoringCycle(shard.MonitorHosts, gomock.Any()).AnyTimes()
+		mockMetric.EXPECT().HostValidationFailed(gomock.Any()).AnyTimes()
 		dummy := &leader.DummyElector{}
 		state = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(),
-			nil, defaultConfig, dummy)
+			mockMetric, defaultConfig, dummy, &webhooks.DummyNotifier{})
 		clusterID := strfmt.UUID(uuid.New().String())
 		host = getTestHost(strfmt.UUID(uuid.New().String()), clusterID, models.HostStatusDiscovering)
 		cluster := getTestCluster(clusterID, "1.1.0.0/16")
@@ -70,10 +80,13 @@ var _ = Describe("monitor_disconnection", func() {
 			mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, host.ID, models.EventSeverityWarning,
 				fmt.Sprintf("Host %s: updated status from \"%s\" to \"disconnected\" (Host has stopped communicating with the installation service)",
 					host.ID.String(), *host.Status),
-				gomock.Any())
+				gomock.Any(), gomock.Any(), gomock.Any())
+			db.First(&host, "id = ? and cluster_id = ?", host.ID, host.ClusterID)
+			resourceVersion := host.ResourceVersion
 			state.HostMonitoring()
 			db.First(&host, "id = ? and cluster_id = ?", host.ID, host.ClusterID)
 			Expect(*host.Status).Should(Equal(models.HostStatusDisconnected))
+			Expect(host.ResourceVersion).Should(Equal(resourceVersion))
 		})
 	})
 
@@ -88,7 +101,7 @@ var _ = Describe("monitor_disconnection", func() {
 		AfterEach(func() {
 			mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, host.ID, models.EventSeverityInfo,
 				fmt.Sprintf("Host %s: updated status from \"disconnected\" to \"discovering\" (Waiting for host to send hardware details)", host.ID.String()),
-				gomock.Any())
+				gomock.Any(), gomock.Any(), gomock.Any())
 			state.HostMonitoring()
 			db.First(&host, "id = ? and cluster_id = ?", host.ID, host.ClusterID)
 			Expect(*host.Status).Should(Equal(models.HostStatusDiscovering))
@@ -115,6 +128,7 @@ var _ = Describe("TestHostMonitoring", func() {
 		ctrl       *gomock.Controller
 		cfg        Config
 		mockEvents *events.MockHandler
+		mockMetric *metrics.MockAPI
 		dbName     = "host_monitor_tests"
 		clusterID  = strfmt.UUID(uuid.New().String())
 	)
@@ -124,11 +138,14 @@ var _ = Describe("TestHostMonitoring", func() {
 		ctrl = gomock.NewController(GinkgoT())
 		mockEvents = events.NewMockHandler(ctrl)
 		mockEvents.EXPECT().
-			AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			AnyTimes()
+		mockMetric = metrics.NewMockAPI(ctrl)
+		mockMetric.EXPECT().MonitoringCycle(shard.MonitorHosts, gomock.Any()).AnyTimes()
+		mockMetric.EXPECT().HostValidationFailed(gomock.Any()).AnyTimes()
 		Expect(envconfig.Process("myapp", &cfg)).ShouldNot(HaveOccurred())
 		state = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(),
-			nil, &cfg, &leader.DummyElector{})
+			mockMetric, &cfg, &leader.DummyElector{}, &webhooks.DummyNotifier{})
 	})
 
 	AfterEach(func() {
@@ -170,4 +187,59 @@ var _ = Describe("TestHostMonitoring", func() {
 		})
 	})
 
+	Context("validate monitor of a shard", func() {
+		It("refreshes only the hosts of the clusters in the shard", func() {
+			monitorShard := &testShard{owned: map[strfmt.UUID]bool{}}
+			state = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(),
+				mockMetric, &cfg, monitorShard, &webhooks.DummyNotifier{})
+			clusterIDs := []strfmt.UUID{strfmt.UUID(uuid.New().String()), strfmt.UUID(uuid.New().String())}
+			monitorShard.owned[clusterIDs[0]] = true
+			for _, id := range clusterIDs {
+				cluster := getTestCluster(id, "1.1.0.0/16")
+				Expect(db.Save(&cluster).Error).ToNot(HaveOccurred())
+				for i := 0; i < 3; i++ {
+					host = getTestHost(strfmt.UUID(uuid.New().String()), id, models.HostStatusDiscovering)
+					host.Inventory = workerInventory()
+					Expect(state.RegisterHost(ctx, &host)).ShouldNot(HaveOccurred())
+					host.CheckedInAt = strfmt.DateTime(time.Now().Add(-4 * time.Minute))
+					db.Save(&host)
+				}
+			}
+
+			state.HostMonitoring()
+			for _, id := range clusterIDs {
+				var count int64
+				Expect(db.Model(&models.Host{}).Where("cluster_id = ? and status = ?", id.String(), models.HostStatusDisconnected).
+					Count(&count).Error).ShouldNot(HaveOccurred())
+				if monitorShard.owned[id] {
+					Expect(count).Should(Equal(int64(3)))
+				} else {
+					Expect(count).Should(BeZero())
+				}
+			}
+			Expect(monitorShard.passes).Should(Equal(map[string]int{shard.MonitorHosts: 3}))
+		})
+	})
+
 })
+
+type testShard struct {
+	owned  map[strfmt.UUID]bool
+	passes map[string]int
+}
+
+func (s *testShard) IsLeader() bool {
+	return true
+}
+
+func (s *testShard) Active() bool {
+	return true
+}
+
+func (s *testShard) Owns(clusterID strfmt.UUID) bool {
+	return s.owned[clusterID]
+}
+
+func (s *testShard) PassCompleted(monitor string, _ time.Time, items int) {
+	s.passes = map[string]int{monitor: items}
+}
@@ -120,6 +120,31 @@ func newValidations(log logrus.FieldLogger, hwValidatorCfg *hardware.ValidatorCf
 			condition: v.isValidPlatform,
 			formatter: v.printValidPlatform,
 		},
+		{
+			id:        IsInstallationDiskValid,
+			condition: v.isInstallationDiskValid,
+			formatter: v.printInstallationDiskValid,
+		},
+		{
+			id:        IsDiskSpeedValid,
+			condition: v.isDiskSpeedValid,
+			formatter: v.printDiskSpeedValid,
+		},
+		{
+			id:        HasMachineNetworkCarrier,
+			condition: v.hasMachineNetworkCarrier,
+			formatter: v.printHasMachineNetworkCarrier,
+		},
+		{
+			id:        IsMachineNetworkLinkSpeedValid,
+			condition: v.isMachineNetworkLinkSpeedValid,
+			formatter: v.printMachineNetworkLinkSpeedValid,
+		},
+		{
+			id:        IsMachineNetworkMtuConsistent,
+			condition: v.isMachineNetworkMtuConsistent,
+			formatter: v.printMachineNetworkMtuConsistent,
+		},
 	}
 	return ret
 }
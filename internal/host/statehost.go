@@ -1,20 +1,37 @@
 package host
 
 import (
+	"context"
+
 	"github.com/filanov/stateswitch"
 	"github.com/go-openapi/swag"
+	"github.com/openshift/assisted-service/internal/history"
 	"github.com/openshift/assisted-service/models"
+	"github.com/openshift/assisted-service/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type stateHost struct {
-	srcState string
-	host     *models.Host
+	srcState       string
+	srcStatusInfo  string
+	transitionType stateswitch.TransitionType
+	host           *models.Host
 }
 
 func newStateHost(h *models.Host) *stateHost {
 	return &stateHost{
-		srcState: swag.StringValue(h.Status),
-		host:     h,
+		srcState:      swag.StringValue(h.Status),
+		srcStatusInfo: swag.StringValue(h.StatusInfo),
+		host:          h,
+	}
+}
+
+// transition returns the transition that is running, for the history of the host
+func (sh *stateHost) transition() *history.Transition {
+	return &history.Transition{
+		Type:           string(sh.transitionType),
+		FromStatus:     sh.srcState,
+		FromStatusInfo: sh.srcStatusInfo,
 	}
 }
 
@@ -26,3 +43,28 @@ func (sh *stateHost) SetState(state stateswitch.State) error {
 	sh.host.Status = swag.String(string(state))
 	return nil
 }
+
+// stateMachine keeps the type of the running transition in the state of the host, and records a span of the
+// transition when it's part of a traced operation
+type stateMachine struct {
+	stateswitch.StateMachine
+}
+
+func (sm *stateMachine) Run(ctx context.Context, transitionType stateswitch.TransitionType, sw stateswitch.StateSwitch,
+	args stateswitch.TransitionArgs) (err error) {
+	sHost, ok := sw.(*stateHost)
+	var attrs []attribute.KeyValue
+	if ok {
+		sHost.transitionType = transitionType
+		attrs = []attribute.KeyValue{attribute.String("host.id", sHost.host.ID.String()),
+			attribute.String("cluster.id", sHost.host.ClusterID.String()), attribute.String("host.from_status", sHost.srcState)}
+	}
+	ctx, span := tracing.StartInTrace(ctx, "host."+string(transitionType), attrs...)
+	defer func() {
+		if ok {
+			span.SetAttributes(attribute.String("host.to_status", swag.StringValue(sHost.host.Status)))
+		}
+		tracing.End(ctx, span, err)
+	}()
+	return sm.StateMachine.Run(transitionType, sw, args)
+}
@@ -19,7 +19,7 @@ const (
 	TransitionTypeRegisterInstalledHost      = "RegisterInstalledHost"
 )
 
-func NewHostStateMachine(th *transitionHandler) stateswitch.StateMachine {
+func NewHostStateMachine(th *transitionHandler) *stateMachine {
 	sm := stateswitch.NewStateMachine()
 
 	// Register host
@@ -348,12 +348,14 @@ func NewHostStateMachine(th *transitionHandler) stateswitch.StateMachine {
 		PostTransition:   th.PostRefreshHost(statusInfoDiscovering),
 	})
 
-	var hasMinRequiredHardware = stateswitch.And(If(HasMinValidDisks), If(HasMinCPUCores), If(HasMinMemory), If(IsPlatformValid))
+	var hasMinRequiredHardware = stateswitch.And(If(HasMinValidDisks), If(HasMinCPUCores), If(HasMinMemory), If(IsPlatformValid),
+		If(IsInstallationDiskValid))
 
 	var requiredInputFieldsExist = stateswitch.And(If(IsMachineCidrDefined))
 
 	var isSufficientForInstall = stateswitch.And(If(HasMemoryForRole), If(HasCPUCoresForRole), If(BelongsToMachineCidr),
-		If(IsHostnameUnique), If(IsHostnameValid), If(IsAPIVipConnected), If(BelongsToMajorityGroup))
+		If(IsHostnameUnique), If(IsHostnameValid), If(IsAPIVipConnected), If(BelongsToMajorityGroup), If(IsDiskSpeedValid),
+		If(HasMachineNetworkCarrier), If(IsMachineNetworkLinkSpeedValid), If(IsMachineNetworkMtuConsistent))
 
 	// In order for this transition to be fired at least one of the validations in minRequiredHardwareValidations must fail.
 	// This transition handles the case that a host does not pass minimum hardware requirements for any of the roles
@@ -470,5 +472,5 @@ func NewHostStateMachine(th *transitionHandler) stateswitch.StateMachine {
 		DestinationState: stateswitch.State(models.HostStatusAddedToExistingCluster),
 	})
 
-	return sm
+	return &stateMachine{StateMachine: sm}
 }
@@ -11,6 +11,10 @@ import (
 	"encoding/json"
 
 	"github.com/openshift/assisted-service/internal/events"
+	"github.com/openshift/assisted-service/internal/history"
+	"github.com/openshift/assisted-service/internal/hostutil"
+	"github.com/openshift/assisted-service/internal/metrics"
+	"github.com/openshift/assisted-service/internal/webhooks"
 
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
@@ -29,6 +33,7 @@ type transitionHandler struct {
 	db            *gorm.DB
 	log           logrus.FieldLogger
 	eventsHandler events.Handler
+	webhooks      webhooks.Notifier
 }
 
 ////////////////////////////////////////////////////////////////////////////
@@ -63,14 +68,23 @@ func (th *transitionHandler) PostRegisterHost(sw stateswitch.StateSwitch, args s
 			return err
 		} else {
 			sHost.host = host
-			return nil
+			if err = notifyStatusChange(params.ctx, th.db, th.webhooks, host, sHost.srcState); err != nil {
+				return err
+			}
+			if err = history.RecordHostTransition(params.ctx, th.db, host, sHost.transition()); err != nil {
+				return err
+			}
+			return replaceInventoryTables(th.db, sHost.host.ClusterID, *sHost.host.ID, nil)
 		}
 	}
 
 	sHost.host.StatusUpdatedAt = strfmt.DateTime(time.Now())
 	sHost.host.StatusInfo = swag.String(statusInfoDiscovering)
 	log.Infof("Register new host %s cluster %s", sHost.host.ID.String(), sHost.host.ClusterID)
-	return th.db.Create(sHost.host).Error
+	if err := th.db.Create(sHost.host).Error; err != nil {
+		return err
+	}
+	return history.RecordHostTransition(params.ctx, th.db, sHost.host, sHost.transition())
 }
 
 func (th *transitionHandler) PostRegisterDuringInstallation(sw stateswitch.StateSwitch, args stateswitch.TransitionArgs) error {
@@ -115,8 +129,7 @@ func (th *transitionHandler) PostRegisterDuringReboot(sw stateswitch.StateSwitch
 
 	var installationDisk *models.Disk = nil
 
-	var inventory models.Inventory
-	err := json.Unmarshal([]byte(sHost.host.Inventory), &inventory)
+	inventory, err := hostutil.UnmarshalInventory(sHost.host)
 	if err != nil {
 		return errors.New(fmt.Sprintf("PostRegisterDuringReboot Could not parse inventory of host %s", *sHost.host.ID))
 	}
@@ -305,6 +318,9 @@ func (th *transitionHandler) PostEnableHost(sw stateswitch.StateSwitch, args sta
 		return errors.New("PostEnableHost invalid argument")
 	}
 
+	if err := replaceInventoryTables(params.db, sHost.host.ClusterID, *sHost.host.ID, nil); err != nil {
+		return err
+	}
 	return th.updateTransitionHost(params.ctx, logutil.FromContext(params.ctx, th.log), params.db, sHost,
 		statusInfoDiscovering, "inventory", "")
 }
@@ -370,7 +386,10 @@ func (th *transitionHandler) updateTransitionHost(ctx context.Context, log logru
 		return err
 	} else {
 		state.host = host
-		return nil
+		if err = notifyStatusChange(ctx, db, th.webhooks, host, state.srcState); err != nil {
+			return err
+		}
+		return history.RecordHostTransition(ctx, db, host, state.transition())
 	}
 }
 
@@ -381,6 +400,7 @@ func (th *transitionHandler) updateTransitionHost(ctx context.Context, log logru
 type TransitionArgsRefreshHost struct {
 	ctx               context.Context
 	eventHandler      events.Handler
+	metricApi         metrics.API
 	conditions        map[validationID]bool
 	validationResults map[string][]validationResult
 	db                *gorm.DB
@@ -515,9 +535,18 @@ func (th *transitionHandler) PostRefreshHost(reason string) stateswitch.PostTran
 			template = strings.Replace(template, "$FAILING_VALIDATIONS", strings.Join(failedValidations, " ; "), 1)
 		}
 
-		_, err = updateHostStatus(params.ctx, logutil.FromContext(params.ctx, th.log), params.db, th.eventsHandler, sHost.host.ClusterID, *sHost.host.ID,
+		host, err := updateHostStatus(params.ctx, logutil.FromContext(params.ctx, th.log), params.db, th.eventsHandler, sHost.host.ClusterID, *sHost.host.ID,
 			sHost.srcState, swag.StringValue(sHost.host.Status), template, "validations_info", string(b))
-		return err
+		if err != nil {
+			return err
+		}
+		if err = notifyStatusChange(params.ctx, params.db, th.webhooks, host, sHost.srcState); err != nil {
+			return err
+		}
+		for _, id := range metrics.NewValidationFailures(sHost.host.ValidationsInfo, string(b)) {
+			params.metricApi.HostValidationFailed(id)
+		}
+		return history.RecordHostTransition(params.ctx, params.db, host, sHost.transition())
 	}
 	return ret
 }
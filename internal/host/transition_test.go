@@ -14,8 +14,10 @@ import (
 	"github.com/openshift/assisted-service/internal/hardware"
 	"github.com/openshift/assisted-service/internal/hostutil"
 	"github.com/openshift/assisted-service/internal/metrics"
+	"github.com/openshift/assisted-service/internal/webhooks"
 	"github.com/openshift/assisted-service/models"
 
+	"github.com/alecthomas/units"
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
 	"github.com/golang/mock/gomock"
@@ -53,7 +55,7 @@ var _ = Describe("RegisterHost", func() {
 		ctrl = gomock.NewController(GinkgoT())
 		db = common.PrepareTestDB(dbName, &events.Event{})
 		mockEvents = events.NewMockHandler(ctrl)
-		hapi = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(), nil, defaultConfig, nil)
+		hapi = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(), nil, defaultConfig, nil, &webhooks.DummyNotifier{})
 		hostId = strfmt.UUID(uuid.New().String())
 		clusterId = strfmt.UUID(uuid.New().String())
 	})
@@ -116,7 +118,7 @@ var _ = Describe("RegisterHost", func() {
 				}).Error).ShouldNot(HaveOccurred())
 
 				if t.expectedEventInfo != "" && t.expectedEventStatus != "" {
-					mockEvents.EXPECT().AddEvent(gomock.Any(), clusterId, &hostId, t.expectedEventStatus, fmt.Sprintf(t.expectedEventInfo, hostId.String()), gomock.Any())
+					mockEvents.EXPECT().AddEvent(gomock.Any(), clusterId, &hostId, t.expectedEventStatus, fmt.Sprintf(t.expectedEventInfo, hostId.String()), gomock.Any(), gomock.Any(), gomock.Any())
 				}
 
 				err := hapi.RegisterHost(ctx, &models.Host{
@@ -227,7 +229,7 @@ var _ = Describe("RegisterHost", func() {
 					mockEvents.EXPECT().AddEvent(gomock.Any(), clusterId, &hostId, models.EventSeverityInfo,
 						fmt.Sprintf("Host %s: updated status from \"%s\" to \"discovering\" (%s)",
 							hostId.String(), t.srcState, statusInfoDiscovering),
-						gomock.Any())
+						gomock.Any(), gomock.Any(), gomock.Any())
 				}
 
 				Expect(hapi.RegisterHost(ctx, &models.Host{
@@ -374,7 +376,7 @@ var _ = Describe("RegisterHost", func() {
 						&hostId,
 						t.eventSeverity,
 						fmt.Sprintf(t.eventMessage, hostId.String()),
-						gomock.Any())
+						gomock.Any(), gomock.Any(), gomock.Any())
 				}
 
 				Expect(hapi.RegisterHost(ctx, &models.Host{
@@ -416,7 +418,7 @@ var _ = Describe("HostInstallationFailed", func() {
 		ctrl = gomock.NewController(GinkgoT())
 		mockMetric = metrics.NewMockAPI(ctrl)
 		mockEvents = events.NewMockHandler(ctrl)
-		hapi = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(), mockMetric, defaultConfig, nil)
+		hapi = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(), mockMetric, defaultConfig, nil, &webhooks.DummyNotifier{})
 		hostId = strfmt.UUID(uuid.New().String())
 		clusterId = strfmt.UUID(uuid.New().String())
 		host = getTestHost(hostId, clusterId, "")
@@ -427,7 +429,7 @@ var _ = Describe("HostInstallationFailed", func() {
 	It("handle_installation_error", func() {
 		mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, &hostId, models.EventSeverityError,
 			fmt.Sprintf("Host %s: updated status from \"installing\" to \"error\" (installation command failed)", host.ID.String()),
-			gomock.Any())
+			gomock.Any(), gomock.Any(), gomock.Any())
 		mockMetric.EXPECT().ReportHostInstallationMetrics(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 		Expect(hapi.HandleInstallationFailure(ctx, &host)).ShouldNot(HaveOccurred())
 		h := getHost(hostId, clusterId, db)
@@ -455,7 +457,7 @@ var _ = Describe("RegisterInstalledOCPHost", func() {
 		ctrl = gomock.NewController(GinkgoT())
 		mockMetric = metrics.NewMockAPI(ctrl)
 		mockEvents = events.NewMockHandler(ctrl)
-		hapi = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(), mockMetric, defaultConfig, nil)
+		hapi = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(), mockMetric, defaultConfig, nil, &webhooks.DummyNotifier{})
 		hostId = strfmt.UUID(uuid.New().String())
 		clusterId = strfmt.UUID(uuid.New().String())
 		host = getTestHost(hostId, clusterId, "")
@@ -485,7 +487,7 @@ var _ = Describe("Cancel host installation", func() {
 		db = common.PrepareTestDB(dbName, &events.Event{})
 		ctrl = gomock.NewController(GinkgoT())
 		mockEventsHandler = events.NewMockHandler(ctrl)
-		hapi = NewManager(getTestLog(), db, mockEventsHandler, nil, nil, createValidatorCfg(), nil, defaultConfig, nil)
+		hapi = NewManager(getTestLog(), db, mockEventsHandler, nil, nil, createValidatorCfg(), nil, defaultConfig, nil, &webhooks.DummyNotifier{})
 	})
 
 	tests := []struct {
@@ -509,7 +511,7 @@ var _ = Describe("Cancel host installation", func() {
 	}
 
 	acceptNewEvents := func(times int) {
-		mockEventsHandler.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(times)
+		mockEventsHandler.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(times)
 	}
 
 	for _, t := range tests {
@@ -559,7 +561,7 @@ var _ = Describe("Reset host", func() {
 		db = common.PrepareTestDB(dbName, &events.Event{})
 		ctrl = gomock.NewController(GinkgoT())
 		mockEventsHandler = events.NewMockHandler(ctrl)
-		hapi = NewManager(getTestLog(), db, mockEventsHandler, nil, nil, createValidatorCfg(), nil, defaultConfig, nil)
+		hapi = NewManager(getTestLog(), db, mockEventsHandler, nil, nil, createValidatorCfg(), nil, defaultConfig, nil, &webhooks.DummyNotifier{})
 	})
 
 	tests := []struct {
@@ -583,7 +585,7 @@ var _ = Describe("Reset host", func() {
 	}
 
 	acceptNewEvents := func(times int) {
-		mockEventsHandler.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(times)
+		mockEventsHandler.EXPECT().AddEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(times)
 	}
 
 	for _, t := range tests {
@@ -633,7 +635,7 @@ var _ = Describe("Install", func() {
 		db = common.PrepareTestDB(dbName, &events.Event{})
 		ctrl = gomock.NewController(GinkgoT())
 		mockEvents = events.NewMockHandler(ctrl)
-		hapi = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(), nil, defaultConfig, nil)
+		hapi = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(), nil, defaultConfig, nil, &webhooks.DummyNotifier{})
 		hostId = strfmt.UUID(uuid.New().String())
 		clusterId = strfmt.UUID(uuid.New().String())
 	})
@@ -725,7 +727,7 @@ var _ = Describe("Install", func() {
 				Expect(db.Create(&host).Error).ShouldNot(HaveOccurred())
 				mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, &hostId, models.EventSeverityInfo,
 					fmt.Sprintf("Host %s: updated status from \"%s\" to \"installing\" (Installation is in progress)", host.ID.String(), t.srcState),
-					gomock.Any())
+					gomock.Any(), gomock.Any(), gomock.Any())
 				t.validation(hapi.Install(ctx, &host, nil))
 			})
 		}
@@ -743,7 +745,7 @@ var _ = Describe("Install", func() {
 			Expect(tx.Error).To(BeNil())
 			mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, &hostId, models.EventSeverityInfo,
 				fmt.Sprintf("Host %s: updated status from \"preparing-for-installation\" to \"installing\" (Installation is in progress)", host.ID.String()),
-				gomock.Any())
+				gomock.Any(), gomock.Any(), gomock.Any())
 			Expect(hapi.Install(ctx, &host, tx)).ShouldNot(HaveOccurred())
 			Expect(tx.Commit().Error).ShouldNot(HaveOccurred())
 			h := getHost(hostId, clusterId, db)
@@ -756,7 +758,7 @@ var _ = Describe("Install", func() {
 			Expect(tx.Error).To(BeNil())
 			mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, &hostId, models.EventSeverityInfo,
 				fmt.Sprintf("Host %s: updated status from \"preparing-for-installation\" to \"installing\" (Installation is in progress)", host.ID.String()),
-				gomock.Any())
+				gomock.Any(), gomock.Any(), gomock.Any())
 			Expect(hapi.Install(ctx, &host, tx)).ShouldNot(HaveOccurred())
 			Expect(tx.Rollback().Error).ShouldNot(HaveOccurred())
 			h := getHost(hostId, clusterId, db)
@@ -774,6 +776,7 @@ var _ = Describe("Disable", func() {
 		db                *gorm.DB
 		ctrl              *gomock.Controller
 		mockEvents        *events.MockHandler
+		mockWebhooks      *webhooks.MockNotifier
 		hostId, clusterId strfmt.UUID
 		host              models.Host
 		dbName            = "transition_disable"
@@ -783,7 +786,8 @@ var _ = Describe("Disable", func() {
 		db = common.PrepareTestDB(dbName, &events.Event{})
 		ctrl = gomock.NewController(GinkgoT())
 		mockEvents = events.NewMockHandler(ctrl)
-		hapi = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(), nil, defaultConfig, nil)
+		mockWebhooks = webhooks.NewMockNotifier(ctrl)
+		hapi = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(), nil, defaultConfig, nil, mockWebhooks)
 		hostId = strfmt.UUID(uuid.New().String())
 		clusterId = strfmt.UUID(uuid.New().String())
 	})
@@ -795,6 +799,14 @@ var _ = Describe("Disable", func() {
 			h := getHost(hostId, clusterId, db)
 			Expect(*h.Status).Should(Equal(models.HostStatusDisabled))
 			Expect(*h.StatusInfo).Should(Equal(statusInfoDisabled))
+
+			var transitions []*models.StateTransition
+			Expect(db.Find(&transitions, "host_id = ?", hostId.String()).Error).ShouldNot(HaveOccurred())
+			Expect(transitions).To(HaveLen(1))
+			Expect(transitions[0].TransitionType).Should(Equal(TransitionTypeDisableHost))
+			Expect(transitions[0].FromStatus).Should(Equal(srcState))
+			Expect(*transitions[0].ToStatus).Should(Equal(models.HostStatusDisabled))
+			Expect(transitions[0].Reason).Should(Equal(statusInfoDisabled))
 		}
 
 		failure := func(reply error) {
@@ -807,7 +819,8 @@ var _ = Describe("Disable", func() {
 			mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, &hostId, models.EventSeverityInfo,
 				fmt.Sprintf(`Host %s: updated status from "%s" to "disabled" (Host was manually disabled)`,
 					host.ID.String(), srcState),
-				gomock.Any()).Times(1)
+				gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
+			mockWebhooks.EXPECT().HostStatusChanged(gomock.Any(), gomock.Any(), gomock.Any(), srcState).Return(nil).Times(1)
 		}
 
 		tests := []struct {
@@ -914,7 +927,7 @@ var _ = Describe("Enable", func() {
 		db = common.PrepareTestDB(dbName, &events.Event{})
 		ctrl = gomock.NewController(GinkgoT())
 		mockEvents = events.NewMockHandler(ctrl)
-		hapi = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(), nil, defaultConfig, nil)
+		hapi = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(), nil, defaultConfig, nil, &webhooks.DummyNotifier{})
 		hostId = strfmt.UUID(uuid.New().String())
 		clusterId = strfmt.UUID(uuid.New().String())
 	})
@@ -1014,7 +1027,7 @@ var _ = Describe("Enable", func() {
 				if t.sendEvent {
 					mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, &hostId, models.EventSeverityInfo,
 						fmt.Sprintf("Host %s: updated status from \"%s\" to \"discovering\" (Waiting for host to send hardware details)", hostutil.GetHostnameForMsg(&host), srcState),
-						gomock.Any())
+						gomock.Any(), gomock.Any(), gomock.Any())
 				}
 				t.validation(hapi.EnableHost(ctx, &host, db))
 			})
@@ -1086,6 +1099,7 @@ var _ = Describe("Refresh Host", func() {
 		host              models.Host
 		cluster           common.Cluster
 		mockEvents        *events.MockHandler
+		mockMetric        *metrics.MockAPI
 		ctrl              *gomock.Controller
 		dbName            string = "host_transition_test_refresh_host"
 	)
@@ -1094,7 +1108,9 @@ var _ = Describe("Refresh Host", func() {
 		db = common.PrepareTestDB(dbName, &events.Event{})
 		ctrl = gomock.NewController(GinkgoT())
 		mockEvents = events.NewMockHandler(ctrl)
-		hapi = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(), nil, defaultConfig, nil)
+		mockMetric = metrics.NewMockAPI(ctrl)
+		mockMetric.EXPECT().HostValidationFailed(gomock.Any()).AnyTimes()
+		hapi = NewManager(getTestLog(), db, mockEvents, nil, nil, createValidatorCfg(), mockMetric, defaultConfig, nil, &webhooks.DummyNotifier{})
 		hostId = strfmt.UUID(uuid.New().String())
 		clusterId = strfmt.UUID(uuid.New().String())
 	})
@@ -1143,7 +1159,7 @@ var _ = Describe("Refresh Host", func() {
 						&hostId,
 						hostutil.GetEventSeverityFromHostStatus(models.HostStatusError),
 						gomock.Any(),
-						gomock.Any())
+						gomock.Any(), gomock.Any(), gomock.Any())
 				}
 				err := hapi.RefreshStatus(ctx, &host, db)
 				Expect(err).ShouldNot(HaveOccurred())
@@ -1187,7 +1203,7 @@ var _ = Describe("Refresh Host", func() {
 				Expect(db.Create(&cluster).Error).ToNot(HaveOccurred())
 				if passedTimeKind == "over_timeout" {
 					mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, &hostId, hostutil.GetEventSeverityFromHostStatus(models.HostStatusError),
-						gomock.Any(), gomock.Any())
+						gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 				}
 				err := hapi.RefreshStatus(ctx, &host, db)
 
@@ -1251,7 +1267,7 @@ var _ = Describe("Refresh Host", func() {
 
 					if passedTimeKind == "over_timeout" {
 						mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, &hostId, hostutil.GetEventSeverityFromHostStatus(models.HostStatusError),
-							gomock.Any(), gomock.Any())
+							gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 					}
 					err := hapi.RefreshStatus(ctx, &host, db)
 
@@ -1301,7 +1317,7 @@ var _ = Describe("Refresh Host", func() {
 			Expect(db.Create(&host).Error).ShouldNot(HaveOccurred())
 
 			mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID,
-				gomock.Any(), hostutil.GetEventSeverityFromHostStatus(models.HostStatusError), gomock.Any(), gomock.Any()).
+				gomock.Any(), hostutil.GetEventSeverityFromHostStatus(models.HostStatusError), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 				AnyTimes()
 
 			err := hapi.RefreshStatus(ctx, &master, db)
@@ -1490,14 +1506,15 @@ var _ = Describe("Refresh Host", func() {
 				statusInfoChecker: makeValueChecker(formatStatusInfoFailedValidation(statusInfoInsufficientHardware,
 					"Machine Network CIDR is undefined; the Machine Network CIDR can be defined by setting either the API or Ingress virtual IPs",
 					"The host is not eligible to participate in Openshift Cluster because the minimum required RAM for any role is 8 GiB, found only 0 GiB",
-					"Require a disk of at least 120 GB", "Require at least 8 GiB RAM role auto-assign, found only 0")),
+					"Require a disk of at least 120 GiB. Rejected disks: unnamed disk (size of 0 GiB is below the required 120 GiB)",
+					"Require at least 8 GiB RAM role auto-assign, found only 0")),
 				inventory: insufficientHWInventory(),
 				validationsChecker: makeJsonChecker(map[validationID]validationCheckResult{
 					IsConnected:          {status: ValidationSuccess, messagePattern: "Host is connected"},
 					HasInventory:         {status: ValidationSuccess, messagePattern: "Valid inventory exists for the host"},
 					HasMinCPUCores:       {status: ValidationSuccess, messagePattern: "Sufficient CPU cores"},
 					HasMinMemory:         {status: ValidationFailure, messagePattern: "The host is not eligible to participate in Openshift Cluster because the minimum required RAM for any role is 8 GiB, found only 0 GiB"},
-					HasMinValidDisks:     {status: ValidationFailure, messagePattern: "Require a disk of at least 120 GB"},
+					HasMinValidDisks:     {status: ValidationFailure, messagePattern: "Require a disk of at least 120 GiB"},
 					IsMachineCidrDefined: {status: ValidationFailure, messagePattern: "Machine Network CIDR is undefined"},
 					HasCPUCoresForRole:   {status: ValidationSuccess, messagePattern: "Sufficient CPU cores for role auto-assign"},
 					HasMemoryForRole:     {status: ValidationFailure, messagePattern: "Require at least 8 GiB RAM role auto-assign, found only 0"},
@@ -1516,14 +1533,14 @@ var _ = Describe("Refresh Host", func() {
 				statusInfoChecker: makeValueChecker(formatStatusInfoFailedValidation(statusInfoInsufficientHardware,
 					"Machine Network CIDR is undefined; the Machine Network CIDR can be defined by setting either the API or Ingress virtual IPs",
 					"The host is not eligible to participate in Openshift Cluster because the minimum required RAM for any role is 8 GiB, found only 0 GiB",
-					"Require a disk of at least 120 GB",
+					"Require a disk of at least 120 GiB. Rejected disks: unnamed disk (size of 0 GiB is below the required 120 GiB)",
 					"Require at least 8 GiB RAM role auto-assign, found only 0")),
 				validationsChecker: makeJsonChecker(map[validationID]validationCheckResult{
 					IsConnected:          {status: ValidationSuccess, messagePattern: "Host is connected"},
 					HasInventory:         {status: ValidationSuccess, messagePattern: "Valid inventory exists for the host"},
 					HasMinCPUCores:       {status: ValidationSuccess, messagePattern: "Sufficient CPU cores"},
 					HasMinMemory:         {status: ValidationFailure, messagePattern: "The host is not eligible to participate in Openshift Cluster because the minimum required RAM for any role is 8 GiB, found only 0 GiB"},
-					HasMinValidDisks:     {status: ValidationFailure, messagePattern: "Require a disk of at least 120 GB"},
+					HasMinValidDisks:     {status: ValidationFailure, messagePattern: "Require a disk of at least 120 GiB"},
 					IsMachineCidrDefined: {status: ValidationFailure, messagePattern: "Machine Network CIDR is undefined"},
 					HasCPUCoresForRole:   {status: ValidationSuccess, messagePattern: "Sufficient CPU cores for role auto-assign"},
 					HasMemoryForRole:     {status: ValidationFailure, messagePattern: "Require at least 8 GiB RAM role auto-assign, found only 0"},
@@ -1543,13 +1560,14 @@ var _ = Describe("Refresh Host", func() {
 				statusInfoChecker: makeValueChecker(formatStatusInfoFailedValidation(statusInfoInsufficientHardware,
 					"Machine Network CIDR is undefined; the Machine Network CIDR can be defined by setting either the API or Ingress virtual IPs",
 					"The host is not eligible to participate in Openshift Cluster because the minimum required RAM for any role is 8 GiB, found only 0 GiB",
-					"Require a disk of at least 120 GB", "Require at least 8 GiB RAM role auto-assign, found only 0")),
+					"Require a disk of at least 120 GiB. Rejected disks: unnamed disk (size of 0 GiB is below the required 120 GiB)",
+					"Require at least 8 GiB RAM role auto-assign, found only 0")),
 				validationsChecker: makeJsonChecker(map[validationID]validationCheckResult{
 					IsConnected:          {status: ValidationSuccess, messagePattern: "Host is connected"},
 					HasInventory:         {status: ValidationSuccess, messagePattern: "Valid inventory exists for the host"},
 					HasMinCPUCores:       {status: ValidationSuccess, messagePattern: "Sufficient CPU cores"},
 					HasMinMemory:         {status: ValidationFailure, messagePattern: "The host is not eligible to participate in Openshift Cluster because the minimum required RAM for any role is 8 GiB, found only 0 GiB"},
-					HasMinValidDisks:     {status: ValidationFailure, messagePattern: "Require a disk of at least 120 GB"},
+					HasMinValidDisks:     {status: ValidationFailure, messagePattern: "Require a disk of at least 120 GiB"},
 					IsMachineCidrDefined: {status: ValidationFailure, messagePattern: "Machine Network CIDR is undefined"},
 					HasCPUCoresForRole:   {status: ValidationSuccess, messagePattern: "Sufficient CPU cores for role"},
 					HasMemoryForRole:     {status: ValidationFailure, messagePattern: "Require at least 8 GiB RAM role"},
@@ -2008,7 +2026,7 @@ var _ = Describe("Refresh Host", func() {
 				Expect(db.Create(&cluster).Error).ToNot(HaveOccurred())
 				if srcState != t.dstState {
 					mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, &hostId, hostutil.GetEventSeverityFromHostStatus(t.dstState),
-						gomock.Any(), gomock.Any())
+						gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 				}
 				err := hapi.RefreshStatus(ctx, &host, db)
 				if t.errorExpected {
@@ -2060,7 +2078,7 @@ var _ = Describe("Refresh Host", func() {
 				Expect(db.Create(&cluster).Error).ToNot(HaveOccurred())
 				if *host.Status != t.dstState {
 					mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, &hostId, hostutil.GetEventSeverityFromHostStatus(t.dstState),
-						gomock.Any(), gomock.Any())
+						gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 				}
 				err := hapi.RefreshStatus(ctx, &host, db)
 				if t.errorExpected {
@@ -2444,7 +2462,7 @@ var _ = Describe("Refresh Host", func() {
 				}
 				if !t.errorExpected && srcState != t.dstState {
 					mockEvents.EXPECT().AddEvent(gomock.Any(), host.ClusterID, &hostId, expectedSeverity,
-						gomock.Any(), gomock.Any())
+						gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 				}
 
 				err := hapi.RefreshStatus(ctx, &host, db)
@@ -2464,6 +2482,374 @@ var _ = Describe("Refresh Host", func() {
 			})
 		}
 	})
+	Context("Hardware profile", func() {
+		refresh := func(profile string) {
+			h := getTestHost(hostId, clusterId, models.HostStatusInsufficient)
+			h.Inventory = masterInventory()
+			h.Role = models.HostRoleMaster
+			Expect(db.Create(&h).Error).ShouldNot(HaveOccurred())
+			host = models.Host{}
+			Expect(db.Take(&host, "id = ? and cluster_id = ?", hostId.String(), clusterId.String()).Error).ToNot(HaveOccurred())
+			cluster = getTestCluster(clusterId, "1.2.3.0/24")
+			cluster.HardwareProfile = profile
+			cluster.ConnectivityMajorityGroups = fmt.Sprintf("{\"%s\":[\"%s\"]}", "1.2.3.0/24", hostId.String())
+			Expect(db.Create(&cluster).Error).ToNot(HaveOccurred())
+			mockEvents.EXPECT().AddEvent(gomock.Any(), clusterId, &hostId, gomock.Any(), gomock.Any(), gomock.Any(),
+				gomock.Any(), gomock.Any()).AnyTimes()
+			Expect(hapi.RefreshStatus(ctx, &host, db)).ToNot(HaveOccurred())
+		}
+
+		validationsInfo := func() string {
+			var resultHost models.Host
+			Expect(db.Take(&resultHost, "id = ? and cluster_id = ?", hostId.String(), clusterId.String()).Error).ToNot(HaveOccurred())
+			return resultHost.ValidationsInfo
+		}
+
+		It("validates against the requirements of the profile of the cluster", func() {
+			refresh("compute")
+			Expect(swag.StringValue(host.Status)).To(Equal(models.HostStatusInsufficient))
+			makeJsonChecker(map[validationID]validationCheckResult{
+				HasMinCPUCores:     {status: ValidationSuccess, messagePattern: "Sufficient CPU cores"},
+				HasMinMemory:       {status: ValidationFailure, messagePattern: "the minimum required RAM for any role is 32 GiB, found only 16 GiB"},
+				HasMinValidDisks:   {status: ValidationFailure, messagePattern: "Require a disk of at least 240 GiB"},
+				HasCPUCoresForRole: {status: ValidationSuccess, messagePattern: "Sufficient CPU cores for role master"},
+				HasMemoryForRole:   {status: ValidationFailure, messagePattern: "Require at least 32 GiB RAM role master, found only 16"},
+			}).check(validationsInfo())
+		})
+
+		It("validates against the default requirements if the profile is unknown", func() {
+			refresh("removed")
+			Expect(swag.StringValue(host.Status)).To(Equal(models.HostStatusKnown))
+			makeJsonChecker(map[validationID]validationCheckResult{
+				HasMinMemory:     {status: ValidationSuccess, messagePattern: "Sufficient minimum RAM"},
+				HasMinValidDisks: {status: ValidationSuccess, messagePattern: "Sufficient disk capacity"},
+				HasMemoryForRole: {status: ValidationSuccess, messagePattern: "Sufficient RAM for role master"},
+			}).check(validationsInfo())
+		})
+	})
+
+	Context("Installation disk", func() {
+		var disks []*models.Disk
+
+		BeforeEach(func() {
+			disks = []*models.Disk{
+				{Name: "sda", Path: "/dev/sda", DriveType: "HDD", SizeBytes: 128849018880, Wwn: "0x5000c500a0b1c2d3"},
+				{Name: "sdb", Path: "/dev/sdb", DriveType: "HDD", SizeBytes: int64(64 * units.GB), Serial: "S3EVNX0K"},
+			}
+		})
+
+		refresh := func(diskID string) {
+			var inventory models.Inventory
+			Expect(json.Unmarshal([]byte(masterInventory()), &inventory)).To(Succeed())
+			inventory.Disks = disks
+			b, err := json.Marshal(&inventory)
+			Expect(err).ToNot(HaveOccurred())
+			h := getTestHost(hostId, clusterId, models.HostStatusInsufficient)
+			h.Inventory = string(b)
+			h.Role = models.HostRoleMaster
+			h.InstallationDiskID = diskID
+			Expect(db.Create(&h).Error).ShouldNot(HaveOccurred())
+			host = models.Host{}
+			Expect(db.Take(&host, "id = ? and cluster_id = ?", hostId.String(), clusterId.String()).Error).ToNot(HaveOccurred())
+			cluster = getTestCluster(clusterId, "1.2.3.0/24")
+			cluster.ConnectivityMajorityGroups = fmt.Sprintf("{\"%s\":[\"%s\"]}", "1.2.3.0/24", hostId.String())
+			Expect(db.Create(&cluster).Error).ToNot(HaveOccurred())
+			mockEvents.EXPECT().AddEvent(gomock.Any(), clusterId, &hostId, gomock.Any(), gomock.Any(), gomock.Any(),
+				gomock.Any(), gomock.Any()).AnyTimes()
+			Expect(hapi.RefreshStatus(ctx, &host, db)).ToNot(HaveOccurred())
+		}
+
+		validationsInfo := func() string {
+			var resultHost models.Host
+			Expect(db.Take(&resultHost, "id = ? and cluster_id = ?", hostId.String(), clusterId.String()).Error).ToNot(HaveOccurred())
+			return resultHost.ValidationsInfo
+		}
+
+		It("succeeds when the service selects the disk", func() {
+			refresh("")
+			Expect(swag.StringValue(host.Status)).To(Equal(models.HostStatusKnown))
+			makeJsonChecker(map[validationID]validationCheckResult{
+				IsInstallationDiskValid: {status: ValidationSuccess, messagePattern: "The installation disk is selected by the service"},
+			}).check(validationsInfo())
+		})
+
+		It("succeeds when the selected disk is valid", func() {
+			refresh("0x5000c500a0b1c2d3")
+			Expect(swag.StringValue(host.Status)).To(Equal(models.HostStatusKnown))
+			makeJsonChecker(map[validationID]validationCheckResult{
+				IsInstallationDiskValid: {status: ValidationSuccess, messagePattern: "Installation disk 0x5000c500a0b1c2d3 is valid"},
+			}).check(validationsInfo())
+		})
+
+		It("fails when the selected disk is too small", func() {
+			refresh("S3EVNX0K")
+			Expect(swag.StringValue(host.Status)).To(Equal(models.HostStatusInsufficient))
+			makeJsonChecker(map[validationID]validationCheckResult{
+				HasMinValidDisks:        {status: ValidationSuccess, messagePattern: "Sufficient disk capacity"},
+				IsInstallationDiskValid: {status: ValidationFailure, messagePattern: "The selected installation disk isn't valid: disk sdb: size of 59 GiB is below the required 120 GiB"},
+			}).check(validationsInfo())
+		})
+
+		It("requires the minimal disk size in GiB", func() {
+			disks = []*models.Disk{
+				{Name: "sda", Path: "/dev/sda", DriveType: "HDD", SizeBytes: int64(125 * units.GB)},
+				{Name: "sdb", Path: "/dev/sdb", DriveType: "HDD", SizeBytes: int64(500 * units.GB)},
+			}
+			refresh("/dev/sda")
+			Expect(swag.StringValue(host.Status)).To(Equal(models.HostStatusInsufficient))
+			makeJsonChecker(map[validationID]validationCheckResult{
+				HasMinValidDisks:        {status: ValidationSuccess, messagePattern: "Rejected disks: sda \\(size of 116 GiB is below the required 120 GiB\\)"},
+				IsInstallationDiskValid: {status: ValidationFailure, messagePattern: "disk sda: size of 116 GiB is below the required 120 GiB"},
+			}).check(validationsInfo())
+		})
+
+		It("fails when the selected disk isn't in the inventory", func() {
+			refresh("/dev/sdz")
+			Expect(swag.StringValue(host.Status)).To(Equal(models.HostStatusInsufficient))
+			makeJsonChecker(map[validationID]validationCheckResult{
+				IsInstallationDiskValid: {status: ValidationFailure, messagePattern: "The selected installation disk isn't valid: disk /dev/sdz isn't in the inventory of the host"},
+			}).check(validationsInfo())
+		})
+	})
+
+	Context("Disk speed", func() {
+		BeforeEach(func() {
+			cfg := createValidatorCfg()
+			cfg.MaxFsyncLatencyMs = 10
+			hapi = NewManager(getTestLog(), db, mockEvents, nil, nil, cfg, mockMetric, defaultConfig, nil, &webhooks.DummyNotifier{})
+		})
+
+		refresh := func(role models.HostRole, diskSpeed string) {
+			var inventory models.Inventory
+			Expect(json.Unmarshal([]byte(masterInventory()), &inventory)).To(Succeed())
+			inventory.Disks[0].Name = "sda"
+			b, err := json.Marshal(&inventory)
+			Expect(err).ToNot(HaveOccurred())
+			h := getTestHost(hostId, clusterId, models.HostStatusInsufficient)
+			h.Inventory = string(b)
+			h.Role = role
+			h.DiskSpeed = diskSpeed
+			Expect(db.Create(&h).Error).ShouldNot(HaveOccurred())
+			host = models.Host{}
+			Expect(db.Take(&host, "id = ? and cluster_id = ?", hostId.String(), clusterId.String()).Error).ToNot(HaveOccurred())
+			cluster = getTestCluster(clusterId, "1.2.3.0/24")
+			cluster.ConnectivityMajorityGroups = fmt.Sprintf("{\"%s\":[\"%s\"]}", "1.2.3.0/24", hostId.String())
+			Expect(db.Create(&cluster).Error).ToNot(HaveOccurred())
+			mockEvents.EXPECT().AddEvent(gomock.Any(), clusterId, &hostId, gomock.Any(), gomock.Any(), gomock.Any(),
+				gomock.Any(), gomock.Any()).AnyTimes()
+			Expect(hapi.RefreshStatus(ctx, &host, db)).ToNot(HaveOccurred())
+		}
+
+		validationsInfo := func() string {
+			var resultHost models.Host
+			Expect(db.Take(&resultHost, "id = ? and cluster_id = ?", hostId.String(), clusterId.String()).Error).ToNot(HaveOccurred())
+			return resultHost.ValidationsInfo
+		}
+
+		It("succeeds when the installation disk of a master is fast enough", func() {
+			refresh(models.HostRoleMaster, `{"path":"/dev/sda","fsync_latency_p50_ms":1.1,"fsync_latency_p99_ms":4.2}`)
+			Expect(swag.StringValue(host.Status)).To(Equal(models.HostStatusKnown))
+			makeJsonChecker(map[validationID]validationCheckResult{
+				IsDiskSpeedValid: {status: ValidationSuccess,
+					messagePattern: "The installation disk is fast enough for etcd, 99th percentile fsync latency of 4.2 ms"},
+			}).check(validationsInfo())
+		})
+
+		It("fails when the installation disk of a master is too slow", func() {
+			refresh(models.HostRoleMaster, `{"path":"/dev/sda","fsync_latency_p50_ms":6.3,"fsync_latency_p99_ms":24.7}`)
+			Expect(swag.StringValue(host.Status)).To(Equal(models.HostStatusInsufficient))
+			makeJsonChecker(map[validationID]validationCheckResult{
+				IsDiskSpeedValid: {status: ValidationFailure,
+					messagePattern: "The installation disk is too slow for etcd, 99th percentile fsync latency of 24.7 ms is above 10 ms"},
+			}).check(validationsInfo())
+		})
+
+		It("is pending until the installation disk of a master is checked", func() {
+			refresh(models.HostRoleMaster, `{"path":"/dev/sdb","fsync_latency_p99_ms":4.2}`)
+			Expect(swag.StringValue(host.Status)).To(Equal(models.HostStatusInsufficient))
+			makeJsonChecker(map[validationID]validationCheckResult{
+				IsDiskSpeedValid: {status: ValidationPending, messagePattern: "Waiting for the speed check of the installation disk"},
+			}).check(validationsInfo())
+		})
+
+		It("isn't required for workers", func() {
+			refresh(models.HostRoleWorker, `{"path":"/dev/sda","fsync_latency_p99_ms":24.7}`)
+			Expect(swag.StringValue(host.Status)).To(Equal(models.HostStatusKnown))
+			makeJsonChecker(map[validationID]validationCheckResult{
+				IsDiskSpeedValid: {status: ValidationSuccess, messagePattern: "The disk speed check isn't required for role worker"},
+			}).check(validationsInfo())
+		})
+	})
+
+	Context("Disk selection policy", func() {
+		refresh := func(policy string) {
+			var inventory models.Inventory
+			Expect(json.Unmarshal([]byte(masterInventory()), &inventory)).To(Succeed())
+			inventory.Disks = []*models.Disk{
+				{Name: "sda", DriveType: "HDD", SizeBytes: int64(200 * units.GB), ByPath: "/dev/disk/by-path/pci-0000:00:1f.2-ata-1"},
+				{Name: "sdb", DriveType: "SSD", SizeBytes: int64(300 * units.GB), ByPath: "/dev/disk/by-path/pci-0000:00:14.0-usb-0:1:1.0-scsi-0:0:0:0"},
+				{Name: "nvme0n1", DriveType: "SSD", SizeBytes: int64(500 * units.GB), ByPath: "/dev/disk/by-path/pci-0000:3b:00.0-nvme-1"},
+			}
+			b, err := json.Marshal(&inventory)
+			Expect(err).ToNot(HaveOccurred())
+			h := getTestHost(hostId, clusterId, models.HostStatusInsufficient)
+			h.Inventory = string(b)
+			h.Role = models.HostRoleMaster
+			Expect(db.Create(&h).Error).ShouldNot(HaveOccurred())
+			host = models.Host{}
+			Expect(db.Take(&host, "id = ? and cluster_id = ?", hostId.String(), clusterId.String()).Error).ToNot(HaveOccurred())
+			cluster = getTestCluster(clusterId, "1.2.3.0/24")
+			cluster.DiskSelectionPolicy = policy
+			cluster.ConnectivityMajorityGroups = fmt.Sprintf("{\"%s\":[\"%s\"]}", "1.2.3.0/24", hostId.String())
+			Expect(db.Create(&cluster).Error).ToNot(HaveOccurred())
+			mockEvents.EXPECT().AddEvent(gomock.Any(), clusterId, &hostId, gomock.Any(), gomock.Any(), gomock.Any(),
+				gomock.Any(), gomock.Any()).AnyTimes()
+			Expect(hapi.RefreshStatus(ctx, &host, db)).ToNot(HaveOccurred())
+		}
+
+		validationsInfo := func() string {
+			var resultHost models.Host
+			Expect(db.Take(&resultHost, "id = ? and cluster_id = ?", hostId.String(), clusterId.String()).Error).ToNot(HaveOccurred())
+			return resultHost.ValidationsInfo
+		}
+
+		It("orders the disks by the default policy", func() {
+			refresh("")
+			Expect(swag.StringValue(host.Status)).To(Equal(models.HostStatusKnown))
+			makeJsonChecker(map[validationID]validationCheckResult{
+				HasMinValidDisks: {status: ValidationSuccess,
+					messagePattern: "Sufficient disk capacity, installation disk candidates in order of preference: sda, sdb, nvme0n1$"},
+			}).check(validationsInfo())
+		})
+
+		It("explains the disks rejected by the policy", func() {
+			refresh(`{"drive_types":["NVMe","SSD"],"exclude_removable":true}`)
+			Expect(swag.StringValue(host.Status)).To(Equal(models.HostStatusKnown))
+			makeJsonChecker(map[validationID]validationCheckResult{
+				HasMinValidDisks: {status: ValidationSuccess,
+					messagePattern: "candidates in order of preference: nvme0n1\\. Rejected disks: " +
+						"sda \\(drive type HDD isn't allowed by the disk selection policy\\), " +
+						"sdb \\(removable disks aren't allowed by the disk selection policy\\)"},
+			}).check(validationsInfo())
+		})
+
+		It("fails when the policy rejects all the disks", func() {
+			refresh(`{"by_path_patterns":["pci-0000:3c:*"],"min_size_gib":250}`)
+			Expect(swag.StringValue(host.Status)).To(Equal(models.HostStatusInsufficient))
+			makeJsonChecker(map[validationID]validationCheckResult{
+				HasMinValidDisks: {status: ValidationFailure,
+					messagePattern: "Require a disk of at least 120 GiB accepted by the disk selection policy of the cluster\\. Rejected disks: " +
+						"sda \\(size of 186 GiB is below the required 250 GiB\\), " +
+						"sdb \\(by-path link /dev/disk/by-path/pci-0000:00:14.0-usb-0:1:1.0-scsi-0:0:0:0 doesn't match the disk selection policy\\), " +
+						"nvme0n1 \\(by-path link /dev/disk/by-path/pci-0000:3b:00.0-nvme-1 doesn't match the disk selection policy\\)"},
+			}).check(validationsInfo())
+		})
+	})
+
+	Context("Machine network interface", func() {
+		BeforeEach(func() {
+			cfg := createValidatorCfg()
+			cfg.MinLinkSpeedMbps = 1000
+			hapi = NewManager(getTestLog(), db, mockEvents, nil, nil, cfg, mockMetric, defaultConfig, nil, &webhooks.DummyNotifier{})
+		})
+
+		inventory := func(hostname string, update func(intf *models.Interface)) string {
+			var inventory models.Inventory
+			Expect(json.Unmarshal([]byte(masterInventoryWithHostname(hostname)), &inventory)).To(Succeed())
+			update(inventory.Interfaces[0])
+			b, err := json.Marshal(&inventory)
+			Expect(err).ToNot(HaveOccurred())
+			return string(b)
+		}
+
+		// refresh adds hosts with the MTUs of otherMtus to the cluster before refreshing the host
+		refresh := func(update func(intf *models.Interface), otherMtus ...int64) {
+			for i, mtu := range otherMtus {
+				mtu := mtu
+				otherID := strfmt.UUID(uuid.New().String())
+				other := getTestHost(otherID, clusterId, models.HostStatusKnown)
+				other.Inventory = inventory(fmt.Sprintf("master-%d", i+1), func(intf *models.Interface) { intf.Mtu = mtu })
+				Expect(db.Create(&other).Error).ShouldNot(HaveOccurred())
+			}
+			h := getTestHost(hostId, clusterId, models.HostStatusInsufficient)
+			h.Inventory = inventory("master-hostname", update)
+			h.Role = models.HostRoleMaster
+			Expect(db.Create(&h).Error).ShouldNot(HaveOccurred())
+			host = models.Host{}
+			Expect(db.Take(&host, "id = ? and cluster_id = ?", hostId.String(), clusterId.String()).Error).ToNot(HaveOccurred())
+			cluster = getTestCluster(clusterId, "1.2.3.0/24")
+			cluster.ConnectivityMajorityGroups = fmt.Sprintf("{\"%s\":[\"%s\"]}", "1.2.3.0/24", hostId.String())
+			Expect(db.Create(&cluster).Error).ToNot(HaveOccurred())
+			mockEvents.EXPECT().AddEvent(gomock.Any(), clusterId, &hostId, gomock.Any(), gomock.Any(), gomock.Any(),
+				gomock.Any(), gomock.Any()).AnyTimes()
+			Expect(hapi.RefreshStatus(ctx, &host, db)).ToNot(HaveOccurred())
+		}
+
+		validationsInfo := func() string {
+			var resultHost models.Host
+			Expect(db.Take(&resultHost, "id = ? and cluster_id = ?", hostId.String(), clusterId.String()).Error).ToNot(HaveOccurred())
+			return resultHost.ValidationsInfo
+		}
+
+		It("succeeds when the interface is connected, fast enough and has the MTU of the other hosts", func() {
+			refresh(func(intf *models.Interface) {
+				intf.SpeedMbps = 10000
+				intf.Mtu = 9000
+			}, 9000, 9000)
+			Expect(swag.StringValue(host.Status)).To(Equal(models.HostStatusKnown))
+			makeJsonChecker(map[validationID]validationCheckResult{
+				HasMachineNetworkCarrier:       {status: ValidationSuccess, messagePattern: "Machine network interface eth0 has a carrier"},
+				IsMachineNetworkLinkSpeedValid: {status: ValidationSuccess, messagePattern: "Machine network interface eth0 has a link speed of 10000 Mbps"},
+				IsMachineNetworkMtuConsistent:  {status: ValidationSuccess, messagePattern: "Machine network interface eth0 has the MTU 9000 of most hosts"},
+			}).check(validationsInfo())
+		})
+
+		It("fails when the interface has no carrier", func() {
+			refresh(func(intf *models.Interface) { intf.HasCarrier = false })
+			Expect(swag.StringValue(host.Status)).To(Equal(models.HostStatusInsufficient))
+			makeJsonChecker(map[validationID]validationCheckResult{
+				HasMachineNetworkCarrier: {status: ValidationFailure,
+					messagePattern: "Machine network interface eth0 has no carrier, check its cable and switch port"},
+			}).check(validationsInfo())
+		})
+
+		It("fails when the link speed is below the minimum", func() {
+			refresh(func(intf *models.Interface) { intf.SpeedMbps = 100 })
+			Expect(swag.StringValue(host.Status)).To(Equal(models.HostStatusInsufficient))
+			makeJsonChecker(map[validationID]validationCheckResult{
+				IsMachineNetworkLinkSpeedValid: {status: ValidationFailure,
+					messagePattern: "Machine network interface eth0 has a link speed of 100 Mbps, below the required 1000 Mbps"},
+			}).check(validationsInfo())
+		})
+
+		It("ignores the link speed of interfaces that don't report it", func() {
+			refresh(func(intf *models.Interface) { intf.SpeedMbps = -1 })
+			Expect(swag.StringValue(host.Status)).To(Equal(models.HostStatusKnown))
+			makeJsonChecker(map[validationID]validationCheckResult{
+				IsMachineNetworkLinkSpeedValid: {status: ValidationSuccess,
+					messagePattern: "Machine network interface eth0 doesn't report its link speed"},
+			}).check(validationsInfo())
+		})
+
+		It("fails when the MTU differs from the one of most hosts", func() {
+			refresh(func(intf *models.Interface) { intf.Mtu = 1500 }, 9000, 9000, 1500)
+			Expect(swag.StringValue(host.Status)).To(Equal(models.HostStatusInsufficient))
+			makeJsonChecker(map[validationID]validationCheckResult{
+				IsMachineNetworkMtuConsistent: {status: ValidationFailure,
+					messagePattern: "Machine network interface eth0 has the MTU 1500, different from the MTUs of other hosts: 9000 \\(master-1, master-2\\)"},
+			}).check(validationsInfo())
+		})
+
+		It("fails when no MTU is used by most hosts", func() {
+			refresh(func(intf *models.Interface) { intf.Mtu = 9000 }, 1500)
+			Expect(swag.StringValue(host.Status)).To(Equal(models.HostStatusInsufficient))
+			makeJsonChecker(map[validationID]validationCheckResult{
+				IsMachineNetworkMtuConsistent: {status: ValidationFailure,
+					messagePattern: "Machine network interface eth0 has the MTU 9000, different from the MTUs of other hosts: 1500 \\(master-1\\)"},
+			}).check(validationsInfo())
+		})
+	})
+
 	Context("Cluster Errors", func() {
 		for _, srcState := range []string{
 			models.HostStatusInstalling,
@@ -2490,7 +2876,7 @@ var _ = Describe("Refresh Host", func() {
 				Expect(db.Create(&c).Error).ToNot(HaveOccurred())
 				mockEvents.EXPECT().AddEvent(gomock.Any(), clusterId, &hostId, models.EventSeverityError,
 					"Host master-hostname: updated status from \"installed\" to \"error\" (Host is part of a cluster that failed to install)",
-					gomock.Any())
+					gomock.Any(), gomock.Any(), gomock.Any())
 				err := hapi.RefreshStatus(ctx, &h, db)
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(swag.StringValue(h.Status)).Should(Equal(models.HostStatusError))
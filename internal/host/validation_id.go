@@ -12,28 +12,35 @@ import (
 type validationID models.HostValidationID
 
 const (
-	IsConnected            = validationID(models.HostValidationIDConnected)
-	HasInventory           = validationID(models.HostValidationIDHasInventory)
-	IsMachineCidrDefined   = validationID(models.HostValidationIDMachineCidrDefined)
-	BelongsToMachineCidr   = validationID(models.HostValidationIDBelongsToMachineCidr)
-	HasMinCPUCores         = validationID(models.HostValidationIDHasMinCPUCores)
-	HasMinValidDisks       = validationID(models.HostValidationIDHasMinValidDisks)
-	HasMinMemory           = validationID(models.HostValidationIDHasMinMemory)
-	HasCPUCoresForRole     = validationID(models.HostValidationIDHasCPUCoresForRole)
-	HasMemoryForRole       = validationID(models.HostValidationIDHasMemoryForRole)
-	IsHostnameUnique       = validationID(models.HostValidationIDHostnameUnique)
-	IsHostnameValid        = validationID(models.HostValidationIDHostnameValid)
-	IsAPIVipConnected      = validationID(models.HostValidationIDAPIVipConnected)
-	BelongsToMajorityGroup = validationID(models.HostValidationIDBelongsToMajorityGroup)
-	IsPlatformValid        = validationID(models.HostValidationIDValidPlatform)
+	IsConnected                    = validationID(models.HostValidationIDConnected)
+	HasInventory                   = validationID(models.HostValidationIDHasInventory)
+	IsMachineCidrDefined           = validationID(models.HostValidationIDMachineCidrDefined)
+	BelongsToMachineCidr           = validationID(models.HostValidationIDBelongsToMachineCidr)
+	HasMinCPUCores                 = validationID(models.HostValidationIDHasMinCPUCores)
+	HasMinValidDisks               = validationID(models.HostValidationIDHasMinValidDisks)
+	HasMinMemory                   = validationID(models.HostValidationIDHasMinMemory)
+	HasCPUCoresForRole             = validationID(models.HostValidationIDHasCPUCoresForRole)
+	HasMemoryForRole               = validationID(models.HostValidationIDHasMemoryForRole)
+	IsHostnameUnique               = validationID(models.HostValidationIDHostnameUnique)
+	IsHostnameValid                = validationID(models.HostValidationIDHostnameValid)
+	IsAPIVipConnected              = validationID(models.HostValidationIDAPIVipConnected)
+	BelongsToMajorityGroup         = validationID(models.HostValidationIDBelongsToMajorityGroup)
+	IsPlatformValid                = validationID(models.HostValidationIDValidPlatform)
+	IsInstallationDiskValid        = validationID(models.HostValidationIDInstallationDiskValid)
+	IsDiskSpeedValid               = validationID(models.HostValidationIDDiskSpeedValid)
+	HasMachineNetworkCarrier       = validationID(models.HostValidationIDHasMachineNetworkCarrier)
+	IsMachineNetworkLinkSpeedValid = validationID(models.HostValidationIDMachineNetworkLinkSpeedValid)
+	IsMachineNetworkMtuConsistent  = validationID(models.HostValidationIDMachineNetworkMtuConsistent)
 )
 
 func (v validationID) category() (string, error) {
 	switch v {
-	case IsConnected, IsMachineCidrDefined, BelongsToMachineCidr, IsAPIVipConnected, BelongsToMajorityGroup:
+	case IsConnected, IsMachineCidrDefined, BelongsToMachineCidr, IsAPIVipConnected, BelongsToMajorityGroup,
+		HasMachineNetworkCarrier, IsMachineNetworkLinkSpeedValid, IsMachineNetworkMtuConsistent:
 		return "network", nil
 	case HasInventory, HasMinCPUCores, HasMinValidDisks, HasMinMemory,
-		HasCPUCoresForRole, HasMemoryForRole, IsHostnameUnique, IsHostnameValid, IsPlatformValid:
+		HasCPUCoresForRole, HasMemoryForRole, IsHostnameUnique, IsHostnameValid, IsPlatformValid,
+		IsInstallationDiskValid, IsDiskSpeedValid:
 		return "hardware", nil
 	}
 	return "", common.NewApiError(http.StatusInternalServerError, errors.Errorf("Unexpected validation id %s", string(v)))
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"strings"
 	"time"
 
 	"github.com/go-openapi/strfmt"
@@ -17,6 +18,7 @@ import (
 	"github.com/openshift/assisted-service/internal/network"
 
 	"github.com/openshift/assisted-service/internal/hardware"
+	"github.com/openshift/assisted-service/internal/hostutil"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
@@ -52,6 +54,7 @@ type validationContext struct {
 	cluster   *common.Cluster
 	inventory *models.Inventory
 	db        *gorm.DB
+	profile   *hardware.Profile
 }
 
 type validationConditon func(context *validationContext) validationStatus
@@ -80,17 +83,27 @@ func (c *validationContext) loadCluster() error {
 	return err
 }
 
+// loadProfile resolves the hardware requirements of the cluster of the host, the default ones if the profile of the
+// cluster was removed from the configuration
+func (c *validationContext) loadProfile(hwValidatorCfg *hardware.ValidatorCfg, log logrus.FieldLogger) {
+	profile, err := hwValidatorCfg.GetProfile(c.cluster.HardwareProfile)
+	if err != nil {
+		log.WithError(err).Warnf("Validating the hosts of cluster %s with the default hardware profile", c.cluster.ID)
+		profile, _ = hwValidatorCfg.GetProfile(hardware.DefaultProfile)
+	}
+	c.profile = profile
+}
+
 func (c *validationContext) loadInventory() error {
 	if c.host.Inventory != "" {
-		var inventory models.Inventory
-		err := json.Unmarshal([]byte(c.host.Inventory), &inventory)
+		inventory, err := hostutil.UnmarshalInventory(c.host)
 		if err != nil {
 			return err
 		}
 		if inventory.CPU == nil || inventory.Memory == nil || len(inventory.Disks) == 0 {
 			return errors.Errorf("Inventory is not valid")
 		}
-		c.inventory = &inventory
+		c.inventory = inventory
 	}
 	return nil
 }
@@ -112,13 +125,14 @@ func (c *validationContext) validateMachineCIDR() error {
 	return err
 }
 
-func newValidationContext(host *models.Host, db *gorm.DB) (*validationContext, error) {
+func newValidationContext(host *models.Host, db *gorm.DB, hwValidatorCfg *hardware.ValidatorCfg, log logrus.FieldLogger) (*validationContext, error) {
 	ret := &validationContext{
 		host: host,
 		db:   db,
 	}
 	err := ret.loadCluster()
 	if err == nil {
+		ret.loadProfile(hwValidatorCfg, log)
 		err = ret.loadInventory()
 	}
 	if err == nil {
@@ -180,7 +194,7 @@ func (v *validator) hasMinCpuCores(c *validationContext) validationStatus {
 	if c.inventory == nil {
 		return ValidationPending
 	}
-	return boolValue(c.inventory.CPU.Count >= v.hwValidatorCfg.MinCPUCores)
+	return boolValue(c.inventory.CPU.Count >= c.profile.MinCPUCores)
 }
 
 func (v *validator) printHasMinCpuCores(c *validationContext, status validationStatus) string {
@@ -188,7 +202,7 @@ func (v *validator) printHasMinCpuCores(c *validationContext, status validationS
 	case ValidationSuccess:
 		return "Sufficient CPU cores"
 	case ValidationFailure:
-		return fmt.Sprintf("The host is not eligible to participate in Openshift Cluster because the minimum required CPU cores for any role is %d, found only %d", v.hwValidatorCfg.MinCPUCores, c.inventory.CPU.Count)
+		return fmt.Sprintf("The host is not eligible to participate in Openshift Cluster because the minimum required CPU cores for any role is %d, found only %d", c.profile.MinCPUCores, c.inventory.CPU.Count)
 	case ValidationPending:
 		return "Missing inventory"
 	default:
@@ -200,7 +214,7 @@ func (v *validator) hasMinMemory(c *validationContext) validationStatus {
 	if c.inventory == nil {
 		return ValidationPending
 	}
-	return boolValue(c.inventory.Memory.PhysicalBytes >= gibToBytes(v.hwValidatorCfg.MinRamGib))
+	return boolValue(c.inventory.Memory.PhysicalBytes >= gibToBytes(c.profile.MinRamGib))
 }
 
 func (v *validator) printHasMinMemory(c *validationContext, status validationStatus) string {
@@ -208,7 +222,7 @@ func (v *validator) printHasMinMemory(c *validationContext, status validationSta
 	case ValidationSuccess:
 		return "Sufficient minimum RAM"
 	case ValidationFailure:
-		return fmt.Sprintf("The host is not eligible to participate in Openshift Cluster because the minimum required RAM for any role is %d GiB, found only %d GiB", v.hwValidatorCfg.MinRamGib,
+		return fmt.Sprintf("The host is not eligible to participate in Openshift Cluster because the minimum required RAM for any role is %d GiB, found only %d GiB", c.profile.MinRamGib,
 			bytesToGiB(c.inventory.Memory.PhysicalBytes))
 	case ValidationPending:
 		return "Missing inventory"
@@ -217,20 +231,91 @@ func (v *validator) printHasMinMemory(c *validationContext, status validationSta
 	}
 }
 
+// diskSelectionPolicy returns the disk selection policy of the cluster of the host, the empty policy if the one of the
+// cluster can't be parsed
+func (v *validator) diskSelectionPolicy(c *validationContext) *models.DiskSelectionPolicy {
+	policy, err := hardware.ParseDiskSelectionPolicy(c.cluster.DiskSelectionPolicy)
+	if err != nil {
+		v.log.WithError(err).Warnf("Validating the disks of the hosts of cluster %s with the empty disk selection policy", c.cluster.ID)
+		policy = &models.DiskSelectionPolicy{}
+	}
+	return policy
+}
+
+func (v *validator) evaluateDisks(c *validationContext) ([]*models.Disk, []hardware.DiskVerdict) {
+	return hardware.EvaluateDisks(c.inventory.Disks, v.diskSelectionPolicy(c), c.profile.MinDiskSizeBytes())
+}
+
 func (v *validator) hasMinValidDisks(c *validationContext) validationStatus {
 	if c.inventory == nil {
 		return ValidationPending
 	}
-	disks := hardware.ListValidDisks(c.inventory, gibToBytes(v.hwValidatorCfg.MinDiskSizeGb))
+	disks, _ := v.evaluateDisks(c)
 	return boolValue(len(disks) > 0)
 }
 
+// printDiskVerdicts explains which disks can be installed on and why the others can't
+func printDiskVerdicts(accepted []*models.Disk, verdicts []hardware.DiskVerdict) string {
+	var message string
+	if len(accepted) > 0 {
+		message = fmt.Sprintf(", installation disk candidates in order of preference: %s",
+			strings.Join(funk.Map(accepted, hardware.DiskLabel).([]string), ", "))
+	}
+	var rejected []string
+	for _, verdict := range verdicts {
+		if !verdict.Accepted() {
+			rejected = append(rejected, fmt.Sprintf("%s (%s)", hardware.DiskLabel(verdict.Disk), verdict.Reason))
+		}
+	}
+	if len(rejected) > 0 {
+		message += fmt.Sprintf(". Rejected disks: %s", strings.Join(rejected, ", "))
+	}
+	return message
+}
+
 func (v *validator) printHasMinValidDisks(c *validationContext, status validationStatus) string {
 	switch status {
 	case ValidationSuccess:
-		return "Sufficient disk capacity"
+		return "Sufficient disk capacity" + printDiskVerdicts(v.evaluateDisks(c))
+	case ValidationFailure:
+		message := fmt.Sprintf("Require a disk of at least %d GiB", c.profile.MinDiskSizeGb)
+		if c.cluster.DiskSelectionPolicy != "" {
+			message += " accepted by the disk selection policy of the cluster"
+		}
+		return message + printDiskVerdicts(v.evaluateDisks(c))
+	case ValidationPending:
+		return "Missing inventory"
+	default:
+		return fmt.Sprintf("Unexpected status %s", status)
+	}
+}
+
+// selectedDisk returns the installation disk selected by the user if it can be the installation disk
+func (v *validator) selectedDisk(c *validationContext) (*models.Disk, error) {
+	return hardware.SelectedDisk(c.inventory, c.host.InstallationDiskID, c.profile.MinDiskSizeBytes())
+}
+
+func (v *validator) isInstallationDiskValid(c *validationContext) validationStatus {
+	if c.inventory == nil {
+		return ValidationPending
+	}
+	if c.host.InstallationDiskID == "" {
+		return ValidationSuccess
+	}
+	_, err := v.selectedDisk(c)
+	return boolValue(err == nil)
+}
+
+func (v *validator) printInstallationDiskValid(c *validationContext, status validationStatus) string {
+	switch status {
+	case ValidationSuccess:
+		if c.host.InstallationDiskID == "" {
+			return "The installation disk is selected by the service"
+		}
+		return fmt.Sprintf("Installation disk %s is valid", c.host.InstallationDiskID)
 	case ValidationFailure:
-		return fmt.Sprintf("Require a disk of at least %d GB", v.hwValidatorCfg.MinDiskSizeGb)
+		_, err := v.selectedDisk(c)
+		return fmt.Sprintf("The selected installation disk isn't valid: %s", err)
 	case ValidationPending:
 		return "Missing inventory"
 	default:
@@ -238,6 +323,68 @@ func (v *validator) printHasMinValidDisks(c *validationContext, status validatio
 	}
 }
 
+// installationDisk returns the disk the host is installed on, the one the disk speed check step benchmarks, nil if
+// it has none
+func (v *validator) installationDisk(c *validationContext) *models.Disk {
+	disk, _ := hardware.InstallationDisk(c.inventory, c.host.InstallationDiskID, v.diskSelectionPolicy(c), c.profile.MinDiskSizeBytes())
+	return disk
+}
+
+// diskSpeed returns the disk speed check of the installation disk of the host, nil if it wasn't checked yet
+func (v *validator) diskSpeed(c *validationContext) *models.DiskSpeedCheckResponse {
+	disk := v.installationDisk(c)
+	if disk == nil || c.host.DiskSpeed == "" {
+		return nil
+	}
+	var response models.DiskSpeedCheckResponse
+	if err := json.Unmarshal([]byte(c.host.DiskSpeed), &response); err != nil {
+		v.log.WithError(err).Warnf("Parse the disk speed check of host %s", c.host.ID)
+		return nil
+	}
+	if response.Path != GetDeviceFullName(disk.Name) {
+		return nil
+	}
+	return &response
+}
+
+func (v *validator) isDiskSpeedValid(c *validationContext) validationStatus {
+	if v.hwValidatorCfg.MaxFsyncLatencyMs == 0 || c.host.Role != models.HostRoleMaster {
+		return ValidationSuccess
+	}
+	if c.inventory == nil {
+		return ValidationPending
+	}
+	response := v.diskSpeed(c)
+	if response == nil {
+		return ValidationPending
+	}
+	return boolValue(response.FsyncLatencyP99Ms <= float64(v.hwValidatorCfg.MaxFsyncLatencyMs))
+}
+
+func (v *validator) printDiskSpeedValid(c *validationContext, status validationStatus) string {
+	switch status {
+	case ValidationSuccess:
+		if v.hwValidatorCfg.MaxFsyncLatencyMs == 0 {
+			return "The disk speed check is disabled"
+		}
+		if c.host.Role != models.HostRoleMaster {
+			return fmt.Sprintf("The disk speed check isn't required for role %s", c.host.Role)
+		}
+		return fmt.Sprintf("The installation disk is fast enough for etcd, 99th percentile fsync latency of %.1f ms",
+			v.diskSpeed(c).FsyncLatencyP99Ms)
+	case ValidationFailure:
+		return fmt.Sprintf("The installation disk is too slow for etcd, 99th percentile fsync latency of %.1f ms is above %d ms",
+			v.diskSpeed(c).FsyncLatencyP99Ms, v.hwValidatorCfg.MaxFsyncLatencyMs)
+	case ValidationPending:
+		if c.inventory == nil {
+			return "Missing inventory"
+		}
+		return "Waiting for the speed check of the installation disk"
+	default:
+		return fmt.Sprintf("Unexpected status %s", status)
+	}
+}
+
 func (v *validator) isMachineCidrDefined(c *validationContext) validationStatus {
 	return boolValue(swag.StringValue(c.cluster.Kind) == models.ClusterKindAddHostsCluster || c.cluster.MachineNetworkCidr != "")
 }
@@ -263,23 +410,23 @@ func (v *validator) hasCpuCoresForRole(c *validationContext) validationStatus {
 	}
 	switch c.host.Role {
 	case models.HostRoleMaster:
-		return boolValue(c.inventory.CPU.Count >= v.hwValidatorCfg.MinCPUCoresMaster)
+		return boolValue(c.inventory.CPU.Count >= c.profile.MinCPUCoresMaster)
 	case models.HostRoleWorker, models.HostRoleAutoAssign:
-		return boolValue(c.inventory.CPU.Count >= v.hwValidatorCfg.MinCPUCoresWorker)
+		return boolValue(c.inventory.CPU.Count >= c.profile.MinCPUCoresWorker)
 	default:
 		v.log.Errorf("Unexpected role %s", c.host.Role)
 		return ValidationError
 	}
 }
 
-func (v *validator) getCpuCountForRole(role models.HostRole) int64 {
-	switch role {
+func (v *validator) getCpuCountForRole(c *validationContext) int64 {
+	switch c.host.Role {
 	case models.HostRoleMaster:
-		return v.hwValidatorCfg.MinCPUCoresMaster
+		return c.profile.MinCPUCoresMaster
 	case models.HostRoleWorker, models.HostRoleAutoAssign:
-		return v.hwValidatorCfg.MinCPUCoresWorker
+		return c.profile.MinCPUCoresWorker
 	default:
-		return v.hwValidatorCfg.MinCPUCores
+		return c.profile.MinCPUCores
 	}
 }
 
@@ -289,7 +436,7 @@ func (v *validator) printHasCpuCoresForRole(c *validationContext, status validat
 		return fmt.Sprintf("Sufficient CPU cores for role %s", c.host.Role)
 	case ValidationFailure:
 		return fmt.Sprintf("Require at least %d CPU cores for %s role, found only %d",
-			v.getCpuCountForRole(c.host.Role), c.host.Role, c.inventory.CPU.Count)
+			v.getCpuCountForRole(c), c.host.Role, c.inventory.CPU.Count)
 	case ValidationPending:
 		return "Missing inventory or role"
 	default:
@@ -303,9 +450,9 @@ func (v *validator) hasMemoryForRole(c *validationContext) validationStatus {
 	}
 	switch c.host.Role {
 	case models.HostRoleMaster:
-		return boolValue(c.inventory.Memory.PhysicalBytes >= gibToBytes(v.hwValidatorCfg.MinRamGibMaster))
+		return boolValue(c.inventory.Memory.PhysicalBytes >= gibToBytes(c.profile.MinRamGibMaster))
 	case models.HostRoleWorker, models.HostRoleAutoAssign:
-		return boolValue(c.inventory.Memory.PhysicalBytes >= gibToBytes(v.hwValidatorCfg.MinRamGibWorker))
+		return boolValue(c.inventory.Memory.PhysicalBytes >= gibToBytes(c.profile.MinRamGibWorker))
 	default:
 		v.log.Errorf("Unexpected role %s", c.host.Role)
 		return ValidationError
@@ -335,14 +482,14 @@ func (v *validator) printValidPlatform(c *validationContext, status validationSt
 	}
 }
 
-func (v *validator) getMemoryForRole(role models.HostRole) int64 {
-	switch role {
+func (v *validator) getMemoryForRole(c *validationContext) int64 {
+	switch c.host.Role {
 	case models.HostRoleMaster:
-		return v.hwValidatorCfg.MinRamGibMaster
+		return c.profile.MinRamGibMaster
 	case models.HostRoleWorker, models.HostRoleAutoAssign:
-		return v.hwValidatorCfg.MinRamGibWorker
+		return c.profile.MinRamGibWorker
 	default:
-		return v.hwValidatorCfg.MinRamGib
+		return c.profile.MinRamGib
 	}
 }
 
@@ -352,7 +499,7 @@ func (v *validator) printHasMemoryForRole(c *validationContext, status validatio
 		return fmt.Sprintf("Sufficient RAM for role %s", c.host.Role)
 	case ValidationFailure:
 		return fmt.Sprintf("Require at least %d GiB RAM role %s, found only %d",
-			v.getMemoryForRole(c.host.Role), c.host.Role, bytesToGiB(c.inventory.Memory.PhysicalBytes))
+			v.getMemoryForRole(c), c.host.Role, bytesToGiB(c.inventory.Memory.PhysicalBytes))
 	case ValidationPending:
 		return "Missing inventory or role"
 	default:
@@ -383,6 +530,159 @@ func (v *validator) printBelongsToMachineCidr(c *validationContext, status valid
 	}
 }
 
+// machineNetworkInterface returns the interface of the host in the machine network, nil if the inventory, the machine
+// network CIDR or the interface is missing
+func (v *validator) machineNetworkInterface(c *validationContext) *models.Interface {
+	if c.inventory == nil || c.cluster.MachineNetworkCidr == "" {
+		return nil
+	}
+	intf, err := network.GetMachineNetworkInterface(c.inventory, c.cluster.MachineNetworkCidr)
+	if err != nil {
+		v.log.WithError(err).Warnf("Find the machine network interface of host %s", c.host.ID)
+		return nil
+	}
+	return intf
+}
+
+func (v *validator) printMissingMachineNetworkInterface(c *validationContext) string {
+	if c.inventory == nil || c.cluster.MachineNetworkCidr == "" {
+		return "Missing inventory or machine network CIDR"
+	}
+	return fmt.Sprintf("Host has no interface in machine network CIDR %s", c.cluster.MachineNetworkCidr)
+}
+
+func (v *validator) hasMachineNetworkCarrier(c *validationContext) validationStatus {
+	if swag.StringValue(c.cluster.Kind) == models.ClusterKindAddHostsCluster {
+		return ValidationSuccess
+	}
+	intf := v.machineNetworkInterface(c)
+	if intf == nil {
+		return ValidationPending
+	}
+	return boolValue(intf.HasCarrier)
+}
+
+func (v *validator) printHasMachineNetworkCarrier(c *validationContext, status validationStatus) string {
+	switch status {
+	case ValidationSuccess:
+		if swag.StringValue(c.cluster.Kind) == models.ClusterKindAddHostsCluster {
+			return "The carrier check of the machine network interface isn't required when adding hosts"
+		}
+		return fmt.Sprintf("Machine network interface %s has a carrier", v.machineNetworkInterface(c).Name)
+	case ValidationFailure:
+		return fmt.Sprintf("Machine network interface %s has no carrier, check its cable and switch port",
+			v.machineNetworkInterface(c).Name)
+	case ValidationPending:
+		return v.printMissingMachineNetworkInterface(c)
+	default:
+		return fmt.Sprintf("Unexpected status %s", status)
+	}
+}
+
+func (v *validator) isMachineNetworkLinkSpeedValid(c *validationContext) validationStatus {
+	if v.hwValidatorCfg.MinLinkSpeedMbps == 0 || swag.StringValue(c.cluster.Kind) == models.ClusterKindAddHostsCluster {
+		return ValidationSuccess
+	}
+	intf := v.machineNetworkInterface(c)
+	if intf == nil {
+		return ValidationPending
+	}
+	// Virtual interfaces don't report their speed
+	return boolValue(intf.SpeedMbps <= 0 || intf.SpeedMbps >= v.hwValidatorCfg.MinLinkSpeedMbps)
+}
+
+func (v *validator) printMachineNetworkLinkSpeedValid(c *validationContext, status validationStatus) string {
+	switch status {
+	case ValidationSuccess:
+		if v.hwValidatorCfg.MinLinkSpeedMbps == 0 {
+			return "The link speed check is disabled"
+		}
+		if swag.StringValue(c.cluster.Kind) == models.ClusterKindAddHostsCluster {
+			return "The link speed check of the machine network interface isn't required when adding hosts"
+		}
+		intf := v.machineNetworkInterface(c)
+		if intf.SpeedMbps <= 0 {
+			return fmt.Sprintf("Machine network interface %s doesn't report its link speed", intf.Name)
+		}
+		return fmt.Sprintf("Machine network interface %s has a link speed of %d Mbps", intf.Name, intf.SpeedMbps)
+	case ValidationFailure:
+		intf := v.machineNetworkInterface(c)
+		return fmt.Sprintf("Machine network interface %s has a link speed of %d Mbps, below the required %d Mbps",
+			intf.Name, intf.SpeedMbps, v.hwValidatorCfg.MinLinkSpeedMbps)
+	case ValidationPending:
+		return v.printMissingMachineNetworkInterface(c)
+	default:
+		return fmt.Sprintf("Unexpected status %s", status)
+	}
+}
+
+// otherMachineNetworkMtus returns the hosts of the cluster by the MTU of their machine network interface, without the host
+// of the context
+func (v *validator) otherMachineNetworkMtus(c *validationContext) map[int64][]*models.Host {
+	mtus := network.GetMachineNetworkMtus(v.log, c.cluster)
+	for mtu, hosts := range mtus {
+		others := make([]*models.Host, 0, len(hosts))
+		for _, h := range hosts {
+			if h.ID.String() != c.host.ID.String() {
+				others = append(others, h)
+			}
+		}
+		if len(others) == 0 {
+			delete(mtus, mtu)
+		} else {
+			mtus[mtu] = others
+		}
+	}
+	return mtus
+}
+
+// isMachineNetworkMtuConsistent checks that no other MTU is used by at least as many hosts as the MTU of the host
+func (v *validator) isMachineNetworkMtuConsistent(c *validationContext) validationStatus {
+	if swag.StringValue(c.cluster.Kind) == models.ClusterKindAddHostsCluster {
+		return ValidationSuccess
+	}
+	intf := v.machineNetworkInterface(c)
+	if intf == nil {
+		return ValidationPending
+	}
+	if intf.Mtu <= 0 {
+		return ValidationSuccess
+	}
+	mtus := v.otherMachineNetworkMtus(c)
+	// The hosts with the MTU of the host, including itself
+	count := len(mtus[intf.Mtu]) + 1
+	for mtu, hosts := range mtus {
+		if mtu != intf.Mtu && len(hosts) >= count {
+			return ValidationFailure
+		}
+	}
+	return ValidationSuccess
+}
+
+func (v *validator) printMachineNetworkMtuConsistent(c *validationContext, status validationStatus) string {
+	switch status {
+	case ValidationSuccess:
+		if swag.StringValue(c.cluster.Kind) == models.ClusterKindAddHostsCluster {
+			return "The MTU check of the machine network interface isn't required when adding hosts"
+		}
+		intf := v.machineNetworkInterface(c)
+		if intf.Mtu <= 0 {
+			return fmt.Sprintf("Machine network interface %s doesn't report its MTU", intf.Name)
+		}
+		return fmt.Sprintf("Machine network interface %s has the MTU %d of most hosts", intf.Name, intf.Mtu)
+	case ValidationFailure:
+		intf := v.machineNetworkInterface(c)
+		mtus := v.otherMachineNetworkMtus(c)
+		delete(mtus, intf.Mtu)
+		return fmt.Sprintf("Machine network interface %s has the MTU %d, different from the MTUs of other hosts: %s",
+			intf.Name, intf.Mtu, network.FormatMtus(mtus))
+	case ValidationPending:
+		return v.printMissingMachineNetworkInterface(c)
+	default:
+		return fmt.Sprintf("Unexpected status %s", status)
+	}
+}
+
 func getRealHostname(host *models.Host, inventory *models.Inventory) string {
 	if host.RequestedHostname != "" {
 		return host.RequestedHostname
@@ -397,13 +697,13 @@ func (v *validator) isHostnameUnique(c *validationContext) validationStatus {
 	realHostname := getRealHostname(c.host, c.inventory)
 	for _, h := range c.cluster.Hosts {
 		if h.ID.String() != c.host.ID.String() && h.Inventory != "" {
-			var otherInventory models.Inventory
-			if err := json.Unmarshal([]byte(h.Inventory), &otherInventory); err != nil {
+			otherInventory, err := hostutil.UnmarshalInventory(h)
+			if err != nil {
 				v.log.WithError(err).Warnf("Illegal inventory for host %s", h.ID.String())
 				// It is not our hostname
 				continue
 			}
-			if realHostname == getRealHostname(h, &otherInventory) {
+			if realHostname == getRealHostname(h, otherInventory) {
 				return ValidationFailure
 			}
 		}
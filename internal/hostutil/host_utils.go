@@ -1,7 +1,6 @@
 package hostutil
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -18,11 +17,10 @@ const (
 )
 
 func GetCurrentHostName(host *models.Host) (string, error) {
-	var inventory models.Inventory
 	if host.RequestedHostname != "" {
 		return host.RequestedHostname, nil
 	}
-	err := json.Unmarshal([]byte(host.Inventory), &inventory)
+	inventory, err := UnmarshalInventory(host)
 	if err != nil {
 		return "", err
 	}
@@ -3,8 +3,11 @@ package hostutil
 import (
 	"testing"
 
+	"github.com/go-openapi/strfmt"
+	"github.com/google/uuid"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/openshift/assisted-service/models"
 )
 
 var _ = Describe("ValidateInstallerArgs", func() {
@@ -26,6 +29,40 @@ var _ = Describe("ValidateInstallerArgs", func() {
 	})
 })
 
+var _ = Describe("UnmarshalInventory", func() {
+	var host *models.Host
+
+	BeforeEach(func() {
+		hostID := strfmt.UUID(uuid.New().String())
+		host = &models.Host{ID: &hostID, ClusterID: strfmt.UUID(uuid.New().String()),
+			Inventory: `{"hostname": "first"}`}
+	})
+
+	It("returns the same inventory while the host reports the same one", func() {
+		inventory, err := UnmarshalInventory(host)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(inventory.Hostname).To(Equal("first"))
+		again, err := UnmarshalInventory(host)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(again).To(BeIdenticalTo(inventory))
+	})
+
+	It("parses the inventory again once it changes", func() {
+		_, err := UnmarshalInventory(host)
+		Expect(err).NotTo(HaveOccurred())
+		host.Inventory = `{"hostname": "second"}`
+		inventory, err := UnmarshalInventory(host)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(inventory.Hostname).To(Equal("second"))
+	})
+
+	It("fails on an invalid inventory", func() {
+		host.Inventory = ""
+		_, err := UnmarshalInventory(host)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
 func TestHostUtil(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "HostUtil Tests")
@@ -0,0 +1,46 @@
+package hostutil
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/openshift/assisted-service/models"
+	"github.com/patrickmn/go-cache"
+)
+
+// Parsed inventories of the hosts, so the inventory is unmarshalled once per report of the host instead of
+// on every validation. An entry is used only while the inventory it was parsed from is the current one.
+var inventoryCache = cache.New(10*time.Minute, 30*time.Minute)
+
+type parsedInventory struct {
+	raw       string
+	inventory *models.Inventory
+}
+
+// UnmarshalInventory returns the parsed inventory of the host. The inventory is shared by all the callers
+// that ask for the same host until it reports a new one, so it must not be modified.
+func UnmarshalInventory(host *models.Host) (*models.Inventory, error) {
+	if host.ID == nil {
+		return unmarshalInventory(host.Inventory)
+	}
+	key := host.ClusterID.String() + "/" + host.ID.String()
+	if cached, ok := inventoryCache.Get(key); ok {
+		if p := cached.(*parsedInventory); p.raw == host.Inventory {
+			return p.inventory, nil
+		}
+	}
+	inventory, err := unmarshalInventory(host.Inventory)
+	if err != nil {
+		return nil, err
+	}
+	inventoryCache.SetDefault(key, &parsedInventory{raw: host.Inventory, inventory: inventory})
+	return inventory, nil
+}
+
+func unmarshalInventory(raw string) (*models.Inventory, error) {
+	var inventory models.Inventory
+	if err := json.Unmarshal([]byte(raw), &inventory); err != nil {
+		return nil, err
+	}
+	return &inventory, nil
+}
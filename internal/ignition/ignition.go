@@ -363,8 +363,7 @@ func (g *installerGenerator) fixMOTDFile(file *config_31_types.File) {
 // modifyBMHFile modifies the File contents so that the serialized BareMetalHost
 // includes a status annotation
 func (g *installerGenerator) modifyBMHFile(file *config_31_types.File, bmh *bmh_v1alpha1.BareMetalHost, host *models.Host) error {
-	inventory := models.Inventory{}
-	err := json.Unmarshal([]byte(host.Inventory), &inventory)
+	inventory, err := hostutil.UnmarshalInventory(host)
 	if err != nil {
 		return err
 	}
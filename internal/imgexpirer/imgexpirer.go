@@ -13,6 +13,7 @@ import (
 	"github.com/openshift/assisted-service/pkg/requestid"
 	"github.com/openshift/assisted-service/pkg/s3wrapper"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
 const imagePrefix = "discovery-image-"
@@ -27,21 +28,25 @@ var (
 type Manager struct {
 	objectHandler s3wrapper.API
 	eventsHandler events.Handler
+	db            *gorm.DB
 	deleteTime    time.Duration
 	leaderElector leader.Leader
 }
 
-func NewManager(objectHandler s3wrapper.API, eventsHandler events.Handler, deleteTime time.Duration, leaderElector leader.ElectorInterface) *Manager {
+func NewManager(objectHandler s3wrapper.API, eventsHandler events.Handler, db *gorm.DB, deleteTime time.Duration, leaderElector leader.ElectorInterface) *Manager {
 	return &Manager{
 		objectHandler: objectHandler,
 		eventsHandler: eventsHandler,
+		db:            db,
 		deleteTime:    deleteTime,
 		leaderElector: leaderElector,
 	}
 }
 
 func (m *Manager) ExpirationTask() {
-	if !m.leaderElector.IsLeader() {
+	// the images aren't deleted in a transaction, the lease of the leader is verified before deleting them so a
+	// former leader that didn't notice it lost the lease doesn't
+	if err := leader.Verify(m.db, m.leaderElector); err != nil {
 		return
 	}
 	ctx := requestid.ToContext(context.Background(), requestid.NewID())
@@ -57,7 +62,8 @@ func (m *Manager) DeletedImageCallback(ctx context.Context, log logrus.FieldLogg
 	}
 	clusterID := strfmt.UUID(matches[1])
 	m.eventsHandler.AddEvent(ctx, clusterID, nil, models.EventSeverityInfo,
-		"Deleted image from backend because it expired. It may be generated again at any time.", time.Now())
+		"Deleted image from backend because it expired. It may be generated again at any time.", time.Now(),
+		events.ClusterImageExpired, nil)
 }
 
 func (m *Manager) DeletedImageNoCallback(ctx context.Context, log logrus.FieldLogger, objectName string) {
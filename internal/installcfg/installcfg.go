@@ -194,8 +194,7 @@ func setBMPlatformInstallconfig(log logrus.FieldLogger, cluster *common.Cluster,
 		hosts[yamlHostIdx].Name = getBMHName(host, &masterIdx, &workerIdx)
 		hosts[yamlHostIdx].Role = string(host.Role)
 
-		var inventory models.Inventory
-		err := json.Unmarshal([]byte(host.Inventory), &inventory)
+		inventory, err := hostutil.UnmarshalInventory(host)
 		if err != nil {
 			log.Warnf("Failed to unmarshall host %s inventory", hostutil.GetHostnameForMsg(host))
 			return err
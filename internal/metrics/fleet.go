@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/models"
+	"github.com/openshift/assisted-service/pkg/leader"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type FleetConfig struct {
+	Interval time.Duration `envconfig:"FLEET_METRICS_INTERVAL" default:"30s"`
+	// Max number of new transitions the time in status is reported for on each interval
+	TransitionsBatchSize int `envconfig:"FLEET_METRICS_TRANSITIONS_BATCH_SIZE" default:"1000"`
+	// Transitions are reported only once they are older than this delay, so a transition that is committed
+	// after a transition with a greater ID is not skipped
+	TransitionsSettleDelay time.Duration `envconfig:"FLEET_METRICS_TRANSITIONS_SETTLE_DELAY" default:"10s"`
+}
+
+// FleetReporter reports the numbers of clusters and hosts by status and the time they spend in each status, so the
+// scrapes of the metrics don't query the db. Only the leader reports them, the other replicas report nothing, so
+// the metrics of all the replicas can be summed.
+type FleetReporter struct {
+	cfg           FleetConfig
+	db            *gorm.DB
+	log           logrus.FieldLogger
+	metricApi     API
+	leaderElector leader.Leader
+
+	// Whether the replica reported as the leader in the last interval
+	reporting bool
+	// ID of the last transition the time in status was reported for
+	lastTransitionID int64
+}
+
+func NewFleetReporter(cfg FleetConfig, db *gorm.DB, log logrus.FieldLogger, metricApi API, leaderElector leader.Leader) *FleetReporter {
+	return &FleetReporter{
+		cfg:           cfg,
+		db:            db,
+		log:           log,
+		metricApi:     metricApi,
+		leaderElector: leaderElector,
+	}
+}
+
+func (r *FleetReporter) ReportTask() {
+	if !r.leaderElector.IsLeader() {
+		if r.reporting {
+			r.metricApi.FleetStatus(nil, nil)
+			r.reporting = false
+		}
+		return
+	}
+	if !r.reporting {
+		if err := r.startReporting(); err != nil {
+			r.log.WithError(err).Error("failed to start reporting the fleet metrics")
+			return
+		}
+	}
+	if err := r.reportFleetStatus(); err != nil {
+		r.log.WithError(err).Error("failed to report the numbers of clusters and hosts by status")
+	}
+	if err := r.reportStatusDurations(); err != nil {
+		r.log.WithError(err).Error("failed to report the time clusters and hosts spend in each status")
+	}
+}
+
+// startReporting skips the status changes that occurred before becoming the leader, the previous leader reported them
+func (r *FleetReporter) startReporting() error {
+	var last models.StateTransition
+	if err := r.db.Select("id").Order("id desc").Limit(1).Find(&last).Error; err != nil {
+		return err
+	}
+	r.lastTransitionID = last.ID
+	r.reporting = true
+	return nil
+}
+
+func (r *FleetReporter) reportFleetStatus() error {
+	clusters, err := r.countByStatus(&common.Cluster{})
+	if err != nil {
+		return err
+	}
+	hosts, err := r.countByStatus(&models.Host{})
+	if err != nil {
+		return err
+	}
+	r.metricApi.FleetStatus(clusters, hosts)
+	return nil
+}
+
+func (r *FleetReporter) countByStatus(model interface{}) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	if err := r.db.Model(model).Select("status, count(*) as count").Where("deleted_at IS NULL").
+		Group("status").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// reportStatusDurations reports the time in status of the settled status changes recorded in the history since the
+// last report, the time in status is the time since the transition that entered it
+func (r *FleetReporter) reportStatusDurations() error {
+	var transitions []*models.StateTransition
+	if err := r.db.Where("id > ? and from_status <> to_status and from_status <> ''", r.lastTransitionID).
+		Order("id").Limit(r.cfg.TransitionsBatchSize).Find(&transitions).Error; err != nil {
+		return err
+	}
+	settled := time.Now().Add(-r.cfg.TransitionsSettleDelay)
+	for _, t := range transitions {
+		if time.Time(*t.TransitionTime).After(settled) {
+			break
+		}
+		var entered models.StateTransition
+		err := r.db.Where("cluster_id = ? and host_id = ? and id < ? and to_status = ? and from_status <> to_status",
+			t.ClusterID.String(), t.HostID.String(), t.ID, t.FromStatus).Order("id desc").Limit(1).Find(&entered).Error
+		if err != nil {
+			return err
+		}
+		r.lastTransitionID = t.ID
+		if entered.ID == 0 {
+			continue
+		}
+		duration := time.Time(*t.TransitionTime).Sub(time.Time(*entered.TransitionTime))
+		if *t.EntityKind == models.StateTransitionEntityKindHost {
+			r.metricApi.HostStatusDuration(t.FromStatus, duration)
+		} else {
+			r.metricApi.ClusterStatusDuration(t.FromStatus, duration)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/models"
+	"github.com/openshift/assisted-service/pkg/leader"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("FleetReporter", func() {
+	var (
+		db            *gorm.DB
+		dbName        = "fleet_metrics"
+		ctrl          *gomock.Controller
+		mockMetric    *MockAPI
+		mockLeader    *leader.MockLeader
+		reporter      *FleetReporter
+		clusterID     strfmt.UUID
+		hostID        strfmt.UUID
+		start         time.Time
+		cfg           = FleetConfig{Interval: time.Second, TransitionsBatchSize: 10}
+		noCounts      = map[string]int64{}
+		anyFleetState = gomock.Any()
+	)
+
+	BeforeEach(func() {
+		db = common.PrepareTestDB(dbName)
+		ctrl = gomock.NewController(GinkgoT())
+		mockMetric = NewMockAPI(ctrl)
+		mockLeader = leader.NewMockLeader(ctrl)
+		reporter = NewFleetReporter(cfg, db, logrus.WithField("pkg", "metrics"), mockMetric, mockLeader)
+		clusterID = strfmt.UUID(uuid.New().String())
+		hostID = strfmt.UUID(uuid.New().String())
+		start = time.Now().Add(-time.Hour).UTC()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+		common.DeleteTestDB(db, dbName)
+	})
+
+	createCluster := func(status string, deleted bool) {
+		id := strfmt.UUID(uuid.New().String())
+		c := &common.Cluster{Cluster: models.Cluster{ID: &id, Status: swag.String(status)}}
+		if deleted {
+			deletedAt := strfmt.DateTime(time.Now())
+			c.DeletedAt = &deletedAt
+		}
+		Expect(db.Create(c).Error).ShouldNot(HaveOccurred())
+	}
+
+	createHost := func(status string) {
+		id := strfmt.UUID(uuid.New().String())
+		Expect(db.Create(&models.Host{ID: &id, ClusterID: clusterID, Status: swag.String(status)}).Error).ShouldNot(HaveOccurred())
+	}
+
+	createTransition := func(hostID strfmt.UUID, from, to string, after time.Duration) {
+		kind := models.StateTransitionEntityKindCluster
+		if hostID != "" {
+			kind = models.StateTransitionEntityKindHost
+		}
+		at := strfmt.DateTime(start.Add(after))
+		Expect(db.Create(&models.StateTransition{
+			ClusterID:      &clusterID,
+			HostID:         hostID,
+			EntityKind:     swag.String(kind),
+			FromStatus:     from,
+			ToStatus:       swag.String(to),
+			TransitionTime: &at,
+		}).Error).ShouldNot(HaveOccurred())
+	}
+
+	It("reports the numbers of clusters and hosts by status", func() {
+		createCluster(models.ClusterStatusInsufficient, false)
+		createCluster(models.ClusterStatusInsufficient, false)
+		createCluster(models.ClusterStatusReady, false)
+		createCluster(models.ClusterStatusReady, true)
+		createHost(models.HostStatusKnown)
+		createHost(models.HostStatusDisconnected)
+		createHost(models.HostStatusKnown)
+
+		mockLeader.EXPECT().IsLeader().Return(true)
+		mockMetric.EXPECT().FleetStatus(
+			map[string]int64{models.ClusterStatusInsufficient: 2, models.ClusterStatusReady: 1},
+			map[string]int64{models.HostStatusKnown: 2, models.HostStatusDisconnected: 1})
+		reporter.ReportTask()
+	})
+
+	It("reports the time in status of the status changes since it became the leader", func() {
+		createTransition("", "", models.ClusterStatusInsufficient, 0)
+		createTransition("", models.ClusterStatusInsufficient, models.ClusterStatusReady, time.Minute)
+		createTransition(hostID, "", models.HostStatusDiscovering, 0)
+		createTransition(hostID, models.HostStatusDiscovering, models.HostStatusKnown, 2*time.Minute)
+
+		By("skipping the status changes that occurred before")
+		mockLeader.EXPECT().IsLeader().Return(true)
+		mockMetric.EXPECT().FleetStatus(noCounts, noCounts)
+		reporter.ReportTask()
+
+		createTransition("", models.ClusterStatusReady, models.ClusterStatusPreparingForInstallation, 4*time.Minute)
+		createTransition(hostID, models.HostStatusKnown, models.HostStatusKnown, 3*time.Minute)
+		createTransition(hostID, models.HostStatusKnown, models.HostStatusPreparingForInstallation, 5*time.Minute)
+		// the transition that entered the status isn't recorded
+		createTransition(strfmt.UUID(uuid.New().String()), models.HostStatusKnown, models.HostStatusInstalling, 5*time.Minute)
+
+		mockLeader.EXPECT().IsLeader().Return(true)
+		mockMetric.EXPECT().FleetStatus(noCounts, noCounts)
+		mockMetric.EXPECT().ClusterStatusDuration(models.ClusterStatusReady, 3*time.Minute)
+		mockMetric.EXPECT().HostStatusDuration(models.HostStatusKnown, 3*time.Minute)
+		reporter.ReportTask()
+
+		By("reporting each status change once")
+		mockLeader.EXPECT().IsLeader().Return(true)
+		mockMetric.EXPECT().FleetStatus(noCounts, noCounts)
+		reporter.ReportTask()
+	})
+
+	It("reports the status changes once they settled", func() {
+		reporter = NewFleetReporter(FleetConfig{Interval: time.Second, TransitionsBatchSize: 10, TransitionsSettleDelay: time.Minute},
+			db, logrus.WithField("pkg", "metrics"), mockMetric, mockLeader)
+		mockLeader.EXPECT().IsLeader().Return(true)
+		mockMetric.EXPECT().FleetStatus(noCounts, noCounts)
+		reporter.ReportTask()
+
+		createTransition("", "", models.ClusterStatusInsufficient, 0)
+		createTransition("", models.ClusterStatusInsufficient, models.ClusterStatusReady, time.Hour)
+		createTransition(hostID, "", models.HostStatusDiscovering, 0)
+		createTransition(hostID, models.HostStatusDiscovering, models.HostStatusKnown, 2*time.Minute)
+
+		By("waiting for the recent status change and the ones that follow it")
+		mockLeader.EXPECT().IsLeader().Return(true)
+		mockMetric.EXPECT().FleetStatus(noCounts, noCounts)
+		reporter.ReportTask()
+
+		By("reporting them once settled")
+		Expect(db.Model(&models.StateTransition{}).Where("cluster_id = ? and host_id = '' and from_status <> ''", clusterID.String()).
+			Update("transition_time", strfmt.DateTime(start.Add(time.Minute))).Error).ShouldNot(HaveOccurred())
+		mockLeader.EXPECT().IsLeader().Return(true)
+		mockMetric.EXPECT().FleetStatus(noCounts, noCounts)
+		mockMetric.EXPECT().ClusterStatusDuration(models.ClusterStatusInsufficient, time.Minute)
+		mockMetric.EXPECT().HostStatusDuration(models.HostStatusDiscovering, 2*time.Minute)
+		reporter.ReportTask()
+	})
+
+	It("stops reporting when it isn't the leader", func() {
+		mockLeader.EXPECT().IsLeader().Return(false)
+		reporter.ReportTask()
+
+		mockLeader.EXPECT().IsLeader().Return(true)
+		mockMetric.EXPECT().FleetStatus(anyFleetState, anyFleetState)
+		reporter.ReportTask()
+
+		By("clearing the reported numbers once")
+		mockLeader.EXPECT().IsLeader().Return(false).Times(2)
+		mockMetric.EXPECT().FleetStatus(nil, nil)
+		reporter.ReportTask()
+		reporter.ReportTask()
+	})
+})
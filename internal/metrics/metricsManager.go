@@ -1,13 +1,13 @@
 package metrics
 
 import (
-	"encoding/json"
 	"time"
 
 	"github.com/go-openapi/strfmt"
 
 	"github.com/alecthomas/units"
 
+	"github.com/openshift/assisted-service/internal/hostutil"
 	"github.com/openshift/assisted-service/models"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
@@ -29,6 +29,14 @@ const (
 	counterClusterHostRAMGb             = "assisted_installer_cluster_host_ram_gb"
 	counterClusterHostDiskGb            = "assisted_installer_cluster_host_disk_gb"
 	counterClusterHostNicGb             = "assisted_installer_cluster_host_nic_gb"
+	counterMonitorCycleSeconds          = "assisted_installer_monitor_cycle_seconds"
+	counterHostStageSeconds             = "assisted_installer_host_stage_seconds"
+	counterHostValidationFailures       = "assisted_installer_host_validation_failures"
+	counterClusterValidationFailures    = "assisted_installer_cluster_validation_failures"
+	counterHostStatusSeconds            = "assisted_installer_host_status_seconds"
+	counterClusterStatusSeconds         = "assisted_installer_cluster_status_seconds"
+	gaugeClustersByStatus               = "assisted_installer_clusters"
+	gaugeHostsByStatus                  = "assisted_installer_hosts"
 )
 
 const (
@@ -42,6 +50,14 @@ const (
 	counterDescriptionClusterHostRAMGb             = "Histogram/sum/count of physical RAM in hosts of completed clusters, by role, result, and OCP version"
 	counterDescriptionClusterHostDiskGb            = "Histogram/sum/count of installation disk capacity in hosts of completed clusters, by type, raid (level), role, result, and OCP version"
 	counterDescriptionClusterHostNicGb             = "Histogram/sum/count of management network NIC speed in hosts of completed clusters, by role, result, and OCP version"
+	counterDescriptionMonitorCycleSeconds          = "Histogram/sum/count of the time a monitor takes to refresh all of its clusters or hosts, by monitor"
+	counterDescriptionHostStageSeconds             = "Histogram/sum/count of the time hosts spend in each installation stage, by stage, role, and stage result"
+	counterDescriptionHostValidationFailures       = "Number of times a host validation changed to failure, by validation"
+	counterDescriptionClusterValidationFailures    = "Number of times a cluster validation changed to failure, by validation"
+	counterDescriptionHostStatusSeconds            = "Histogram/sum/count of the time hosts spend in each status before changing to another, by status"
+	counterDescriptionClusterStatusSeconds         = "Histogram/sum/count of the time clusters spend in each status before changing to another, by status"
+	gaugeDescriptionClustersByStatus               = "Number of clusters, by status"
+	gaugeDescriptionHostsByStatus                  = "Number of hosts, by status"
 )
 
 const (
@@ -59,6 +75,10 @@ const (
 	discoveryAgentVersionLabel = "discoveryAgentVersion"
 	hwVendorLabel              = "vendor"
 	hwProductLabel             = "product"
+	monitorLabel               = "monitor"
+	stageLabel                 = "stage"
+	validationLabel            = "validation"
+	statusLabel                = "status"
 )
 
 type API interface {
@@ -67,6 +87,12 @@ type API interface {
 	Duration(operation string, duration time.Duration)
 	ClusterInstallationFinished(log logrus.FieldLogger, result, clusterVersion string, clusterID strfmt.UUID, emailDomain string, installationStartedTime strfmt.DateTime)
 	ReportHostInstallationMetrics(log logrus.FieldLogger, clusterVersion string, clusterID strfmt.UUID, emailDomain string, boot *models.Disk, h *models.Host, previousProgress *models.HostProgressInfo, currentStage models.HostStage)
+	MonitoringCycle(monitor string, duration time.Duration)
+	HostValidationFailed(validationID string)
+	ClusterValidationFailed(validationID string)
+	HostStatusDuration(status string, duration time.Duration)
+	ClusterStatusDuration(status string, duration time.Duration)
+	FleetStatus(clustersByStatus map[string]int64, hostsByStatus map[string]int64)
 }
 
 type MetricsManager struct {
@@ -82,6 +108,14 @@ type MetricsManager struct {
 	serviceLogicClusterHostRAMGb             *prometheus.HistogramVec
 	serviceLogicClusterHostDiskGb            *prometheus.HistogramVec
 	serviceLogicClusterHostNicGb             *prometheus.HistogramVec
+	serviceLogicMonitorCycleSeconds          *prometheus.HistogramVec
+	serviceLogicHostStageSeconds             *prometheus.HistogramVec
+	serviceLogicHostValidationFailures       *prometheus.CounterVec
+	serviceLogicClusterValidationFailures    *prometheus.CounterVec
+	serviceLogicHostStatusSeconds            *prometheus.HistogramVec
+	serviceLogicClusterStatusSeconds         *prometheus.HistogramVec
+	serviceLogicClustersByStatus             *prometheus.GaugeVec
+	serviceLogicHostsByStatus                *prometheus.GaugeVec
 }
 
 func NewMetricsManager(registry prometheus.Registerer) *MetricsManager {
@@ -169,6 +203,70 @@ func NewMetricsManager(registry prometheus.Registerer) *MetricsManager {
 			Help:      counterDescriptionClusterHostNicGb,
 			Buckets:   []float64{1, 10, 20, 40, 100},
 		}, []string{roleLabel, resultLabel, openshiftVersionLabel, clusterIdLabel, emailDomainLabel}),
+
+		serviceLogicMonitorCycleSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      counterMonitorCycleSeconds,
+			Help:      counterDescriptionMonitorCycleSeconds,
+			Buckets:   []float64{0.1, 0.5, 1, 2, 4, 8, 15, 30, 60, 120, 300},
+		}, []string{monitorLabel}),
+
+		serviceLogicHostStageSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      counterHostStageSeconds,
+			Help:      counterDescriptionHostStageSeconds,
+			Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600, 900, 1200, 1800, 2700, 3600},
+		}, []string{stageLabel, roleLabel, resultLabel}),
+
+		serviceLogicHostValidationFailures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      counterHostValidationFailures,
+				Help:      counterDescriptionHostValidationFailures,
+			}, []string{validationLabel}),
+
+		serviceLogicClusterValidationFailures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      counterClusterValidationFailures,
+				Help:      counterDescriptionClusterValidationFailures,
+			}, []string{validationLabel}),
+
+		serviceLogicHostStatusSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      counterHostStatusSeconds,
+			Help:      counterDescriptionHostStatusSeconds,
+			Buckets:   []float64{1, 10, 30, 60, 300, 600, 1800, 3600, 7200, 14400, 43200, 86400, 604800},
+		}, []string{statusLabel}),
+
+		serviceLogicClusterStatusSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      counterClusterStatusSeconds,
+			Help:      counterDescriptionClusterStatusSeconds,
+			Buckets:   []float64{1, 10, 30, 60, 300, 600, 1800, 3600, 7200, 14400, 43200, 86400, 604800},
+		}, []string{statusLabel}),
+
+		serviceLogicClustersByStatus: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      gaugeClustersByStatus,
+				Help:      gaugeDescriptionClustersByStatus,
+			}, []string{statusLabel}),
+
+		serviceLogicHostsByStatus: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      gaugeHostsByStatus,
+				Help:      gaugeDescriptionHostsByStatus,
+			}, []string{statusLabel}),
 	}
 
 	registry.MustRegister(
@@ -182,6 +280,14 @@ func NewMetricsManager(registry prometheus.Registerer) *MetricsManager {
 		m.serviceLogicClusterHostRAMGb,
 		m.serviceLogicClusterHostDiskGb,
 		m.serviceLogicClusterHostNicGb,
+		m.serviceLogicMonitorCycleSeconds,
+		m.serviceLogicHostStageSeconds,
+		m.serviceLogicHostValidationFailures,
+		m.serviceLogicClusterValidationFailures,
+		m.serviceLogicHostStatusSeconds,
+		m.serviceLogicClusterStatusSeconds,
+		m.serviceLogicClustersByStatus,
+		m.serviceLogicHostsByStatus,
 	)
 	return m
 }
@@ -199,6 +305,38 @@ func (m *MetricsManager) ClusterInstallationFinished(log logrus.FieldLogger, res
 	m.serviceLogicClusterInstallationSeconds.WithLabelValues(result, clusterVersion, clusterID.String(), emailDomain).Observe(duration)
 }
 
+func (m *MetricsManager) MonitoringCycle(monitor string, duration time.Duration) {
+	m.serviceLogicMonitorCycleSeconds.WithLabelValues(monitor).Observe(duration.Seconds())
+}
+
+func (m *MetricsManager) HostValidationFailed(validationID string) {
+	m.serviceLogicHostValidationFailures.WithLabelValues(validationID).Inc()
+}
+
+func (m *MetricsManager) ClusterValidationFailed(validationID string) {
+	m.serviceLogicClusterValidationFailures.WithLabelValues(validationID).Inc()
+}
+
+func (m *MetricsManager) HostStatusDuration(status string, duration time.Duration) {
+	m.serviceLogicHostStatusSeconds.WithLabelValues(status).Observe(duration.Seconds())
+}
+
+func (m *MetricsManager) ClusterStatusDuration(status string, duration time.Duration) {
+	m.serviceLogicClusterStatusSeconds.WithLabelValues(status).Observe(duration.Seconds())
+}
+
+// FleetStatus replaces the numbers of clusters and hosts by status, the statuses that are missing are removed
+func (m *MetricsManager) FleetStatus(clustersByStatus map[string]int64, hostsByStatus map[string]int64) {
+	m.serviceLogicClustersByStatus.Reset()
+	for status, count := range clustersByStatus {
+		m.serviceLogicClustersByStatus.WithLabelValues(status).Set(float64(count))
+	}
+	m.serviceLogicHostsByStatus.Reset()
+	for status, count := range hostsByStatus {
+		m.serviceLogicHostsByStatus.WithLabelValues(status).Set(float64(count))
+	}
+}
+
 func (m *MetricsManager) Duration(operation string, duration time.Duration) {
 	m.serviceLogicOperationDurationMiliSeconds.WithLabelValues(operation).Observe(float64(duration.Milliseconds()))
 }
@@ -212,9 +350,8 @@ func (m *MetricsManager) ReportHostInstallationMetrics(log logrus.FieldLogger, c
 		}
 		installationStageStr := string(currentStage)
 
-		var hwInfo models.Inventory
 		hwVendor, hwProduct := UnknownHWValue, UnknownHWValue
-		if err := json.Unmarshal([]byte(h.Inventory), &hwInfo); err == nil {
+		if hwInfo, err := hostutil.UnmarshalInventory(h); err == nil {
 			if hwInfo.SystemVendor != nil {
 				hwVendor = hwInfo.SystemVendor.Manufacturer
 				hwProduct = hwInfo.SystemVendor.ProductName
@@ -240,6 +377,7 @@ func (m *MetricsManager) ReportHostInstallationMetrics(log logrus.FieldLogger, c
 				string(previousProgress.CurrentStage), hwVendor, hwProduct, diskType, string(phaseResult), duration)
 			m.serviceLogicHostInstallationPhaseSeconds.WithLabelValues(string(previousProgress.CurrentStage),
 				string(phaseResult), clusterVersion, clusterID.String(), emailDomain, h.DiscoveryAgentVersion, hwVendor, hwProduct, diskType).Observe(duration)
+			m.serviceLogicHostStageSeconds.WithLabelValues(string(previousProgress.CurrentStage), roleStr, string(phaseResult)).Observe(duration)
 		}
 	}
 }
@@ -249,9 +387,8 @@ func (m *MetricsManager) handleHostInstallationComplete(log logrus.FieldLogger,
 	log.Infof("service Logic Cluster Hosts clusterVersion %s, roleStr %s, vendor %s, product %s, disk %s, result %s",
 		clusterVersion, roleStr, hwVendor, hwProduct, diskType, installationStageStr)
 	m.serviceLogicClusterHosts.WithLabelValues(roleStr, installationStageStr, clusterVersion, clusterID.String(), emailDomain, hwVendor, hwProduct, diskType).Inc()
-	var hwInfo models.Inventory
 
-	err := json.Unmarshal([]byte(h.Inventory), &hwInfo)
+	hwInfo, err := hostutil.UnmarshalInventory(h)
 	if err != nil {
 		log.Errorf("failed to report host hardware installation metrics for %s", h.ID)
 	} else {
@@ -0,0 +1,37 @@
+package metrics
+
+import "encoding/json"
+
+// Status of the failing validations of the hosts and the clusters
+const validationFailure = "failure"
+
+// validationsInfo is the validations_info of a host or a cluster, the validations by their category
+type validationsInfo map[string][]struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// NewValidationFailures returns the IDs of the validations that fail in the validations info and didn't fail in
+// the previous validations info of the host or the cluster, which is empty before the first refresh
+func NewValidationFailures(previous, current string) []string {
+	var previousInfo, currentInfo validationsInfo
+	_ = json.Unmarshal([]byte(previous), &previousInfo)
+	if err := json.Unmarshal([]byte(current), &currentInfo); err != nil {
+		return nil
+	}
+	failed := make(map[string]bool)
+	for _, validations := range previousInfo {
+		for _, v := range validations {
+			failed[v.ID] = v.Status == validationFailure
+		}
+	}
+	var ret []string
+	for _, validations := range currentInfo {
+		for _, v := range validations {
+			if v.Status == validationFailure && !failed[v.ID] {
+				ret = append(ret, v.ID)
+			}
+		}
+	}
+	return ret
+}
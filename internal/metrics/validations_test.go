@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewValidationFailures", func() {
+	It("returns the validations that started failing", func() {
+		Expect(NewValidationFailures(
+			`{"network":[{"id":"connected","status":"failure"}],"hardware":[{"id":"has-min-cpu-cores","status":"success"}]}`,
+			`{"network":[{"id":"connected","status":"failure"}],"hardware":[{"id":"has-min-cpu-cores","status":"failure"},`+
+				`{"id":"machine-cidr-defined","status":"pending"}]}`)).
+			To(Equal([]string{"has-min-cpu-cores"}))
+	})
+
+	It("returns the failing validations of a host or a cluster without validations", func() {
+		Expect(NewValidationFailures("",
+			`{"network":[{"id":"connected","status":"failure"},{"id":"machine-cidr-defined","status":"success"}]}`)).
+			To(Equal([]string{"connected"}))
+	})
+})
@@ -2,7 +2,6 @@ package migrations
 
 import (
 	"github.com/go-gormigrate/gormigrate/v2"
-	. "github.com/onsi/gomega"
 	"github.com/openshift/assisted-service/internal/common"
 	"github.com/openshift/assisted-service/models"
 	"gorm.io/gorm"
@@ -10,8 +9,9 @@ import (
 
 func changeOverridesToText() *gormigrate.Migration {
 	migrate := func(tx *gorm.DB) error {
-		err := tx.Migrator().DropTable(&models.Cluster{})
-		Expect(err).ShouldNot(HaveOccurred())
+		if err := tx.Migrator().DropTable(&models.Cluster{}); err != nil {
+			return err
+		}
 
 		type Cluster struct {
 			common.Cluster
@@ -21,8 +21,9 @@ func changeOverridesToText() *gormigrate.Migration {
 	}
 
 	rollback := func(tx *gorm.DB) error {
-		err := tx.Migrator().DropTable(&models.Cluster{})
-		Expect(err).ShouldNot(HaveOccurred())
+		if err := tx.Migrator().DropTable(&models.Cluster{}); err != nil {
+			return err
+		}
 
 		type Cluster struct {
 			common.Cluster
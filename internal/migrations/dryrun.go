@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// DryRun runs the given migration function in a transaction that is always rolled back,
+// and returns the statements that would have changed the database
+func DryRun(db *gorm.DB, run func(tx *gorm.DB) error) ([]string, error) {
+	recorder := &statementRecorder{}
+	tx := db.Session(&gorm.Session{Logger: recorder}).Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	defer tx.Rollback()
+
+	if err := run(tx); err != nil {
+		return recorder.statements, err
+	}
+	return recorder.statements, nil
+}
+
+// statementRecorder is a gorm logger that keeps the executed statements, skipping read-only ones
+type statementRecorder struct {
+	statements []string
+}
+
+func (r *statementRecorder) LogMode(logger.LogLevel) logger.Interface {
+	return r
+}
+
+func (r *statementRecorder) Info(context.Context, string, ...interface{}) {}
+
+func (r *statementRecorder) Warn(context.Context, string, ...interface{}) {}
+
+func (r *statementRecorder) Error(context.Context, string, ...interface{}) {}
+
+func (r *statementRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	statement := strings.ToUpper(strings.TrimSpace(sql))
+	if strings.HasPrefix(statement, "SELECT") || strings.HasPrefix(statement, "PRAGMA") {
+		return
+	}
+	r.statements = append(r.statements, sql)
+}
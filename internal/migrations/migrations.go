@@ -1,16 +1,155 @@
 package migrations
 
 import (
+	"fmt"
 	"sort"
 
 	gormigrate "github.com/go-gormigrate/gormigrate/v2"
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/internal/events"
+	"github.com/openshift/assisted-service/internal/webhooks"
+	"github.com/openshift/assisted-service/models"
+	"github.com/openshift/assisted-service/pkg/leader"
+	"github.com/openshift/assisted-service/pkg/shard"
+	"github.com/pkg/errors"
 	"gorm.io/gorm"
 )
 
+// MigrationStatus describes a known migration and whether it was already applied to the database
+type MigrationStatus struct {
+	ID      string
+	Applied bool
+}
+
+// AutoMigrate creates or updates the tables of all the models persisted by the service.
+// It runs before the versioned migrations, that take care of the changes AutoMigrate can't handle.
+func AutoMigrate(db *gorm.DB) error {
+	return db.AutoMigrate(&models.Host{}, &common.Cluster{}, &events.Event{}, &common.IdempotencyKey{},
+		&common.HostDisk{}, &common.HostInterface{}, &common.HostAddress{},
+		&webhooks.Subscription{}, &models.WebhookDelivery{}, &models.WebhookDeliveryAttempt{}, &models.StateTransition{},
+		&leader.Lease{}, &shard.Member{})
+}
+
+// AutoMigrateLeaderLeases creates the table of the leases of the database leader elector. The leader that runs
+// AutoMigrate is elected with a lease, so the table is created before the election, by all the replicas.
+func AutoMigrateLeaderLeases(db *gorm.DB) error {
+	if err := db.AutoMigrate(&leader.Lease{}); err != nil && !db.Migrator().HasTable(&leader.Lease{}) {
+		return errors.Wrap(err, "failed to create leader leases table")
+	}
+	return nil
+}
+
 func Migrate(db *gorm.DB) error {
 	return gormigrate.New(db, gormigrate.DefaultOptions, all()).Migrate()
 }
 
+// Status returns all the known migrations ordered by their ID, each marked as applied or pending
+func Status(db *gorm.DB) ([]MigrationStatus, error) {
+	applied, err := appliedIDs(db)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]MigrationStatus, 0, len(all()))
+	for _, m := range all() {
+		ret = append(ret, MigrationStatus{ID: m.ID, Applied: applied[m.ID]})
+	}
+	return ret, nil
+}
+
+// Pending returns the IDs of the migrations that were not applied yet
+func Pending(db *gorm.DB) ([]string, error) {
+	statuses, err := Status(db)
+	if err != nil {
+		return nil, err
+	}
+	var ret []string
+	for _, s := range statuses {
+		if !s.Applied {
+			ret = append(ret, s.ID)
+		}
+	}
+	return ret, nil
+}
+
+// destructiveRollbacks are the migrations whose rollback loses data, with what it loses
+var destructiveRollbacks = map[string]string{
+	"20201019194303": "drops and recreates the clusters table, deleting all the clusters",
+}
+
+// RollbackWarnings returns the data losses of the migrations that a rollback of steps migrations would undo
+func RollbackWarnings(db *gorm.DB, steps int) ([]string, error) {
+	targets, err := rollbackTargets(db, steps)
+	if err != nil {
+		return nil, err
+	}
+	var ret []string
+	for _, m := range targets {
+		if loss, destructive := destructiveRollbacks[m.ID]; destructive {
+			ret = append(ret, fmt.Sprintf("rollback of migration %s %s", m.ID, loss))
+		}
+	}
+	return ret, nil
+}
+
+// Rollback undoes the last applied migrations, up to steps of them, and returns the IDs that were rolled back.
+// It refuses to undo any of them if one of their rollbacks loses data, unless force is set.
+func Rollback(db *gorm.DB, steps int, force bool) ([]string, error) {
+	targets, err := rollbackTargets(db, steps)
+	if err != nil {
+		return nil, err
+	}
+	if !force {
+		for _, m := range targets {
+			if loss, destructive := destructiveRollbacks[m.ID]; destructive {
+				return nil, errors.Errorf("rollback of migration %s %s and must be forced", m.ID, loss)
+			}
+		}
+	}
+	gm := gormigrate.New(db, gormigrate.DefaultOptions, all())
+	var ret []string
+	for _, m := range targets {
+		if err = gm.RollbackMigration(m); err != nil {
+			return ret, errors.Wrapf(err, "failed to roll back migration %s", m.ID)
+		}
+		ret = append(ret, m.ID)
+	}
+	return ret, nil
+}
+
+// rollbackTargets returns the last applied migrations, up to steps of them, the last one first
+func rollbackTargets(db *gorm.DB, steps int) ([]*gormigrate.Migration, error) {
+	if steps < 1 {
+		return nil, errors.Errorf("number of migrations to roll back must be positive, got %d", steps)
+	}
+	applied, err := appliedIDs(db)
+	if err != nil {
+		return nil, err
+	}
+	migrations := all()
+	var ret []*gormigrate.Migration
+	for i := len(migrations) - 1; i >= 0 && len(ret) < steps; i-- {
+		if applied[migrations[i].ID] {
+			ret = append(ret, migrations[i])
+		}
+	}
+	return ret, nil
+}
+
+func appliedIDs(db *gorm.DB) (map[string]bool, error) {
+	applied := make(map[string]bool)
+	if !db.Migrator().HasTable(gormigrate.DefaultOptions.TableName) {
+		return applied, nil
+	}
+	var ids []string
+	if err := db.Table(gormigrate.DefaultOptions.TableName).Pluck(gormigrate.DefaultOptions.IDColumnName, &ids).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to read applied migrations")
+	}
+	for _, id := range ids {
+		applied[id] = true
+	}
+	return applied, nil
+}
+
 func all() []*gormigrate.Migration {
 	allMigrations := []*gormigrate.Migration{
 		changeOverridesToText(),
@@ -1,8 +1,10 @@
 package migrations
 
 import (
+	gormigrate "github.com/go-gormigrate/gormigrate/v2"
 	"github.com/openshift/assisted-service/internal/common"
 	"github.com/openshift/assisted-service/internal/events"
+	"gorm.io/gorm"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -15,3 +17,80 @@ var _ = Describe("Migrate", func() {
 		Expect(err).ToNot(HaveOccurred())
 	})
 })
+
+var _ = Describe("Migration status and rollback", func() {
+	var db *gorm.DB
+
+	BeforeEach(func() {
+		db = common.PrepareTestDB("migration_status_test", &events.Event{})
+		Expect(db.Migrator().DropTable(gormigrate.DefaultOptions.TableName)).ToNot(HaveOccurred())
+	})
+
+	It("reports all migrations as pending on a new database", func() {
+		statuses, err := Status(db)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(statuses).To(HaveLen(len(all())))
+		for _, s := range statuses {
+			Expect(s.Applied).To(BeFalse())
+		}
+		pending, err := Pending(db)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pending).To(ContainElement("20201019194303"))
+	})
+
+	It("reports applied migrations", func() {
+		Expect(Migrate(db)).ToNot(HaveOccurred())
+		statuses, err := Status(db)
+		Expect(err).ToNot(HaveOccurred())
+		for _, s := range statuses {
+			Expect(s.Applied).To(BeTrue())
+		}
+		pending, err := Pending(db)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pending).To(BeEmpty())
+	})
+
+	It("rolls back the last applied migrations", func() {
+		Expect(Migrate(db)).ToNot(HaveOccurred())
+		ids, err := Rollback(db, 5, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids).To(HaveLen(len(all())))
+		Expect(ids[len(ids)-1]).To(Equal(all()[0].ID))
+
+		pending, err := Pending(db)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pending).To(HaveLen(len(all())))
+
+		ids, err = Rollback(db, 1, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids).To(BeEmpty())
+	})
+
+	It("refuses destructive rollbacks unless forced", func() {
+		Expect(Migrate(db)).ToNot(HaveOccurred())
+		warnings, err := RollbackWarnings(db, 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(warnings).To(ConsistOf("rollback of migration 20201019194303 drops and recreates the clusters table, deleting all the clusters"))
+
+		_, err = Rollback(db, 1, false)
+		Expect(err).To(MatchError(ContainSubstring("must be forced")))
+		pending, err := Pending(db)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pending).To(BeEmpty())
+	})
+
+	It("refuses a non positive number of steps", func() {
+		_, err := Rollback(db, 0, true)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("dry run reports statements without applying them", func() {
+		statements, err := DryRun(db, Migrate)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(statements).To(ContainElement(ContainSubstring("20201019194303")))
+
+		pending, err := Pending(db)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pending).To(HaveLen(len(all())))
+	})
+})
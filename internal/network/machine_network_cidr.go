@@ -2,8 +2,10 @@ package network
 
 import (
 	"encoding/json"
+	"fmt"
 	"net"
 	"net/http"
+	"sort"
 	"strings"
 
 	"github.com/go-openapi/swag"
@@ -12,6 +14,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/internal/hostutil"
 
 	"github.com/openshift/assisted-service/models"
 	"github.com/sirupsen/logrus"
@@ -40,8 +43,7 @@ func CalculateMachineNetworkCIDR(apiVip string, ingressVip string, hosts []*mode
 		if swag.StringValue(h.Status) == models.HostStatusDisabled {
 			continue
 		}
-		var inventory models.Inventory
-		err := json.Unmarshal([]byte(h.Inventory), &inventory)
+		inventory, err := hostutil.UnmarshalInventory(h)
 		if err != nil {
 			continue
 		}
@@ -120,27 +122,83 @@ func VerifyMachineCIDR(machineCidr string, hosts []*models.Host, log logrus.Fiel
 }
 
 func GetMachineCIDRInterface(host *models.Host, cluster *common.Cluster) (string, error) {
-	var inventory models.Inventory
-	var err error
-	if err = json.Unmarshal([]byte(host.Inventory), &inventory); err != nil {
+	inventory, err := hostutil.UnmarshalInventory(host)
+	if err != nil {
 		return "", err
 	}
-	_, ipNet, err := net.ParseCIDR(cluster.MachineNetworkCidr)
+	intf, err := GetMachineNetworkInterface(inventory, cluster.MachineNetworkCidr)
 	if err != nil {
 		return "", err
 	}
+	if intf == nil {
+		return "", errors.Errorf("No matching interface found for host %s", host.ID.String())
+	}
+	return intf.Name, nil
+}
+
+// GetMachineNetworkInterface returns the first interface of the inventory with an address in the machine network CIDR,
+// nil if there is none
+func GetMachineNetworkInterface(inventory *models.Inventory, machineNetworkCidr string) (*models.Interface, error) {
+	_, ipNet, err := net.ParseCIDR(machineNetworkCidr)
+	if err != nil {
+		return nil, err
+	}
 	for _, intf := range inventory.Interfaces {
 		for _, a := range intf.IPV4Addresses {
 			ip, _, err := net.ParseCIDR(a)
 			if err != nil {
-				return "", err
+				return nil, err
 			}
 			if ipNet.Contains(ip) {
-				return intf.Name, nil
+				return intf, nil
 			}
 		}
 	}
-	return "", errors.Errorf("No matching interface found for host %s", host.ID.String())
+	return nil, nil
+}
+
+// GetMachineNetworkMtus returns the hosts of the cluster by the MTU of their machine network interface. The hosts
+// without an inventory or a machine network interface, and the interfaces that don't report an MTU, are skipped.
+func GetMachineNetworkMtus(log logrus.FieldLogger, cluster *common.Cluster) map[int64][]*models.Host {
+	mtus := make(map[int64][]*models.Host)
+	for _, h := range cluster.Hosts {
+		if h.Inventory == "" {
+			continue
+		}
+		inventory, err := hostutil.UnmarshalInventory(h)
+		if err != nil {
+			log.WithError(err).Warnf("Error unmarshalling host %s inventory %s", h.ID, h.Inventory)
+			continue
+		}
+		intf, err := GetMachineNetworkInterface(inventory, cluster.MachineNetworkCidr)
+		if err != nil {
+			log.WithError(err).Warnf("Failed to find the machine network interface of host %s", h.ID)
+			continue
+		}
+		if intf != nil && intf.Mtu > 0 {
+			mtus[intf.Mtu] = append(mtus[intf.Mtu], h)
+		}
+	}
+	return mtus
+}
+
+// FormatMtus lists the MTUs and their hosts for validation messages, e.g. "1500 (host-a), 9000 (host-b, host-c)"
+func FormatMtus(mtus map[int64][]*models.Host) string {
+	values := make([]int64, 0, len(mtus))
+	for mtu := range mtus {
+		values = append(values, mtu)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	groups := make([]string, 0, len(values))
+	for _, mtu := range values {
+		names := make([]string, 0, len(mtus[mtu]))
+		for _, h := range mtus[mtu] {
+			names = append(names, hostutil.GetHostnameForMsg(h))
+		}
+		sort.Strings(names)
+		groups = append(groups, fmt.Sprintf("%d (%s)", mtu, strings.Join(names, ", ")))
+	}
+	return strings.Join(groups, ", ")
 }
 
 func IpInCidr(ipAddr, cidr string) (bool, error) {
@@ -156,8 +214,7 @@ func IpInCidr(ipAddr, cidr string) (bool, error) {
 }
 
 func belongsToNetwork(log logrus.FieldLogger, h *models.Host, machineIpnet *net.IPNet) bool {
-	var inventory models.Inventory
-	err := json.Unmarshal([]byte(h.Inventory), &inventory)
+	inventory, err := hostutil.UnmarshalInventory(h)
 	if err != nil {
 		log.WithError(err).Warnf("Error unmarshalling host %s inventory %s", h.ID, h.Inventory)
 		return false
@@ -199,8 +256,8 @@ func GetClusterNetworks(hosts []*models.Host, log logrus.FieldLogger) []string {
 	cidrs := make(map[string]bool)
 	for _, h := range hosts {
 		if h.Inventory != "" {
-			var inventory models.Inventory
-			err = json.Unmarshal([]byte(h.Inventory), &inventory)
+			var inventory *models.Inventory
+			inventory, err = hostutil.UnmarshalInventory(h)
 			if err != nil {
 				log.WithError(err).Warnf("Unmarshal inventory %s", h.Inventory)
 				continue
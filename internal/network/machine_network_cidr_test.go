@@ -135,6 +135,48 @@ var _ = Describe("inventory", func() {
 
 		})
 	})
+	Context("GetMachineNetworkMtus", func() {
+		createMtuInventory := func(hostname string, mtu int64, ipv4Address string) string {
+			inventory := models.Inventory{
+				Hostname: hostname,
+				Interfaces: []*models.Interface{
+					{Name: "eth0", Mtu: 9000, IPV4Addresses: []string{"10.0.0.10/24"}},
+					{Name: "eth1", Mtu: mtu, IPV4Addresses: []string{ipv4Address}},
+				},
+			}
+			ret, _ := json.Marshal(&inventory)
+			return string(ret)
+		}
+
+		It("groups the hosts by the MTU of their machine network interface", func() {
+			cluster := createCluster("1.2.5.6", "1.2.4.0/23",
+				createMtuInventory("host-c", 9000, "1.2.5.9/23"),
+				createMtuInventory("host-a", 1500, "1.2.5.7/23"),
+				createMtuInventory("host-b", 9000, "1.2.5.8/23"),
+				createMtuInventory("host-d", 0, "1.2.5.10/23"),
+				createMtuInventory("host-e", 1500, "3.3.3.3/16"),
+				"")
+			mtus := GetMachineNetworkMtus(logrus.New(), cluster)
+			Expect(mtus).To(Equal(map[int64][]*models.Host{
+				1500: {cluster.Hosts[1]},
+				9000: {cluster.Hosts[0], cluster.Hosts[2]},
+			}))
+			Expect(FormatMtus(mtus)).To(Equal("1500 (host-a), 9000 (host-b, host-c)"))
+		})
+
+		It("finds the machine network interface", func() {
+			inventory := models.Inventory{Interfaces: []*models.Interface{
+				createInterface("3.3.3.3/16"), {Name: "eth1", IPV4Addresses: []string{"1.2.5.7/23"}}}}
+			intf, err := GetMachineNetworkInterface(&inventory, "1.2.4.0/23")
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(intf.Name).To(Equal("eth1"))
+			intf, err = GetMachineNetworkInterface(&inventory, "1.1.0.0/16")
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(intf).To(BeNil())
+			_, err = GetMachineNetworkInterface(&inventory, "")
+			Expect(err).To(HaveOccurred())
+		})
+	})
 	Context("VerifyVips", func() {
 		var log logrus.FieldLogger
 
@@ -0,0 +1,190 @@
+package refresh
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/openshift/assisted-service/internal/cluster"
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/internal/host"
+	"github.com/openshift/assisted-service/models"
+	dbPkg "github.com/openshift/assisted-service/pkg/db"
+	"github.com/openshift/assisted-service/pkg/requestid"
+	"github.com/openshift/assisted-service/pkg/transaction"
+	"github.com/sirupsen/logrus"
+	"github.com/thoas/go-funk"
+	"gorm.io/gorm"
+)
+
+//go:generate mockgen -source=refresh.go -package=refresh -destination=mock_refresh.go
+
+// Notifier is told about changes that might affect the status of hosts and clusters, so they are refreshed
+// right away instead of on the next tick of the monitors.
+type Notifier interface {
+	// HostChanged requests a refresh of the host and of its cluster
+	HostChanged(clusterID, hostID strfmt.UUID)
+	// ClusterChanged requests a refresh of all the hosts of the cluster and of the cluster
+	ClusterChanged(clusterID strfmt.UUID)
+}
+
+type Config struct {
+	Workers int `envconfig:"REFRESH_WORKERS" default:"4"`
+	// Time to wait before refreshing a cluster, so changes that come close together are refreshed at once
+	Delay time.Duration `envconfig:"REFRESH_DELAY" default:"500ms"`
+}
+
+// the same states that the cluster monitor skips
+var noNeedToRefreshInStates = []string{
+	models.ClusterStatusInstalled,
+	models.ClusterStatusError,
+}
+
+// request is the pending refresh of a cluster, merging all the notifications received for it
+type request struct {
+	allHosts bool
+	hosts    map[strfmt.UUID]bool
+}
+
+var _ Notifier = &Bus{}
+
+// Bus refreshes the hosts and clusters it is notified about in the background. Notifications of a cluster
+// that is already waiting to be refreshed are merged into the pending refresh, and every cluster is
+// refreshed while holding its lock, so the refreshes don't race with the monitors or with the API.
+type Bus struct {
+	Config
+	log        logrus.FieldLogger
+	db         *gorm.DB
+	hostAPI    host.API
+	clusterAPI cluster.API
+	queue      chan strfmt.UUID
+
+	mu      sync.Mutex
+	pending map[strfmt.UUID]*request
+	// done is closed once the workers stopped, the delayed refreshes don't wait for them then
+	done <-chan struct{}
+}
+
+func NewBus(cfg Config, log logrus.FieldLogger, db *gorm.DB, hostAPI host.API, clusterAPI cluster.API) *Bus {
+	return &Bus{
+		Config:     cfg,
+		log:        log,
+		db:         db,
+		hostAPI:    hostAPI,
+		clusterAPI: clusterAPI,
+		queue:      make(chan strfmt.UUID, cfg.Workers),
+		pending:    make(map[strfmt.UUID]*request),
+	}
+}
+
+func (b *Bus) HostChanged(clusterID, hostID strfmt.UUID) {
+	b.notify(clusterID, func(r *request) {
+		r.hosts[hostID] = true
+	})
+}
+
+func (b *Bus) ClusterChanged(clusterID strfmt.UUID) {
+	b.notify(clusterID, func(r *request) {
+		r.allHosts = true
+	})
+}
+
+func (b *Bus) notify(clusterID strfmt.UUID, merge func(r *request)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	r, ok := b.pending[clusterID]
+	if !ok {
+		r = &request{hosts: make(map[strfmt.UUID]bool)}
+		b.pending[clusterID] = r
+		done := b.done
+		time.AfterFunc(b.Delay, func() {
+			select {
+			case b.queue <- clusterID:
+			case <-done:
+			}
+		})
+	}
+	merge(r)
+}
+
+// Start runs the workers that refresh the clusters until the context is cancelled
+func (b *Bus) Start(ctx context.Context) {
+	b.mu.Lock()
+	b.done = ctx.Done()
+	b.mu.Unlock()
+	for i := 0; i < b.Workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case clusterID := <-b.queue:
+					b.refresh(clusterID)
+				}
+			}
+		}()
+	}
+}
+
+func (b *Bus) take(clusterID strfmt.UUID) *request {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	r := b.pending[clusterID]
+	delete(b.pending, clusterID)
+	return r
+}
+
+func (b *Bus) refresh(clusterID strfmt.UUID) {
+	var (
+		requestID = requestid.NewID()
+		ctx       = requestid.ToContext(context.Background(), requestID)
+		log       = requestid.RequestIDLogger(b.log, requestID)
+	)
+	r := b.take(clusterID)
+	if r == nil {
+		return
+	}
+	// the refresh stands for the monitors, its writes don't change the resource versions the users edit by
+	db := dbPkg.SkipResourceVersion(b.db)
+	if r.allHosts {
+		if err := b.clusterAPI.SetConnectivityMajorityGroupsForCluster(clusterID, db); err != nil {
+			log.WithError(err).Errorf("failed to set majority group for cluster %s", clusterID)
+		}
+	}
+	err := transaction.InClusterTransaction(ctx, db, clusterID, func(ctx context.Context, tx *gorm.DB) error {
+		var hosts []*models.Host
+		if err := tx.Find(&hosts, "cluster_id = ?", clusterID.String()).Error; err != nil {
+			return err
+		}
+		for _, h := range hosts {
+			if !r.allHosts && !r.hosts[*h.ID] {
+				continue
+			}
+			if err := b.hostAPI.RefreshStatus(ctx, h, tx); err != nil {
+				log.WithError(err).Errorf("failed to refresh host %s state", *h.ID)
+			}
+		}
+		// load the cluster after the refresh of its hosts, its status depends on them
+		var c common.Cluster
+		if err := tx.Preload("Hosts").Take(&c, "id = ?", clusterID.String()).Error; err != nil {
+			return err
+		}
+		if funk.ContainsString(noNeedToRefreshInStates, swag.StringValue(c.Status)) {
+			return nil
+		}
+		_, err := b.clusterAPI.RefreshStatus(ctx, &c, tx)
+		return err
+	})
+	if err != nil {
+		log.WithError(err).Errorf("failed to refresh cluster %s state", clusterID)
+	}
+}
+
+// DummyNotifier ignores the notifications, leaving the refresh to the monitors
+type DummyNotifier struct{}
+
+func (d *DummyNotifier) HostChanged(clusterID, hostID strfmt.UUID) {}
+
+func (d *DummyNotifier) ClusterChanged(clusterID strfmt.UUID) {}
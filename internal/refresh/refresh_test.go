@@ -0,0 +1,156 @@
+package refresh
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/assisted-service/internal/cluster"
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/internal/host"
+	"github.com/openshift/assisted-service/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func TestRefresh(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Refresh Suite")
+}
+
+var _ = Describe("Bus", func() {
+	var (
+		db             *gorm.DB
+		ctrl           *gomock.Controller
+		mockHostAPI    *host.MockAPI
+		mockClusterAPI *cluster.MockAPI
+		bus            *Bus
+		ctx            context.Context
+		cancel         context.CancelFunc
+		clusterID      strfmt.UUID
+		hostIDs        []strfmt.UUID
+		dbName         = "refresh_bus"
+
+		mu               sync.Mutex
+		refreshedHosts   []strfmt.UUID
+		clusterRefreshes int
+	)
+
+	refreshed := func() []strfmt.UUID {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]strfmt.UUID{}, refreshedHosts...)
+	}
+
+	clusterRefreshed := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return clusterRefreshes
+	}
+
+	createCluster := func(status string) {
+		clusterID = strfmt.UUID(uuid.New().String())
+		Expect(db.Create(&common.Cluster{Cluster: models.Cluster{ID: &clusterID, Status: swag.String(status)}}).Error).
+			ShouldNot(HaveOccurred())
+		hostIDs = nil
+		for i := 0; i < 3; i++ {
+			hostID := strfmt.UUID(uuid.New().String())
+			hostIDs = append(hostIDs, hostID)
+			Expect(db.Create(&models.Host{ID: &hostID, ClusterID: clusterID, Status: swag.String(models.HostStatusKnown)}).Error).
+				ShouldNot(HaveOccurred())
+		}
+	}
+
+	BeforeEach(func() {
+		db = common.PrepareTestDB(dbName)
+		ctrl = gomock.NewController(GinkgoT())
+		mockHostAPI = host.NewMockAPI(ctrl)
+		mockClusterAPI = cluster.NewMockAPI(ctrl)
+		refreshedHosts = nil
+		clusterRefreshes = 0
+		mockHostAPI.EXPECT().RefreshStatus(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, h *models.Host, _ *gorm.DB) error {
+				mu.Lock()
+				defer mu.Unlock()
+				refreshedHosts = append(refreshedHosts, *h.ID)
+				return nil
+			}).AnyTimes()
+		mockClusterAPI.EXPECT().RefreshStatus(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, c *common.Cluster, _ *gorm.DB) (*common.Cluster, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				Expect(c.Hosts).To(HaveLen(len(hostIDs)))
+				clusterRefreshes++
+				return c, nil
+			}).AnyTimes()
+
+		bus = NewBus(Config{Workers: 2, Delay: 50 * time.Millisecond}, logrus.New(), db, mockHostAPI, mockClusterAPI)
+		ctx, cancel = context.WithCancel(context.Background())
+		bus.Start(ctx)
+	})
+
+	AfterEach(func() {
+		cancel()
+		ctrl.Finish()
+		common.DeleteTestDB(db, dbName)
+	})
+
+	It("refreshes the changed hosts and their cluster once", func() {
+		createCluster(models.ClusterStatusInsufficient)
+		bus.HostChanged(clusterID, hostIDs[0])
+		bus.HostChanged(clusterID, hostIDs[1])
+		bus.HostChanged(clusterID, hostIDs[0])
+
+		Eventually(clusterRefreshed).Should(Equal(1))
+		Expect(refreshed()).To(ConsistOf(hostIDs[0], hostIDs[1]))
+		Consistently(clusterRefreshed, 200*time.Millisecond).Should(Equal(1))
+	})
+
+	It("refreshes all the hosts of a changed cluster", func() {
+		createCluster(models.ClusterStatusReady)
+		mockClusterAPI.EXPECT().SetConnectivityMajorityGroupsForCluster(clusterID, gomock.Any()).Return(nil).Times(1)
+		bus.HostChanged(clusterID, hostIDs[0])
+		bus.ClusterChanged(clusterID)
+
+		Eventually(clusterRefreshed).Should(Equal(1))
+		Expect(refreshed()).To(ConsistOf(hostIDs[0], hostIDs[1], hostIDs[2]))
+	})
+
+	It("refreshes again a cluster changed after it was refreshed", func() {
+		createCluster(models.ClusterStatusInsufficient)
+		bus.HostChanged(clusterID, hostIDs[0])
+		Eventually(clusterRefreshed).Should(Equal(1))
+		bus.HostChanged(clusterID, hostIDs[2])
+		Eventually(clusterRefreshed).Should(Equal(2))
+		Expect(refreshed()).To(Equal([]strfmt.UUID{hostIDs[0], hostIDs[2]}))
+	})
+
+	It("drops the delayed refreshes once stopped", func() {
+		cancel()
+		// let the workers exit
+		time.Sleep(100 * time.Millisecond)
+		goroutines := runtime.NumGoroutine()
+		for i := 0; i < 10; i++ {
+			bus.ClusterChanged(strfmt.UUID(uuid.New().String()))
+		}
+		// the delayed refreshes ran by now
+		time.Sleep(200 * time.Millisecond)
+		Expect(runtime.NumGoroutine()).To(BeNumerically("<=", goroutines))
+	})
+
+	It("doesn't refresh an installed cluster", func() {
+		createCluster(models.ClusterStatusInstalled)
+		bus.HostChanged(clusterID, hostIDs[1])
+
+		Eventually(refreshed).Should(Equal([]strfmt.UUID{hostIDs[1]}))
+		Consistently(clusterRefreshed, 200*time.Millisecond).Should(BeZero())
+	})
+})
@@ -0,0 +1,418 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/google/uuid"
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/internal/hostutil"
+	"github.com/openshift/assisted-service/models"
+	"github.com/openshift/assisted-service/pkg/leader"
+	logutil "github.com/openshift/assisted-service/pkg/log"
+	"github.com/openshift/assisted-service/pkg/requestid"
+	"github.com/openshift/assisted-service/pkg/workerpool"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+//go:generate mockgen -source=webhooks.go -package=webhooks -destination=mock_webhooks.go
+
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+// Headers of the deliveries, the signature is the hex encoded HMAC-SHA256 of the body keyed by the secret of
+// the subscription, prefixed by "sha256="
+const (
+	HeaderEvent     = "X-Assisted-Event"
+	HeaderDelivery  = "X-Assisted-Delivery"
+	HeaderSignature = "X-Assisted-Signature"
+)
+
+type Config struct {
+	DeliveryInterval time.Duration `envconfig:"WEBHOOK_DELIVERY_INTERVAL" default:"5s"`
+	DeliveryWorkers  int           `envconfig:"WEBHOOK_DELIVERY_WORKERS" default:"4"`
+	DeliveryTimeout  time.Duration `envconfig:"WEBHOOK_DELIVERY_TIMEOUT" default:"10s"`
+	// A delivery fails after this many attempts, waiting twice as long as before between the attempts
+	MaxAttempts     int           `envconfig:"WEBHOOK_MAX_ATTEMPTS" default:"10"`
+	RetryBackoff    time.Duration `envconfig:"WEBHOOK_RETRY_BACKOFF" default:"10s"`
+	MaxRetryBackoff time.Duration `envconfig:"WEBHOOK_MAX_RETRY_BACKOFF" default:"1h"`
+	// Allows the webhooks to private, loopback and link-local addresses, for deployments where all the users are
+	// trusted with the network of the service
+	AllowPrivateTargets bool `envconfig:"WEBHOOK_ALLOW_PRIVATE_TARGETS" default:"false"`
+}
+
+// Number of deliveries that are attempted on each run of the delivery task
+const deliveryBatchSize = 100
+
+// Networks that webhooks are not delivered to unless AllowPrivateTargets is set, on top of the loopback,
+// link-local, multicast and unspecified addresses
+var privateNetworks = parseCIDRs("0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7")
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	ret := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, ret[i], _ = net.ParseCIDR(cidr)
+	}
+	return ret
+}
+
+// checkTargetIP returns an error if webhooks can't be delivered to the address
+func checkTargetIP(ip net.IP) error {
+	if ip == nil {
+		return errors.New("webhook target is not an IP address")
+	}
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || ip.IsUnspecified() {
+		return errors.Errorf("webhook target %s is not a public address", ip)
+	}
+	for _, n := range privateNetworks {
+		if n.Contains(ip) {
+			return errors.Errorf("webhook target %s is not a public address", ip)
+		}
+	}
+	return nil
+}
+
+// checkTargetHost returns an error if the host of a webhook URL resolves to an address that webhooks can't be
+// delivered to
+func checkTargetHost(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		return checkTargetIP(ip)
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return errors.Wrapf(err, "failed to resolve webhook target %s", host)
+	}
+	for _, addr := range addrs {
+		if err = checkTargetIP(addr.IP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type Notifier interface {
+	// ClusterStatusChanged queues the deliveries of the status change of the cluster to the subscriptions it
+	// matches. db is the transaction of the change, so the deliveries are queued only if the change commits.
+	ClusterStatusChanged(ctx context.Context, db *gorm.DB, cluster *common.Cluster, from string) error
+	// HostStatusChanged queues the deliveries of the status change of the host like ClusterStatusChanged
+	HostStatusChanged(ctx context.Context, db *gorm.DB, host *models.Host, from string) error
+}
+
+var _ Notifier = &Manager{}
+
+// Subscription is the database row of a webhook, with the columns that are not part of the API
+type Subscription struct {
+	models.Webhook
+	// Key of the payload signatures
+	Secret string
+	// EventFilter joined by commas, since the database has no list columns
+	Filter string
+}
+
+func (Subscription) TableName() string {
+	return "webhooks"
+}
+
+func (s *Subscription) matches(event string) bool {
+	if s.Filter == "" {
+		return true
+	}
+	for _, f := range strings.Split(s.Filter, ",") {
+		if f == event || (strings.HasSuffix(f, ".*") && strings.HasPrefix(event, strings.TrimSuffix(f, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Subscription) toAPI() *models.Webhook {
+	ret := s.Webhook
+	ret.EventFilter = []string{}
+	if s.Filter != "" {
+		ret.EventFilter = strings.Split(s.Filter, ",")
+	}
+	return &ret
+}
+
+// Payload is the body of the deliveries
+type Payload struct {
+	DeliveryID  strfmt.UUID     `json:"delivery_id"`
+	Event       string          `json:"event"`
+	ClusterID   strfmt.UUID     `json:"cluster_id"`
+	ClusterName string          `json:"cluster_name,omitempty"`
+	HostID      strfmt.UUID     `json:"host_id,omitempty"`
+	HostName    string          `json:"host_name,omitempty"`
+	From        string          `json:"from"`
+	To          string          `json:"to"`
+	StatusInfo  string          `json:"status_info,omitempty"`
+	Time        strfmt.DateTime `json:"time"`
+}
+
+// Sign returns the signature of the payload with the secret, as sent in the X-Assisted-Signature header
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type Manager struct {
+	cfg           Config
+	log           logrus.FieldLogger
+	db            *gorm.DB
+	client        *http.Client
+	leaderElector leader.Leader
+}
+
+func NewManager(cfg Config, log logrus.FieldLogger, db *gorm.DB, leaderElector leader.Leader) *Manager {
+	dialer := &net.Dialer{Timeout: cfg.DeliveryTimeout}
+	if !cfg.AllowPrivateTargets {
+		// the addresses are checked once resolved, as the names may resolve to other addresses than when the
+		// webhooks were registered
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			return checkTargetIP(net.ParseIP(host))
+		}
+	}
+	return &Manager{
+		cfg: cfg,
+		log: log,
+		db:  db,
+		client: &http.Client{
+			Timeout:   cfg.DeliveryTimeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: cfg.DeliveryTimeout},
+		},
+		leaderElector: leaderElector,
+	}
+}
+
+func (m *Manager) ClusterStatusChanged(ctx context.Context, db *gorm.DB, cluster *common.Cluster, from string) error {
+	return m.enqueue(ctx, db, cluster.OrgID, &Payload{
+		Event:       "cluster." + swag.StringValue(cluster.Status),
+		ClusterID:   *cluster.ID,
+		ClusterName: cluster.Name,
+		From:        from,
+		To:          swag.StringValue(cluster.Status),
+		StatusInfo:  swag.StringValue(cluster.StatusInfo),
+	})
+}
+
+func (m *Manager) HostStatusChanged(ctx context.Context, db *gorm.DB, host *models.Host, from string) error {
+	var cluster common.Cluster
+	if err := db.Select("id", "name", "org_id").Take(&cluster, "id = ?", host.ClusterID.String()).Error; err != nil {
+		return errors.Wrapf(err, "failed to get cluster %s of host %s", host.ClusterID, host.ID)
+	}
+	return m.enqueue(ctx, db, cluster.OrgID, &Payload{
+		Event:       "host." + swag.StringValue(host.Status),
+		ClusterID:   host.ClusterID,
+		ClusterName: cluster.Name,
+		HostID:      *host.ID,
+		HostName:    hostutil.GetHostnameForMsg(host),
+		From:        from,
+		To:          swag.StringValue(host.Status),
+		StatusInfo:  swag.StringValue(host.StatusInfo),
+	})
+}
+
+// enqueue adds a delivery of the payload for each of the subscriptions to the cluster or its organization that
+// match the event
+func (m *Manager) enqueue(ctx context.Context, db *gorm.DB, orgID string, payload *Payload) error {
+	log := logutil.FromContext(ctx, m.log)
+	var subscriptions []*Subscription
+	if err := db.Where("cluster_id = ? or (cluster_id = '' and org_id = ?)", payload.ClusterID.String(), orgID).
+		Find(&subscriptions).Error; err != nil {
+		return errors.Wrapf(err, "failed to get the webhooks of cluster %s", payload.ClusterID)
+	}
+	now := strfmt.DateTime(time.Now().UTC())
+	payload.Time = now
+	for _, s := range subscriptions {
+		if !s.matches(payload.Event) {
+			continue
+		}
+		id := strfmt.UUID(uuid.New().String())
+		payload.DeliveryID = id
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		delivery := &models.WebhookDelivery{
+			ID:            &id,
+			WebhookID:     s.ID,
+			ClusterID:     payload.ClusterID,
+			HostID:        payload.HostID,
+			Event:         swag.String(payload.Event),
+			Payload:       string(b),
+			Status:        swag.String(DeliveryStatusPending),
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		}
+		if err = db.Create(delivery).Error; err != nil {
+			return errors.Wrapf(err, "failed to queue the delivery of %s to webhook %s", payload.Event, s.ID)
+		}
+		log.Debugf("Queued delivery %s of %s to webhook %s", id, payload.Event, s.ID)
+	}
+	return nil
+}
+
+// DeliveryTask attempts the pending deliveries that are due. The deliveries of each subscription are attempted
+// one after the other, in the order they were queued, up to the first one that fails. The deliveries queued after
+// a delivery that waits for its next attempt wait for it too.
+func (m *Manager) DeliveryTask() {
+	if !m.leaderElector.IsLeader() {
+		return
+	}
+	ctx := requestid.ToContext(context.Background(), requestid.NewID())
+	log := requestid.RequestIDLogger(m.log, requestid.FromContext(ctx))
+
+	now := strfmt.DateTime(time.Now().UTC())
+	var deliveries []*models.WebhookDelivery
+	if err := m.db.Where("status = ? and next_attempt_at <= ?", DeliveryStatusPending, now).
+		Where("not exists (select 1 from webhook_deliveries w where w.webhook_id = webhook_deliveries.webhook_id "+
+			"and w.status = ? and w.created_at < webhook_deliveries.created_at and w.next_attempt_at > ?)", DeliveryStatusPending, now).
+		Order("created_at").Limit(deliveryBatchSize).Find(&deliveries).Error; err != nil {
+		log.WithError(err).Error("failed to get the pending webhook deliveries")
+		return
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+	var ids []string
+	queued := make(map[strfmt.UUID][]*models.WebhookDelivery)
+	for _, d := range deliveries {
+		if _, ok := queued[*d.WebhookID]; !ok {
+			ids = append(ids, d.WebhookID.String())
+		}
+		queued[*d.WebhookID] = append(queued[*d.WebhookID], d)
+	}
+	var subscriptions []*Subscription
+	if err := m.db.Where("id in (?)", ids).Find(&subscriptions).Error; err != nil {
+		log.WithError(err).Error("failed to get the webhooks of the pending deliveries")
+		return
+	}
+
+	// the deliveries of subscriptions that were deleted after they were read are dropped with them
+	pool := workerpool.New(m.cfg.DeliveryWorkers)
+	for _, s := range subscriptions {
+		s := s
+		pool.Submit(s.ID.String(), func() {
+			for _, d := range queued[*s.ID] {
+				if !m.deliver(ctx, log, s, d) {
+					return
+				}
+			}
+		})
+	}
+	pool.Wait()
+}
+
+// deliver attempts the delivery and records the attempt, it returns whether the delivery succeeded
+func (m *Manager) deliver(ctx context.Context, log logrus.FieldLogger, s *Subscription, d *models.WebhookDelivery) bool {
+	start := time.Now()
+	statusCode, err := m.post(ctx, s, d)
+	succeeded := err == nil
+	attempt := &models.WebhookDeliveryAttempt{
+		DeliveryID:  *d.ID,
+		AttemptedAt: strfmt.DateTime(start.UTC()),
+		DurationMs:  time.Since(start).Milliseconds(),
+		StatusCode:  int64(statusCode),
+	}
+	attempts := d.Attempts + 1
+	updates := map[string]interface{}{"attempts": attempts}
+	switch {
+	case succeeded:
+		updates["status"] = DeliveryStatusSucceeded
+	case attempts >= int64(m.cfg.MaxAttempts):
+		attempt.Error = truncate(err.Error(), 1024)
+		updates["status"] = DeliveryStatusFailed
+		log.WithError(err).Warnf("Giving up webhook delivery %s to %s after %d attempts", d.ID, s.URL, attempts)
+	default:
+		attempt.Error = truncate(err.Error(), 1024)
+		updates["next_attempt_at"] = strfmt.DateTime(time.Now().Add(m.backoff(attempts)).UTC())
+		log.WithError(err).Infof("Failed webhook delivery %s to %s, attempt %d", d.ID, s.URL, attempts)
+	}
+
+	// a former leader that didn't notice it lost the lease doesn't record its attempts
+	if err = leader.InTransaction(m.db, m.leaderElector, func(tx *gorm.DB) error {
+		if err = tx.Create(attempt).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id = ?", d.ID.String()).Updates(updates).Error
+	}); err != nil {
+		log.WithError(err).Errorf("failed to record the attempt of webhook delivery %s", d.ID)
+		return false
+	}
+	return succeeded
+}
+
+// post returns the status code of the response, with an error unless the response was successful
+func (m *Manager) post(ctx context.Context, s *Subscription, d *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL.String(), bytes.NewReader([]byte(d.Payload)))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, swag.StringValue(d.Event))
+	req.Header.Set(HeaderDelivery, d.ID.String())
+	req.Header.Set(HeaderSignature, Sign(s.Secret, []byte(d.Payload)))
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, errors.Errorf("unexpected response status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the time to wait after the given number of failed attempts
+func (m *Manager) backoff(attempts int64) time.Duration {
+	wait := m.cfg.RetryBackoff
+	for i := int64(1); i < attempts && wait < m.cfg.MaxRetryBackoff; i++ {
+		wait *= 2
+	}
+	if wait > m.cfg.MaxRetryBackoff {
+		wait = m.cfg.MaxRetryBackoff
+	}
+	return wait
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// DummyNotifier ignores the status changes, for when no webhooks are delivered
+type DummyNotifier struct{}
+
+func (d *DummyNotifier) ClusterStatusChanged(ctx context.Context, db *gorm.DB, cluster *common.Cluster, from string) error {
+	return nil
+}
+
+func (d *DummyNotifier) HostStatusChanged(ctx context.Context, db *gorm.DB, host *models.Host, from string) error {
+	return nil
+}
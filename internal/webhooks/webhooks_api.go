@@ -0,0 +1,172 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/google/uuid"
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/internal/identity"
+	"github.com/openshift/assisted-service/models"
+	"github.com/openshift/assisted-service/pkg/auth"
+	logutil "github.com/openshift/assisted-service/pkg/log"
+	"github.com/openshift/assisted-service/restapi"
+	operations "github.com/openshift/assisted-service/restapi/operations/webhooks"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var _ restapi.WebhooksAPI = &Api{}
+
+type Api struct {
+	cfg Config
+	db  *gorm.DB
+	log logrus.FieldLogger
+}
+
+func NewApi(cfg Config, db *gorm.DB, log logrus.FieldLogger) *Api {
+	return &Api{
+		cfg: cfg,
+		db:  db,
+		log: log,
+	}
+}
+
+func (a *Api) RegisterWebhook(ctx context.Context, params operations.RegisterWebhookParams) middleware.Responder {
+	log := logutil.FromContext(ctx, a.log)
+	p := params.NewWebhookParams
+
+	u, err := url.Parse(p.URL.String())
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return common.NewApiError(http.StatusBadRequest, errors.Errorf("webhook URL %s is not an HTTP URL", p.URL))
+	}
+	if !a.cfg.AllowPrivateTargets {
+		if err = checkTargetHost(ctx, u.Hostname()); err != nil {
+			return common.NewApiError(http.StatusBadRequest, err)
+		}
+	}
+	orgID := auth.OrgIDFromContext(ctx)
+	if p.ClusterID != "" {
+		var cluster common.Cluster
+		if err = a.db.Select("id").Take(&cluster, identity.AddUserFilter(ctx, "id = ?"), p.ClusterID.String()).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return common.NewApiError(http.StatusNotFound, errors.Errorf("cluster %s not found", p.ClusterID))
+			}
+			log.WithError(err).Errorf("failed to get cluster %s", p.ClusterID)
+			return common.NewApiError(http.StatusInternalServerError, err)
+		}
+	} else if orgID == "" && !identity.IsAdmin(ctx) {
+		return common.NewApiError(http.StatusBadRequest,
+			errors.New("webhooks of all the clusters of the organization require a user of an organization"))
+	}
+
+	id := strfmt.UUID(uuid.New().String())
+	now := strfmt.DateTime(time.Now().UTC())
+	s := &Subscription{
+		Webhook: models.Webhook{
+			ID:        &id,
+			ClusterID: p.ClusterID,
+			OrgID:     orgID,
+			UserName:  auth.UserNameFromContext(ctx),
+			URL:       p.URL,
+			CreatedAt: &now,
+		},
+		Secret: swag.StringValue(p.Secret),
+		Filter: strings.Join(p.EventFilter, ","),
+	}
+	if err = a.db.Create(s).Error; err != nil {
+		log.WithError(err).Error("failed to create webhook")
+		return common.NewApiError(http.StatusInternalServerError, err)
+	}
+	log.Infof("Registered webhook %s to %s", id, u.Host)
+	return operations.NewRegisterWebhookCreated().WithPayload(s.toAPI())
+}
+
+func (a *Api) ListWebhooks(ctx context.Context, params operations.ListWebhooksParams) middleware.Responder {
+	log := logutil.FromContext(ctx, a.log)
+	db := a.db
+	if query := identity.AddUserFilter(ctx, ""); query != "" {
+		db = db.Where(query)
+	}
+	if params.ClusterID != nil {
+		db = db.Where("cluster_id = ?", params.ClusterID.String())
+	}
+	var subscriptions []*Subscription
+	if err := db.Order("created_at").Find(&subscriptions).Error; err != nil {
+		log.WithError(err).Error("failed to list webhooks")
+		return common.NewApiError(http.StatusInternalServerError, err)
+	}
+	ret := make(models.WebhookList, len(subscriptions))
+	for i, s := range subscriptions {
+		ret[i] = s.toAPI()
+	}
+	return operations.NewListWebhooksOK().WithPayload(ret)
+}
+
+func (a *Api) getSubscription(ctx context.Context, id strfmt.UUID) (*Subscription, error) {
+	var s Subscription
+	if err := a.db.Take(&s, identity.AddUserFilter(ctx, "id = ?"), id.String()).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.NewApiError(http.StatusNotFound, errors.Errorf("webhook %s not found", id))
+		}
+		logutil.FromContext(ctx, a.log).WithError(err).Errorf("failed to get webhook %s", id)
+		return nil, common.NewApiError(http.StatusInternalServerError, err)
+	}
+	return &s, nil
+}
+
+func (a *Api) GetWebhook(ctx context.Context, params operations.GetWebhookParams) middleware.Responder {
+	s, err := a.getSubscription(ctx, params.WebhookID)
+	if err != nil {
+		return common.GenerateErrorResponder(err)
+	}
+	return operations.NewGetWebhookOK().WithPayload(s.toAPI())
+}
+
+func (a *Api) DeregisterWebhook(ctx context.Context, params operations.DeregisterWebhookParams) middleware.Responder {
+	log := logutil.FromContext(ctx, a.log)
+	if _, err := a.getSubscription(ctx, params.WebhookID); err != nil {
+		return common.GenerateErrorResponder(err)
+	}
+	id := params.WebhookID.String()
+	if err := a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("delivery_id in (?)", tx.Model(&models.WebhookDelivery{}).Select("id").Where("webhook_id = ?", id)).
+			Delete(&models.WebhookDeliveryAttempt{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&Subscription{}).Error
+	}); err != nil {
+		log.WithError(err).Errorf("failed to delete webhook %s", id)
+		return common.NewApiError(http.StatusInternalServerError, err)
+	}
+	log.Infof("Deregistered webhook %s", id)
+	return operations.NewDeregisterWebhookNoContent()
+}
+
+func (a *Api) ListWebhookDeliveries(ctx context.Context, params operations.ListWebhookDeliveriesParams) middleware.Responder {
+	log := logutil.FromContext(ctx, a.log)
+	if _, err := a.getSubscription(ctx, params.WebhookID); err != nil {
+		return common.GenerateErrorResponder(err)
+	}
+	db := a.db.Where("webhook_id = ?", params.WebhookID.String())
+	if params.Status != nil {
+		db = db.Where("status = ?", *params.Status)
+	}
+	var deliveries models.WebhookDeliveryList
+	if err := db.Preload("AttemptLog", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Order("created_at desc").Limit(int(swag.Int64Value(params.Limit))).Find(&deliveries).Error; err != nil {
+		log.WithError(err).Errorf("failed to list the deliveries of webhook %s", params.WebhookID)
+		return common.NewApiError(http.StatusInternalServerError, err)
+	}
+	return operations.NewListWebhookDeliveriesOK().WithPayload(deliveries)
+}
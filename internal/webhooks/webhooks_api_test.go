@@ -0,0 +1,98 @@
+package webhooks_test
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/internal/webhooks"
+	"github.com/openshift/assisted-service/models"
+	operations "github.com/openshift/assisted-service/restapi/operations/webhooks"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("Webhooks API", func() {
+	var (
+		db        *gorm.DB
+		api       *webhooks.Api
+		ctx       = context.Background()
+		dbName    = "webhooks_api_test"
+		clusterID strfmt.UUID
+	)
+
+	BeforeEach(func() {
+		db = common.PrepareTestDB(dbName, &webhooks.Subscription{}, &models.WebhookDelivery{}, &models.WebhookDeliveryAttempt{})
+		api = webhooks.NewApi(webhooks.Config{}, db, logrus.WithField("pkg", "webhooks"))
+		clusterID = strfmt.UUID(uuid.New().String())
+		Expect(db.Create(&common.Cluster{Cluster: models.Cluster{ID: &clusterID}}).Error).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		common.DeleteTestDB(db, dbName)
+	})
+
+	register := func(clusterID strfmt.UUID, url string) middleware.Responder {
+		u := strfmt.URI(url)
+		return api.RegisterWebhook(ctx, operations.RegisterWebhookParams{NewWebhookParams: &models.WebhookCreateParams{
+			ClusterID:   clusterID,
+			URL:         &u,
+			Secret:      swag.String("0123456789abcdef"),
+			EventFilter: []string{"cluster.installed", "host.*"},
+		}})
+	}
+
+	It("registers, lists and deregisters webhooks", func() {
+		reply := register(clusterID, "https://203.0.113.10/hook")
+		Expect(reply).To(BeAssignableToTypeOf(&operations.RegisterWebhookCreated{}))
+		webhook := reply.(*operations.RegisterWebhookCreated).Payload
+		Expect(webhook.ClusterID).To(Equal(clusterID))
+		Expect(webhook.EventFilter).To(Equal([]string{"cluster.installed", "host.*"}))
+
+		list := api.ListWebhooks(ctx, operations.ListWebhooksParams{ClusterID: &clusterID})
+		Expect(list.(*operations.ListWebhooksOK).Payload).To(HaveLen(1))
+
+		get := api.GetWebhook(ctx, operations.GetWebhookParams{WebhookID: *webhook.ID})
+		Expect(get.(*operations.GetWebhookOK).Payload.URL.String()).To(Equal("https://203.0.113.10/hook"))
+
+		deliveries := api.ListWebhookDeliveries(ctx, operations.ListWebhookDeliveriesParams{WebhookID: *webhook.ID})
+		Expect(deliveries.(*operations.ListWebhookDeliveriesOK).Payload).To(BeEmpty())
+
+		Expect(api.DeregisterWebhook(ctx, operations.DeregisterWebhookParams{WebhookID: *webhook.ID})).
+			To(BeAssignableToTypeOf(&operations.DeregisterWebhookNoContent{}))
+		verifyApiError(api.GetWebhook(ctx, operations.GetWebhookParams{WebhookID: *webhook.ID}), http.StatusNotFound)
+	})
+
+	It("fails on a URL that is not HTTP", func() {
+		verifyApiError(register(clusterID, "ftp://203.0.113.10/hook"), http.StatusBadRequest)
+	})
+
+	It("fails on a URL of a private address", func() {
+		for _, url := range []string{
+			"http://127.0.0.1/hook", "http://localhost:8080/hook", "http://10.1.2.3/hook",
+			"http://169.254.169.254/latest/meta-data", "http://[::1]/hook", "http://[fd00::1]/hook",
+		} {
+			verifyApiError(register(clusterID, url), http.StatusBadRequest)
+		}
+	})
+
+	It("accepts a URL of a private address when private targets are allowed", func() {
+		api = webhooks.NewApi(webhooks.Config{AllowPrivateTargets: true}, db, logrus.WithField("pkg", "webhooks"))
+		Expect(register(clusterID, "http://10.1.2.3/hook")).To(BeAssignableToTypeOf(&operations.RegisterWebhookCreated{}))
+	})
+
+	It("fails on a cluster that does not exist", func() {
+		verifyApiError(register(strfmt.UUID(uuid.New().String()), "https://203.0.113.10/hook"), http.StatusNotFound)
+	})
+})
+
+func verifyApiError(responder middleware.Responder, expectedHttpStatus int32) {
+	ExpectWithOffset(1, responder).To(BeAssignableToTypeOf(&common.ApiErrorResponse{}))
+	ExpectWithOffset(1, responder.(*common.ApiErrorResponse).StatusCode()).To(Equal(expectedHttpStatus))
+}
@@ -0,0 +1,237 @@
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/internal/webhooks"
+	"github.com/openshift/assisted-service/models"
+	"github.com/openshift/assisted-service/pkg/leader"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const secret = "0123456789abcdef"
+
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+var _ = Describe("Webhooks", func() {
+	var (
+		db        *gorm.DB
+		cfg       webhooks.Config
+		manager   *webhooks.Manager
+		server    *httptest.Server
+		mu        sync.Mutex
+		requests  []received
+		status    int
+		dbName    = "webhooks_test"
+		clusterID strfmt.UUID
+		cluster   *common.Cluster
+	)
+
+	BeforeEach(func() {
+		db = common.PrepareTestDB(dbName, &webhooks.Subscription{}, &models.WebhookDelivery{}, &models.WebhookDeliveryAttempt{})
+		requests = nil
+		status = http.StatusOK
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			mu.Lock()
+			defer mu.Unlock()
+			requests = append(requests, received{header: r.Header, body: body})
+			w.WriteHeader(status)
+		}))
+		cfg = webhooks.Config{
+			DeliveryWorkers:     1,
+			DeliveryTimeout:     time.Second,
+			MaxAttempts:         2,
+			RetryBackoff:        time.Hour,
+			MaxRetryBackoff:     time.Hour,
+			AllowPrivateTargets: true,
+		}
+		manager = webhooks.NewManager(cfg, logrus.WithField("pkg", "webhooks"), db, &leader.DummyElector{})
+
+		clusterID = strfmt.UUID(uuid.New().String())
+		cluster = &common.Cluster{Cluster: models.Cluster{
+			ID:     &clusterID,
+			Name:   "test-cluster",
+			OrgID:  "org",
+			Status: swag.String(models.ClusterStatusInstalled),
+		}}
+		Expect(db.Create(cluster).Error).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		common.DeleteTestDB(db, dbName)
+	})
+
+	subscribe := func(clusterID strfmt.UUID, orgID string, filter string) strfmt.UUID {
+		id := strfmt.UUID(uuid.New().String())
+		u := strfmt.URI(server.URL)
+		s := &webhooks.Subscription{
+			Webhook: models.Webhook{ID: &id, ClusterID: clusterID, OrgID: orgID, URL: &u},
+			Secret:  secret,
+			Filter:  filter,
+		}
+		Expect(db.Create(s).Error).ShouldNot(HaveOccurred())
+		return id
+	}
+
+	deliveries := func(webhookID strfmt.UUID) []*models.WebhookDelivery {
+		var ret []*models.WebhookDelivery
+		Expect(db.Preload("AttemptLog").Where("webhook_id = ?", webhookID.String()).Find(&ret).Error).ShouldNot(HaveOccurred())
+		return ret
+	}
+
+	Context("status changes", func() {
+		It("queues deliveries to the subscriptions of the cluster and its organization that match", func() {
+			byCluster := subscribe(clusterID, "", "")
+			byOrg := subscribe("", "org", "cluster.*")
+			filtered := subscribe(clusterID, "", "cluster.error,host.insufficient")
+			otherOrg := subscribe("", "other-org", "")
+
+			Expect(manager.ClusterStatusChanged(context.Background(), db, cluster, models.ClusterStatusFinalizing)).ShouldNot(HaveOccurred())
+
+			Expect(deliveries(byCluster)).To(HaveLen(1))
+			Expect(deliveries(byOrg)).To(HaveLen(1))
+			Expect(deliveries(filtered)).To(BeEmpty())
+			Expect(deliveries(otherOrg)).To(BeEmpty())
+
+			d := deliveries(byCluster)[0]
+			Expect(*d.Event).To(Equal("cluster.installed"))
+			Expect(*d.Status).To(Equal(webhooks.DeliveryStatusPending))
+			var payload webhooks.Payload
+			Expect(json.Unmarshal([]byte(d.Payload), &payload)).ShouldNot(HaveOccurred())
+			Expect(payload.DeliveryID).To(Equal(*d.ID))
+			Expect(payload.ClusterName).To(Equal("test-cluster"))
+			Expect(payload.From).To(Equal(models.ClusterStatusFinalizing))
+			Expect(payload.To).To(Equal(models.ClusterStatusInstalled))
+		})
+
+		It("queues deliveries of host status changes", func() {
+			webhookID := subscribe(clusterID, "", "host.insufficient")
+			hostID := strfmt.UUID(uuid.New().String())
+			host := &models.Host{ID: &hostID, ClusterID: clusterID, Status: swag.String(models.HostStatusInsufficient)}
+
+			Expect(manager.HostStatusChanged(context.Background(), db, host, models.HostStatusKnown)).ShouldNot(HaveOccurred())
+			ds := deliveries(webhookID)
+			Expect(ds).To(HaveLen(1))
+			Expect(ds[0].HostID).To(Equal(hostID))
+			Expect(*ds[0].Event).To(Equal("host.insufficient"))
+		})
+	})
+
+	Context("DeliveryTask", func() {
+		var webhookID strfmt.UUID
+
+		BeforeEach(func() {
+			webhookID = subscribe(clusterID, "", "")
+			Expect(manager.ClusterStatusChanged(context.Background(), db, cluster, models.ClusterStatusFinalizing)).ShouldNot(HaveOccurred())
+		})
+
+		It("posts signed payloads and records the attempt", func() {
+			manager.DeliveryTask()
+
+			Expect(requests).To(HaveLen(1))
+			d := deliveries(webhookID)[0]
+			Expect(requests[0].header.Get(webhooks.HeaderEvent)).To(Equal("cluster.installed"))
+			Expect(requests[0].header.Get(webhooks.HeaderDelivery)).To(Equal(d.ID.String()))
+			Expect(requests[0].header.Get(webhooks.HeaderSignature)).To(Equal(webhooks.Sign(secret, requests[0].body)))
+			Expect(string(requests[0].body)).To(Equal(d.Payload))
+
+			Expect(*d.Status).To(Equal(webhooks.DeliveryStatusSucceeded))
+			Expect(d.Attempts).To(Equal(int64(1)))
+			Expect(d.AttemptLog).To(HaveLen(1))
+			Expect(d.AttemptLog[0].StatusCode).To(Equal(int64(http.StatusOK)))
+
+			manager.DeliveryTask()
+			Expect(requests).To(HaveLen(1))
+		})
+
+		It("retries failed deliveries after the backoff and fails them after the last attempt", func() {
+			status = http.StatusInternalServerError
+			manager.DeliveryTask()
+
+			d := deliveries(webhookID)[0]
+			Expect(*d.Status).To(Equal(webhooks.DeliveryStatusPending))
+			Expect(d.AttemptLog).To(HaveLen(1))
+			Expect(d.AttemptLog[0].StatusCode).To(Equal(int64(http.StatusInternalServerError)))
+			Expect(d.AttemptLog[0].Error).NotTo(BeEmpty())
+			Expect(time.Time(d.NextAttemptAt)).To(BeTemporally(">", time.Now().Add(50*time.Minute)))
+
+			By("waiting for the backoff")
+			manager.DeliveryTask()
+			Expect(requests).To(HaveLen(1))
+
+			By("attempting again once the backoff passed")
+			Expect(db.Model(&models.WebhookDelivery{}).Where("id = ?", d.ID.String()).
+				Update("next_attempt_at", strfmt.DateTime(time.Now().Add(-time.Minute).UTC())).Error).ShouldNot(HaveOccurred())
+			manager.DeliveryTask()
+			Expect(requests).To(HaveLen(2))
+			d = deliveries(webhookID)[0]
+			Expect(*d.Status).To(Equal(webhooks.DeliveryStatusFailed))
+			Expect(d.Attempts).To(Equal(int64(2)))
+			Expect(d.AttemptLog).To(HaveLen(2))
+		})
+
+		It("attempts the deliveries of a subscription in order up to the first failure", func() {
+			Expect(db.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID.String()).
+				Update("created_at", strfmt.DateTime(time.Now().Add(-time.Second).UTC())).Error).ShouldNot(HaveOccurred())
+			Expect(manager.ClusterStatusChanged(context.Background(), db, cluster, models.ClusterStatusError)).ShouldNot(HaveOccurred())
+			status = http.StatusInternalServerError
+			manager.DeliveryTask()
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].header.Get(webhooks.HeaderEvent)).To(Equal("cluster.installed"))
+			var payload webhooks.Payload
+			Expect(json.Unmarshal(requests[0].body, &payload)).ShouldNot(HaveOccurred())
+			Expect(payload.From).To(Equal(models.ClusterStatusFinalizing))
+
+			By("waiting for the backoff of the first delivery")
+			status = http.StatusOK
+			manager.DeliveryTask()
+			Expect(requests).To(HaveLen(1))
+
+			By("attempting both once the backoff passed")
+			Expect(db.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID.String()).
+				Update("next_attempt_at", strfmt.DateTime(time.Now().Add(-time.Minute).UTC())).Error).ShouldNot(HaveOccurred())
+			manager.DeliveryTask()
+			Expect(requests).To(HaveLen(3))
+			Expect(json.Unmarshal(requests[1].body, &payload)).ShouldNot(HaveOccurred())
+			Expect(payload.From).To(Equal(models.ClusterStatusFinalizing))
+			Expect(json.Unmarshal(requests[2].body, &payload)).ShouldNot(HaveOccurred())
+			Expect(payload.From).To(Equal(models.ClusterStatusError))
+		})
+
+		It("does not connect to private addresses", func() {
+			cfg.AllowPrivateTargets = false
+			manager = webhooks.NewManager(cfg, logrus.WithField("pkg", "webhooks"), db, &leader.DummyElector{})
+			manager.DeliveryTask()
+
+			Expect(requests).To(BeEmpty())
+			d := deliveries(webhookID)[0]
+			Expect(*d.Status).To(Equal(webhooks.DeliveryStatusPending))
+			Expect(d.AttemptLog).To(HaveLen(1))
+			Expect(d.AttemptLog[0].Error).To(ContainSubstring("is not a public address"))
+		})
+	})
+})
+
+func TestWebhooks(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Webhooks test Suite")
+}
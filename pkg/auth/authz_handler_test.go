@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/go-openapi/runtime"
+	rtclient "github.com/go-openapi/runtime/client"
 	"github.com/go-openapi/runtime/security"
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
@@ -18,9 +19,11 @@ import (
 	"github.com/google/uuid"
 	"github.com/openshift/assisted-service/client"
 	"github.com/openshift/assisted-service/client/events"
+	"github.com/openshift/assisted-service/client/history"
 	"github.com/openshift/assisted-service/client/installer"
 	"github.com/openshift/assisted-service/client/managed_domains"
 	"github.com/openshift/assisted-service/client/versions"
+	"github.com/openshift/assisted-service/client/webhooks"
 	"github.com/openshift/assisted-service/internal/common"
 	"github.com/openshift/assisted-service/models"
 	logutil "github.com/openshift/assisted-service/pkg/log"
@@ -199,6 +202,8 @@ func TestAuthz(t *testing.T) {
 			Logger:                logrus.Printf,
 			VersionsAPI:           fakeVersionsAPI{},
 			ManagedDomainsAPI:     fakeManagedDomainsAPI{},
+			WebhooksAPI:           fakeWebhooksAPI{},
+			HistoryAPI:            fakeHistoryAPI{},
 			InnerMiddleware:       nil,
 		})
 	if err != nil {
@@ -234,6 +239,10 @@ func TestAuthz(t *testing.T) {
 			URL:      srvUrl,
 			AuthInfo: AgentAuthHeaderWriter("fake_pull_secret"),
 		})
+	// the generated client has no consumer for event streams
+	for _, cli := range []*client.AssistedInstall{userClient, agentClient} {
+		cli.Transport.(*rtclient.Runtime).Consumers["text/event-stream"] = runtime.TextConsumer()
+	}
 
 	verifyResponseErrorCode := func(err error, expectUnauthorizedCode bool) {
 		expectedCode := "403"
@@ -469,6 +478,56 @@ func TestAuthz(t *testing.T) {
 			allowedRoles: []ocm.RoleType{ocm.AdminRole, ocm.ReadOnlyAdminRole, ocm.UserRole},
 			apiCall:      listEvents,
 		},
+		{
+			name:         "stream cluster events",
+			allowedRoles: []ocm.RoleType{ocm.AdminRole, ocm.ReadOnlyAdminRole, ocm.UserRole},
+			apiCall:      streamClusterEvents,
+		},
+		{
+			name:         "stream events",
+			allowedRoles: []ocm.RoleType{ocm.AdminRole, ocm.ReadOnlyAdminRole},
+			apiCall:      streamEvents,
+		},
+		{
+			name:         "register webhook",
+			allowedRoles: []ocm.RoleType{ocm.AdminRole, ocm.UserRole},
+			apiCall:      registerWebhook,
+		},
+		{
+			name:         "list webhooks",
+			allowedRoles: []ocm.RoleType{ocm.AdminRole, ocm.ReadOnlyAdminRole, ocm.UserRole},
+			apiCall:      listWebhooks,
+		},
+		{
+			name:         "get webhook",
+			allowedRoles: []ocm.RoleType{ocm.AdminRole, ocm.ReadOnlyAdminRole, ocm.UserRole},
+			apiCall:      getWebhook,
+		},
+		{
+			name:         "deregister webhook",
+			allowedRoles: []ocm.RoleType{ocm.AdminRole, ocm.UserRole},
+			apiCall:      deregisterWebhook,
+		},
+		{
+			name:         "list webhook deliveries",
+			allowedRoles: []ocm.RoleType{ocm.AdminRole, ocm.ReadOnlyAdminRole, ocm.UserRole},
+			apiCall:      listWebhookDeliveries,
+		},
+		{
+			name:         "list cluster transitions",
+			allowedRoles: []ocm.RoleType{ocm.AdminRole, ocm.ReadOnlyAdminRole, ocm.UserRole},
+			apiCall:      listClusterTransitions,
+		},
+		{
+			name:         "list host transitions",
+			allowedRoles: []ocm.RoleType{ocm.AdminRole, ocm.ReadOnlyAdminRole, ocm.UserRole},
+			apiCall:      listHostTransitions,
+		},
+		{
+			name:         "get cluster installation timeline",
+			allowedRoles: []ocm.RoleType{ocm.AdminRole, ocm.ReadOnlyAdminRole, ocm.UserRole},
+			apiCall:      getClusterInstallationTimeline,
+		},
 		{
 			name:         "list managed domains",
 			allowedRoles: []ocm.RoleType{ocm.AdminRole, ocm.ReadOnlyAdminRole, ocm.UserRole},
@@ -943,6 +1002,69 @@ func listEvents(ctx context.Context, cli *client.AssistedInstall) error {
 	return err
 }
 
+func streamClusterEvents(ctx context.Context, cli *client.AssistedInstall) error {
+	_, err := cli.Events.StreamClusterEvents(
+		ctx,
+		&events.StreamClusterEventsParams{ClusterID: strfmt.UUID(uuid.New().String())})
+	return err
+}
+
+func streamEvents(ctx context.Context, cli *client.AssistedInstall) error {
+	_, err := cli.Events.StreamEvents(ctx, &events.StreamEventsParams{})
+	return err
+}
+
+func registerWebhook(ctx context.Context, cli *client.AssistedInstall) error {
+	url := strfmt.URI("https://example.com/hook")
+	_, err := cli.Webhooks.RegisterWebhook(
+		ctx,
+		&webhooks.RegisterWebhookParams{
+			NewWebhookParams: &models.WebhookCreateParams{
+				URL:    &url,
+				Secret: swag.String("0123456789abcdef"),
+			},
+		})
+	return err
+}
+
+func listWebhooks(ctx context.Context, cli *client.AssistedInstall) error {
+	_, err := cli.Webhooks.ListWebhooks(ctx, &webhooks.ListWebhooksParams{})
+	return err
+}
+
+func getWebhook(ctx context.Context, cli *client.AssistedInstall) error {
+	_, err := cli.Webhooks.GetWebhook(ctx, &webhooks.GetWebhookParams{WebhookID: strfmt.UUID(uuid.New().String())})
+	return err
+}
+
+func deregisterWebhook(ctx context.Context, cli *client.AssistedInstall) error {
+	_, err := cli.Webhooks.DeregisterWebhook(ctx, &webhooks.DeregisterWebhookParams{WebhookID: strfmt.UUID(uuid.New().String())})
+	return err
+}
+
+func listWebhookDeliveries(ctx context.Context, cli *client.AssistedInstall) error {
+	_, err := cli.Webhooks.ListWebhookDeliveries(ctx, &webhooks.ListWebhookDeliveriesParams{WebhookID: strfmt.UUID(uuid.New().String())})
+	return err
+}
+
+func listClusterTransitions(ctx context.Context, cli *client.AssistedInstall) error {
+	_, err := cli.History.ListClusterTransitions(ctx, &history.ListClusterTransitionsParams{ClusterID: strfmt.UUID(uuid.New().String())})
+	return err
+}
+
+func listHostTransitions(ctx context.Context, cli *client.AssistedInstall) error {
+	_, err := cli.History.ListHostTransitions(ctx, &history.ListHostTransitionsParams{
+		ClusterID: strfmt.UUID(uuid.New().String()),
+		HostID:    strfmt.UUID(uuid.New().String()),
+	})
+	return err
+}
+
+func getClusterInstallationTimeline(ctx context.Context, cli *client.AssistedInstall) error {
+	_, err := cli.History.GetClusterInstallationTimeline(ctx, &history.GetClusterInstallationTimelineParams{ClusterID: strfmt.UUID(uuid.New().String())})
+	return err
+}
+
 func listManagedDomains(ctx context.Context, cli *client.AssistedInstall) error {
 	_, err := cli.ManagedDomains.ListManagedDomains(
 		ctx,
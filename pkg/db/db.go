@@ -1,9 +1,89 @@
 package db
 
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const (
+	DialectPostgres = "postgres"
+	DialectSqlite   = "sqlite"
+)
+
 type Config struct {
-	Host string `envconfig:"DB_HOST"`
-	Port string `envconfig:"DB_PORT"`
-	User string `envconfig:"DB_USER"`
-	Pass string `envconfig:"DB_PASS"`
-	Name string `envconfig:"DB_NAME"`
+	Dialect string `envconfig:"DB_DIALECT" default:"postgres"`
+	Host    string `envconfig:"DB_HOST"`
+	Port    string `envconfig:"DB_PORT"`
+	User    string `envconfig:"DB_USER"`
+	Pass    string `envconfig:"DB_PASS"`
+	Name    string `envconfig:"DB_NAME"`
+	SslMode string `envconfig:"DB_SSL_MODE" default:"disable"`
+	// Path of the database file, used only by the sqlite dialect
+	Path string `envconfig:"DB_PATH" default:"/data/assisted-service.db"`
+	// Time a sqlite connection waits for a lock held by another connection before failing
+	BusyTimeout     time.Duration `envconfig:"DB_BUSY_TIMEOUT" default:"5s"`
+	MaxOpenConns    int           `envconfig:"DB_MAX_OPEN_CONNS" default:"20"`
+	MaxIdleConns    int           `envconfig:"DB_MAX_IDLE_CONNS" default:"5"`
+	ConnMaxLifetime time.Duration `envconfig:"DB_CONN_MAX_LIFETIME" default:"30m"`
+}
+
+// Workers returns the number of workers that write to the database concurrently, when the configured number is
+// 0: defaultWorkers, or a single one on sqlite. A sqlite database has a single writer, and the transactions that
+// read before they write fail instead of waiting for it when they run concurrently.
+func Workers(db *gorm.DB, configured, defaultWorkers int) int {
+	switch {
+	case configured > 0:
+		return configured
+	case db.Dialector.Name() == DialectSqlite:
+		return 1
+	default:
+		return defaultWorkers
+	}
+}
+
+// Open connects to the database described by the configuration and applies the connection pool settings
+func Open(cfg Config) (*gorm.DB, error) {
+	dialector, err := newDialector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to %s database", cfg.Dialect)
+	}
+	if err = RegisterResourceVersion(db); err != nil {
+		return nil, err
+	}
+	if err = RegisterTracing(db); err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	return db, nil
+}
+
+func newDialector(cfg Config) (gorm.Dialector, error) {
+	switch cfg.Dialect {
+	case DialectPostgres:
+		return postgres.Open(fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=%s",
+			cfg.Host, cfg.Port, cfg.User, cfg.Name, cfg.Pass, cfg.SslMode)), nil
+	case DialectSqlite:
+		if cfg.Path == "" {
+			return nil, errors.Errorf("database path must be set for dialect %s", cfg.Dialect)
+		}
+		return sqlite.Open(fmt.Sprintf("file:%s?_busy_timeout=%d&_journal_mode=WAL",
+			cfg.Path, cfg.BusyTimeout.Milliseconds())), nil
+	default:
+		return nil, errors.Errorf("unsupported database dialect %s", cfg.Dialect)
+	}
 }
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestDB(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "DB Suite")
+}
+
+var _ = Describe("Open", func() {
+	var (
+		dir string
+		cfg Config
+	)
+
+	type record struct {
+		ID   uint
+		Name string
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "db-test")
+		Expect(err).ShouldNot(HaveOccurred())
+		cfg = Config{
+			Dialect:         DialectSqlite,
+			Path:            filepath.Join(dir, "test.db"),
+			BusyTimeout:     time.Second,
+			MaxOpenConns:    4,
+			MaxIdleConns:    2,
+			ConnMaxLifetime: time.Minute,
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("persists data in a sqlite file across connections", func() {
+		db, err := Open(cfg)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(db.AutoMigrate(&record{})).ShouldNot(HaveOccurred())
+		Expect(db.Create(&record{Name: "first"}).Error).ShouldNot(HaveOccurred())
+		sqlDB, err := db.DB()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(sqlDB.Stats().MaxOpenConnections).Should(Equal(4))
+		Expect(sqlDB.Close()).ShouldNot(HaveOccurred())
+
+		db, err = Open(cfg)
+		Expect(err).ShouldNot(HaveOccurred())
+		var r record
+		Expect(db.First(&r).Error).ShouldNot(HaveOccurred())
+		Expect(r.Name).Should(Equal("first"))
+	})
+
+	It("fails on an empty sqlite path", func() {
+		cfg.Path = ""
+		_, err := Open(cfg)
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("runs a single worker on sqlite unless configured", func() {
+		db, err := Open(cfg)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(Workers(db, 0, 8)).Should(Equal(1))
+		Expect(Workers(db, 4, 8)).Should(Equal(4))
+	})
+
+	It("fails on an unknown dialect", func() {
+		cfg.Dialect = "mysql"
+		_, err := Open(cfg)
+		Expect(err).Should(HaveOccurred())
+	})
+})
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+)
+
+// ResourceVersionColumn is incremented by every update of a model that has it, see RegisterResourceVersion
+const ResourceVersionColumn = "resource_version"
+
+type skipResourceVersionKey struct{}
+
+// RegisterResourceVersion makes every update of a model with a resource version column increment it in the same
+// statement, so API clients can detect concurrent changes of the object they are editing.
+func RegisterResourceVersion(db *gorm.DB) error {
+	return db.Callback().Update().Before("gorm:update").
+		Register("assisted:resource_version", incrementResourceVersion)
+}
+
+// SkipResourceVersion returns a session whose updates don't increment the resource version, for bookkeeping
+// columns that change too often to be part of the version, like the last time an agent checked in, and for the
+// writes of the monitors and the agents, that would fail the preconditions of the users all the time.
+// The flag is kept in the context of the session, so the statements and transactions derived from it keep it.
+func SkipResourceVersion(db *gorm.DB) *gorm.DB {
+	return db.WithContext(context.WithValue(db.Statement.Context, skipResourceVersionKey{}, true))
+}
+
+// incrementResourceVersion builds the update statement itself, the gorm:update callback executes any
+// statement that was already built instead of building its own assignments.
+func incrementResourceVersion(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || stmt.SQL.Len() != 0 {
+		return
+	}
+	if _, ok := stmt.Schema.FieldsByDBName[ResourceVersionColumn]; !ok {
+		return
+	}
+	if skip, ok := stmt.Context.Value(skipResourceVersionKey{}).(bool); ok && skip {
+		return
+	}
+
+	if !stmt.Unscoped {
+		for _, c := range stmt.Schema.UpdateClauses {
+			stmt.AddClause(c)
+		}
+	}
+	assignments := callbacks.ConvertToAssignments(stmt)
+	if len(assignments) == 0 {
+		return
+	}
+	set := make(clause.Set, 0, len(assignments)+1)
+	for _, a := range assignments {
+		if a.Column.Name != ResourceVersionColumn {
+			set = append(set, a)
+		}
+	}
+	set = append(set, clause.Assignment{
+		Column: clause.Column{Name: ResourceVersionColumn},
+		Value:  gorm.Expr(stmt.Quote(ResourceVersionColumn) + " + 1"),
+	})
+	stmt.AddClauseIfNotExists(clause.Update{})
+	stmt.AddClause(set)
+	stmt.Build("UPDATE", "SET", "WHERE")
+}
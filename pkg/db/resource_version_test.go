@@ -0,0 +1,100 @@
+package db
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("ResourceVersion", func() {
+	type versioned struct {
+		ID              uint
+		Name            string
+		ResourceVersion int64 `gorm:"not null;default:0"`
+	}
+	type unversioned struct {
+		ID   uint
+		Name string
+	}
+
+	var (
+		dir    string
+		dbConn *gorm.DB
+		record versioned
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "resource-version")
+		Expect(err).ToNot(HaveOccurred())
+		dbConn, err = Open(Config{Dialect: DialectSqlite, Path: filepath.Join(dir, "test.db"), MaxOpenConns: 1})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dbConn.AutoMigrate(&versioned{}, &unversioned{})).ToNot(HaveOccurred())
+		record = versioned{Name: "a"}
+		Expect(dbConn.Create(&record).Error).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		sqlDB, err := dbConn.DB()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sqlDB.Close()).ToNot(HaveOccurred())
+		Expect(os.RemoveAll(dir)).ToNot(HaveOccurred())
+	})
+
+	version := func() int64 {
+		var r versioned
+		Expect(dbConn.First(&r, record.ID).Error).ToNot(HaveOccurred())
+		return r.ResourceVersion
+	}
+
+	It("is incremented by every kind of update", func() {
+		Expect(version()).To(Equal(int64(0)))
+		Expect(dbConn.Model(&versioned{}).Where("id = ?", record.ID).Update("name", "b").Error).ToNot(HaveOccurred())
+		Expect(version()).To(Equal(int64(1)))
+		Expect(dbConn.Model(&versioned{}).Where("id = ?", record.ID).
+			Updates(map[string]interface{}{"name": "c"}).Error).ToNot(HaveOccurred())
+		Expect(version()).To(Equal(int64(2)))
+		Expect(dbConn.Model(&record).Updates(&versioned{Name: "d"}).Error).ToNot(HaveOccurred())
+		Expect(version()).To(Equal(int64(3)))
+		record.Name = "e"
+		Expect(dbConn.Save(&record).Error).ToNot(HaveOccurred())
+		Expect(version()).To(Equal(int64(4)))
+	})
+
+	It("can't be overwritten by an update", func() {
+		Expect(dbConn.Model(&versioned{}).Where("id = ?", record.ID).
+			Updates(map[string]interface{}{"name": "b", "resource_version": 10}).Error).ToNot(HaveOccurred())
+		Expect(version()).To(Equal(int64(1)))
+	})
+
+	It("is left unchanged when skipped", func() {
+		Expect(SkipResourceVersion(dbConn).Model(&versioned{}).Where("id = ?", record.ID).
+			Update("name", "b").Error).ToNot(HaveOccurred())
+		Expect(version()).To(Equal(int64(0)))
+	})
+
+	It("is left unchanged by the statements and transactions of a skipping session", func() {
+		skipping := SkipResourceVersion(dbConn)
+		Expect(skipping.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&versioned{}).Where("id = ?", record.ID).Update("name", "b").Error; err != nil {
+				return err
+			}
+			return tx.Model(&versioned{}).Where("id = ?", record.ID).Update("name", "c").Error
+		})).ToNot(HaveOccurred())
+		Expect(version()).To(Equal(int64(0)))
+		Expect(dbConn.Model(&versioned{}).Where("id = ?", record.ID).Update("name", "d").Error).ToNot(HaveOccurred())
+		Expect(version()).To(Equal(int64(1)))
+	})
+
+	It("doesn't affect models without a resource version", func() {
+		r := unversioned{Name: "a"}
+		Expect(dbConn.Create(&r).Error).ToNot(HaveOccurred())
+		Expect(dbConn.Model(&r).Update("name", "b").Error).ToNot(HaveOccurred())
+		Expect(dbConn.First(&r, r.ID).Error).ToNot(HaveOccurred())
+		Expect(r.Name).To(Equal("b"))
+	})
+})
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"github.com/openshift/assisted-service/pkg/tracing"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const spanKey = "assisted:span"
+
+// RegisterTracing records a span for every statement of a traced operation, the statement has to run in a session
+// with the context of the operation, see gorm.DB.WithContext. Statements without a span in their context aren't
+// recorded, so the work in the background doesn't start a trace for each statement.
+func RegisterTracing(db *gorm.DB) error {
+	for _, err := range []error{
+		db.Callback().Create().Before("gorm:create").Register("assisted:trace_create", startSpan("db.create")),
+		db.Callback().Create().After("gorm:create").Register("assisted:trace_create_end", endSpan),
+		db.Callback().Query().Before("gorm:query").Register("assisted:trace_query", startSpan("db.query")),
+		db.Callback().Query().After("gorm:query").Register("assisted:trace_query_end", endSpan),
+		db.Callback().Update().Before("gorm:update").Register("assisted:trace_update", startSpan("db.update")),
+		db.Callback().Update().After("gorm:update").Register("assisted:trace_update_end", endSpan),
+		db.Callback().Delete().Before("gorm:delete").Register("assisted:trace_delete", startSpan("db.delete")),
+		db.Callback().Delete().After("gorm:delete").Register("assisted:trace_delete_end", endSpan),
+		db.Callback().Row().Before("gorm:row").Register("assisted:trace_row", startSpan("db.row")),
+		db.Callback().Row().After("gorm:row").Register("assisted:trace_row_end", endSpan),
+		db.Callback().Raw().Before("gorm:raw").Register("assisted:trace_raw", startSpan("db.raw")),
+		db.Callback().Raw().After("gorm:raw").Register("assisted:trace_raw_end", endSpan),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startSpan(name string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		_, span := tracing.StartInTrace(db.Statement.Context, name, attribute.String("db.table", db.Statement.Table))
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+func endSpan(db *gorm.DB) {
+	s, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span := s.(trace.Span)
+	if !span.IsRecording() {
+		return
+	}
+	span.SetAttributes(attribute.String("db.statement", db.Statement.SQL.String()), attribute.Int64("db.rows_affected", db.RowsAffected))
+	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	tracing.End(db.Statement.Context, span, err)
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/assisted-service/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("Tracing", func() {
+	type traced struct {
+		ID   uint
+		Name string
+	}
+
+	type exportedSpan struct {
+		Name     string
+		ParentID trace.SpanID
+	}
+
+	var (
+		dir      string
+		dbConn   *gorm.DB
+		recorder *tracetest.SpanRecorder
+	)
+
+	ended := func() []exportedSpan {
+		var spans []exportedSpan
+		for _, s := range recorder.Ended() {
+			spans = append(spans, exportedSpan{Name: s.Name(), ParentID: s.Parent().SpanID()})
+		}
+		return spans
+	}
+
+	BeforeEach(func() {
+		recorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+		var err error
+		dir, err = ioutil.TempDir("", "db-tracing")
+		Expect(err).ToNot(HaveOccurred())
+		dbConn, err = Open(Config{Dialect: DialectSqlite, Path: filepath.Join(dir, "test.db"), MaxOpenConns: 1})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dbConn.AutoMigrate(&traced{})).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		sqlDB, err := dbConn.DB()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sqlDB.Close()).ToNot(HaveOccurred())
+		Expect(os.RemoveAll(dir)).ToNot(HaveOccurred())
+	})
+
+	It("records the statements of a traced operation", func() {
+		ctx, span := tracing.Start(context.Background(), "operation")
+		db := dbConn.WithContext(ctx)
+		record := traced{Name: "a"}
+		Expect(db.Create(&record).Error).ToNot(HaveOccurred())
+		Expect(db.Model(&record).Update("name", "b").Error).ToNot(HaveOccurred())
+		Expect(db.First(&traced{}, record.ID).Error).ToNot(HaveOccurred())
+		Expect(db.Delete(&record).Error).ToNot(HaveOccurred())
+		span.End()
+
+		parentID := span.SpanContext().SpanID()
+		Expect(ended()).To(ConsistOf(
+			exportedSpan{Name: "db.create", ParentID: parentID},
+			exportedSpan{Name: "db.update", ParentID: parentID},
+			exportedSpan{Name: "db.query", ParentID: parentID},
+			exportedSpan{Name: "db.delete", ParentID: parentID},
+			exportedSpan{Name: "operation"},
+		))
+	})
+
+	It("doesn't record statements out of a trace", func() {
+		record := traced{Name: "a"}
+		Expect(dbConn.Create(&record).Error).ToNot(HaveOccurred())
+		Expect(dbConn.WithContext(context.Background()).First(&traced{}, record.ID).Error).ToNot(HaveOccurred())
+		Expect(ended()).To(BeEmpty())
+	})
+})
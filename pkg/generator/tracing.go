@@ -0,0 +1,36 @@
+package generator
+
+import (
+	"context"
+
+	"github.com/openshift/assisted-service/internal/common"
+	"github.com/openshift/assisted-service/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type tracingGenerator struct {
+	ISOInstallConfigGenerator
+}
+
+// WithTracing returns the generator with a span for the generation of the install config of every cluster
+func WithTracing(generator ISOInstallConfigGenerator) ISOInstallConfigGenerator {
+	return &tracingGenerator{ISOInstallConfigGenerator: generator}
+}
+
+func (g *tracingGenerator) UploadBaseISO() (err error) {
+	ctx, span := tracing.Start(context.Background(), "generator.UploadBaseISO")
+	defer func() { tracing.End(ctx, span, err) }()
+	return g.ISOInstallConfigGenerator.UploadBaseISO()
+}
+
+func (g *tracingGenerator) GenerateInstallConfig(ctx context.Context, cluster common.Cluster, cfg []byte) (err error) {
+	ctx, span := tracing.Start(ctx, "generator.GenerateInstallConfig", attribute.String("cluster.id", cluster.ID.String()))
+	defer func() { tracing.End(ctx, span, err) }()
+	return g.ISOInstallConfigGenerator.GenerateInstallConfig(ctx, cluster, cfg)
+}
+
+func (g *tracingGenerator) AbortInstallConfig(ctx context.Context, cluster common.Cluster) (err error) {
+	ctx, span := tracing.Start(ctx, "generator.AbortInstallConfig", attribute.String("cluster.id", cluster.ID.String()))
+	defer func() { tracing.End(ctx, span, err) }()
+	return g.ISOInstallConfigGenerator.AbortInstallConfig(ctx, cluster)
+}
@@ -0,0 +1,261 @@
+package leader
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+// Lease is a row per lease name, the replica in holder is the leader until expires_at.
+// Every acquisition of the lease increments the token, so work done by a former leader can be fenced off.
+// The table is created by the migrations of the service, before any leader is elected.
+type Lease struct {
+	Name       string `gorm:"primaryKey"`
+	Holder     string
+	Token      int64 `gorm:"not null;default:0"`
+	AcquiredAt time.Time
+	RenewedAt  time.Time
+	ExpiresAt  time.Time
+}
+
+func (Lease) TableName() string {
+	return "leader_leases"
+}
+
+var _ ElectorInterface = &DBElector{}
+
+// DBElector elects a leader between the replicas that share the service database, for deployments
+// without kubernetes. The leader renews the lease every RetryInterval and stops being the leader if it
+// couldn't renew it for RenewDeadline, which must be shorter than LeaseDuration so the leader steps down
+// before another replica can take over. Expiration times are set by the clock of each replica, so the
+// difference between LeaseDuration and RenewDeadline should cover the clock skew between them.
+type DBElector struct {
+	log       logrus.FieldLogger
+	config    Config
+	db        *gorm.DB
+	leaseName string
+	identity  string
+
+	mu        sync.RWMutex
+	token     int64
+	renewedAt time.Time
+}
+
+func NewDBElector(db *gorm.DB, config Config, leaseName string, logger logrus.FieldLogger) *DBElector {
+	logger = logger.WithField("lease", leaseName)
+	return &DBElector{log: logger, config: config, db: db, leaseName: leaseName}
+}
+
+func (l *DBElector) IsLeader() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.isLeader(time.Now())
+}
+
+func (l *DBElector) isLeader(now time.Time) bool {
+	return l.token != 0 && now.Sub(l.renewedAt) < l.config.RenewDeadline
+}
+
+// Token returns the fencing token of the current leadership, or 0 if this replica isn't the leader.
+// Work that must not be done by a former leader can verify the token with VerifyLease.
+func (l *DBElector) Token() int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if !l.isLeader(time.Now()) {
+		return 0
+	}
+	return l.token
+}
+
+func (l *DBElector) verify(db *gorm.DB) error {
+	token := l.Token()
+	if token == 0 {
+		return errors.Errorf("not the leader of lease %s", l.leaseName)
+	}
+	return VerifyLease(db, l.leaseName, token)
+}
+
+// Wait for leader, run given function, drop leader and exit.
+func (l *DBElector) RunWithLeader(ctx context.Context, run func() error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	err := l.StartLeaderElection(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	if err = l.waitForLeader(ctx); err != nil {
+		cancel()
+		return err
+	}
+	err = run()
+	cancel()
+	l.release()
+	return err
+}
+
+func (l *DBElector) waitForLeader(ctx context.Context) error {
+	ticker := time.NewTicker(l.config.RetryInterval)
+	defer ticker.Stop()
+	l.log.Infof("Start waiting for leader")
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.Errorf("cancelled while waiting for leader")
+		case <-ticker.C:
+			if l.IsLeader() {
+				l.log.Infof("Got leader, stop waiting")
+				return nil
+			}
+		}
+	}
+}
+
+// StartLeaderElection keeps trying to acquire or renew the lease until the context is cancelled,
+// the lease is released when that happens.
+func (l *DBElector) StartLeaderElection(ctx context.Context) error {
+	if l.config.RenewDeadline >= l.config.LeaseDuration {
+		return errors.Errorf("leader renew deadline %s must be shorter than the lease duration %s",
+			l.config.RenewDeadline, l.config.LeaseDuration)
+	}
+	if err := l.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&Lease{Name: l.leaseName}).Error; err != nil {
+		return errors.Wrapf(err, "failed to create lease %s", l.leaseName)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+	l.identity = hostname + "_" + string(uuid.NewUUID())
+
+	l.log.Infof("Attempting to acquire leader lease")
+	go func() {
+		ticker := time.NewTicker(l.config.RetryInterval)
+		defer ticker.Stop()
+		for {
+			l.acquireOrRenew()
+			select {
+			case <-ctx.Done():
+				l.log.Infof("Given context was cancelled, exiting leader elector")
+				l.release()
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+func (l *DBElector) acquireOrRenew() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now().UTC()
+	if l.token != 0 {
+		reply := l.db.Model(&Lease{}).
+			Where("name = ? and holder = ? and token = ?", l.leaseName, l.identity, l.token).
+			Updates(map[string]interface{}{"renewed_at": now, "expires_at": now.Add(l.config.LeaseDuration)})
+		switch {
+		case reply.Error == nil && reply.RowsAffected == 1:
+			l.renewedAt = now
+		case reply.Error == nil:
+			l.log.Infof("Leader lease was taken by another replica, NO LONGER LEADER")
+			l.token = 0
+		case !l.isLeader(now):
+			l.log.WithError(reply.Error).Infof("Failed to renew leader lease before the deadline, NO LONGER LEADER")
+			l.token = 0
+		default:
+			l.log.WithError(reply.Error).Warnf("Failed to renew leader lease")
+		}
+		return
+	}
+
+	var token int64
+	err := l.db.Transaction(func(tx *gorm.DB) error {
+		reply := tx.Model(&Lease{}).
+			Where("name = ? and (holder in (?) or expires_at < ?)", l.leaseName, []string{"", l.identity}, now).
+			Updates(map[string]interface{}{
+				"holder":      l.identity,
+				"token":       gorm.Expr("token + 1"),
+				"acquired_at": now,
+				"renewed_at":  now,
+				"expires_at":  now.Add(l.config.LeaseDuration),
+			})
+		if reply.Error != nil || reply.RowsAffected == 0 {
+			return reply.Error
+		}
+		return tx.Model(&Lease{}).Where("name = ?", l.leaseName).Pluck("token", &token).Error
+	})
+	if err != nil {
+		l.log.WithError(err).Warnf("Failed to acquire leader lease")
+		return
+	}
+	if token != 0 {
+		l.log.Infof("Successfully acquired leadership lease, fencing token %d", token)
+		l.token = token
+		l.renewedAt = now
+	}
+}
+
+// release gives up the lease so another replica doesn't have to wait for it to expire
+func (l *DBElector) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.token == 0 {
+		return
+	}
+	err := l.db.Model(&Lease{}).
+		Where("name = ? and holder = ? and token = ?", l.leaseName, l.identity, l.token).
+		Updates(map[string]interface{}{"holder": "", "expires_at": time.Now().UTC()}).Error
+	if err != nil {
+		l.log.WithError(err).Warnf("Failed to release leader lease")
+	}
+	l.token = 0
+}
+
+// VerifyLease fails unless the lease is still held with the given fencing token. Running it in the transaction
+// of a write makes sure a former leader that didn't notice it lost the lease can't commit it: on postgres the
+// lease row is locked until the transaction ends, so the lease can't be acquired by another replica meanwhile.
+func VerifyLease(tx *gorm.DB, leaseName string, token int64) error {
+	query := tx.Model(&Lease{}).Where("name = ? and token = ? and expires_at > ?", leaseName, token, time.Now().UTC())
+	if tx.Dialector.Name() == "postgres" {
+		query = query.Clauses(clause.Locking{Strength: "SHARE"})
+	}
+	var tokens []int64
+	if err := query.Pluck("token", &tokens).Error; err != nil {
+		return errors.Wrapf(err, "failed to verify lease %s", leaseName)
+	}
+	if len(tokens) == 0 {
+		return errors.Errorf("lease %s is no longer held with token %d", leaseName, token)
+	}
+	return nil
+}
+
+// Verify fails unless l is still the leader. The leadership of the database elector is verified with its lease,
+// see VerifyLease, the other electors are trusted by IsLeader.
+func Verify(db *gorm.DB, l Leader) error {
+	if f, ok := l.(interface{ verify(db *gorm.DB) error }); ok {
+		return f.verify(db)
+	}
+	if !l.IsLeader() {
+		return errors.Errorf("not the leader")
+	}
+	return nil
+}
+
+// InTransaction runs fn in a transaction that is committed only if l is still the leader once fn is done,
+// for the writes of the work that only the leader does.
+func InTransaction(db *gorm.DB, l Leader, fn func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return Verify(tx, l)
+	})
+}
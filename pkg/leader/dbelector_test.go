@@ -0,0 +1,137 @@
+package leader
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/assisted-service/pkg/db"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func TestLeader(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Leader Suite")
+}
+
+var _ = Describe("DBElector", func() {
+	const leaseName = "test-lease"
+
+	var (
+		dir    string
+		dbConn *gorm.DB
+		cfg    = Config{LeaseDuration: 500 * time.Millisecond, RenewDeadline: 300 * time.Millisecond,
+			RetryInterval: 50 * time.Millisecond}
+		log = logrus.New()
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "leader")
+		Expect(err).ToNot(HaveOccurred())
+		dbConn, err = db.Open(db.Config{Dialect: db.DialectSqlite, Path: filepath.Join(dir, "test.db"),
+			BusyTimeout: time.Second, MaxOpenConns: 1})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dbConn.AutoMigrate(&Lease{})).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		sqlDB, err := dbConn.DB()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sqlDB.Close()).ToNot(HaveOccurred())
+		Expect(os.RemoveAll(dir)).ToNot(HaveOccurred())
+	})
+
+	start := func(ctx context.Context) *DBElector {
+		elector := NewDBElector(dbConn, cfg, leaseName, log)
+		Expect(elector.StartLeaderElection(ctx)).To(Succeed())
+		return elector
+	}
+
+	It("elects a single leader and hands over when it stops", func() {
+		ctx1, cancel1 := context.WithCancel(context.Background())
+		defer cancel1()
+		first := start(ctx1)
+		Eventually(first.IsLeader).Should(BeTrue())
+		firstToken := first.Token()
+		Expect(VerifyLease(dbConn, leaseName, firstToken)).To(Succeed())
+
+		ctx2, cancel2 := context.WithCancel(context.Background())
+		defer cancel2()
+		second := start(ctx2)
+		Consistently(second.IsLeader, cfg.LeaseDuration*2).Should(BeFalse())
+		Expect(first.IsLeader()).To(BeTrue())
+
+		cancel1()
+		Eventually(second.IsLeader).Should(BeTrue())
+		Expect(first.IsLeader()).To(BeFalse())
+		Expect(second.Token()).To(BeNumerically(">", firstToken))
+		Expect(VerifyLease(dbConn, leaseName, firstToken)).ToNot(Succeed())
+		Expect(VerifyLease(dbConn, leaseName, second.Token())).To(Succeed())
+	})
+
+	It("steps down when it can't renew the lease", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		elector := start(ctx)
+		Eventually(elector.IsLeader).Should(BeTrue())
+		token := elector.Token()
+
+		// another replica took over the lease
+		Expect(dbConn.Model(&Lease{}).Where("name = ?", leaseName).
+			Updates(map[string]interface{}{"holder": "other", "token": token + 1}).Error).ToNot(HaveOccurred())
+		Eventually(elector.IsLeader).Should(BeFalse())
+		Expect(elector.Token()).To(BeZero())
+	})
+
+	It("commits the transactions of the leader only", func() {
+		type record struct {
+			ID uint
+		}
+		Expect(dbConn.AutoMigrate(&record{})).ToNot(HaveOccurred())
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		elector := start(ctx)
+		Eventually(elector.IsLeader).Should(BeTrue())
+		create := func(tx *gorm.DB) error {
+			return tx.Create(&record{}).Error
+		}
+		Expect(InTransaction(dbConn, elector, create)).To(Succeed())
+
+		// another replica took over the lease before this one noticed
+		Expect(dbConn.Model(&Lease{}).Where("name = ?", leaseName).
+			Updates(map[string]interface{}{"holder": "other", "token": elector.Token() + 1}).Error).ToNot(HaveOccurred())
+		Expect(InTransaction(dbConn, elector, create)).ToNot(Succeed())
+		var count int64
+		Expect(dbConn.Model(&record{}).Count(&count).Error).ToNot(HaveOccurred())
+		Expect(count).To(Equal(int64(1)))
+	})
+
+	It("runs with leader and releases the lease", func() {
+		ran := false
+		elector := NewDBElector(dbConn, cfg, leaseName, log)
+		Expect(elector.RunWithLeader(context.Background(), func() error {
+			ran = true
+			Expect(elector.IsLeader()).To(BeTrue())
+			return nil
+		})).To(Succeed())
+		Expect(ran).To(BeTrue())
+		Expect(elector.IsLeader()).To(BeFalse())
+
+		var l Lease
+		Expect(dbConn.Take(&l, "name = ?", leaseName).Error).ToNot(HaveOccurred())
+		Expect(l.Holder).To(BeEmpty())
+	})
+
+	It("refuses a renew deadline that isn't shorter than the lease", func() {
+		elector := NewDBElector(dbConn, Config{LeaseDuration: time.Second, RenewDeadline: time.Second,
+			RetryInterval: time.Second}, leaseName, log)
+		Expect(elector.StartLeaderElection(context.Background())).ToNot(Succeed())
+	})
+})
@@ -18,11 +18,20 @@ import (
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
 )
 
+const (
+	ElectorKubernetes = "kubernetes"
+	ElectorDatabase   = "database"
+	ElectorNone       = "none"
+)
+
 type Config struct {
 	LeaseDuration time.Duration `envconfig:"LEADER_LEASE_DURATION" default:"15s"`
 	RetryInterval time.Duration `envconfig:"LEADER_RETRY_INTERVAL" default:"2s"`
 	RenewDeadline time.Duration `envconfig:"LEADER_RENEW_DEADLINE" default:"10s"`
 	Namespace     string        `envconfig:"NAMESPACE" default:"assisted-installer"`
+	// How replicas elect a leader, one of kubernetes, database or none (every replica is a leader).
+	// Empty selects kubernetes on kubernetes deployments and none on the others.
+	Elector string `envconfig:"LEADER_ELECTOR" default:""`
 }
 
 //go:generate mockgen -source=leaderelector.go -package=leader -destination=mock_leader_elector.go
@@ -0,0 +1,100 @@
+package s3wrapper
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/openshift/assisted-service/pkg/tracing"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// tracingClient records a span for every call of the object storage
+type tracingClient struct {
+	API
+}
+
+// WithTracing returns the API with a span for every call that has a context
+func WithTracing(api API) API {
+	return &tracingClient{API: api}
+}
+
+func startSpan(ctx context.Context, operation, objectName string) (context.Context, func(err error)) {
+	ctx, span := tracing.Start(ctx, "s3."+operation, attribute.String("s3.object", objectName))
+	return ctx, func(err error) { tracing.End(ctx, span, err) }
+}
+
+func (c *tracingClient) Upload(ctx context.Context, data []byte, objectName string) (err error) {
+	ctx, end := startSpan(ctx, "Upload", objectName)
+	defer func() { end(err) }()
+	return c.API.Upload(ctx, data, objectName)
+}
+
+func (c *tracingClient) UploadStream(ctx context.Context, reader io.Reader, objectName string) (err error) {
+	ctx, end := startSpan(ctx, "UploadStream", objectName)
+	defer func() { end(err) }()
+	return c.API.UploadStream(ctx, reader, objectName)
+}
+
+func (c *tracingClient) UploadFile(ctx context.Context, filePath, objectName string) (err error) {
+	ctx, end := startSpan(ctx, "UploadFile", objectName)
+	defer func() { end(err) }()
+	return c.API.UploadFile(ctx, filePath, objectName)
+}
+
+func (c *tracingClient) UploadISO(ctx context.Context, ignitionConfig, objectPrefix string) (err error) {
+	ctx, end := startSpan(ctx, "UploadISO", objectPrefix)
+	defer func() { end(err) }()
+	return c.API.UploadISO(ctx, ignitionConfig, objectPrefix)
+}
+
+func (c *tracingClient) Download(ctx context.Context, objectName string) (_ io.ReadCloser, _ int64, err error) {
+	ctx, end := startSpan(ctx, "Download", objectName)
+	defer func() { end(err) }()
+	return c.API.Download(ctx, objectName)
+}
+
+func (c *tracingClient) DoesObjectExist(ctx context.Context, objectName string) (_ bool, err error) {
+	ctx, end := startSpan(ctx, "DoesObjectExist", objectName)
+	defer func() { end(err) }()
+	return c.API.DoesObjectExist(ctx, objectName)
+}
+
+func (c *tracingClient) DeleteObject(ctx context.Context, objectName string) (err error) {
+	ctx, end := startSpan(ctx, "DeleteObject", objectName)
+	defer func() { end(err) }()
+	return c.API.DeleteObject(ctx, objectName)
+}
+
+func (c *tracingClient) GetObjectSizeBytes(ctx context.Context, objectName string) (_ int64, err error) {
+	ctx, end := startSpan(ctx, "GetObjectSizeBytes", objectName)
+	defer func() { end(err) }()
+	return c.API.GetObjectSizeBytes(ctx, objectName)
+}
+
+func (c *tracingClient) GeneratePresignedDownloadURL(ctx context.Context, objectName string, downloadFilename string,
+	duration time.Duration) (_ string, err error) {
+	ctx, end := startSpan(ctx, "GeneratePresignedDownloadURL", objectName)
+	defer func() { end(err) }()
+	return c.API.GeneratePresignedDownloadURL(ctx, objectName, downloadFilename, duration)
+}
+
+func (c *tracingClient) UpdateObjectTimestamp(ctx context.Context, objectName string) (_ bool, err error) {
+	ctx, end := startSpan(ctx, "UpdateObjectTimestamp", objectName)
+	defer func() { end(err) }()
+	return c.API.UpdateObjectTimestamp(ctx, objectName)
+}
+
+func (c *tracingClient) ExpireObjects(ctx context.Context, prefix string, deleteTime time.Duration,
+	callback func(ctx context.Context, log logrus.FieldLogger, objectName string)) {
+	ctx, end := startSpan(ctx, "ExpireObjects", prefix)
+	defer end(nil)
+	c.API.ExpireObjects(ctx, prefix, deleteTime, callback)
+}
+
+func (c *tracingClient) ListObjectsByPrefix(ctx context.Context, prefix string) (_ []string, err error) {
+	ctx, end := startSpan(ctx, "ListObjectsByPrefix", prefix)
+	defer func() { end(err) }()
+	return c.API.ListObjectsByPrefix(ctx, prefix)
+}
@@ -0,0 +1,180 @@
+package shard
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/openshift/assisted-service/pkg/leader"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+// Member is a row per live replica, refreshed by the heartbeats of the replica.
+// The table is created by the migrations of the service.
+type Member struct {
+	Name        string `gorm:"primaryKey"`
+	HeartbeatAt time.Time
+}
+
+func (Member) TableName() string {
+	return "monitor_shard_members"
+}
+
+var _ Shard = &Membership{}
+var _ leader.Leader = &Membership{}
+
+// Membership partitions the monitored clusters between the replicas that share the service database.
+// Every replica sends heartbeats to the database and the clusters are assigned to the live replicas with
+// consistent hashing, so when a replica joins or disappears only its part of the clusters moves. A replica
+// stops monitoring when it can't send heartbeats for half of MemberTimeout, well before the others drop it.
+// While the replicas notice a change of the members at slightly different times a cluster might be refreshed
+// by two of them, which is safe since the refresh holds the lock of the cluster.
+type Membership struct {
+	log      logrus.FieldLogger
+	config   Config
+	db       *gorm.DB
+	metrics  *shardMetrics
+	identity string
+
+	mu            sync.RWMutex
+	members       []string
+	ring          *Ring
+	lastHeartbeat time.Time
+	passStartedAt map[string]time.Time
+}
+
+func NewMembership(db *gorm.DB, config Config, registry prometheus.Registerer, logger logrus.FieldLogger) *Membership {
+	return &Membership{
+		log:           logger,
+		config:        config,
+		db:            db,
+		metrics:       newShardMetrics(registry),
+		passStartedAt: make(map[string]time.Time),
+	}
+}
+
+// Name identifies the replica between the members
+func (m *Membership) Name() string {
+	return m.identity
+}
+
+func (m *Membership) Active() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.active()
+}
+
+func (m *Membership) active() bool {
+	return m.ring != nil && time.Since(m.lastHeartbeat) < m.config.MemberTimeout/2
+}
+
+// IsLeader lets the Membership be passed to the managers instead of the leader elector, every live member
+// is the leader of its own shard.
+func (m *Membership) IsLeader() bool {
+	return m.Active()
+}
+
+func (m *Membership) Owns(clusterID strfmt.UUID) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.active() && m.ring.Owner(clusterID.String()) == m.identity
+}
+
+func (m *Membership) PassCompleted(monitor string, startedAt time.Time, items int) {
+	m.mu.Lock()
+	prevStartedAt, ok := m.passStartedAt[monitor]
+	m.passStartedAt[monitor] = startedAt
+	m.mu.Unlock()
+	if !ok {
+		prevStartedAt = startedAt
+	}
+	m.metrics.passCompleted(monitor, m.identity, time.Since(prevStartedAt), items)
+}
+
+// Start joins the replica to the members and keeps sending heartbeats until the context is cancelled,
+// the replica leaves when that happens so its clusters move to the other replicas right away.
+func (m *Membership) Start(ctx context.Context) error {
+	if m.config.HeartbeatInterval >= m.config.MemberTimeout/2 {
+		return errors.Errorf("shard heartbeat interval %s must be shorter than half of the member timeout %s",
+			m.config.HeartbeatInterval, m.config.MemberTimeout)
+	}
+	if m.config.VirtualNodes <= 0 {
+		return errors.Errorf("shard virtual nodes must be positive, got %d", m.config.VirtualNodes)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+	m.identity = hostname + "_" + string(uuid.NewUUID())
+	m.log = m.log.WithField("shard", m.identity)
+
+	m.log.Infof("Joining monitoring shard members")
+	m.heartbeat()
+	go func() {
+		ticker := time.NewTicker(m.config.HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				m.log.Infof("Given context was cancelled, leaving monitoring shard members")
+				m.leave()
+				return
+			case <-ticker.C:
+				m.heartbeat()
+			}
+		}
+	}()
+	return nil
+}
+
+func (m *Membership) heartbeat() {
+	sentAt := time.Now()
+	now := sentAt.UTC()
+	err := m.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"heartbeat_at"}),
+	}).Create(&Member{Name: m.identity, HeartbeatAt: now}).Error
+	if err != nil {
+		m.log.WithError(err).Warnf("Failed to send shard heartbeat")
+		return
+	}
+
+	deadline := now.Add(-m.config.MemberTimeout)
+	if err = m.db.Where("heartbeat_at < ?", deadline).Delete(&Member{}).Error; err != nil {
+		m.log.WithError(err).Warnf("Failed to remove shard members without heartbeats")
+	}
+	var members []string
+	if err = m.db.Model(&Member{}).Where("heartbeat_at >= ?", deadline).Order("name").Pluck("name", &members).Error; err != nil {
+		m.log.WithError(err).Warnf("Failed to get shard members")
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ring == nil || !reflect.DeepEqual(members, m.members) {
+		m.log.Infof("Shard members changed from %v to %v, rebalancing monitored clusters", m.members, members)
+		m.members = members
+		m.ring = NewRing(members, m.config.VirtualNodes)
+		m.metrics.members.Set(float64(len(members)))
+	}
+	m.lastHeartbeat = sentAt
+}
+
+func (m *Membership) leave() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.db.Where("name = ?", m.identity).Delete(&Member{}).Error; err != nil {
+		m.log.WithError(err).Warnf("Failed to leave shard members")
+	}
+	m.ring = nil
+	m.members = nil
+}
@@ -0,0 +1,58 @@
+package shard
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	gaugeShardLagSeconds = "assisted_installer_monitor_shard_lag_seconds"
+	gaugeShardItems      = "assisted_installer_monitor_shard_items"
+	gaugeShardMembers    = "assisted_installer_monitor_shard_members"
+)
+
+const (
+	gaugeDescriptionShardLagSeconds = "Seconds from the start of the previous pass of a monitor over the shard to the end of the last one, the longest a cluster or host waited for a refresh, by monitor and shard"
+	gaugeDescriptionShardItems      = "Number of clusters or hosts refreshed in the last pass of a monitor over the shard, by monitor and shard"
+	gaugeDescriptionShardMembers    = "Number of live replicas that the monitoring is partitioned between"
+)
+
+const (
+	subsystem    = "service"
+	monitorLabel = "monitor"
+	shardLabel   = "shard"
+)
+
+type shardMetrics struct {
+	lagSeconds *prometheus.GaugeVec
+	items      *prometheus.GaugeVec
+	members    prometheus.Gauge
+}
+
+func newShardMetrics(registry prometheus.Registerer) *shardMetrics {
+	m := &shardMetrics{
+		lagSeconds: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Subsystem: subsystem,
+			Name:      gaugeShardLagSeconds,
+			Help:      gaugeDescriptionShardLagSeconds,
+		}, []string{monitorLabel, shardLabel}),
+		items: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Subsystem: subsystem,
+			Name:      gaugeShardItems,
+			Help:      gaugeDescriptionShardItems,
+		}, []string{monitorLabel, shardLabel}),
+		members: prometheus.NewGauge(prometheus.GaugeOpts{
+			Subsystem: subsystem,
+			Name:      gaugeShardMembers,
+			Help:      gaugeDescriptionShardMembers,
+		}),
+	}
+	registry.MustRegister(m.lagSeconds, m.items, m.members)
+	return m
+}
+
+func (m *shardMetrics) passCompleted(monitor, shard string, lag time.Duration, items int) {
+	m.lagSeconds.WithLabelValues(monitor, shard).Set(lag.Seconds())
+	m.items.WithLabelValues(monitor, shard).Set(float64(items))
+}
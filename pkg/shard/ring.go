@@ -0,0 +1,52 @@
+package shard
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// Ring assigns keys to members with consistent hashing. Every member is placed on the ring in a number of
+// virtual nodes, so when a member joins or leaves only the keys of its neighbours move and the keys stay
+// evenly spread between the members.
+type Ring struct {
+	points []uint64
+	owners map[uint64]string
+}
+
+func NewRing(members []string, virtualNodes int) *Ring {
+	r := &Ring{owners: make(map[uint64]string, len(members)*virtualNodes)}
+	for _, member := range members {
+		for i := 0; i < virtualNodes; i++ {
+			point := hash(fmt.Sprintf("%s#%d", member, i))
+			// on a collision keep the same owner regardless of the order of the members
+			if owner, ok := r.owners[point]; ok && owner < member {
+				continue
+			} else if !ok {
+				r.points = append(r.points, point)
+			}
+			r.owners[point] = member
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+	return r
+}
+
+// Owner returns the member that owns the key, or an empty string if the ring has no members
+func (r *Ring) Owner(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+	h := hash(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[r.points[i]]
+}
+
+func hash(key string) uint64 {
+	sum := sha256.Sum256([]byte(key))
+	return binary.BigEndian.Uint64(sum[:8])
+}
@@ -0,0 +1,55 @@
+package shard
+
+import (
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/openshift/assisted-service/pkg/leader"
+)
+
+const (
+	MonitorClusters = "cluster"
+	MonitorHosts    = "host"
+)
+
+type Config struct {
+	// Partition the monitoring of clusters and hosts between all the replicas instead of running it on the leader
+	Enabled           bool          `envconfig:"MONITOR_SHARDING" default:"false"`
+	HeartbeatInterval time.Duration `envconfig:"MONITOR_SHARD_HEARTBEAT_INTERVAL" default:"5s"`
+	// A replica that didn't send a heartbeat for this long is removed and its clusters move to the other replicas
+	MemberTimeout time.Duration `envconfig:"MONITOR_SHARD_MEMBER_TIMEOUT" default:"30s"`
+	VirtualNodes  int           `envconfig:"MONITOR_SHARD_VIRTUAL_NODES" default:"100"`
+}
+
+// Shard is the part of the clusters that the monitors of this replica refresh
+type Shard interface {
+	// Active returns false if the replica shouldn't monitor any cluster at the moment
+	Active() bool
+	// Owns returns true if the cluster belongs to the shard
+	Owns(clusterID strfmt.UUID) bool
+	// PassCompleted reports that a monitor finished going over the shard, refreshing the given number of items
+	PassCompleted(monitor string, startedAt time.Time, items int)
+}
+
+// Of returns the shard that the monitors should refresh. Replicas running sharded monitoring pass their
+// Membership to the managers instead of the leader elector, without it the leader monitors all the clusters.
+func Of(l leader.Leader) Shard {
+	if s, ok := l.(Shard); ok {
+		return s
+	}
+	return &leaderShard{leader: l}
+}
+
+type leaderShard struct {
+	leader leader.Leader
+}
+
+func (s *leaderShard) Active() bool {
+	return s.leader.IsLeader()
+}
+
+func (s *leaderShard) Owns(strfmt.UUID) bool {
+	return true
+}
+
+func (s *leaderShard) PassCompleted(string, time.Time, int) {}
@@ -0,0 +1,179 @@
+package shard
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/assisted-service/pkg/db"
+	"github.com/openshift/assisted-service/pkg/leader"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func TestShard(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Shard Suite")
+}
+
+func clusterIDs(n int) []strfmt.UUID {
+	ids := make([]strfmt.UUID, n)
+	for i := range ids {
+		ids[i] = strfmt.UUID(uuid.New().String())
+	}
+	return ids
+}
+
+var _ = Describe("Ring", func() {
+	ids := clusterIDs(3000)
+
+	It("spreads the keys between the members", func() {
+		members := []string{"a", "b", "c"}
+		ring := NewRing(members, 100)
+		owned := map[string]int{}
+		for _, id := range ids {
+			owned[ring.Owner(id.String())]++
+		}
+		Expect(owned).To(HaveLen(len(members)))
+		for _, m := range members {
+			Expect(owned[m]).To(BeNumerically(">", len(ids)/len(members)/2), fmt.Sprintf("member %s", m))
+		}
+	})
+
+	It("moves only the keys of a member that left", func() {
+		before := NewRing([]string{"a", "b", "c"}, 100)
+		after := NewRing([]string{"c", "a"}, 100)
+		for _, id := range ids {
+			if owner := before.Owner(id.String()); owner != "b" {
+				Expect(after.Owner(id.String())).To(Equal(owner))
+			}
+		}
+	})
+
+	It("has no owner without members", func() {
+		Expect(NewRing(nil, 100).Owner(ids[0].String())).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Of", func() {
+	It("monitors every cluster on the leader", func() {
+		s := Of(&leader.DummyElector{})
+		Expect(s.Active()).To(BeTrue())
+		Expect(s.Owns(clusterIDs(1)[0])).To(BeTrue())
+	})
+
+	It("uses the membership of a sharded replica", func() {
+		m := NewMembership(nil, Config{}, prometheus.NewRegistry(), logrus.New())
+		Expect(Of(m)).To(BeIdenticalTo(m))
+	})
+})
+
+var _ = Describe("Membership", func() {
+	var (
+		dir    string
+		dbConn *gorm.DB
+		cfg    = Config{HeartbeatInterval: 50 * time.Millisecond, MemberTimeout: 500 * time.Millisecond, VirtualNodes: 100}
+		ids    = clusterIDs(100)
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "shard")
+		Expect(err).ToNot(HaveOccurred())
+		dbConn, err = db.Open(db.Config{Dialect: db.DialectSqlite, Path: filepath.Join(dir, "test.db"),
+			BusyTimeout: time.Second, MaxOpenConns: 1})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dbConn.AutoMigrate(&Member{})).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		sqlDB, err := dbConn.DB()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sqlDB.Close()).ToNot(HaveOccurred())
+		Expect(os.RemoveAll(dir)).ToNot(HaveOccurred())
+	})
+
+	start := func(ctx context.Context) *Membership {
+		m := NewMembership(dbConn, cfg, prometheus.NewRegistry(), logrus.New())
+		Expect(m.Start(ctx)).To(Succeed())
+		return m
+	}
+
+	owners := func(members ...*Membership) func() []int {
+		return func() []int {
+			counts := make([]int, len(members))
+			for _, id := range ids {
+				for i, m := range members {
+					if m.Owns(id) {
+						counts[i]++
+					}
+				}
+			}
+			return counts
+		}
+	}
+
+	It("partitions the clusters between the members and rebalances when one leaves", func() {
+		ctx1, cancel1 := context.WithCancel(context.Background())
+		defer cancel1()
+		first := start(ctx1)
+		Expect(first.Active()).To(BeTrue())
+		Expect(owners(first)()).To(Equal([]int{len(ids)}))
+
+		ctx2, cancel2 := context.WithCancel(context.Background())
+		defer cancel2()
+		second := start(ctx2)
+		Eventually(func() int {
+			counts := owners(first, second)()
+			if counts[0] == 0 || counts[1] == 0 {
+				return 0
+			}
+			return counts[0] + counts[1]
+		}).Should(Equal(len(ids)))
+		Expect(testutil.ToFloat64(second.metrics.members)).To(Equal(float64(2)))
+
+		cancel2()
+		Eventually(owners(first)).Should(Equal([]int{len(ids)}))
+		Expect(second.Active()).To(BeFalse())
+	})
+
+	It("drops a member that stopped sending heartbeats", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		m := start(ctx)
+		Expect(dbConn.Create(&Member{Name: "gone", HeartbeatAt: time.Now().UTC().Add(-time.Minute)}).Error).ToNot(HaveOccurred())
+		Consistently(owners(m), cfg.HeartbeatInterval*3).Should(Equal([]int{len(ids)}))
+
+		var count int64
+		Expect(dbConn.Model(&Member{}).Where("name = ?", "gone").Count(&count).Error).ToNot(HaveOccurred())
+		Expect(count).To(BeZero())
+	})
+
+	It("reports the lag of the monitors", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		m := start(ctx)
+		startedAt := time.Now().Add(-time.Minute)
+		m.PassCompleted(MonitorClusters, startedAt, 10)
+		m.PassCompleted(MonitorClusters, time.Now(), 12)
+		Expect(testutil.ToFloat64(m.metrics.lagSeconds.WithLabelValues(MonitorClusters, m.Name()))).
+			To(BeNumerically(">=", 60))
+		Expect(testutil.ToFloat64(m.metrics.items.WithLabelValues(MonitorClusters, m.Name()))).To(Equal(float64(12)))
+	})
+
+	It("refuses a heartbeat interval that isn't shorter than half of the member timeout", func() {
+		m := NewMembership(dbConn, Config{HeartbeatInterval: time.Second, MemberTimeout: 2 * time.Second, VirtualNodes: 1},
+			prometheus.NewRegistry(), logrus.New())
+		Expect(m.Start(context.Background())).ToNot(Succeed())
+	})
+})
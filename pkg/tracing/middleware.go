@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+
+	rmiddleware "github.com/go-openapi/runtime/middleware"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware starts a span for every API call, named by its operation. It should be added as an inner middleware
+// because it relies on the MatchedRoute. A call with a W3C traceparent header continues the trace of the caller.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagation.TraceContext{}.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		name := r.Method + " " + r.URL.Path
+		attrs := []attribute.KeyValue{attribute.String("http.method", r.Method), attribute.String("http.target", r.URL.Path)}
+		if route := rmiddleware.MatchedRouteFrom(r); route != nil {
+			name = route.Operation.ID
+			attrs = append(attrs, attribute.String("http.route", route.PathPattern))
+		}
+		ctx, span := start(ctx, name, trace.SpanKindServer, attrs...)
+		defer span.End()
+
+		sw := &statusWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(sw, r.WithContext(ctx))
+		span.SetAttributes(attribute.Int("http.status_code", sw.statusCode))
+		if sw.statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(sw.statusCode))
+		}
+	})
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (w *statusWriter) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("type assertion failed http.ResponseWriter not a http.Hijacker")
+	}
+	return h.Hijack()
+}
@@ -0,0 +1,66 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/openshift/assisted-service/pkg/requestid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Config of the export of the spans, they are exported with OTLP over HTTP only when an endpoint is set
+type Config struct {
+	Endpoint       string        `envconfig:"OTEL_EXPORTER_OTLP_ENDPOINT" default:""`
+	ServiceName    string        `envconfig:"OTEL_SERVICE_NAME" default:"assisted-service"`
+	ExportInterval time.Duration `envconfig:"TRACING_EXPORT_INTERVAL" default:"5s"`
+	ExportTimeout  time.Duration `envconfig:"TRACING_EXPORT_TIMEOUT" default:"10s"`
+	// Spans that end while the queue is full are dropped
+	MaxQueueSize       int `envconfig:"TRACING_MAX_QUEUE_SIZE" default:"2048"`
+	MaxExportBatchSize int `envconfig:"TRACING_MAX_EXPORT_BATCH_SIZE" default:"512"`
+}
+
+const instrumentationName = "github.com/openshift/assisted-service"
+
+// RequestIDKey is the attribute of the spans with the ID of the request they are part of
+const RequestIDKey = attribute.Key("request_id")
+
+// Start starts a span of the operation as a child of the span of the context. Until a provider is registered
+// with otel.SetTracerProvider the span records nothing.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return start(ctx, name, trace.SpanKindInternal, attrs...)
+}
+
+// StartInTrace starts a span only if the context is already part of a trace, for operations that run too often in
+// the background to start traces of their own
+func StartInTrace(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if span := trace.SpanFromContext(ctx); !span.SpanContext().IsValid() {
+		return ctx, span
+	}
+	return Start(ctx, name, attrs...)
+}
+
+func start(ctx context.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if requestID := requestid.FromContext(ctx); requestID != "" {
+		attrs = append(attrs, RequestIDKey.String(requestID))
+	}
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+// End ends the span of an operation, marking it as failed if the operation returned an error
+func End(ctx context.Context, span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Detach returns a context for work that outlives the request, it has the request ID and the span of the context
+// but isn't canceled with it
+func Detach(ctx context.Context) context.Context {
+	detached := requestid.ToContext(context.Background(), requestid.FromContext(ctx))
+	return trace.ContextWithSpan(detached, trace.SpanFromContext(ctx))
+}
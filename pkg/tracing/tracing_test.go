@@ -0,0 +1,223 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/assisted-service/pkg/requestid"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestTracing(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tracing Suite")
+}
+
+// collector is an OTLP receiver that keeps the spans it was sent
+type collector struct {
+	lock  sync.Mutex
+	spans map[string]*tracepb.Span
+	attrs []*commonpb.KeyValue
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer GinkgoRecover()
+	Expect(r.URL.Path).To(Equal("/v1/traces"))
+	Expect(r.Header.Get("Content-Type")).To(Equal("application/x-protobuf"))
+	body, err := ioutil.ReadAll(r.Body)
+	Expect(err).ToNot(HaveOccurred())
+	var req collectortrace.ExportTraceServiceRequest
+	Expect(proto.Unmarshal(body, &req)).To(Succeed())
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, rs := range req.ResourceSpans {
+		c.attrs = rs.Resource.Attributes
+		for _, ss := range rs.ScopeSpans {
+			Expect(ss.Scope.Name).To(Equal(instrumentationName))
+			for _, s := range ss.Spans {
+				c.spans[s.Name] = s
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/x-protobuf")
+}
+
+func (c *collector) span(name string) *tracepb.Span {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	s, ok := c.spans[name]
+	Expect(ok).To(BeTrue(), "span %s wasn't exported", name)
+	return s
+}
+
+func attributeValue(attrs []*commonpb.KeyValue, key string) *commonpb.AnyValue {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return nil
+}
+
+var _ = Describe("Tracing", func() {
+	var (
+		c        *collector
+		server   *httptest.Server
+		provider *sdktrace.TracerProvider
+	)
+
+	BeforeEach(func() {
+		c = &collector{spans: make(map[string]*tracepb.Span)}
+		server = httptest.NewServer(c)
+		var err error
+		provider, err = NewProvider(Config{
+			Endpoint:           server.URL,
+			ServiceName:        "test-service",
+			ExportInterval:     time.Hour,
+			ExportTimeout:      time.Second,
+			MaxQueueSize:       10,
+			MaxExportBatchSize: 5,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		otel.SetTracerProvider(provider)
+	})
+
+	AfterEach(func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		server.Close()
+	})
+
+	shutdown := func() {
+		Expect(provider.Shutdown(context.Background())).To(Succeed())
+	}
+
+	It("rejects an endpoint that isn't an HTTP URL", func() {
+		_, err := NewProvider(Config{Endpoint: "collector:4318"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("exports the spans with their parents", func() {
+		ctx := requestid.ToContext(context.Background(), "the-request")
+		ctx, parent := Start(ctx, "parent")
+		childCtx, child := Start(ctx, "child")
+		End(childCtx, child, errors.New("failed"))
+		End(ctx, parent, nil)
+		shutdown()
+
+		p := c.span("parent")
+		ch := c.span("child")
+		Expect(ch.TraceId).To(Equal(p.TraceId))
+		Expect(ch.ParentSpanId).To(Equal(p.SpanId))
+		Expect(p.ParentSpanId).To(BeEmpty())
+		Expect(attributeValue(p.Attributes, string(RequestIDKey)).GetStringValue()).To(Equal("the-request"))
+		Expect(p.Status.Code).To(Equal(tracepb.Status_STATUS_CODE_UNSET))
+		Expect(ch.Status.Code).To(Equal(tracepb.Status_STATUS_CODE_ERROR))
+		Expect(ch.Status.Message).To(Equal("failed"))
+		Expect(ch.Events).To(HaveLen(1))
+		Expect(ch.Events[0].Name).To(Equal("exception"))
+		Expect(attributeValue(c.attrs, "service.name").GetStringValue()).To(Equal("test-service"))
+	})
+
+	It("exports a full batch before the export interval", func() {
+		for _, name := range []string{"a", "b", "c", "d", "e"} {
+			_, span := Start(context.Background(), name)
+			span.End()
+		}
+		Eventually(func() int {
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			return len(c.spans)
+		}).Should(Equal(5))
+		shutdown()
+	})
+
+	It("doesn't start a span out of a trace", func() {
+		ctx, span := StartInTrace(context.Background(), "background")
+		Expect(span.IsRecording()).To(BeFalse())
+		Expect(trace.SpanFromContext(ctx)).To(Equal(span))
+
+		ctx, parent := Start(context.Background(), "parent")
+		ctx, span = StartInTrace(ctx, "in-trace")
+		Expect(span.IsRecording()).To(BeTrue())
+		span.End()
+		parent.End()
+		shutdown()
+		Expect(c.span("in-trace").ParentSpanId).To(Equal(c.span("parent").SpanId))
+	})
+
+	It("keeps the span and request ID in a detached context", func() {
+		ctx, cancel := context.WithCancel(requestid.ToContext(context.Background(), "the-request"))
+		ctx, parent := Start(ctx, "parent")
+		detached := Detach(ctx)
+		cancel()
+		Expect(detached.Err()).ToNot(HaveOccurred())
+		Expect(requestid.FromContext(detached)).To(Equal("the-request"))
+		Expect(trace.SpanFromContext(detached)).To(Equal(parent))
+		parent.End()
+		shutdown()
+	})
+
+	Context("middleware", func() {
+		var handler http.Handler
+
+		BeforeEach(func() {
+			handler = requestid.Middleware(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, span := Start(r.Context(), "handler")
+				span.End()
+				if r.URL.Path == "/fail" {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			})))
+		})
+
+		It("starts a server span with the request ID", func() {
+			req := httptest.NewRequest(http.MethodGet, "/ok", nil)
+			req.Header.Set("X-Request-ID", "the-request")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			shutdown()
+
+			s := c.span("GET /ok")
+			Expect(s.Kind).To(Equal(tracepb.Span_SPAN_KIND_SERVER))
+			Expect(s.ParentSpanId).To(BeEmpty())
+			Expect(attributeValue(s.Attributes, "http.status_code").GetIntValue()).To(Equal(int64(200)))
+			Expect(attributeValue(s.Attributes, string(RequestIDKey)).GetStringValue()).To(Equal("the-request"))
+			Expect(s.Status.Code).To(Equal(tracepb.Status_STATUS_CODE_UNSET))
+			Expect(c.span("handler").ParentSpanId).To(Equal(s.SpanId))
+		})
+
+		It("marks server errors as failed", func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/fail", nil))
+			shutdown()
+
+			s := c.span("POST /fail")
+			Expect(attributeValue(s.Attributes, "http.status_code").GetIntValue()).To(Equal(int64(500)))
+			Expect(s.Status.Code).To(Equal(tracepb.Status_STATUS_CODE_ERROR))
+		})
+
+		It("continues the trace of the caller", func() {
+			req := httptest.NewRequest(http.MethodGet, "/ok", nil)
+			req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			shutdown()
+
+			s := c.span("GET /ok")
+			Expect(hex.EncodeToString(s.TraceId)).To(Equal("0af7651916cd43dd8448eb211c80319c"))
+			Expect(hex.EncodeToString(s.ParentSpanId)).To(Equal("b7ad6b7169203331"))
+		})
+	})
+})
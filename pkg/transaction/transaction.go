@@ -1,11 +1,149 @@
 package transaction
 
-import "gorm.io/gorm"
+import (
+	"context"
+	"sync"
 
-func AddForUpdateQueryOption(db *gorm.DB) *gorm.DB {
-	if db.Dialector.Name() != "sqlite3" {
-		// return a new object and not overwrite pointer value because GORM have a pointer to parent
-		return db.Set("gorm:query_option", "FOR UPDATE")
+	"github.com/go-openapi/strfmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const dialectPostgres = "postgres"
+
+// lockProcess takes the lock of the cluster held by this process, on the dialects that don't support row locking.
+// It is taken before the transaction gets a connection from the pool, so the holder of the lock never waits for a
+// connection held by the transactions waiting for it.
+func lockProcess(db *gorm.DB, clusterID strfmt.UUID) func() {
+	if db.Dialector.Name() == dialectPostgres {
+		return func() {}
+	}
+	return processLocks.lock(clusterID.String())
+}
+
+// lockRow selects the cluster row FOR UPDATE on postgres
+func lockRow(tx *gorm.DB, clusterID strfmt.UUID) error {
+	if tx.Dialector.Name() != dialectPostgres {
+		return nil
+	}
+	var ids []string
+	return tx.Table("clusters").Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", clusterID.String()).Pluck("id", &ids).Error
+}
+
+// BeginInCluster starts a transaction that holds the lock of the cluster, with the context to use in it.
+// On postgres the cluster row is selected FOR UPDATE, other dialects don't support row locking and fall back
+// to a lock table held by this process. The caller checks the error of the returned transaction, the returned
+// error is the one of the locking.
+// The returned function releases the lock and runs the actions deferred by AfterClusterTransaction, it must be
+// called after the transaction was committed or rolled back.
+func BeginInCluster(ctx context.Context, db *gorm.DB, clusterID strfmt.UUID) (context.Context, *gorm.DB, func(), error) {
+	ctx, runDeferred := withDeferred(ctx)
+	release := lockProcess(db, clusterID)
+	var once sync.Once
+	unlock := func() {
+		once.Do(func() {
+			release()
+			runDeferred()
+		})
+	}
+	tx := db.Begin()
+	if tx.Error != nil {
+		return ctx, tx, unlock, nil
+	}
+	return ctx, tx, unlock, lockRow(tx, clusterID)
+}
+
+// InClusterTransaction runs fn in a new transaction that holds the lock of the cluster, see BeginInCluster.
+// The transaction is committed if fn returns no error and rolled back otherwise.
+func InClusterTransaction(ctx context.Context, db *gorm.DB, clusterID strfmt.UUID, fn func(ctx context.Context, tx *gorm.DB) error) error {
+	ctx, runDeferred := withDeferred(ctx)
+	defer runDeferred()
+	defer lockProcess(db, clusterID)()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := lockRow(tx, clusterID); err != nil {
+			return err
+		}
+		return fn(ctx, tx)
+	})
+}
+
+type deferredKey struct{}
+
+// deferred are the actions to run once a cluster transaction ended
+type deferred struct {
+	mu      sync.Mutex
+	actions []func()
+	done    bool
+}
+
+func withDeferred(ctx context.Context) (context.Context, func()) {
+	d := &deferred{}
+	return context.WithValue(ctx, deferredKey{}, d), func() {
+		d.mu.Lock()
+		actions := d.actions
+		d.actions = nil
+		d.done = true
+		d.mu.Unlock()
+		for _, action := range actions {
+			action()
+		}
+	}
+}
+
+// AfterClusterTransaction defers fn until the cluster transaction of the context ended and its lock was released,
+// whether it was committed or not. It returns false, without running fn, when the context has no cluster
+// transaction or it already ended.
+// Writes that go through their own connection use it, on SQLite they would wait for the single writer lock held
+// by the transaction.
+func AfterClusterTransaction(ctx context.Context, fn func()) bool {
+	d, ok := ctx.Value(deferredKey{}).(*deferred)
+	if !ok {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.done {
+		return false
+	}
+	d.actions = append(d.actions, fn)
+	return true
+}
+
+var processLocks = &lockTable{locks: make(map[string]*keyLock)}
+
+// lockTable is a set of mutexes by key, entries are removed once no one holds or waits for them
+type lockTable struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+func (t *lockTable) lock(key string) func() {
+	t.mu.Lock()
+	l, ok := t.locks[key]
+	if !ok {
+		l = &keyLock{}
+		t.locks[key] = l
+	}
+	l.refs++
+	t.mu.Unlock()
+
+	l.Lock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.Unlock()
+			t.mu.Lock()
+			l.refs--
+			if l.refs == 0 {
+				delete(t.locks, key)
+			}
+			t.mu.Unlock()
+		})
 	}
-	return db
 }
@@ -0,0 +1,195 @@
+package transaction
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/assisted-service/pkg/db"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func TestTransaction(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Transaction Suite")
+}
+
+var _ = Describe("InClusterTransaction", func() {
+	type cluster struct {
+		ID      string `gorm:"primaryKey"`
+		Counter int
+	}
+
+	var (
+		dir       string
+		dbConn    *gorm.DB
+		clusterID strfmt.UUID
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "transaction")
+		Expect(err).ToNot(HaveOccurred())
+		dbConn, err = db.Open(db.Config{
+			Dialect:      db.DialectSqlite,
+			Path:         filepath.Join(dir, "test.db"),
+			MaxOpenConns: 10,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dbConn.AutoMigrate(&cluster{})).ToNot(HaveOccurred())
+		clusterID = strfmt.UUID(uuid.New().String())
+		Expect(dbConn.Create(&cluster{ID: clusterID.String()}).Error).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		sqlDB, err := dbConn.DB()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sqlDB.Close()).ToNot(HaveOccurred())
+		Expect(os.RemoveAll(dir)).ToNot(HaveOccurred())
+	})
+
+	increment := func(_ context.Context, tx *gorm.DB) error {
+		var c cluster
+		if err := tx.Take(&c, "id = ?", clusterID.String()).Error; err != nil {
+			return err
+		}
+		return tx.Model(&c).Update("counter", c.Counter+1).Error
+	}
+
+	It("serializes concurrent updates of the same cluster", func() {
+		const workers = 20
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				errs <- InClusterTransaction(context.Background(), dbConn, clusterID, increment)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		var c cluster
+		Expect(dbConn.Take(&c, "id = ?", clusterID.String()).Error).ToNot(HaveOccurred())
+		Expect(c.Counter).To(Equal(workers))
+		Expect(processLocks.locks).To(BeEmpty())
+	})
+
+	It("rolls back and releases the lock on failure", func() {
+		err := InClusterTransaction(context.Background(), dbConn, clusterID, func(ctx context.Context, tx *gorm.DB) error {
+			Expect(increment(ctx, tx)).ToNot(HaveOccurred())
+			return errors.New("failed")
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(processLocks.locks).To(BeEmpty())
+
+		var c cluster
+		Expect(dbConn.Take(&c, "id = ?", clusterID.String()).Error).ToNot(HaveOccurred())
+		Expect(c.Counter).To(Equal(0))
+
+		Expect(InClusterTransaction(context.Background(), dbConn, clusterID, increment)).ToNot(HaveOccurred())
+	})
+
+	It("doesn't hold a connection while waiting for the lock", func() {
+		sqlDB, err := dbConn.DB()
+		Expect(err).ToNot(HaveOccurred())
+		sqlDB.SetMaxOpenConns(2)
+
+		const workers = 10
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				errs <- InClusterTransaction(context.Background(), dbConn, clusterID, func(ctx context.Context, tx *gorm.DB) error {
+					// a read out of the transaction needs a second connection, once the others wait for the lock
+					time.Sleep(10 * time.Millisecond)
+					var c cluster
+					if err := dbConn.Take(&c, "id = ?", clusterID.String()).Error; err != nil {
+						return err
+					}
+					return increment(ctx, tx)
+				})
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			Expect(err).ToNot(HaveOccurred())
+		}
+	})
+
+	It("runs the deferred actions once the transaction ended", func() {
+		var actions []string
+		Expect(AfterClusterTransaction(context.Background(), func() {})).To(BeFalse())
+
+		var txCtx context.Context
+		err := InClusterTransaction(context.Background(), dbConn, clusterID, func(ctx context.Context, tx *gorm.DB) error {
+			txCtx = ctx
+			Expect(AfterClusterTransaction(ctx, func() {
+				Expect(processLocks.locks).To(BeEmpty())
+				actions = append(actions, "deferred")
+			})).To(BeTrue())
+			actions = append(actions, "transaction")
+			return errors.New("failed")
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(actions).To(Equal([]string{"transaction", "deferred"}))
+		Expect(AfterClusterTransaction(txCtx, func() {})).To(BeFalse())
+	})
+
+	It("begins a transaction that holds the lock until it is released", func() {
+		ctx, tx, unlock, err := BeginInCluster(context.Background(), dbConn, clusterID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tx.Error).ToNot(HaveOccurred())
+		deferredRan := false
+		Expect(AfterClusterTransaction(ctx, func() { deferredRan = true })).To(BeTrue())
+		Expect(increment(ctx, tx)).ToNot(HaveOccurred())
+		Expect(tx.Commit().Error).ToNot(HaveOccurred())
+		Expect(processLocks.locks).To(HaveLen(1))
+		Expect(deferredRan).To(BeFalse())
+		unlock()
+		unlock()
+		Expect(processLocks.locks).To(BeEmpty())
+		Expect(deferredRan).To(BeTrue())
+	})
+})
+
+var _ = Describe("lockTable", func() {
+	It("blocks a second holder of the same key until the first releases it", func() {
+		table := &lockTable{locks: make(map[string]*keyLock)}
+		unlock := table.lock("a")
+
+		acquired := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			table.lock("a")()
+			close(acquired)
+		}()
+		Consistently(acquired).ShouldNot(BeClosed())
+
+		unlockOther := table.lock("b")
+		unlockOther()
+
+		unlock()
+		unlock()
+		Eventually(acquired).Should(BeClosed())
+		Expect(table.locks).To(BeEmpty())
+	})
+})
@@ -0,0 +1,66 @@
+package workerpool
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// Pool runs tasks on a fixed number of workers. Tasks are assigned to workers by their key, so tasks with the
+// same key run one after the other in the order they were submitted, while tasks with different keys can run
+// concurrently.
+//
+// Sample usage:
+//    pool := workerpool.New(4)
+//    for _, h := range hosts {
+//        h := h
+//        pool.Submit(h.ClusterID.String(), func() { refresh(h) })
+//    }
+//    pool.Wait()
+//
+type Pool struct {
+	queues []chan func()
+	wg     sync.WaitGroup
+	close  sync.Once
+}
+
+// New starts a pool with the given number of workers. A pool of a single worker runs the tasks on the goroutine
+// that submits them, one at a time.
+func New(workers int) *Pool {
+	if workers <= 1 {
+		return &Pool{}
+	}
+	p := &Pool{queues: make([]chan func(), workers)}
+	for i := range p.queues {
+		queue := make(chan func(), workers)
+		p.queues[i] = queue
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for task := range queue {
+				task()
+			}
+		}()
+	}
+	return p
+}
+
+// Submit queues the task on the worker of the key, blocking while the queue of the worker is full
+func (p *Pool) Submit(key string, task func()) {
+	if len(p.queues) == 0 {
+		task()
+		return
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	p.queues[h.Sum32()%uint32(len(p.queues))] <- task
+}
+
+// Wait stops accepting tasks and returns after all the submitted tasks are done, it can be called more than once
+func (p *Pool) Wait() {
+	p.close.Do(func() {
+		for _, queue := range p.queues {
+			close(queue)
+		}
+	})
+	p.wg.Wait()
+}
@@ -0,0 +1,75 @@
+package workerpool
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestWorkerPool(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Worker Pool Suite")
+}
+
+var _ = Describe("Pool", func() {
+	It("runs the tasks of a key in order", func() {
+		var (
+			mu    sync.Mutex
+			order = map[string][]int{}
+		)
+		pool := New(4)
+		for i := 0; i < 100; i++ {
+			key, i := fmt.Sprintf("cluster-%d", i%7), i
+			pool.Submit(key, func() {
+				mu.Lock()
+				defer mu.Unlock()
+				order[key] = append(order[key], i)
+			})
+		}
+		pool.Wait()
+
+		total := 0
+		for key, tasks := range order {
+			total += len(tasks)
+			for j := 1; j < len(tasks); j++ {
+				Expect(tasks[j]).To(BeNumerically(">", tasks[j-1]), key)
+			}
+		}
+		Expect(total).To(Equal(100))
+	})
+
+	It("runs tasks of different keys concurrently up to the number of workers", func() {
+		var running, maxRunning int32
+		pool := New(3)
+		for i := 0; i < 30; i++ {
+			pool.Submit(fmt.Sprintf("cluster-%d", i), func() {
+				current := atomic.AddInt32(&running, 1)
+				for {
+					max := atomic.LoadInt32(&maxRunning)
+					if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+			})
+		}
+		pool.Wait()
+		Expect(maxRunning).To(BeNumerically(">", 1))
+		Expect(maxRunning).To(BeNumerically("<=", 3))
+	})
+
+	It("runs the tasks on the caller with a single worker", func() {
+		ran := 0
+		pool := New(1)
+		pool.Submit("a", func() { ran++ })
+		Expect(ran).To(Equal(1))
+		pool.Wait()
+		pool.Wait()
+	})
+})
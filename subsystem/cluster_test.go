@@ -55,7 +55,7 @@ var (
 			{DriveType: "SSD", Name: "loop0", SizeBytes: validDiskSize},
 			{DriveType: "HDD", Name: "sdb", SizeBytes: validDiskSize}},
 		Interfaces: []*models.Interface{
-			{IPV4Addresses: []string{"1.2.3.4/24"}},
+			{IPV4Addresses: []string{"1.2.3.4/24"}, HasCarrier: true},
 		},
 		SystemVendor: &models.SystemVendor{Manufacturer: "manu", ProductName: "prod", SerialNumber: "3534"},
 		Timestamp:    1601853088,
@@ -67,7 +67,7 @@ var (
 			{DriveType: "SSD", Name: "loop0", SizeBytes: validDiskSize},
 			{DriveType: "HDD", Name: "sdb", SizeBytes: validDiskSize}},
 		Interfaces: []*models.Interface{
-			{IPV4Addresses: []string{"1.2.3.4/24"}},
+			{IPV4Addresses: []string{"1.2.3.4/24"}, HasCarrier: true},
 		},
 		SystemVendor: &models.SystemVendor{Manufacturer: "manu", ProductName: "prod", SerialNumber: "3534"},
 		Timestamp:    1601853088,
@@ -83,6 +83,7 @@ var (
 				IPV4Addresses: []string{
 					"1.2.3.4/24",
 				},
+				HasCarrier: true,
 			},
 		},
 		SystemVendor: &models.SystemVendor{Manufacturer: "manu", ProductName: "prod", SerialNumber: "3534"},
@@ -2076,6 +2077,7 @@ var _ = Describe("cluster install", func() {
 					IPV4Addresses: []string{
 						"1.2.3.4/24",
 					},
+					HasCarrier: true,
 				},
 			},
 			SystemVendor: &models.SystemVendor{Manufacturer: "manu", ProductName: "prod", SerialNumber: "3534"},
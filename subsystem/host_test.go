@@ -80,7 +80,8 @@ var _ = Describe("Host tests", func() {
 					IPV4Addresses: []string{
 						"1.2.3.4/24",
 					},
-					SpeedMbps: 20,
+					SpeedMbps:  20,
+					HasCarrier: true,
 				},
 				{
 					Name: "eth1",
@@ -2,6 +2,7 @@ package subsystem
 
 import (
 	"net/url"
+	"os"
 	"testing"
 
 	"github.com/go-openapi/runtime"
@@ -11,8 +12,8 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/openshift/assisted-service/client"
 	"github.com/openshift/assisted-service/pkg/auth"
+	dbPkg "github.com/openshift/assisted-service/pkg/db"
 	"github.com/sirupsen/logrus"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -22,8 +23,7 @@ var log *logrus.Logger
 var wiremock *WireMock
 
 var Options struct {
-	DBHost             string `envconfig:"DB_HOST"`
-	DBPort             string `envconfig:"DB_PORT"`
+	DBConfig           dbPkg.Config
 	EnableAuth         bool   `envconfig:"ENABLE_AUTH"`
 	InventoryHost      string `envconfig:"INVENTORY"`
 	TestToken          string `envconfig:"TEST_TOKEN"`
@@ -67,7 +67,13 @@ func init() {
 	agentBMClient = client.New(agentClientCfg)
 	badAgentBMClient = client.New(badAgentClientCfg)
 
-	db, err = gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if Options.DBConfig.Dialect == dbPkg.DialectSqlite {
+		// Opening a missing sqlite file creates an empty database instead of failing
+		if _, err = os.Stat(Options.DBConfig.Path); err != nil {
+			logrus.Fatal("DB_PATH must point at the database file of the service under test, ", err)
+		}
+	}
+	db, err = dbPkg.Open(Options.DBConfig)
 	if err != nil {
 		logrus.Fatal("Fail to connect to DB, ", err)
 	}
@@ -13,6 +13,8 @@ ENVS = [
     ("HW_VALIDATOR_MIN_RAM_GIB_WORKER", "3"),
     ("HW_VALIDATOR_MIN_RAM_GIB_MASTER", "8"),
     ("HW_VALIDATOR_MIN_DISK_SIZE_GIB", "10"),
+    ("HW_VALIDATOR_MAX_FSYNC_LATENCY_MS", "0"),
+    ("HW_VALIDATOR_MIN_LINK_SPEED_MBPS", "0"),
     ("INSTALLER_IMAGE", ""),
     ("CONTROLLER_IMAGE", ""),
     ("OPENSHIFT_INSTALL_RELEASE_IMAGE", ""),